@@ -0,0 +1,7 @@
+// package api embeds the OpenAPI spec of the service, so the server validates the requests against it
+package api
+
+import _ "embed"
+
+//go:embed schema.yaml
+var Schema []byte
@@ -0,0 +1,187 @@
+// apikey manages the API keys of the calling services. The key is printed once when it is issued or rotated,
+// only its hash is stored
+//
+//	go run ./cmd/apikey issue -client billing -scopes balance:read,funds:deposit
+//	go run ./cmd/apikey rotate -id 1
+//	go run ./cmd/apikey revoke -id 1
+//	go run ./cmd/apikey list
+//
+// The clients may reserve, recognize and unreserve the orders of their services only
+//
+//	go run ./cmd/apikey bind -client billing -services 1,2
+//	go run ./cmd/apikey unbind -client billing -services 2
+//	go run ./cmd/apikey denials -limit 50
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"http-avito-test/internal/auth"
+	"http-avito-test/internal/storage"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"go.uber.org/zap"
+)
+
+func main() {
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		log.Fatalf("zap.NewDevelopment: %v", err)
+	}
+	defer logger.Sync()
+
+	if len(os.Args) < 2 {
+		logger.Fatal("no command provided, use issue, rotate, revoke, list, bind, unbind or denials")
+	}
+
+	if err := godotenv.Load("../../.env"); err != nil {
+		logger.Debug("No .env file found", zap.Error(err))
+	}
+
+	ctx := context.Background()
+
+	s, err := storage.NewStorage(ctx, logger)
+	if err != nil {
+		logger.Fatal("failed to create storage instance", zap.Error(err))
+	}
+	defer s.Close()
+
+	command, args := os.Args[1], os.Args[2:]
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+
+	switch command {
+	case "issue":
+		client := flags.String("client", "", "name of the calling service")
+		scopes := flags.String("scopes", "", "comma separated scopes of the key")
+		flags.Parse(args)
+
+		if *client == "" {
+			logger.Fatal("no client provided")
+		}
+		parsed, err := auth.ParseScopes(*scopes)
+		if err != nil {
+			logger.Fatal("failed to parse scopes", zap.Error(err))
+		}
+		if len(parsed) == 0 {
+			logger.Fatal("no scopes provided", zap.Any("known", auth.Scopes))
+		}
+
+		key, err := auth.NewKey()
+		if err != nil {
+			logger.Fatal("failed to generate API key", zap.Error(err))
+		}
+		id, err := s.CreateAPIKey(ctx, *client, key, parsed)
+		if err != nil {
+			logger.Fatal("failed to store API key", zap.Error(err))
+		}
+		printKey(id, key)
+
+	case "rotate":
+		id := flags.Int64("id", 0, "id of the key to rotate")
+		flags.Parse(args)
+
+		key, err := auth.NewKey()
+		if err != nil {
+			logger.Fatal("failed to generate API key", zap.Error(err))
+		}
+		newID, err := s.RotateAPIKey(ctx, *id, key)
+		if err != nil {
+			logger.Fatal("failed to rotate API key", zap.Error(err))
+		}
+		printKey(newID, key)
+
+	case "revoke":
+		id := flags.Int64("id", 0, "id of the key to revoke")
+		flags.Parse(args)
+
+		if err := s.RevokeAPIKey(ctx, *id); err != nil {
+			logger.Fatal("failed to revoke API key", zap.Error(err))
+		}
+
+	case "list":
+		flags.Parse(args)
+
+		keys, err := s.ListAPIKeys(ctx)
+		if err != nil {
+			logger.Fatal("failed to read API keys", zap.Error(err))
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(keys); err != nil {
+			logger.Fatal("failed to write API keys", zap.Error(err))
+		}
+
+	case "bind", "unbind":
+		client := flags.String("client", "", "name of the calling service")
+		services := flags.String("services", "", "comma separated service ids")
+		flags.Parse(args)
+
+		if *client == "" {
+			logger.Fatal("no client provided")
+		}
+		serviceIDs, err := parseServiceIDs(*services)
+		if err != nil {
+			logger.Fatal("failed to parse service ids", zap.Error(err))
+		}
+
+		if command == "bind" {
+			err = s.BindServices(ctx, *client, serviceIDs)
+		} else {
+			err = s.UnbindServices(ctx, *client, serviceIDs)
+		}
+		if err != nil {
+			logger.Fatal("failed to update client services", zap.Error(err))
+		}
+
+	case "denials":
+		limit := flags.Int64("limit", 100, "maximum number of denials")
+		offset := flags.Int64("offset", 0, "number of the newest denials to skip")
+		flags.Parse(args)
+
+		denials, err := s.ListAuthorizationDenials(ctx, *limit, *offset)
+		if err != nil {
+			logger.Fatal("failed to read authorization denials", zap.Error(err))
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(denials); err != nil {
+			logger.Fatal("failed to write authorization denials", zap.Error(err))
+		}
+
+	default:
+		logger.Fatal("unknown command, use issue, rotate, revoke, list, bind, unbind or denials", zap.String("command", command))
+	}
+}
+
+func parseServiceIDs(list string) ([]int64, error) {
+	var ids []int64
+	for _, s := range strings.Split(list, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("wrong service id %q", s)
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("no service ids provided")
+	}
+	return ids, nil
+}
+
+func printKey(id int64, key auth.Key) {
+	fmt.Printf("id: %d\nkey: %s\n", id, key.String())
+}
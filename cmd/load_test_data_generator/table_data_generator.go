@@ -16,7 +16,7 @@ type Posting struct {
 	Description      *string
 }
 
-const cacheBookAccountID = int64(0)
+const cacheBookAccountID = int64(-1)
 
 //  GenerateTableData generates a slice of user data values ​​that will be added to the table for performance tests.
 //  Function takes userCount and totalRecordCount int values.
@@ -0,0 +1,74 @@
+// reconcile imports the CSV bank statement, matches it to the cash book postings and prints the reconciliation as JSON
+//
+//	go run ./cmd/reconcile -file statement.csv -window 72h
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"http-avito-test/internal/reconcile"
+	"http-avito-test/internal/storage"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/joho/godotenv"
+	"go.uber.org/zap"
+)
+
+func main() {
+	file := flag.String("file", "", "path to the CSV bank statement with the header date,amount,reference")
+	window := flag.Duration("window", 72*time.Hour, "maximum distance between the dates of the statement line and the posting")
+	flag.Parse()
+
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		log.Fatalf("zap.NewDevelopment: %v", err)
+	}
+	defer logger.Sync()
+
+	if *file == "" {
+		logger.Fatal("no bank statement file provided")
+	}
+
+	if err := godotenv.Load("../../.env"); err != nil {
+		logger.Debug("No .env file found", zap.Error(err))
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		logger.Fatal("failed to open bank statement", zap.Error(err))
+	}
+	defer f.Close()
+
+	lines, err := reconcile.ParseCSV(f)
+	if err != nil {
+		logger.Fatal("failed to parse bank statement", zap.Error(err))
+	}
+
+	ctx := context.Background()
+
+	s, err := storage.NewStorage(ctx, logger)
+	if err != nil {
+		logger.Fatal("failed to create storage instance", zap.Error(err))
+	}
+	defer s.Close()
+
+	statementID, err := s.ImportStatement(ctx, filepath.Base(*file), lines, *window)
+	if err != nil {
+		logger.Fatal("failed to import bank statement", zap.Error(err))
+	}
+
+	rec, err := s.ReadReconciliation(ctx, statementID)
+	if err != nil {
+		logger.Fatal("failed to read reconciliation", zap.Error(err))
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(rec); err != nil {
+		logger.Fatal("failed to write reconciliation", zap.Error(err))
+	}
+}
@@ -5,9 +5,13 @@ import (
 	"http-avito-test/internal/exchanger"
 	"http-avito-test/internal/server"
 	"http-avito-test/internal/storage"
+	"http-avito-test/internal/tracing"
+	"http-avito-test/internal/worker"
 	"log"
 	"net/http"
+	"time"
 
+	"github.com/caarlos0/env/v6"
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
 )
@@ -23,7 +27,24 @@ func main() {
 		logger.Debug("No .env file found", zap.Error(err))
 	}
 
-	ctx := context.Background()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tracingCfg := tracing.Config{}
+	if err := env.Parse(&tracingCfg); err != nil {
+		logger.Fatal("failed to parse tracing config", zap.Error(err))
+	}
+	shutdownTracing, err := tracing.Setup(ctx, tracingCfg, logger)
+	if err != nil {
+		logger.Fatal("failed to set up tracing", zap.Error(err))
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("failed to export the remaining spans", zap.Error(err))
+		}
+	}()
 
 	storage, err := storage.NewStorage(ctx, logger)
 	if err != nil {
@@ -32,6 +53,25 @@ func main() {
 
 	e := exchanger.New()
 
+	workerCfg := worker.Config{}
+	if err := env.Parse(&workerCfg); err != nil {
+		logger.Fatal("failed to parse background jobs config", zap.Error(err))
+	}
+
+	worker.Start(
+		ctx,
+		logger,
+		worker.SettleTransfers(logger, storage, workerCfg.SettleInterval),
+		worker.ExpirePaymentRequests(logger, storage, workerCfg.PaymentRequestExpiryInterval),
+		worker.ExpireBonuses(logger, storage, workerCfg.BonusExpiryInterval),
+		worker.SweepRateLimits(logger, storage, workerCfg.RateLimitSweepInterval, workerCfg.RateLimitBucketTTL),
+	)
+
+	serverCfg := server.ServerConfig{}
+	if err := env.Parse(&serverCfg); err != nil {
+		logger.Fatal("failed to parse server config", zap.Error(err))
+	}
+
 	srv, err := server.New(
 		logger,
 		storage,
@@ -46,7 +86,7 @@ func main() {
 	go func() {
 		mux := http.NewServeMux()
 
-		mux.Handle("/", http.FileServer(http.Dir("../../file_storage")))
+		mux.Handle("/", http.FileServer(http.Dir(serverCfg.ReportDir)))
 
 		log.Println("Запуск сервера на http://localhost:4000")
 		err := http.ListenAndServe(":4000", mux)
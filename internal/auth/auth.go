@@ -0,0 +1,134 @@
+// package auth issues the API keys of the calling services and checks their scopes
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrMalformedKey = errors.New("malformed API key")
+	ErrUnknownScope = errors.New("unknown scope")
+)
+
+// Scope is the group of operations the key is allowed to call
+type Scope string
+
+const (
+	ScopeBalanceRead   Scope = "balance:read"
+	ScopeFundsDeposit  Scope = "funds:deposit"
+	ScopeFundsWithdraw Scope = "funds:withdraw"
+	ScopeFundsTransfer Scope = "funds:transfer"
+	ScopeFundsReserve  Scope = "funds:reserve"
+	ScopeReportsRead   Scope = "reports:read"
+	ScopeAdmin         Scope = "admin"
+)
+
+var Scopes = []Scope{
+	ScopeBalanceRead,
+	ScopeFundsDeposit,
+	ScopeFundsWithdraw,
+	ScopeFundsTransfer,
+	ScopeFundsReserve,
+	ScopeReportsRead,
+	ScopeAdmin,
+}
+
+// ParseScopes reads the comma separated list of scopes like "balance:read,funds:deposit"
+func ParseScopes(list string) ([]Scope, error) {
+	var scopes []Scope
+	for _, s := range strings.Split(list, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		scope := Scope(s)
+		if !scope.valid() {
+			return nil, fmt.Errorf("%w %q", ErrUnknownScope, s)
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
+
+func (s Scope) valid() bool {
+	for _, scope := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope reports whether the scope is in the list
+func HasScope(scopes []Scope, scope Scope) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	prefixLength = 8
+	secretLength = 32
+)
+
+// Key is the API key given to the client once. Only the prefix and the hash of the secret are stored,
+// the prefix finds the stored key and the hash checks the secret
+type Key struct {
+	Prefix string
+	Secret string
+}
+
+// NewKey generates the random key
+func NewKey() (Key, error) {
+	prefix, err := randomHex(prefixLength)
+	if err != nil {
+		return Key{}, err
+	}
+	secret, err := randomHex(secretLength)
+	if err != nil {
+		return Key{}, err
+	}
+	return Key{Prefix: prefix, Secret: secret}, nil
+}
+
+// ParseKey splits the key written as "prefix.secret"
+func ParseKey(s string) (Key, error) {
+	i := strings.IndexByte(s, '.')
+	if i <= 0 || i == len(s)-1 {
+		return Key{}, ErrMalformedKey
+	}
+	return Key{Prefix: s[:i], Secret: s[i+1:]}, nil
+}
+
+func (k Key) String() string {
+	return k.Prefix + "." + k.Secret
+}
+
+// Hash is stored instead of the secret. The secret is random, so a fast hash without a salt is enough
+func (k Key) Hash() []byte {
+	sum := sha256.Sum256([]byte(k.Secret))
+	return sum[:]
+}
+
+// Matches compares the secret with the stored hash in constant time
+func (k Key) Matches(hash []byte) bool {
+	return subtle.ConstantTimeCompare(k.Hash(), hash) == 1
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
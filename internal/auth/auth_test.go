@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScopes(t *testing.T) {
+	scopes, err := ParseScopes("balance:read, funds:deposit,")
+	require.NoError(t, err)
+	assert.Equal(t, []Scope{ScopeBalanceRead, ScopeFundsDeposit}, scopes)
+
+	assert.True(t, HasScope(scopes, ScopeFundsDeposit))
+	assert.False(t, HasScope(scopes, ScopeFundsReserve))
+
+	_, err = ParseScopes("balance:read,funds:steal")
+	assert.ErrorIs(t, err, ErrUnknownScope)
+}
+
+func TestKey(t *testing.T) {
+	key, err := NewKey()
+	require.NoError(t, err)
+	assert.Len(t, key.Prefix, 16)
+	assert.Len(t, key.Secret, 64)
+
+	parsed, err := ParseKey(key.String())
+	require.NoError(t, err)
+	assert.Equal(t, key, parsed)
+
+	hash := key.Hash()
+	assert.True(t, parsed.Matches(hash))
+
+	other, err := NewKey()
+	require.NoError(t, err)
+	assert.False(t, Key{Prefix: key.Prefix, Secret: other.Secret}.Matches(hash))
+
+	for _, s := range []string{"", "nodot", ".secret", "prefix."} {
+		_, err := ParseKey(s)
+		assert.ErrorIs(t, err, ErrMalformedKey, s)
+	}
+}
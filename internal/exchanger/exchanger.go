@@ -2,6 +2,7 @@
 package exchanger
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -12,9 +13,15 @@ import (
 	"time"
 
 	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+var tracer = otel.Tracer("http-avito-test/internal/exchanger")
+
 const envApiKey = "API_KEY"
 
 // creating a client to work with a remote api
@@ -36,14 +43,30 @@ func New() *ExchangerClient {
 }
 
 // exchanger returns the amount recalculated for the specified currency
-func (e *ExchangerClient) ExchangeRates(logger *zap.Logger, value decimal.Decimal, currency string) (decimal.Decimal, error) {
+func (e *ExchangerClient) ExchangeRates(ctx context.Context, logger *zap.Logger, value decimal.Decimal, currency string) (decimal.Decimal, error) {
+	// the span is not propagated to the third-party service, the trace context stays inside the service
+	ctx, span := tracer.Start(ctx, "Exchanger.ExchangeRates", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("http.method", http.MethodGet),
+		attribute.String("currency", currency),
+	))
+	defer span.End()
+
+	result, err := e.exchangeRates(ctx, logger, value, currency)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return result, err
+}
+
+func (e *ExchangerClient) exchangeRates(ctx context.Context, logger *zap.Logger, value decimal.Decimal, currency string) (decimal.Decimal, error) {
 	logger.Debug("starting exchanger rates")
 
 	var ex *generated.ExchangerResult
 
 	url := fmt.Sprintf(`https://api.apilayer.com/exchangerates_data/convert?to=%s&from=RUB&amount=%s`, currency, value)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	req.Header.Set("apikey", e.apiKey)
 
 	if err != nil {
@@ -54,6 +77,7 @@ func (e *ExchangerClient) ExchangeRates(logger *zap.Logger, value decimal.Decima
 	if err != nil {
 		return decimal.NewFromInt(0), err
 	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("http.status_code", res.StatusCode))
 	if res.Body != nil {
 		defer res.Body.Close()
 	}
@@ -2,6 +2,7 @@ package exchanger
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io/ioutil"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.uber.org/zap"
 )
 
@@ -68,7 +70,7 @@ func TestExchangeRates(t *testing.T) {
 	t.Run("green case", func(t *testing.T) {
 		var logger, err = zap.NewDevelopment()
 		assert.NoError(t, err)
-		value, err := newClient.ExchangeRates(logger, decimal.NewFromInt(100), "EUR")
+		value, err := newClient.ExchangeRates(context.Background(), logger, decimal.NewFromInt(100), "EUR")
 		assert.NoError(t, err)
 		result := decimal.NewFromFloat32(1.7477)
 		assert.Equal(t, result, value)
@@ -77,8 +79,30 @@ func TestExchangeRates(t *testing.T) {
 	t.Run("wrong currency code", func(t *testing.T) {
 		var logger, err = zap.NewDevelopment()
 		assert.NoError(t, err)
-		_, err = newClientErr.ExchangeRates(logger, decimal.NewFromInt(100), "test")
+		_, err = newClientErr.ExchangeRates(context.Background(), logger, decimal.NewFromInt(100), "test")
 		result := errors.New("You have entered an invalid \"to\" property. [Example: to=GBP]")
 		assert.Equal(t, result, err)
 	})
+	t.Run("trace context is not sent", func(t *testing.T) {
+		provider := sdktrace.NewTracerProvider()
+		defer provider.Shutdown(context.Background())
+		ctx, span := provider.Tracer("test").Start(context.Background(), "request")
+		defer span.End()
+
+		var header http.Header
+		client := ExchangerClient{
+			Client: &http.Client{
+				Transport: RoundTripFunc(func(req *http.Request) *http.Response {
+					header = req.Header
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       ioutil.NopCloser(bytes.NewBufferString(response)),
+					}
+				}),
+			},
+		}
+		_, err := client.ExchangeRates(ctx, zap.NewNop(), decimal.NewFromInt(100), "EUR")
+		assert.NoError(t, err)
+		assert.Empty(t, header.Get("traceparent"))
+	})
 }
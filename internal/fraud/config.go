@@ -0,0 +1,69 @@
+package fraud
+
+import (
+	"encoding/json"
+	"fmt"
+	"http-avito-test/internal/money"
+	"io"
+	"os"
+	"time"
+)
+
+// RuleConfig is a single rule of the rule set file. Only the fields used by the kind of the rule are required
+type RuleConfig struct {
+	Name   string `json:"name"`
+	Kind   string `json:"kind"`
+	Action Action `json:"action"`
+	// Operations limits the rule to the operation types, an empty list checks all of them
+	Operations []OperationType `json:"operations"`
+	MaxAge     Duration        `json:"max_age"`
+	Window     Duration        `json:"window"`
+	// Amount is in rubles
+	Amount   money.Money `json:"amount"`
+	Max      int64       `json:"max"`
+	Accounts []int64     `json:"accounts"`
+}
+
+// Duration reads time.Duration from its string form like "24h"
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+// Load builds the engine from the JSON rule set file
+func Load(path string) (*Engine, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Parse(f)
+}
+
+// Parse builds the engine from the JSON rule set of the form {"rules": [...]}
+func Parse(r io.Reader) (*Engine, error) {
+	var set struct {
+		Rules []RuleConfig `json:"rules"`
+	}
+
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&set); err != nil {
+		return nil, fmt.Errorf("malformed fraud rule set: %w", err)
+	}
+	return NewEngine(set.Rules...)
+}
@@ -0,0 +1,103 @@
+// package fraud provides the rule-based checks of money movements before they are committed
+package fraud
+
+import (
+	"context"
+	"fmt"
+	"http-avito-test/internal/money"
+	"time"
+)
+
+// Action is the outcome of a matched rule
+type Action string
+
+const (
+	ActionAllow Action = "allow"
+	ActionBlock Action = "block"
+	ActionFlag  Action = "flag"
+)
+
+type OperationType string
+
+const (
+	OperationWithdrawal  OperationType = "withdrawal"
+	OperationTransfer    OperationType = "transfer"
+	OperationReservation OperationType = "reservation"
+)
+
+// Operation describes the money movement being checked
+type Operation struct {
+	Type      OperationType
+	AccountID int64
+	// Counterparty is the recipient of a transfer, it is zero for other operations
+	Counterparty int64
+	Amount       money.Money
+	At           time.Time
+}
+
+// History gives the rules access to the past operations of the accounts.
+// It is read inside the transaction of the checked operation
+type History interface {
+	// FirstActivity returns the date of the first posting of the account, false if it has no postings
+	FirstActivity(ctx context.Context, accountID int64) (time.Time, bool, error)
+	// DistinctRecipients returns the number of distinct user accounts the account transferred money to since the time
+	DistinctRecipients(ctx context.Context, accountID int64, since time.Time) (int64, error)
+	// HasTransfer reports whether the sender transferred money to the recipient since the time
+	HasTransfer(ctx context.Context, sender, recipient int64, since time.Time) (bool, error)
+}
+
+// Hit is a rule matched by the operation
+type Hit struct {
+	Rule   string
+	Action Action
+}
+
+// Verdict is the result of the check. Action is allow when no blocking rule is matched,
+// Hits contains the flagging rules and the rule that made the final decision
+type Verdict struct {
+	Action Action
+	Hits   []Hit
+}
+
+// Engine evaluates the configured rules in order. The first matched allow or block rule stops
+// the evaluation, flag rules are collected and the evaluation continues
+type Engine struct {
+	rules []rule
+}
+
+func NewEngine(configs ...RuleConfig) (*Engine, error) {
+	e := &Engine{}
+	for i, c := range configs {
+		r, err := newRule(c)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i, err)
+		}
+		e.rules = append(e.rules, r)
+	}
+	return e, nil
+}
+
+func (e *Engine) Check(ctx context.Context, h History, op Operation) (Verdict, error) {
+	var v = Verdict{Action: ActionAllow}
+
+	for _, r := range e.rules {
+		if !r.applies(op.Type) {
+			continue
+		}
+
+		matched, err := r.match(ctx, h, op)
+		if err != nil {
+			return Verdict{}, fmt.Errorf("rule %q: %w", r.name, err)
+		}
+		if !matched {
+			continue
+		}
+
+		v.Hits = append(v.Hits, Hit{Rule: r.name, Action: r.action})
+		if r.action != ActionFlag {
+			v.Action = r.action
+			return v, nil
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,129 @@
+package fraud
+
+import (
+	"context"
+	"errors"
+	"http-avito-test/internal/money"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type history struct {
+	first      map[int64]time.Time
+	recipients int64
+	transfers  map[[2]int64]bool
+	err        error
+}
+
+func (h history) FirstActivity(ctx context.Context, accountID int64) (time.Time, bool, error) {
+	t, ok := h.first[accountID]
+	return t, ok, h.err
+}
+
+func (h history) DistinctRecipients(ctx context.Context, accountID int64, since time.Time) (int64, error) {
+	return h.recipients, h.err
+}
+
+func (h history) HasTransfer(ctx context.Context, sender, recipient int64, since time.Time) (bool, error) {
+	return h.transfers[[2]int64{sender, recipient}], h.err
+}
+
+const ruleSet = `{"rules": [
+	{"name": "trusted", "kind": "accounts", "action": "allow", "accounts": [7]},
+	{"name": "new account", "kind": "new_account_amount", "action": "block", "operations": ["withdrawal", "transfer"], "max_age": "24h", "amount": "10000"},
+	{"name": "many recipients", "kind": "distinct_recipients", "action": "flag", "window": "1h", "max": 5},
+	{"name": "round trip", "kind": "round_trip", "action": "flag", "window": "1h"}
+]}`
+
+func TestEngineCheck(t *testing.T) {
+	var now = time.Date(2022, time.November, 1, 12, 0, 0, 0, time.UTC)
+
+	e, err := Parse(strings.NewReader(ruleSet))
+	require.NoError(t, err)
+
+	h := history{
+		first: map[int64]time.Time{
+			2: now.Add(-time.Hour),
+			3: now.Add(-48 * time.Hour),
+			7: now.Add(-time.Hour),
+		},
+		recipients: 6,
+		transfers:  map[[2]int64]bool{{4, 3}: true},
+	}
+
+	var tests = []struct {
+		name   string
+		op     Operation
+		action Action
+		hits   []Hit
+	}{
+		{
+			name:   "new account withdraws large amount",
+			op:     Operation{Type: OperationWithdrawal, AccountID: 2, Amount: money.New(2000000, money.RUB), At: now},
+			action: ActionBlock,
+			hits:   []Hit{{Rule: "new account", Action: ActionBlock}},
+		},
+		{
+			name:   "new account reserves large amount",
+			op:     Operation{Type: OperationReservation, AccountID: 2, Amount: money.New(2000000, money.RUB), At: now},
+			action: ActionAllow,
+		},
+		{
+			name:   "trusted account",
+			op:     Operation{Type: OperationWithdrawal, AccountID: 7, Amount: money.New(2000000, money.RUB), At: now},
+			action: ActionAllow,
+			hits:   []Hit{{Rule: "trusted", Action: ActionAllow}},
+		},
+		{
+			name:   "old account transfers back",
+			op:     Operation{Type: OperationTransfer, AccountID: 3, Counterparty: 4, Amount: money.New(2000000, money.RUB), At: now},
+			action: ActionAllow,
+			hits: []Hit{
+				{Rule: "many recipients", Action: ActionFlag},
+				{Rule: "round trip", Action: ActionFlag},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := e.Check(context.Background(), h, tt.op)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.action, v.Action)
+			assert.Equal(t, tt.hits, v.Hits)
+		})
+	}
+
+	t.Run("history error", func(t *testing.T) {
+		h := history{err: errors.New("connection lost")}
+
+		_, err := e.Check(context.Background(), h, Operation{Type: OperationWithdrawal, AccountID: 2, Amount: money.New(2000000, money.RUB), At: now})
+		assert.Error(t, err)
+	})
+}
+
+func TestParse(t *testing.T) {
+	var tests = []struct {
+		name string
+		set  string
+	}{
+		{name: "unknown kind", set: `{"rules": [{"name": "r", "kind": "unknown", "action": "flag"}]}`},
+		{name: "unknown action", set: `{"rules": [{"name": "r", "kind": "round_trip", "action": "deny", "window": "1h"}]}`},
+		{name: "unknown operation", set: `{"rules": [{"name": "r", "kind": "round_trip", "action": "flag", "window": "1h", "operations": ["deposit"]}]}`},
+		{name: "missing window", set: `{"rules": [{"name": "r", "kind": "round_trip", "action": "flag"}]}`},
+		{name: "wrong duration", set: `{"rules": [{"name": "r", "kind": "round_trip", "action": "flag", "window": "hour"}]}`},
+		{name: "unknown field", set: `{"rules": [{"name": "r", "kind": "round_trip", "action": "flag", "window": "1h", "limit": 1}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.set))
+			assert.Error(t, err)
+		})
+	}
+}
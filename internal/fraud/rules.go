@@ -0,0 +1,135 @@
+package fraud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"http-avito-test/internal/money"
+	"time"
+)
+
+// Kinds of the rules available for the configuration
+const (
+	// KindNewAccountAmount matches operations of accounts younger than MaxAge for more than Amount rubles
+	KindNewAccountAmount = "new_account_amount"
+	// KindDistinctRecipients matches transfers that make the number of distinct recipients within Window greater than Max
+	KindDistinctRecipients = "distinct_recipients"
+	// KindRoundTrip matches transfers back to the account that sent money to the sender within Window
+	KindRoundTrip = "round_trip"
+	// KindAccounts matches operations of the listed Accounts
+	KindAccounts = "accounts"
+)
+
+type matchFunc func(ctx context.Context, h History, op Operation) (bool, error)
+
+type rule struct {
+	name       string
+	action     Action
+	operations map[OperationType]bool
+	match      matchFunc
+}
+
+// applies reports whether the rule checks the operations of the type, a rule without operations checks all of them
+func (r rule) applies(t OperationType) bool {
+	return len(r.operations) == 0 || r.operations[t]
+}
+
+func newRule(c RuleConfig) (rule, error) {
+	if c.Name == "" {
+		return rule{}, errors.New("rule name is empty")
+	}
+
+	switch c.Action {
+	case ActionAllow, ActionBlock, ActionFlag:
+	default:
+		return rule{}, fmt.Errorf("unknown action %q", c.Action)
+	}
+
+	r := rule{
+		name:       c.Name,
+		action:     c.Action,
+		operations: make(map[OperationType]bool),
+	}
+	for _, t := range c.Operations {
+		switch t {
+		case OperationWithdrawal, OperationTransfer, OperationReservation:
+			r.operations[t] = true
+		default:
+			return rule{}, fmt.Errorf("unknown operation %q", t)
+		}
+	}
+
+	switch c.Kind {
+	case KindNewAccountAmount:
+		if c.MaxAge.Duration <= 0 || !c.Amount.IsPositive() {
+			return rule{}, fmt.Errorf("%s rule requires positive max_age and amount", c.Kind)
+		}
+		r.match = newAccountAmount(c.MaxAge.Duration, c.Amount)
+	case KindDistinctRecipients:
+		if c.Window.Duration <= 0 || c.Max <= 0 {
+			return rule{}, fmt.Errorf("%s rule requires positive window and max", c.Kind)
+		}
+		r.match = distinctRecipients(c.Window.Duration, c.Max)
+	case KindRoundTrip:
+		if c.Window.Duration <= 0 {
+			return rule{}, fmt.Errorf("%s rule requires positive window", c.Kind)
+		}
+		r.match = roundTrip(c.Window.Duration)
+	case KindAccounts:
+		if len(c.Accounts) == 0 {
+			return rule{}, fmt.Errorf("%s rule requires accounts", c.Kind)
+		}
+		r.match = accounts(c.Accounts)
+	default:
+		return rule{}, fmt.Errorf("unknown rule kind %q", c.Kind)
+	}
+	return r, nil
+}
+
+func newAccountAmount(maxAge time.Duration, amount money.Money) matchFunc {
+	return func(ctx context.Context, h History, op Operation) (bool, error) {
+		if !op.Amount.GreaterThan(amount) {
+			return false, nil
+		}
+
+		first, ok, err := h.FirstActivity(ctx, op.AccountID)
+		if err != nil {
+			return false, err
+		}
+		return !ok || op.At.Sub(first) < maxAge, nil
+	}
+}
+
+func distinctRecipients(window time.Duration, max int64) matchFunc {
+	return func(ctx context.Context, h History, op Operation) (bool, error) {
+		if op.Type != OperationTransfer {
+			return false, nil
+		}
+
+		count, err := h.DistinctRecipients(ctx, op.AccountID, op.At.Add(-window))
+		if err != nil {
+			return false, err
+		}
+		return count > max, nil
+	}
+}
+
+func roundTrip(window time.Duration) matchFunc {
+	return func(ctx context.Context, h History, op Operation) (bool, error) {
+		if op.Type != OperationTransfer {
+			return false, nil
+		}
+		return h.HasTransfer(ctx, op.Counterparty, op.AccountID, op.At.Add(-window))
+	}
+}
+
+func accounts(ids []int64) matchFunc {
+	var set = make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+
+	return func(ctx context.Context, h History, op Operation) (bool, error) {
+		return set[op.AccountID], nil
+	}
+}
@@ -4,15 +4,31 @@
 package generated
 
 import (
+	"http-avito-test/internal/money"
 	"http-avito-test/internal/storage"
+	"time"
 
 	"github.com/shopspring/decimal"
 )
 
+// Defines values for DepositCallbackRequestStatus.
+const (
+	DepositCallbackRequestStatusFailed    DepositCallbackRequestStatus = "failed"
+	DepositCallbackRequestStatusSucceeded DepositCallbackRequestStatus = "succeeded"
+	DepositCallbackRequestStatusTimeout   DepositCallbackRequestStatus = "timeout"
+)
+
+// Defines values for ListWithdrawalRequestsRequestStatus.
+const (
+	ListWithdrawalRequestsRequestStatusApproved ListWithdrawalRequestsRequestStatus = "approved"
+	ListWithdrawalRequestsRequestStatusPending  ListWithdrawalRequestsRequestStatus = "pending"
+	ListWithdrawalRequestsRequestStatusRejected ListWithdrawalRequestsRequestStatus = "rejected"
+)
+
 // AccountDepositRequest defines model for AccountDepositRequest.
 type AccountDepositRequest struct {
-	Amount float32 `json:"amount"`
-	UserId int64   `json:"user_id"`
+	Amount Amount `json:"amount"`
+	UserId int64  `json:"user_id"`
 }
 
 // AccountDepositResponse defines model for AccountDepositResponse.
@@ -25,14 +41,199 @@ type AccountDepositResponse struct {
 
 // AccountWithdrawalRequest defines model for AccountWithdrawalRequest.
 type AccountWithdrawalRequest struct {
-	Amount      float32 `json:"amount"`
+	Amount      Amount  `json:"amount"`
 	Description *string `json:"description"`
+	DryRun      *bool   `json:"dry_run,omitempty"`
 	UserId      int64   `json:"user_id"`
 }
 
 // AccountWithdrawalResponse defines model for AccountWithdrawalResponse.
 type AccountWithdrawalResponse = AccountDepositResponse
 
+// Amount defines model for Amount.
+type Amount = money.Amount
+
+// AnswerPaymentRequestRequest defines model for AnswerPaymentRequestRequest.
+type AnswerPaymentRequestRequest struct {
+	Payer     int64 `json:"payer"`
+	RequestId int64 `json:"request_id"`
+}
+
+// AnswerPaymentRequestResponse defines model for AnswerPaymentRequestResponse.
+type AnswerPaymentRequestResponse = AccountDepositResponse
+
+// CancelTransferRequest defines model for CancelTransferRequest.
+type CancelTransferRequest struct {
+	Sender     int64 `json:"sender"`
+	TransferId int64 `json:"transfer_id"`
+}
+
+// CancelTransferResponse defines model for CancelTransferResponse.
+type CancelTransferResponse = AccountDepositResponse
+
+// CreateEscrowRequest defines model for CreateEscrowRequest.
+type CreateEscrowRequest struct {
+	Amount      Amount  `json:"amount"`
+	Beneficiary int64   `json:"beneficiary"`
+	Description *string `json:"description"`
+	Payer       int64   `json:"payer"`
+}
+
+// CreateEscrowResponse defines model for CreateEscrowResponse.
+type CreateEscrowResponse struct {
+	Result struct {
+		EscrowId int64  `json:"escrow_id"`
+		Message  string `json:"message"`
+	} `json:"result"`
+	Status string `json:"status"`
+}
+
+// CreatePaymentRequestRequest defines model for CreatePaymentRequestRequest.
+type CreatePaymentRequestRequest struct {
+	Amount      Amount  `json:"amount"`
+	Description *string `json:"description"`
+	Payer       int64   `json:"payer"`
+	Requester   int64   `json:"requester"`
+}
+
+// CreatePaymentRequestResponse defines model for CreatePaymentRequestResponse.
+type CreatePaymentRequestResponse struct {
+	Result struct {
+		Message   string `json:"message"`
+		RequestId int64  `json:"request_id"`
+	} `json:"result"`
+	Status string `json:"status"`
+}
+
+// DecideWithdrawalRequest defines model for DecideWithdrawalRequest.
+type DecideWithdrawalRequest struct {
+	Operator  string  `json:"operator"`
+	Reason    *string `json:"reason,omitempty"`
+	RequestId int64   `json:"request_id"`
+}
+
+// DecideWithdrawalResponse defines model for DecideWithdrawalResponse.
+type DecideWithdrawalResponse = AccountDepositResponse
+
+// DelayedTransferResponse defines model for DelayedTransferResponse.
+type DelayedTransferResponse struct {
+	Result struct {
+		Message    string `json:"message"`
+		TransferId int64  `json:"transfer_id"`
+	} `json:"result"`
+	Status string `json:"status"`
+}
+
+// DepositCallbackRequest defines model for DepositCallbackRequest.
+type DepositCallbackRequest struct {
+	DepositId int64                        `json:"deposit_id"`
+	PaymentId string                       `json:"payment_id"`
+	Status    DepositCallbackRequestStatus `json:"status"`
+}
+
+// DepositCallbackRequestStatus defines model for DepositCallbackRequestStatus.
+type DepositCallbackRequestStatus string
+
+// DepositCallbackResponse defines model for DepositCallbackResponse.
+type DepositCallbackResponse = AccountDepositResponse
+
+// Error defines model for Error.
+type Error struct {
+	// stable code of the error like INSUFFICIENT_FUNDS or USER_NOT_FOUND
+	Code      string       `json:"code"`
+	Details   *[]Violation `json:"details,omitempty"`
+	Message   string       `json:"message"`
+	RequestId string       `json:"request_id"`
+}
+
+// ErrorResponse defines model for ErrorResponse.
+type ErrorResponse struct {
+	Error  Error  `json:"error"`
+	Status string `json:"status"`
+}
+
+// EscrowCommandRequest defines model for EscrowCommandRequest.
+type EscrowCommandRequest struct {
+	EscrowId int64 `json:"escrow_id"`
+}
+
+// EscrowCommandResponse defines model for EscrowCommandResponse.
+type EscrowCommandResponse = AccountDepositResponse
+
+// GenerateVouchersRequest defines model for GenerateVouchersRequest.
+type GenerateVouchersRequest struct {
+	Amount         Amount    `json:"amount"`
+	Count          int64     `json:"count"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	MaxRedemptions int64     `json:"max_redemptions"`
+}
+
+// GenerateVouchersResponse defines model for GenerateVouchersResponse.
+type GenerateVouchersResponse struct {
+	Result struct {
+		BatchId int64    `json:"batch_id"`
+		Codes   []string `json:"codes"`
+	} `json:"result"`
+	Status string `json:"status"`
+}
+
+// GrantBonusRequest defines model for GrantBonusRequest.
+type GrantBonusRequest struct {
+	Amount    Amount    `json:"amount"`
+	ExpiresAt time.Time `json:"expires_at"`
+	UserId    int64     `json:"user_id"`
+}
+
+// GrantBonusResponse defines model for GrantBonusResponse.
+type GrantBonusResponse struct {
+	Result struct {
+		GrantId int64  `json:"grant_id"`
+		Message string `json:"message"`
+	} `json:"result"`
+	Status string `json:"status"`
+}
+
+// ListFraudFlagsRequest defines model for ListFraudFlagsRequest.
+type ListFraudFlagsRequest struct {
+	Limit  int64 `json:"limit"`
+	Offset int64 `json:"offset"`
+}
+
+// ListFraudFlagsResponse defines model for ListFraudFlagsResponse.
+type ListFraudFlagsResponse struct {
+	Result []storage.FraudFlag `json:"result"`
+	Status string              `json:"status"`
+}
+
+// ListPaymentRequestsRequest defines model for ListPaymentRequestsRequest.
+type ListPaymentRequestsRequest struct {
+	Limit  int64 `json:"limit"`
+	Offset int64 `json:"offset"`
+	UserId int64 `json:"user_id"`
+}
+
+// ListPaymentRequestsResponse defines model for ListPaymentRequestsResponse.
+type ListPaymentRequestsResponse struct {
+	Result []storage.PaymentRequest `json:"result"`
+	Status string                   `json:"status"`
+}
+
+// ListWithdrawalRequestsRequest defines model for ListWithdrawalRequestsRequest.
+type ListWithdrawalRequestsRequest struct {
+	Limit  int64                                `json:"limit"`
+	Offset int64                                `json:"offset"`
+	Status *ListWithdrawalRequestsRequestStatus `json:"status,omitempty"`
+}
+
+// ListWithdrawalRequestsRequestStatus defines model for ListWithdrawalRequestsRequestStatus.
+type ListWithdrawalRequestsRequestStatus string
+
+// ListWithdrawalRequestsResponse defines model for ListWithdrawalRequestsResponse.
+type ListWithdrawalRequestsResponse struct {
+	Result []storage.WithdrawalRequest `json:"result"`
+	Status string                      `json:"status"`
+}
+
 // MonthlyReportRequest defines model for MonthlyReportRequest.
 type MonthlyReportRequest struct {
 	Month int64 `json:"month"`
@@ -47,6 +248,52 @@ type MonthlyReportResponse struct {
 	Status string `json:"status"`
 }
 
+// PendingDepositResponse defines model for PendingDepositResponse.
+type PendingDepositResponse struct {
+	Result struct {
+		ConfirmationUrl *string `json:"confirmation_url,omitempty"`
+		DepositId       int64   `json:"deposit_id"`
+		Message         string  `json:"message"`
+	} `json:"result"`
+	Status string `json:"status"`
+}
+
+// QueuedWithdrawalResponse defines model for QueuedWithdrawalResponse.
+type QueuedWithdrawalResponse struct {
+	Result struct {
+		Message   string `json:"message"`
+		RequestId int64  `json:"request_id"`
+	} `json:"result"`
+	Status string `json:"status"`
+}
+
+// QuoteResponse defines model for QuoteResponse.
+type QuoteResponse struct {
+	Result struct {
+		Balance decimal.Decimal `json:"balance"`
+		Fee     decimal.Decimal `json:"fee"`
+		UserId  int64           `json:"user_id"`
+	} `json:"result"`
+	Status string `json:"status"`
+}
+
+// ReadBalanceBucketsRequest defines model for ReadBalanceBucketsRequest.
+type ReadBalanceBucketsRequest struct {
+	UserId int64 `json:"user_id"`
+}
+
+// ReadBalanceBucketsResponse defines model for ReadBalanceBucketsResponse.
+type ReadBalanceBucketsResponse struct {
+	Result storage.BalanceBuckets `json:"result"`
+	Status string                 `json:"status"`
+}
+
+// ReadEscrowResponse defines model for ReadEscrowResponse.
+type ReadEscrowResponse struct {
+	Result storage.Escrow `json:"result"`
+	Status string         `json:"status"`
+}
+
 // ReadUserHistoryRequest defines model for ReadUserHistoryRequest.
 type ReadUserHistoryRequest struct {
 	Limit  int64         `json:"limit"`
@@ -63,7 +310,7 @@ type ReadUserHistoryResponse struct {
 
 // ReadUserRequest defines model for ReadUserRequest.
 type ReadUserRequest struct {
-	Currency *string `json:"currency"`
+	Currency *string `json:"currency,omitempty"`
 	UserId   int64   `json:"user_id"`
 }
 
@@ -76,34 +323,98 @@ type ReadUserResponse struct {
 	Status string `json:"status"`
 }
 
+// ReadUsersRequest defines model for ReadUsersRequest.
+type ReadUsersRequest struct {
+	UserIds []int64 `json:"user_ids"`
+}
+
+// ReadUsersResponse defines model for ReadUsersResponse.
+type ReadUsersResponse struct {
+	Result []ReadUsersResult `json:"result"`
+	Status string            `json:"status"`
+}
+
+// ReadUsersResult defines model for ReadUsersResult.
+type ReadUsersResult struct {
+	Balance *decimal.Decimal `json:"balance,omitempty"`
+	Error   *string          `json:"error,omitempty"`
+	UserId  int64            `json:"user_id"`
+}
+
+// ReconciliationRequest defines model for ReconciliationRequest.
+type ReconciliationRequest struct {
+	StatementId int64 `json:"statement_id"`
+}
+
+// ReconciliationResponse defines model for ReconciliationResponse.
+type ReconciliationResponse struct {
+	Result storage.Reconciliation `json:"result"`
+	Status string                 `json:"status"`
+}
+
+// RedeemVoucherRequest defines model for RedeemVoucherRequest.
+type RedeemVoucherRequest struct {
+	Code   string `json:"code"`
+	UserId int64  `json:"user_id"`
+}
+
+// RedeemVoucherResponse defines model for RedeemVoucherResponse.
+type RedeemVoucherResponse struct {
+	Result struct {
+		Amount  decimal.Decimal `json:"amount"`
+		Message string          `json:"message"`
+	} `json:"result"`
+	Status string `json:"status"`
+}
+
 // ReservationOfFundsRequest defines model for ReservationOfFundsRequest.
 type ReservationOfFundsRequest struct {
-	OrderId   int64   `json:"order_id"`
-	Price     float32 `json:"price"`
-	ServiceId int64   `json:"service_id"`
-	UserId    int64   `json:"user_id"`
+	DryRun    *bool  `json:"dry_run,omitempty"`
+	OrderId   int64  `json:"order_id"`
+	Price     Amount `json:"price"`
+	ServiceId int64  `json:"service_id"`
+	UserId    int64  `json:"user_id"`
 }
 
 // ReservationOfFundsResponse defines model for ReservationOfFundsResponse.
 type ReservationOfFundsResponse = AccountDepositResponse
 
+// ResolveReconciliationRequest defines model for ResolveReconciliationRequest.
+type ResolveReconciliationRequest struct {
+	LineId    *int64  `json:"line_id,omitempty"`
+	Note      *string `json:"note,omitempty"`
+	Operator  string  `json:"operator"`
+	PostingId *int64  `json:"posting_id,omitempty"`
+}
+
+// ResolveReconciliationResponse defines model for ResolveReconciliationResponse.
+type ResolveReconciliationResponse = AccountDepositResponse
+
 // RevenueRecognitionRequest defines model for RevenueRecognitionRequest.
 type RevenueRecognitionRequest struct {
-	OrderId   int64   `json:"order_id"`
-	ServiceId int64   `json:"service_id"`
-	Sum       float32 `json:"sum"`
-	UserId    int64   `json:"user_id"`
+	OrderId   int64  `json:"order_id"`
+	ServiceId int64  `json:"service_id"`
+	Sum       Amount `json:"sum"`
+	UserId    int64  `json:"user_id"`
 }
 
 // RevenueRecognitionResponse defines model for RevenueRecognitionResponse.
 type RevenueRecognitionResponse = AccountDepositResponse
 
+// SplitEscrowRequest defines model for SplitEscrowRequest.
+type SplitEscrowRequest struct {
+	BeneficiaryShare Amount `json:"beneficiary_share"`
+	EscrowId         int64  `json:"escrow_id"`
+}
+
 // TransferCommandRequest defines model for TransferCommandRequest.
 type TransferCommandRequest struct {
-	Amount      float32 `json:"amount"`
+	Amount      Amount  `json:"amount"`
 	Description *string `json:"description"`
+	DryRun      *bool   `json:"dry_run,omitempty"`
 	Recipient   int64   `json:"recipient"`
 	Sender      int64   `json:"sender"`
+	UndoWindow  *int64  `json:"undo_window,omitempty"`
 }
 
 // TransferCommandResponse defines model for TransferCommandResponse.
@@ -122,54 +433,199 @@ type UnreservationOfFundsResponse = AccountDepositResponse
 // Version defines model for Version.
 type Version = int64
 
+// Violation defines model for Violation.
+type Violation struct {
+	// path of the value like user_ids[1], empty for the whole body
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// AcceptPaymentRequestJSONBody defines parameters for AcceptPaymentRequest.
+type AcceptPaymentRequestJSONBody = AnswerPaymentRequestRequest
+
 // AccountDepositJSONBody defines parameters for AccountDeposit.
 type AccountDepositJSONBody = AccountDepositRequest
 
 // AccountWithdrawalJSONBody defines parameters for AccountWithdrawal.
 type AccountWithdrawalJSONBody = AccountWithdrawalRequest
 
+// ApproveWithdrawalJSONBody defines parameters for ApproveWithdrawal.
+type ApproveWithdrawalJSONBody = DecideWithdrawalRequest
+
+// CancelTransferJSONBody defines parameters for CancelTransfer.
+type CancelTransferJSONBody = CancelTransferRequest
+
+// CreateEscrowJSONBody defines parameters for CreateEscrow.
+type CreateEscrowJSONBody = CreateEscrowRequest
+
+// CreatePaymentRequestJSONBody defines parameters for CreatePaymentRequest.
+type CreatePaymentRequestJSONBody = CreatePaymentRequestRequest
+
+// DeclinePaymentRequestJSONBody defines parameters for DeclinePaymentRequest.
+type DeclinePaymentRequestJSONBody = AnswerPaymentRequestRequest
+
+// DepositCallbackJSONBody defines parameters for DepositCallback.
+type DepositCallbackJSONBody = DepositCallbackRequest
+
+// GenerateVouchersJSONBody defines parameters for GenerateVouchers.
+type GenerateVouchersJSONBody = GenerateVouchersRequest
+
+// GrantBonusJSONBody defines parameters for GrantBonus.
+type GrantBonusJSONBody = GrantBonusRequest
+
+// ListFraudFlagsJSONBody defines parameters for ListFraudFlags.
+type ListFraudFlagsJSONBody = ListFraudFlagsRequest
+
+// ListIncomingPaymentRequestsJSONBody defines parameters for ListIncomingPaymentRequests.
+type ListIncomingPaymentRequestsJSONBody = ListPaymentRequestsRequest
+
+// ListOutgoingPaymentRequestsJSONBody defines parameters for ListOutgoingPaymentRequests.
+type ListOutgoingPaymentRequestsJSONBody = ListPaymentRequestsRequest
+
+// ListWithdrawalRequestsJSONBody defines parameters for ListWithdrawalRequests.
+type ListWithdrawalRequestsJSONBody = ListWithdrawalRequestsRequest
+
 // MonthlyReportJSONBody defines parameters for MonthlyReport.
 type MonthlyReportJSONBody = MonthlyReportRequest
 
+// ReadBalanceBucketsJSONBody defines parameters for ReadBalanceBuckets.
+type ReadBalanceBucketsJSONBody = ReadBalanceBucketsRequest
+
+// ReadEscrowJSONBody defines parameters for ReadEscrow.
+type ReadEscrowJSONBody = EscrowCommandRequest
+
+// ReadReconciliationJSONBody defines parameters for ReadReconciliation.
+type ReadReconciliationJSONBody = ReconciliationRequest
+
 // ReadUserJSONBody defines parameters for ReadUser.
 type ReadUserJSONBody = ReadUserRequest
 
 // ReadUserHistoryJSONBody defines parameters for ReadUserHistory.
 type ReadUserHistoryJSONBody = ReadUserHistoryRequest
 
+// ReadUsersJSONBody defines parameters for ReadUsers.
+type ReadUsersJSONBody = ReadUsersRequest
+
+// RedeemVoucherJSONBody defines parameters for RedeemVoucher.
+type RedeemVoucherJSONBody = RedeemVoucherRequest
+
+// RefundEscrowJSONBody defines parameters for RefundEscrow.
+type RefundEscrowJSONBody = EscrowCommandRequest
+
+// RejectWithdrawalJSONBody defines parameters for RejectWithdrawal.
+type RejectWithdrawalJSONBody = DecideWithdrawalRequest
+
+// ReleaseEscrowJSONBody defines parameters for ReleaseEscrow.
+type ReleaseEscrowJSONBody = EscrowCommandRequest
+
 // ReservationOfFundsJSONBody defines parameters for ReservationOfFunds.
 type ReservationOfFundsJSONBody = ReservationOfFundsRequest
 
+// ResolveReconciliationJSONBody defines parameters for ResolveReconciliation.
+type ResolveReconciliationJSONBody = ResolveReconciliationRequest
+
 // RevenueRecognitionJSONBody defines parameters for RevenueRecognition.
 type RevenueRecognitionJSONBody = RevenueRecognitionRequest
 
+// SplitEscrowJSONBody defines parameters for SplitEscrow.
+type SplitEscrowJSONBody = SplitEscrowRequest
+
 // TransferCommandJSONBody defines parameters for TransferCommand.
 type TransferCommandJSONBody = TransferCommandRequest
 
 // UnreservationOfFundsJSONBody defines parameters for UnreservationOfFunds.
 type UnreservationOfFundsJSONBody = UnreservationOfFundsRequest
 
+// AcceptPaymentRequestJSONRequestBody defines body for AcceptPaymentRequest for application/json ContentType.
+type AcceptPaymentRequestJSONRequestBody = AcceptPaymentRequestJSONBody
+
 // AccountDepositJSONRequestBody defines body for AccountDeposit for application/json ContentType.
 type AccountDepositJSONRequestBody = AccountDepositJSONBody
 
 // AccountWithdrawalJSONRequestBody defines body for AccountWithdrawal for application/json ContentType.
 type AccountWithdrawalJSONRequestBody = AccountWithdrawalJSONBody
 
+// ApproveWithdrawalJSONRequestBody defines body for ApproveWithdrawal for application/json ContentType.
+type ApproveWithdrawalJSONRequestBody = ApproveWithdrawalJSONBody
+
+// CancelTransferJSONRequestBody defines body for CancelTransfer for application/json ContentType.
+type CancelTransferJSONRequestBody = CancelTransferJSONBody
+
+// CreateEscrowJSONRequestBody defines body for CreateEscrow for application/json ContentType.
+type CreateEscrowJSONRequestBody = CreateEscrowJSONBody
+
+// CreatePaymentRequestJSONRequestBody defines body for CreatePaymentRequest for application/json ContentType.
+type CreatePaymentRequestJSONRequestBody = CreatePaymentRequestJSONBody
+
+// DeclinePaymentRequestJSONRequestBody defines body for DeclinePaymentRequest for application/json ContentType.
+type DeclinePaymentRequestJSONRequestBody = DeclinePaymentRequestJSONBody
+
+// DepositCallbackJSONRequestBody defines body for DepositCallback for application/json ContentType.
+type DepositCallbackJSONRequestBody = DepositCallbackJSONBody
+
+// GenerateVouchersJSONRequestBody defines body for GenerateVouchers for application/json ContentType.
+type GenerateVouchersJSONRequestBody = GenerateVouchersJSONBody
+
+// GrantBonusJSONRequestBody defines body for GrantBonus for application/json ContentType.
+type GrantBonusJSONRequestBody = GrantBonusJSONBody
+
+// ListFraudFlagsJSONRequestBody defines body for ListFraudFlags for application/json ContentType.
+type ListFraudFlagsJSONRequestBody = ListFraudFlagsJSONBody
+
+// ListIncomingPaymentRequestsJSONRequestBody defines body for ListIncomingPaymentRequests for application/json ContentType.
+type ListIncomingPaymentRequestsJSONRequestBody = ListIncomingPaymentRequestsJSONBody
+
+// ListOutgoingPaymentRequestsJSONRequestBody defines body for ListOutgoingPaymentRequests for application/json ContentType.
+type ListOutgoingPaymentRequestsJSONRequestBody = ListOutgoingPaymentRequestsJSONBody
+
+// ListWithdrawalRequestsJSONRequestBody defines body for ListWithdrawalRequests for application/json ContentType.
+type ListWithdrawalRequestsJSONRequestBody = ListWithdrawalRequestsJSONBody
+
 // MonthlyReportJSONRequestBody defines body for MonthlyReport for application/json ContentType.
 type MonthlyReportJSONRequestBody = MonthlyReportJSONBody
 
+// ReadBalanceBucketsJSONRequestBody defines body for ReadBalanceBuckets for application/json ContentType.
+type ReadBalanceBucketsJSONRequestBody = ReadBalanceBucketsJSONBody
+
+// ReadEscrowJSONRequestBody defines body for ReadEscrow for application/json ContentType.
+type ReadEscrowJSONRequestBody = ReadEscrowJSONBody
+
+// ReadReconciliationJSONRequestBody defines body for ReadReconciliation for application/json ContentType.
+type ReadReconciliationJSONRequestBody = ReadReconciliationJSONBody
+
 // ReadUserJSONRequestBody defines body for ReadUser for application/json ContentType.
 type ReadUserJSONRequestBody = ReadUserJSONBody
 
 // ReadUserHistoryJSONRequestBody defines body for ReadUserHistory for application/json ContentType.
 type ReadUserHistoryJSONRequestBody = ReadUserHistoryJSONBody
 
+// ReadUsersJSONRequestBody defines body for ReadUsers for application/json ContentType.
+type ReadUsersJSONRequestBody = ReadUsersJSONBody
+
+// RedeemVoucherJSONRequestBody defines body for RedeemVoucher for application/json ContentType.
+type RedeemVoucherJSONRequestBody = RedeemVoucherJSONBody
+
+// RefundEscrowJSONRequestBody defines body for RefundEscrow for application/json ContentType.
+type RefundEscrowJSONRequestBody = RefundEscrowJSONBody
+
+// RejectWithdrawalJSONRequestBody defines body for RejectWithdrawal for application/json ContentType.
+type RejectWithdrawalJSONRequestBody = RejectWithdrawalJSONBody
+
+// ReleaseEscrowJSONRequestBody defines body for ReleaseEscrow for application/json ContentType.
+type ReleaseEscrowJSONRequestBody = ReleaseEscrowJSONBody
+
 // ReservationOfFundsJSONRequestBody defines body for ReservationOfFunds for application/json ContentType.
 type ReservationOfFundsJSONRequestBody = ReservationOfFundsJSONBody
 
+// ResolveReconciliationJSONRequestBody defines body for ResolveReconciliation for application/json ContentType.
+type ResolveReconciliationJSONRequestBody = ResolveReconciliationJSONBody
+
 // RevenueRecognitionJSONRequestBody defines body for RevenueRecognition for application/json ContentType.
 type RevenueRecognitionJSONRequestBody = RevenueRecognitionJSONBody
 
+// SplitEscrowJSONRequestBody defines body for SplitEscrow for application/json ContentType.
+type SplitEscrowJSONRequestBody = SplitEscrowJSONBody
+
 // TransferCommandJSONRequestBody defines body for TransferCommand for application/json ContentType.
 type TransferCommandJSONRequestBody = TransferCommandJSONBody
 
@@ -0,0 +1,68 @@
+package i18n
+
+// Report header keys, the error messages are keyed by the codes of the API errors
+const (
+	ReportServiceID    = "report.service_id"
+	ReportTotalRevenue = "report.total_revenue"
+)
+
+// catalog holds the messages by language and key. The English error messages are written next to the errors
+// in the handlers, because they often name the failed operation, so only the Russian ones are here
+var catalog = map[Lang]map[string]string{
+	English: {
+		ReportServiceID:    "service_id",
+		ReportTotalRevenue: "total_revenue",
+	},
+	Russian: {
+		ReportServiceID:    "id_услуги",
+		ReportTotalRevenue: "общая_выручка",
+
+		"MALFORMED_BODY":         "некорректное тело запроса",
+		"INVALID_FIELD":          "неверное значение \"{field}\"",
+		"VALIDATION_FAILED":      "тело запроса не соответствует схеме",
+		"BODY_TOO_LARGE":         "тело запроса больше {limit} байт",
+		"NOT_FOUND":              "путь не найден",
+		"METHOD_NOT_ALLOWED":     "метод не поддерживается",
+		"INVALID_SIGNATURE":      "неверная подпись уведомления",
+		"INVALID_CURRENCY":       "неверный код валюты",
+		"PAYMENT_PROVIDER_ERROR": "ошибка платежного провайдера",
+		"INVALID_STATEMENT":      "некорректная банковская выписка ({reason})",
+		"INTERNAL":               "внутренняя ошибка сервера",
+		"UNAUTHENTICATED":        "API-ключ отсутствует или недействителен",
+		"INSUFFICIENT_SCOPE":     "у API-ключа нет права {scope}",
+		"SERVICE_NOT_ALLOWED":    "клиент не привязан к услуге {service_id}",
+		"RATE_LIMITED":           "слишком много запросов, повторите через {retry_after} с",
+
+		"INSUFFICIENT_FUNDS":           "недостаточно средств на счете",
+		"INSUFFICIENT_RESERVE":         "сумма больше зарезервированной",
+		"USER_NOT_FOUND":               "пользователь не существует",
+		"FRAUD_BLOCKED":                "операция заблокирована правилами антифрода",
+		"SERIALIZATION_FAILURE":        "операция конфликтует с параллельной операцией, повторите ее",
+		"UNBALANCED_ENTRY":             "проводки операции не сходятся, операция не выполнена",
+		"ORDER_EXISTS":                 "заказ уже существует",
+		"ORDER_NOT_FOUND":              "заказ на резервирование не существует",
+		"ORDER_FINISHED":               "запись о разрезервировании или в сводном отчете уже существует",
+		"REPORT_NOT_FOUND":             "записи не найдены",
+		"TRANSFER_NOT_FOUND":           "перевод не существует",
+		"TRANSFER_FINISHED":            "перевод уже проведен или отменен",
+		"UNDO_WINDOW_EXPIRED":          "время отмены перевода истекло",
+		"PAYMENT_REQUEST_NOT_FOUND":    "запрос на оплату не существует",
+		"PAYMENT_REQUEST_FINISHED":     "запрос на оплату уже принят, отклонен или истек",
+		"ESCROW_NOT_FOUND":             "эскроу не существует",
+		"ESCROW_FINISHED":              "эскроу уже выплачен или возвращен",
+		"ESCROW_SPLIT_EXCEEDED":        "доля получателя больше суммы эскроу",
+		"VOUCHER_NOT_FOUND":            "ваучер не существует",
+		"VOUCHER_EXPIRED":              "срок действия ваучера истек",
+		"VOUCHER_USED_UP":              "достигнут лимит погашений ваучера",
+		"VOUCHER_ALREADY_REDEEMED":     "ваучер уже погашен пользователем",
+		"DEPOSIT_NOT_FOUND":            "пополнение не существует",
+		"DEPOSIT_FINISHED":             "пополнение уже подтверждено или отклонено",
+		"WITHDRAWAL_REQUEST_NOT_FOUND": "заявка на вывод не существует",
+		"WITHDRAWAL_REQUEST_DECIDED":   "заявка на вывод уже одобрена или отклонена",
+		"STATEMENT_NOT_FOUND":          "банковская выписка не существует",
+		"STATEMENT_LINE_NOT_FOUND":     "строка банковской выписки не существует",
+		"STATEMENT_LINE_RECONCILED":    "строка банковской выписки уже сопоставлена или разрешена",
+		"POSTING_NOT_FOUND":            "проводка кассовой книги не существует",
+		"POSTING_RECONCILED":           "проводка кассовой книги уже сопоставлена или разрешена",
+	},
+}
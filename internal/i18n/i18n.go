@@ -0,0 +1,86 @@
+// package i18n selects the language of the client and translates the messages of the API
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Lang is the primary language subtag like "en"
+type Lang string
+
+const (
+	English Lang = "en"
+	Russian Lang = "ru"
+)
+
+// Fallback is used when the client accepts none of the supported languages
+const Fallback = English
+
+// FromAcceptLanguage returns the supported language the client prefers most in the Accept-Language header
+func FromAcceptLanguage(header string) Lang {
+	type weighted struct {
+		lang Lang
+		q    float64
+	}
+
+	var langs []weighted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			v, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+			if err != nil {
+				v = 0
+			}
+			q = v
+		}
+		if q <= 0 {
+			continue
+		}
+
+		// the region is not used, "ru-RU" is "ru"
+		if i := strings.IndexByte(tag, '-'); i >= 0 {
+			tag = tag[:i]
+		}
+		if tag == "*" {
+			tag = string(Fallback)
+		}
+		langs = append(langs, weighted{Lang(tag), q})
+	}
+
+	sort.SliceStable(langs, func(i, j int) bool { return langs[i].q > langs[j].q })
+	for _, l := range langs {
+		if _, ok := catalog[l.lang]; ok {
+			return l.lang
+		}
+	}
+	return Fallback
+}
+
+// Message returns the message of the key in the language, the English message is used when the language
+// has no translation. The {name} placeholders are replaced with args. False means the catalog has no such key
+func Message(lang Lang, key string, args map[string]string) (string, bool) {
+	message, ok := catalog[lang][key]
+	if !ok {
+		message, ok = catalog[Fallback][key]
+		if !ok {
+			return "", false
+		}
+	}
+
+	for name, value := range args {
+		message = strings.ReplaceAll(message, "{"+name+"}", value)
+	}
+	return message, true
+}
@@ -0,0 +1,42 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromAcceptLanguage(t *testing.T) {
+	var tests = []struct {
+		header string
+		lang   Lang
+	}{
+		{"", English},
+		{"ru", Russian},
+		{"ru-RU,ru;q=0.9,en;q=0.8", Russian},
+		{"en-US,en;q=0.9,ru;q=0.8", English},
+		{"de;q=1, ru;q=0.5", Russian},
+		{"en;q=0.3, RU;q=0.7", Russian},
+		{"ru;q=0, en", English},
+		{"de, fr", English},
+		{"*", English},
+		{"ru;q=abc", English},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.lang, FromAcceptLanguage(tc.header), tc.header)
+	}
+}
+
+func TestMessage(t *testing.T) {
+	msg, ok := Message(Russian, "INVALID_FIELD", map[string]string{"field": "user_id"})
+	assert.True(t, ok)
+	assert.Equal(t, "неверное значение \"user_id\"", msg)
+
+	// English is the fallback of the missing translation
+	msg, ok = Message(Lang("de"), ReportTotalRevenue, nil)
+	assert.True(t, ok)
+	assert.Equal(t, "total_revenue", msg)
+
+	_, ok = Message(English, "INVALID_FIELD", nil)
+	assert.False(t, ok)
+}
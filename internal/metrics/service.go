@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Default is the registry served at /metrics
+var Default = prometheus.NewRegistry()
+
+// the metrics of the service, the latencies are in seconds and the amounts are in the major units of the currency
+var (
+	HTTPRequests = promauto.With(Default).NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Number of the HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "status"})
+	HTTPRequestDuration = promauto.With(Default).NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Latency of the HTTP requests by route, method and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	StorageDuration = promauto.With(Default).NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "storage_operation_duration_seconds",
+		Help:    "Latency of the storage operations.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation"})
+	StorageErrors = promauto.With(Default).NewCounterVec(prometheus.CounterOpts{
+		Name: "storage_errors_total",
+		Help: "Number of the failed storage operations by the storage error, other is any error the storage does not define.",
+	}, []string{"operation", "error"})
+	SerializationFailures = promauto.With(Default).NewCounterVec(prometheus.CounterOpts{
+		Name: "storage_serialization_failures_total",
+		Help: "Number of the storage operations failed by the serializable transaction conflicts.",
+	}, []string{"operation"})
+
+	ExchangerDuration = promauto.With(Default).NewHistogram(prometheus.HistogramOpts{
+		Name:    "exchanger_request_duration_seconds",
+		Help:    "Latency of the currency exchange rate requests.",
+		Buckets: prometheus.DefBuckets,
+	})
+	ExchangerErrors = promauto.With(Default).NewCounterVec(prometheus.CounterOpts{
+		Name: "exchanger_errors_total",
+		Help: "Number of the failed currency exchange rate requests, invalid_currency is the currency unknown to the exchanger.",
+	}, []string{"error"})
+
+	AmountMoved = promauto.With(Default).NewCounterVec(prometheus.CounterOpts{
+		Name: "amount_moved_total",
+		Help: "Total amount moved by the committed operations.",
+	}, []string{"operation", "currency"})
+)
+
+// DroppedSpans is the number of the spans not exported to the tracing backend
+var DroppedSpans = promauto.With(Default).NewCounterVec(prometheus.CounterOpts{
+	Name: "tracing_dropped_spans_total",
+	Help: "Number of the spans not exported by the reason, queue_full is the full export queue and export_failed is the failed export.",
+}, []string{"reason"})
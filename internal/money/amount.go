@@ -0,0 +1,65 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Amount is an amount in major units as it is sent in the API requests.
+// The amount is a decimal string like "100.50", the JSON number form is still accepted
+// for compatibility and is reported by Numeric. The text is kept as is and parsed by Money,
+// so a number never passes through a float
+type Amount struct {
+	text    string
+	numeric bool
+}
+
+// NewAmount returns the amount of the decimal string
+func NewAmount(s string) Amount {
+	return Amount{text: s}
+}
+
+// Numeric reports whether the amount was sent as a JSON number
+func (a Amount) Numeric() bool {
+	return a.numeric
+}
+
+// Money parses the amount in the currency, see Parse
+func (a Amount) Money(currency Currency) (Money, error) {
+	return Parse(a.text, currency)
+}
+
+func (a Amount) String() string {
+	return a.text
+}
+
+// MarshalJSON writes the amount as a decimal string
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.text)
+}
+
+// UnmarshalJSON keeps the text of a decimal string or a number, the value is checked by Money
+func (a *Amount) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 {
+		return fmt.Errorf("%w: empty value", ErrAmount)
+	}
+	if string(b) == "null" {
+		return nil
+	}
+
+	switch c := b[0]; {
+	case c == '"':
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		*a = Amount{text: s}
+	case c == '-' || '0' <= c && c <= '9':
+		*a = Amount{text: string(b), numeric: true}
+	default:
+		return fmt.Errorf("%w: %s is neither a string nor a number", ErrAmount, b)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package money
+
+// Currency is the ISO 4217 code of the currency
+type Currency string
+
+const (
+	RUB Currency = "RUB"
+	USD Currency = "USD"
+	EUR Currency = "EUR"
+	JPY Currency = "JPY"
+)
+
+// exponents holds the number of decimal places of the currencies whose minor unit is not a hundredth
+var exponents = map[Currency]int32{
+	JPY:   0,
+	"KRW": 0,
+	"BHD": 3,
+	"KWD": 3,
+	"OMR": 3,
+}
+
+// Exponent returns the number of decimal places of the minor unit of the currency
+func (c Currency) Exponent() int32 {
+	if e, ok := exponents[c]; ok {
+		return e
+	}
+	return 2
+}
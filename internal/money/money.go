@@ -0,0 +1,236 @@
+// package money represents amounts of money as an integer number of minor units of their currency
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrAmount    = errors.New("malformed amount")
+	ErrPrecision = errors.New("amount has more decimal places than the currency allows")
+	ErrCurrency  = errors.New("amounts have different currencies")
+)
+
+// Money is an amount in minor units of the currency, for example kopecks of the ruble.
+// The zero value is zero rubles
+type Money struct {
+	minor    int64
+	currency Currency
+}
+
+// New returns the amount of minor units of the currency
+func New(minor int64, currency Currency) Money {
+	return Money{minor: minor, currency: currency}
+}
+
+// FromMajor converts the amount in major units to minor ones.
+// The amount must not have more decimal places than the currency allows
+func FromMajor(major decimal.Decimal, currency Currency) (Money, error) {
+	minor := major.Shift(currency.Exponent())
+	if !minor.Equal(minor.Truncate(0)) {
+		return Money{}, ErrPrecision
+	}
+	if !minor.Equal(decimal.NewFromInt(minor.IntPart())) {
+		return Money{}, ErrAmount
+	}
+	return New(minor.IntPart(), currency), nil
+}
+
+// Round converts the amount in major units to minor ones rounding half away from zero
+func Round(major decimal.Decimal, currency Currency) Money {
+	return New(major.Round(currency.Exponent()).Shift(currency.Exponent()).IntPart(), currency)
+}
+
+// Parse reads the amount in major units from its decimal form like "100.50"
+func Parse(s string, currency Currency) (Money, error) {
+	major, err := decimal.NewFromString(s)
+	if err != nil || strings.ContainsAny(s, "eE") {
+		return Money{}, fmt.Errorf("%w: %q", ErrAmount, s)
+	}
+	return FromMajor(major, currency)
+}
+
+// Minor returns the amount in minor units
+func (m Money) Minor() int64 {
+	return m.minor
+}
+
+// Major returns the amount in major units
+func (m Money) Major() decimal.Decimal {
+	return decimal.New(m.minor, -m.Currency().Exponent())
+}
+
+// Currency returns the currency of the amount, the amount without the currency is in rubles
+func (m Money) Currency() Currency {
+	if m.currency == "" {
+		return RUB
+	}
+	return m.currency
+}
+
+func (m Money) IsZero() bool {
+	return m.minor == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.minor > 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.minor < 0
+}
+
+// Neg returns the amount with the opposite sign
+func (m Money) Neg() Money {
+	return New(-m.minor, m.currency)
+}
+
+// Add returns the sum of the amounts. The amounts must have the same currency
+func (m Money) Add(o Money) Money {
+	m.mustMatch(o)
+	return New(m.minor+o.minor, m.Currency())
+}
+
+// Sub returns the difference of the amounts. The amounts must have the same currency
+func (m Money) Sub(o Money) Money {
+	m.mustMatch(o)
+	return New(m.minor-o.minor, m.Currency())
+}
+
+// Cmp compares the amounts and returns -1, 0 or +1. The amounts must have the same currency
+func (m Money) Cmp(o Money) int {
+	m.mustMatch(o)
+	switch {
+	case m.minor < o.minor:
+		return -1
+	case m.minor > o.minor:
+		return 1
+	}
+	return 0
+}
+
+func (m Money) Equal(o Money) bool {
+	return m.Cmp(o) == 0
+}
+
+func (m Money) GreaterThan(o Money) bool {
+	return m.Cmp(o) > 0
+}
+
+func (m Money) LessThan(o Money) bool {
+	return m.Cmp(o) < 0
+}
+
+// Min returns the smaller of the amounts
+func Min(a, b Money) Money {
+	if b.LessThan(a) {
+		return b
+	}
+	return a
+}
+
+// Max returns the larger of the amounts
+func Max(a, b Money) Money {
+	if b.GreaterThan(a) {
+		return b
+	}
+	return a
+}
+
+// mustMatch panics when the amounts have different currencies, mixing them is a programming error
+func (m Money) mustMatch(o Money) {
+	if m.Currency() != o.Currency() {
+		panic(fmt.Errorf("%w: %s and %s", ErrCurrency, m.Currency(), o.Currency()))
+	}
+}
+
+// Format returns the amount in major units with all decimal places of the currency like "100.50"
+func (m Money) Format() string {
+	return m.Major().StringFixed(m.Currency().Exponent())
+}
+
+// String returns the amount with its currency like "100.50 RUB"
+func (m Money) String() string {
+	return m.Format() + " " + string(m.Currency())
+}
+
+// MarshalJSON writes the amount in major units as a decimal string
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.Major().String())
+}
+
+// UnmarshalJSON reads the amount in major units from a decimal string or a number.
+// The currency of the receiver is kept, rubles are used when it is not set
+func (m *Money) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if strings.HasPrefix(s, `"`) {
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+	}
+
+	v, err := Parse(s, m.Currency())
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// UnmarshalText reads the amount in major units, so it can be set from the environment variables
+func (m *Money) UnmarshalText(b []byte) error {
+	v, err := Parse(string(b), m.Currency())
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// Value stores the amount in minor units, so it is written to bigint columns
+func (m Money) Value() (driver.Value, error) {
+	return m.minor, nil
+}
+
+// Scan reads the amount in minor units from integer and numeric columns.
+// The currency of the receiver is kept, rubles are used when it is not set
+func (m *Money) Scan(src interface{}) error {
+	var minor int64
+
+	switch v := src.(type) {
+	case int64:
+		minor = v
+	case string:
+		return m.scanText(v)
+	case []byte:
+		return m.scanText(string(v))
+	case nil:
+		return fmt.Errorf("%w: cannot scan NULL", ErrAmount)
+	default:
+		return fmt.Errorf("%w: cannot scan %T", ErrAmount, src)
+	}
+
+	*m = New(minor, m.Currency())
+	return nil
+}
+
+func (m *Money) scanText(s string) error {
+	minor, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		d, err := decimal.NewFromString(s)
+		if err != nil || !d.Equal(d.Truncate(0)) {
+			return fmt.Errorf("%w: cannot scan %q", ErrAmount, s)
+		}
+		minor = d.IntPart()
+	}
+
+	*m = New(minor, m.Currency())
+	return nil
+}
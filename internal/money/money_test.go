@@ -0,0 +1,176 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromMajor(t *testing.T) {
+	m, err := FromMajor(decimal.RequireFromString("100.5"), RUB)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10050), m.Minor())
+	assert.Equal(t, RUB, m.Currency())
+
+	m, err = FromMajor(decimal.RequireFromString("7"), JPY)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), m.Minor())
+
+	_, err = FromMajor(decimal.RequireFromString("100.555"), RUB)
+	assert.ErrorIs(t, err, ErrPrecision)
+
+	_, err = FromMajor(decimal.RequireFromString("1.5"), JPY)
+	assert.ErrorIs(t, err, ErrPrecision)
+
+	_, err = FromMajor(decimal.RequireFromString("1000000000000000000000"), RUB)
+	assert.ErrorIs(t, err, ErrAmount)
+}
+
+func TestRound(t *testing.T) {
+	assert.Equal(t, int64(10056), Round(decimal.RequireFromString("100.555"), RUB).Minor())
+	assert.Equal(t, int64(-10056), Round(decimal.RequireFromString("-100.555"), RUB).Minor())
+	assert.Equal(t, int64(10055), Round(decimal.RequireFromString("100.5549"), RUB).Minor())
+}
+
+func TestParse(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input string
+		minor int64
+		err   error
+	}{
+		{"integer", "100", 10000, nil},
+		{"two decimal places", "100.05", 10005, nil},
+		{"negative", "-0.5", -50, nil},
+		{"too many decimal places", "1.001", 0, ErrPrecision},
+		{"exponent", "1e2", 0, ErrAmount},
+		{"not a number", "ten", 0, ErrAmount},
+		{"empty", "", 0, ErrAmount},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := Parse(tc.input, RUB)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.minor, m.Minor())
+		})
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	a := New(1000, RUB)
+	b := New(250, RUB)
+
+	assert.Equal(t, int64(1250), a.Add(b).Minor())
+	assert.Equal(t, int64(750), a.Sub(b).Minor())
+	assert.Equal(t, int64(-1000), a.Neg().Minor())
+	assert.True(t, a.GreaterThan(b))
+	assert.True(t, b.LessThan(a))
+	assert.Equal(t, b, Min(a, b))
+
+	// the zero value is in rubles
+	assert.True(t, Money{}.Add(a).Equal(a))
+
+	assert.Panics(t, func() { a.Add(New(1, USD)) })
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "100.50", New(10050, RUB).Format())
+	assert.Equal(t, "-0.05", New(-5, RUB).Format())
+	assert.Equal(t, "100.50 RUB", New(10050, RUB).String())
+	assert.Equal(t, "7 JPY", New(7, JPY).String())
+	assert.Equal(t, "1.005 KWD", New(1005, "KWD").String())
+}
+
+func TestJSON(t *testing.T) {
+	b, err := json.Marshal(New(10050, RUB))
+	require.NoError(t, err)
+	assert.Equal(t, `"100.5"`, string(b))
+
+	var v struct {
+		Amount Money `json:"amount"`
+	}
+	err = json.Unmarshal([]byte(`{"amount":"100.05"}`), &v)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10005), v.Amount.Minor())
+
+	err = json.Unmarshal([]byte(`{"amount":12.5}`), &v)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1250), v.Amount.Minor())
+
+	err = json.Unmarshal([]byte(`{"amount":"12.505"}`), &v)
+	assert.ErrorIs(t, err, ErrPrecision)
+}
+
+func TestSQL(t *testing.T) {
+	v, err := New(10050, RUB).Value()
+	require.NoError(t, err)
+	assert.Equal(t, int64(10050), v)
+
+	var m Money
+	require.NoError(t, m.Scan(int64(10050)))
+	assert.True(t, New(10050, RUB).Equal(m))
+
+	require.NoError(t, m.Scan("-300"))
+	assert.Equal(t, int64(-300), m.Minor())
+
+	// sum over bigint columns is numeric
+	require.NoError(t, m.Scan([]byte("12345")))
+	assert.Equal(t, int64(12345), m.Minor())
+
+	assert.ErrorIs(t, m.Scan(nil), ErrAmount)
+	assert.ErrorIs(t, m.Scan("1.5"), ErrAmount)
+
+	var usd = New(0, USD)
+	require.NoError(t, usd.Scan(int64(100)))
+	assert.Equal(t, USD, usd.Currency())
+}
+
+func TestAmount(t *testing.T) {
+	var v struct {
+		Amount Amount `json:"amount"`
+	}
+
+	err := json.Unmarshal([]byte(`{"amount":"100.05"}`), &v)
+	require.NoError(t, err)
+	assert.False(t, v.Amount.Numeric())
+	m, err := v.Amount.Money(RUB)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10005), m.Minor())
+
+	// the number is read from its text, so it is exact beyond the float precision
+	err = json.Unmarshal([]byte(`{"amount":123456789012.34}`), &v)
+	require.NoError(t, err)
+	assert.True(t, v.Amount.Numeric())
+	m, err = v.Amount.Money(RUB)
+	require.NoError(t, err)
+	assert.Equal(t, int64(12345678901234), m.Minor())
+
+	err = json.Unmarshal([]byte(`{"amount":1.001}`), &v)
+	require.NoError(t, err)
+	_, err = v.Amount.Money(RUB)
+	assert.ErrorIs(t, err, ErrPrecision)
+
+	err = json.Unmarshal([]byte(`{"amount":1e2}`), &v)
+	require.NoError(t, err)
+	_, err = v.Amount.Money(RUB)
+	assert.ErrorIs(t, err, ErrAmount)
+
+	err = json.Unmarshal([]byte(`{"amount":true}`), &v)
+	assert.ErrorIs(t, err, ErrAmount)
+
+	// a missing amount is empty and does not parse
+	v.Amount = Amount{}
+	_, err = v.Amount.Money(RUB)
+	assert.ErrorIs(t, err, ErrAmount)
+
+	b, err := json.Marshal(NewAmount("12.50"))
+	require.NoError(t, err)
+	assert.Equal(t, `"12.50"`, string(b))
+}
@@ -0,0 +1,160 @@
+// package openapi reads the request schemas of the OpenAPI spec and validates request bodies against them
+package openapi
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	refPrefix = "#/components/schemas/"
+	mediaJSON = "application/json"
+)
+
+// Schema is the subset of the OpenAPI schema object used by the request validation
+type Schema struct {
+	Ref        string             `yaml:"$ref"`
+	Type       string             `yaml:"type"`
+	Format     string             `yaml:"format"`
+	Nullable   bool               `yaml:"nullable"`
+	Required   []string           `yaml:"required"`
+	Properties map[string]*Schema `yaml:"properties"`
+	Items      *Schema            `yaml:"items"`
+	Enum       []string           `yaml:"enum"`
+	Pattern    string             `yaml:"pattern"`
+	Minimum    *float64           `yaml:"minimum"`
+	Maximum    *float64           `yaml:"maximum"`
+	MinLength  *int               `yaml:"minLength"`
+	MaxLength  *int               `yaml:"maxLength"`
+	MinItems   *int               `yaml:"minItems"`
+	MaxItems   *int               `yaml:"maxItems"`
+
+	pattern *regexp.Regexp
+}
+
+type mediaType struct {
+	Schema *Schema `yaml:"schema"`
+}
+
+type operation struct {
+	RequestBody struct {
+		Content map[string]mediaType `yaml:"content"`
+	} `yaml:"requestBody"`
+}
+
+// Document holds the JSON request schemas of the spec operations by method and path
+type Document struct {
+	Paths map[string]map[string]*Schema
+}
+
+type document struct {
+	Paths      map[string]map[string]yaml.Node `yaml:"paths"`
+	Components struct {
+		Schemas map[string]*Schema `yaml:"schemas"`
+	} `yaml:"components"`
+}
+
+var methods = map[string]string{
+	"get":    "GET",
+	"put":    "PUT",
+	"post":   "POST",
+	"delete": "DELETE",
+	"patch":  "PATCH",
+}
+
+// Load reads the spec and resolves the references of the request schemas
+func Load(spec []byte) (*Document, error) {
+	var doc document
+	if err := yaml.Unmarshal(spec, &doc); err != nil {
+		return nil, err
+	}
+
+	d := &Document{Paths: make(map[string]map[string]*Schema)}
+	for path, item := range doc.Paths {
+		for key, node := range item {
+			method, ok := methods[key]
+			if !ok {
+				continue
+			}
+
+			var op operation
+			if err := node.Decode(&op); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+
+			media, ok := op.RequestBody.Content[mediaJSON]
+			if !ok || media.Schema == nil {
+				continue
+			}
+
+			schema, err := resolve(media.Schema, doc.Components.Schemas, nil)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+
+			if d.Paths[path] == nil {
+				d.Paths[path] = make(map[string]*Schema)
+			}
+			d.Paths[path][method] = schema
+		}
+	}
+	return d, nil
+}
+
+// RequestSchema returns the schema of the JSON request body of the operation
+func (d *Document) RequestSchema(method, path string) (*Schema, bool) {
+	s, ok := d.Paths[path][method]
+	return s, ok
+}
+
+// resolve replaces the references with the component schemas, seen guards against reference cycles
+func resolve(s *Schema, components map[string]*Schema, seen []string) (*Schema, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	if s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, refPrefix)
+		target, ok := components[name]
+		if name == s.Ref || !ok {
+			return nil, fmt.Errorf("unknown schema reference %q", s.Ref)
+		}
+		for _, n := range seen {
+			if n == name {
+				return nil, fmt.Errorf("schema reference cycle at %q", s.Ref)
+			}
+		}
+		return resolve(target, components, append(seen[:len(seen):len(seen)], name))
+	}
+
+	r := *s
+	if r.Pattern != "" {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", r.Pattern, err)
+		}
+		r.pattern = re
+	}
+
+	if len(s.Properties) > 0 {
+		r.Properties = make(map[string]*Schema, len(s.Properties))
+		for name, p := range s.Properties {
+			resolved, err := resolve(p, components, seen)
+			if err != nil {
+				return nil, fmt.Errorf("property %q: %w", name, err)
+			}
+			r.Properties[name] = resolved
+		}
+	}
+
+	items, err := resolve(s.Items, components, seen)
+	if err != nil {
+		return nil, fmt.Errorf("items: %w", err)
+	}
+	r.Items = items
+
+	return &r, nil
+}
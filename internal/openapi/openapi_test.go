@@ -0,0 +1,141 @@
+package openapi
+
+import (
+	"http-avito-test/api"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSpec = `
+paths:
+  /api/{version}/transfer:
+    parameters:
+      - $ref: '#/components/parameters/Version'
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/TransferRequest'
+  /api/{version}/import:
+    post:
+      requestBody:
+        content:
+          text/csv:
+            schema:
+              type: string
+components:
+  schemas:
+    Amount:
+      type: string
+      format: decimal
+      pattern: '^-?[0-9]+(\.[0-9]{1,2})?$'
+    TransferRequest:
+      type: object
+      properties:
+        sender:
+          type: integer
+          format: int64
+          minimum: 1
+        amount:
+          $ref: '#/components/schemas/Amount'
+        description:
+          type: string
+          nullable: true
+        tags:
+          type: array
+          maxItems: 2
+          items:
+            type: string
+            enum:
+              - gift
+              - rent
+        at:
+          type: string
+          format: date-time
+        dry_run:
+          type: boolean
+      required:
+        - sender
+        - amount
+`
+
+func TestLoad(t *testing.T) {
+	d, err := Load([]byte(testSpec))
+	require.NoError(t, err)
+
+	_, ok := d.RequestSchema("POST", "/api/{version}/transfer")
+	assert.True(t, ok)
+
+	_, ok = d.RequestSchema("GET", "/api/{version}/transfer")
+	assert.False(t, ok)
+
+	// only JSON bodies are validated
+	_, ok = d.RequestSchema("POST", "/api/{version}/import")
+	assert.False(t, ok)
+
+	_, err = Load([]byte(`
+paths:
+  /x:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Missing'
+`))
+	assert.Error(t, err)
+}
+
+func TestLoadServiceSpec(t *testing.T) {
+	d, err := Load(api.Schema)
+	require.NoError(t, err)
+
+	_, ok := d.RequestSchema("POST", "/api/{version}/accountdeposit")
+	assert.True(t, ok)
+}
+
+func TestValidate(t *testing.T) {
+	d, err := Load([]byte(testSpec))
+	require.NoError(t, err)
+
+	s, _ := d.RequestSchema("POST", "/api/{version}/transfer")
+
+	var tests = []struct {
+		name       string
+		body       string
+		violations []Violation
+	}{
+		{"valid", `{"sender":2, "amount":"100.50", "description":null, "tags":["gift"], "at":"2022-12-31T23:59:59Z", "dry_run":true}`, nil},
+		{"field names in other case", `{"Sender":2, "Amount":"100"}`, nil},
+		{"numeric decimal", `{"sender":2, "amount":100.5}`, nil},
+		{"malformed JSON", `{"sender":2,`, []Violation{{Message: "malformed JSON"}}},
+		{"not an object", `[1]`, []Violation{{Message: "must be an object"}}},
+		{"missing required", `{}`, []Violation{
+			{Field: "sender", Message: "is required"},
+			{Field: "amount", Message: "is required"},
+		}},
+		{"unknown field", `{"sender":2, "amount":"1", "fee":1}`, []Violation{{Field: "fee", Message: "unknown field"}}},
+		{"wrong types", `{"sender":"2", "amount":true, "dry_run":1}`, []Violation{
+			{Field: "amount", Message: "must be a string"},
+			{Field: "dry_run", Message: "must be a boolean"},
+			{Field: "sender", Message: "must be an integer"},
+		}},
+		{"fractional integer", `{"sender":2.5, "amount":"1"}`, []Violation{{Field: "sender", Message: "must be an integer"}}},
+		{"out of range", `{"sender":0, "amount":"1"}`, []Violation{{Field: "sender", Message: "must be greater than or equal to 1"}}},
+		{"not nullable", `{"sender":null, "amount":"1"}`, []Violation{{Field: "sender", Message: "must not be null"}}},
+		{"malformed decimal", `{"sender":2, "amount":"1e2"}`, []Violation{{Field: "amount", Message: "must be a decimal like 100.50"}}},
+		{"array items", `{"sender":2, "amount":"1", "tags":["gift", "car", "rent"]}`, []Violation{
+			{Field: "tags", Message: "must have at most 2 items"},
+			{Field: "tags[1]", Message: "must be one of gift, rent"},
+		}},
+		{"date-time", `{"sender":2, "amount":"1", "at":"31.12.2022"}`, []Violation{{Field: "at", Message: "must be a date-time like 2006-01-02T15:04:05Z"}}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.violations, s.Validate([]byte(tc.body)))
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// formatDecimal is the format of the amounts sent as decimal strings,
+// the JSON numbers are still accepted for them during the transition period
+const formatDecimal = "decimal"
+
+// Violation is a mismatch between the request body and its schema
+type Violation struct {
+	// Field is the path of the value like "user_ids[1]", it is empty for the whole body
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	if v.Field == "" {
+		return v.Message
+	}
+	return v.Field + ": " + v.Message
+}
+
+// Validate checks the JSON body against the schema and returns all found violations.
+// Property names are matched case-insensitively like encoding/json does, the properties missing
+// from the schema are reported as unknown
+func (s *Schema) Validate(body []byte) []Violation {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return []Violation{{Message: "malformed JSON"}}
+	}
+	if dec.More() {
+		return []Violation{{Message: "malformed JSON"}}
+	}
+
+	var violations []Violation
+	s.validate("", v, &violations)
+	return violations
+}
+
+func (s *Schema) validate(field string, v interface{}, violations *[]Violation) {
+	report := func(format string, args ...interface{}) {
+		*violations = append(*violations, Violation{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if v == nil {
+		if !s.Nullable {
+			report("must not be null")
+		}
+		return
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			report("must be an object")
+			return
+		}
+		s.validateObject(field, obj, violations)
+	case "array":
+		arr, ok := v.([]interface{})
+		if !ok {
+			report("must be an array")
+			return
+		}
+		if s.MinItems != nil && len(arr) < *s.MinItems {
+			report("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(arr) > *s.MaxItems {
+			report("must have at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range arr {
+				s.Items.validate(fmt.Sprintf("%s[%d]", field, i), item, violations)
+			}
+		}
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			report("must be an integer")
+			return
+		}
+		if _, err := strconv.ParseInt(n.String(), 10, 64); err != nil {
+			report("must be an integer")
+			return
+		}
+		s.validateRange(n, report)
+	case "number":
+		n, ok := v.(json.Number)
+		if !ok {
+			report("must be a number")
+			return
+		}
+		s.validateRange(n, report)
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			report("must be a boolean")
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			if n, isNumber := v.(json.Number); isNumber && s.Format == formatDecimal {
+				str = n.String()
+			} else {
+				report("must be a string")
+				return
+			}
+		}
+		s.validateString(str, report)
+	}
+}
+
+func (s *Schema) validateObject(field string, obj map[string]interface{}, violations *[]Violation) {
+	prefix := field
+	if prefix != "" {
+		prefix += "."
+	}
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var present = make(map[string]bool, len(obj))
+	for _, name := range names {
+		property, ok := s.property(name)
+		if !ok {
+			*violations = append(*violations, Violation{Field: prefix + name, Message: "unknown field"})
+			continue
+		}
+		present[property] = true
+
+		if p := s.Properties[property]; p != nil {
+			p.validate(prefix+property, obj[name], violations)
+		}
+	}
+
+	for _, name := range s.Required {
+		if !present[name] {
+			*violations = append(*violations, Violation{Field: prefix + name, Message: "is required"})
+		}
+	}
+}
+
+// property finds the schema property of the JSON key, preferring the exact match
+func (s *Schema) property(key string) (string, bool) {
+	if _, ok := s.Properties[key]; ok {
+		return key, true
+	}
+	for name := range s.Properties {
+		if strings.EqualFold(name, key) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func (s *Schema) validateRange(n json.Number, report func(string, ...interface{})) {
+	f, err := n.Float64()
+	if err != nil {
+		report("must be a number")
+		return
+	}
+	if s.Minimum != nil && f < *s.Minimum {
+		report("must be greater than or equal to %v", *s.Minimum)
+	}
+	if s.Maximum != nil && f > *s.Maximum {
+		report("must be less than or equal to %v", *s.Maximum)
+	}
+}
+
+func (s *Schema) validateString(str string, report func(string, ...interface{})) {
+	if len(s.Enum) > 0 {
+		var found bool
+		for _, e := range s.Enum {
+			if e == str {
+				found = true
+				break
+			}
+		}
+		if !found {
+			report("must be one of %s", strings.Join(s.Enum, ", "))
+			return
+		}
+	}
+
+	if s.MinLength != nil && len([]rune(str)) < *s.MinLength {
+		report("must be at least %d characters long", *s.MinLength)
+	}
+	if s.MaxLength != nil && len([]rune(str)) > *s.MaxLength {
+		report("must be at most %d characters long", *s.MaxLength)
+	}
+
+	if s.Format == "date-time" {
+		if _, err := time.Parse(time.RFC3339, str); err != nil {
+			report("must be a date-time like 2006-01-02T15:04:05Z")
+		}
+	}
+
+	if s.pattern != nil && !s.pattern.MatchString(str) {
+		if s.Format == formatDecimal {
+			report("must be a decimal like 100.50")
+		} else {
+			report("must match %s", s.Pattern)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"http-avito-test/internal/money"
+	"io/ioutil"
+	"net/http"
+)
+
+const (
+	FakeProviderName = "fake"
+
+	// SignatureHeader holds the hex encoded HMAC-SHA256 of the callback body
+	SignatureHeader = "X-Signature"
+)
+
+// Fake is a local provider that confirms payments by callbacks signed with the shared secret.
+// It is used in tests and for local development instead of a real acquiring
+type Fake struct {
+	secret []byte
+}
+
+type fakeCallback struct {
+	DepositID int64          `json:"deposit_id"`
+	PaymentID string         `json:"payment_id"`
+	Status    CallbackStatus `json:"status"`
+}
+
+func NewFake(secret string) *Fake {
+	return &Fake{
+		secret: []byte(secret),
+	}
+}
+
+func (f *Fake) Name() string {
+	return FakeProviderName
+}
+
+// CreatePayment registers the payment of the deposit, the fake provider does not redirect the user anywhere
+func (f *Fake) CreatePayment(ctx context.Context, depositID int64, amount money.Money) (Payment, error) {
+	return Payment{
+		ExternalID: fmt.Sprintf("%s-%d", FakeProviderName, depositID),
+	}, nil
+}
+
+// ParseCallback verifies the signature of the callback and returns its content
+func (f *Fake) ParseCallback(r *http.Request) (Callback, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return Callback{}, ErrCallback
+	}
+
+	signature, err := hex.DecodeString(r.Header.Get(SignatureHeader))
+	if err != nil || !hmac.Equal(signature, f.sign(body)) {
+		return Callback{}, ErrSignature
+	}
+
+	var c fakeCallback
+	err = json.Unmarshal(body, &c)
+	if err != nil {
+		return Callback{}, ErrCallback
+	}
+
+	switch c.Status {
+	case CallbackStatusSucceeded, CallbackStatusFailed, CallbackStatusTimeout:
+	default:
+		return Callback{}, ErrCallback
+	}
+
+	if c.DepositID <= 0 || c.PaymentID == "" {
+		return Callback{}, ErrCallback
+	}
+
+	return Callback{
+		DepositID:  c.DepositID,
+		ExternalID: c.PaymentID,
+		Status:     c.Status,
+	}, nil
+}
+
+// Sign returns the value of the signature header for the callback body
+func (f *Fake) Sign(body []byte) string {
+	return hex.EncodeToString(f.sign(body))
+}
+
+func (f *Fake) sign(body []byte) []byte {
+	mac := hmac.New(sha256.New, f.secret)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
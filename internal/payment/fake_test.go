@@ -0,0 +1,62 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"http-avito-test/internal/money"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeCreatePayment(t *testing.T) {
+	f := NewFake("secret")
+
+	p, err := f.CreatePayment(context.Background(), 7, money.New(10000, money.RUB))
+	assert.NoError(t, err)
+	assert.Equal(t, "fake-7", p.ExternalID)
+}
+
+func TestFakeParseCallback(t *testing.T) {
+	f := NewFake("secret")
+
+	newRequest := func(body, signature string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/deposit/callback", bytes.NewBufferString(body))
+		req.Header.Set(SignatureHeader, signature)
+		return req
+	}
+
+	t.Run("green case", func(t *testing.T) {
+		body := `{"deposit_id":7, "payment_id":"fake-7", "status":"succeeded"}`
+
+		c, err := f.ParseCallback(newRequest(body, f.Sign([]byte(body))))
+		assert.NoError(t, err)
+		assert.Equal(t, Callback{DepositID: 7, ExternalID: "fake-7", Status: CallbackStatusSucceeded}, c)
+	})
+
+	t.Run("wrong signature", func(t *testing.T) {
+		body := `{"deposit_id":7, "payment_id":"fake-7", "status":"succeeded"}`
+
+		_, err := f.ParseCallback(newRequest(body, NewFake("other").Sign([]byte(body))))
+		assert.ErrorIs(t, err, ErrSignature)
+
+		_, err = f.ParseCallback(newRequest(body, "not hex"))
+		assert.ErrorIs(t, err, ErrSignature)
+	})
+
+	t.Run("malformed callback", func(t *testing.T) {
+		tests := []string{
+			`{"deposit_id":7, "payment_id":"fake-7", "status":"unknown"}`,
+			`{"deposit_id":0, "payment_id":"fake-7", "status":"failed"}`,
+			`{"deposit_id":7, "status":"timeout"}`,
+			`not json`,
+		}
+
+		for _, body := range tests {
+			_, err := f.ParseCallback(newRequest(body, f.Sign([]byte(body))))
+			assert.ErrorIs(t, err, ErrCallback)
+		}
+	})
+}
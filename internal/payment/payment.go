@@ -0,0 +1,30 @@
+// package payment provides adapters of the acquiring providers that confirm deposits
+package payment
+
+import "errors"
+
+var (
+	ErrSignature = errors.New("callback signature is invalid")
+	ErrCallback  = errors.New("malformed provider callback")
+)
+
+// Payment describes the payment created on the provider side for a pending deposit
+type Payment struct {
+	ExternalID      string
+	ConfirmationURL string
+}
+
+type CallbackStatus string
+
+const (
+	CallbackStatusSucceeded CallbackStatus = "succeeded"
+	CallbackStatusFailed    CallbackStatus = "failed"
+	CallbackStatusTimeout   CallbackStatus = "timeout"
+)
+
+// Callback is the verified notification of the provider about the result of the payment
+type Callback struct {
+	DepositID  int64
+	ExternalID string
+	Status     CallbackStatus
+}
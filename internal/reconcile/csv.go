@@ -0,0 +1,69 @@
+package reconcile
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"http-avito-test/internal/money"
+	"io"
+	"strings"
+	"time"
+)
+
+// DateLayout is the layout of the statement dates
+const DateLayout = "2006-01-02"
+
+var ErrStatement = errors.New("malformed bank statement")
+
+var statementHeader = []string{"date", "amount", "reference"}
+
+// ParseCSV reads the statement with the header date,amount,reference.
+// Amounts are in rubles with at most two decimal places
+func ParseCSV(r io.Reader) ([]Line, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = len(statementHeader)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStatement, err)
+	}
+	for i, name := range statementHeader {
+		if !strings.EqualFold(strings.TrimSpace(header[i]), name) {
+			return nil, fmt.Errorf("%w: unexpected header %q", ErrStatement, strings.Join(header, ","))
+		}
+	}
+
+	var lines []Line
+	for number := 2; ; number++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrStatement, err)
+		}
+
+		date, err := time.Parse(DateLayout, strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: wrong date %q", ErrStatement, number, record[0])
+		}
+
+		amount, err := money.Parse(strings.TrimSpace(record[1]), money.RUB)
+		if err != nil || amount.IsZero() {
+			return nil, fmt.Errorf("%w: line %d: wrong amount %q", ErrStatement, number, record[1])
+		}
+
+		lines = append(lines, Line{
+			Number:    number,
+			Date:      date,
+			Amount:    amount,
+			Reference: strings.TrimSpace(record[2]),
+		})
+	}
+
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("%w: no lines", ErrStatement)
+	}
+	return lines, nil
+}
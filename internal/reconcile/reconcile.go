@@ -0,0 +1,69 @@
+// package reconcile parses bank statements and matches their lines to the cash book postings
+package reconcile
+
+import (
+	"http-avito-test/internal/money"
+	"strconv"
+	"time"
+)
+
+// Line is a single operation of the bank statement.
+// Amount is positive for the money received by the bank account and negative for the money paid out
+type Line struct {
+	Number    int
+	Date      time.Time
+	Amount    money.Money
+	Reference string
+}
+
+// Posting is a cash book posting. The cash book is the counter account of the users,
+// so a deposit has a negative amount and a withdrawal has a positive one
+type Posting struct {
+	ID     int64
+	Date   time.Time
+	Amount money.Money
+}
+
+// Match pairs the statement lines with the postings, every posting is used once.
+// A posting is a candidate for the line when it has the opposite amount and its date is within the window around the line date.
+// Among the candidates the posting whose id is the reference of the line wins, then the one with the closest date.
+// The result maps indexes of the matched lines to the posting ids
+func Match(lines []Line, postings []Posting, window time.Duration) map[int]int64 {
+	var matches = make(map[int]int64)
+	var used = make(map[int64]bool)
+
+	for i, l := range lines {
+		var best *Posting
+		var bestDistance time.Duration
+
+		for j := range postings {
+			p := &postings[j]
+			if used[p.ID] || !p.Amount.Neg().Equal(l.Amount) {
+				continue
+			}
+
+			distance := p.Date.Sub(l.Date)
+			if distance < 0 {
+				distance = -distance
+			}
+			if distance > window {
+				continue
+			}
+
+			if l.Reference != "" && l.Reference == strconv.FormatInt(p.ID, 10) {
+				best = p
+				break
+			}
+			if best == nil || distance < bestDistance {
+				best = p
+				bestDistance = distance
+			}
+		}
+
+		if best != nil {
+			used[best.ID] = true
+			matches[i] = best.ID
+		}
+	}
+	return matches
+}
@@ -0,0 +1,74 @@
+package reconcile
+
+import (
+	"errors"
+	"http-avito-test/internal/money"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCSV(t *testing.T) {
+	t.Run("green case", func(t *testing.T) {
+		statement := "date,amount,reference\n2022-11-01,1500.50,12\n2022-11-02, -300,\n"
+
+		lines, err := ParseCSV(strings.NewReader(statement))
+		require.NoError(t, err)
+
+		require.Len(t, lines, 2)
+		assert.Equal(t, 2, lines[0].Number)
+		assert.Equal(t, time.Date(2022, time.November, 1, 0, 0, 0, 0, time.UTC), lines[0].Date)
+		assert.True(t, money.New(150050, money.RUB).Equal(lines[0].Amount))
+		assert.Equal(t, "12", lines[0].Reference)
+		assert.True(t, money.New(-30000, money.RUB).Equal(lines[1].Amount))
+		assert.Equal(t, "", lines[1].Reference)
+	})
+
+	var tests = []struct {
+		name      string
+		statement string
+	}{
+		{name: "empty statement", statement: ""},
+		{name: "wrong header", statement: "day,sum,ref\n2022-11-01,10,\n"},
+		{name: "no lines", statement: "date,amount,reference\n"},
+		{name: "wrong date", statement: "date,amount,reference\n01.11.2022,10,\n"},
+		{name: "wrong amount", statement: "date,amount,reference\n2022-11-01,10.555,\n"},
+		{name: "zero amount", statement: "date,amount,reference\n2022-11-01,0,\n"},
+		{name: "missing column", statement: "date,amount,reference\n2022-11-01,10\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCSV(strings.NewReader(tt.statement))
+			assert.True(t, errors.Is(err, ErrStatement))
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	var day = time.Date(2022, time.November, 1, 0, 0, 0, 0, time.UTC)
+
+	lines := []Line{
+		{Number: 2, Date: day, Amount: money.New(10000, money.RUB), Reference: "3"},
+		{Number: 3, Date: day, Amount: money.New(10000, money.RUB)},
+		{Number: 4, Date: day, Amount: money.New(-5000, money.RUB)},
+		{Number: 5, Date: day, Amount: money.New(700, money.RUB)},
+	}
+
+	postings := []Posting{
+		{ID: 1, Date: day.Add(30 * time.Hour), Amount: money.New(-10000, money.RUB)},
+		{ID: 2, Date: day.Add(2 * time.Hour), Amount: money.New(-10000, money.RUB)},
+		{ID: 3, Date: day.Add(40 * time.Hour), Amount: money.New(-10000, money.RUB)},
+		{ID: 4, Date: day.Add(-100 * time.Hour), Amount: money.New(5000, money.RUB)},
+		{ID: 5, Date: day.Add(time.Hour), Amount: money.New(-800, money.RUB)},
+	}
+
+	matches := Match(lines, postings, 48*time.Hour)
+
+	// the reference wins over the closer date, the second line takes the closest of the rest,
+	// the withdrawal is out of the window and the last line has no posting with the same amount
+	assert.Equal(t, map[int]int64{0: 3, 1: 2}, matches)
+}
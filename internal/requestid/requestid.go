@@ -0,0 +1,61 @@
+// package requestid carries the id of the request through the context, so the log lines of the handler,
+// the storage and the database driver written for the request can be correlated
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"go.uber.org/zap"
+)
+
+// Header is the header the client sends the id in and the response returns it in
+const Header = "X-Request-ID"
+
+// maxLength limits the ids sent by the clients
+const maxLength = 128
+
+type contextKey struct{}
+
+// NewContext returns the context carrying the id
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the id the context carries
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(contextKey{}).(string)
+	return id, ok && id != ""
+}
+
+// New generates the random id
+func New() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// Valid reports whether the id sent by the client can be used, it must be printable ASCII without spaces,
+// so it cannot break the log lines and the headers
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// Logger returns the logger that writes the id the context carries
+func Logger(ctx context.Context, logger *zap.Logger) *zap.Logger {
+	if id, ok := FromContext(ctx); ok && logger != nil {
+		return logger.With(zap.String("requestID", id))
+	}
+	return logger
+}
@@ -0,0 +1,44 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestContext(t *testing.T) {
+	_, ok := FromContext(context.Background())
+	assert.False(t, ok)
+
+	id, ok := FromContext(NewContext(context.Background(), "abc"))
+	assert.True(t, ok)
+	assert.Equal(t, "abc", id)
+
+	assert.Len(t, New(), 32)
+	assert.NotEqual(t, New(), New())
+}
+
+func TestValid(t *testing.T) {
+	assert.True(t, Valid("5f0c6a7e-request"))
+	assert.True(t, Valid(New()))
+
+	for _, id := range []string{"", "with space", "line\nbreak", "кириллица", strings.Repeat("a", 129)} {
+		assert.False(t, Valid(id), id)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	logger := zap.New(core)
+
+	Logger(NewContext(context.Background(), "abc"), logger).Info("with id")
+	Logger(context.Background(), logger).Info("without id")
+
+	entries := logs.AllUntimed()
+	assert.Equal(t, map[string]interface{}{"requestID": "abc"}, entries[0].ContextMap())
+	assert.Empty(t, entries[1].ContextMap())
+}
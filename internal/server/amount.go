@@ -0,0 +1,20 @@
+package server
+
+import (
+	"http-avito-test/internal/generated"
+	"http-avito-test/internal/money"
+	"net/http"
+)
+
+// numericAmountWarning is sent while the amounts given as JSON numbers are still accepted
+const numericAmountWarning = `299 - "numeric amounts are deprecated, send amounts as decimal strings"`
+
+// parseAmount reads the amount in rubles of the request.
+// The amount sent as a JSON number is accepted and the response is marked with the deprecation headers
+func parseAmount(w http.ResponseWriter, amount generated.Amount) (money.Money, error) {
+	if amount.Numeric() {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Warning", numericAmountWarning)
+	}
+	return amount.Money(money.RUB)
+}
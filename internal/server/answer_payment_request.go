@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"http-avito-test/internal/generated"
+	"io/ioutil"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+const (
+	AcceptedPaymentRequestMessage = "payment request accepted successfully"
+	DeclinedPaymentRequestMessage = "payment request declined successfully"
+)
+
+func (h *Handler) AcceptPaymentRequest(w http.ResponseWriter, r *http.Request) {
+	h.answerPaymentRequest(w, r, h.Store.AcceptPaymentRequest, AcceptedPaymentRequestMessage)
+}
+
+func (h *Handler) DeclinePaymentRequest(w http.ResponseWriter, r *http.Request) {
+	h.answerPaymentRequest(w, r, h.Store.DeclinePaymentRequest, DeclinedPaymentRequestMessage)
+}
+
+func (h *Handler) answerPaymentRequest(
+	w http.ResponseWriter,
+	r *http.Request,
+	answer func(ctx context.Context, payer, requestID int64) error,
+	message string) {
+	var hand *generated.AnswerPaymentRequestRequest
+
+	body, _ := ioutil.ReadAll(r.Body)
+	err := json.Unmarshal(body, &hand)
+	if err != nil || hand == nil {
+		h.malformedBody(w, r)
+		return
+	}
+
+	switch {
+	case hand.Payer <= 0:
+		h.invalidField(w, r, "payer")
+		return
+	case hand.RequestId <= 0:
+		h.invalidField(w, r, "request_id")
+		return
+	}
+
+	err = answer(r.Context(), hand.Payer, hand.RequestId)
+	if err != nil {
+		h.writeStorageError(w, r, err, "error answering payment request")
+		return
+	}
+
+	result := generated.AnswerPaymentRequestResponse{
+		Result: struct {
+			Message string "json:\"message\""
+		}{
+			Message: message,
+		},
+		Status: "ok",
+	}
+
+	marshalledRequest, err := json.Marshal(result)
+	if err != nil {
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, writeErr := w.Write(marshalledRequest)
+	if writeErr != nil {
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
+		return
+	}
+}
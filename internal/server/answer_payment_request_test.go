@@ -0,0 +1,192 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"http-avito-test/internal/generated"
+	"http-avito-test/internal/storage"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAcceptPaymentRequest(t *testing.T) {
+	t.Run("green case", func(t *testing.T) {
+		var testAccept = generated.AnswerPaymentRequestResponse{
+			Result: struct {
+				Message string "json:\"message\""
+			}{
+				Message: "payment request accepted successfully",
+			},
+			Status: "ok",
+		}
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().AcceptPaymentRequest(gomock.Any(), int64(3), int64(5)).Return(nil)
+
+		arg := bytes.NewBuffer([]byte(`{"payer":3, "request_id":5}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/payreq/accept", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.AcceptPaymentRequest(w, req)
+
+		body, err := ioutil.ReadAll(w.Result().Body)
+		assert.NoError(t, err)
+
+		js, err := json.Marshal(testAccept)
+		assert.NoError(t, err)
+
+		assert.Equal(t, string(js), string(body))
+	})
+
+	t.Run("wrong values", func(t *testing.T) {
+		tests := []struct {
+			name string
+			arg  string
+			body string
+		}{
+			{"malformed request body", ``, "malformed request body"},
+			{"wrong payer", `{"payer":0, "request_id":5}`, "wrong value of \"payer\""},
+			{"wrong request id", `{"payer":3, "request_id":0}`, "wrong value of \"request_id\""},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				m := NewMockStorager(ctrl)
+
+				arg := bytes.NewBuffer([]byte(tt.arg))
+				req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/payreq/accept", arg)
+				w := httptest.NewRecorder()
+
+				s := Handler{
+					Store: m,
+				}
+
+				s.AcceptPaymentRequest(w, req)
+
+				body, err := ioutil.ReadAll(w.Body)
+				assert.NoError(t, err)
+
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, tt.body, errorMessage(t, body))
+			})
+		}
+	})
+
+	t.Run("accept errors", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			err    error
+			status int
+			body   string
+		}{
+			{"request does not exist", storage.ErrNoPaymentRequest, http.StatusNotFound, "the payment request does not exist"},
+			{"request is finished", storage.ErrPaymentRequestFinished, http.StatusConflict, "the payment request is already accepted, declined or expired"},
+			{"not enough money", storage.ErrTransfer, http.StatusBadRequest, "not enough money in the account"},
+			{"payer does not exist", storage.ErrUserAvailability, http.StatusNotFound, "user does not exist"},
+			{"error answering payment request", errors.New(""), http.StatusInternalServerError, "error answering payment request"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				m := NewMockStorager(ctrl)
+				m.EXPECT().AcceptPaymentRequest(gomock.Any(), int64(3), int64(5)).Return(tt.err)
+
+				arg := bytes.NewBuffer([]byte(`{"payer":3, "request_id":5}`))
+				req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/payreq/accept", arg)
+				w := httptest.NewRecorder()
+
+				s := Handler{
+					Store: m,
+				}
+
+				s.AcceptPaymentRequest(w, req)
+
+				body, err := ioutil.ReadAll(w.Body)
+				assert.NoError(t, err)
+
+				assert.Equal(t, tt.status, w.Code)
+				assert.Equal(t, tt.body, errorMessage(t, body))
+			})
+		}
+	})
+}
+
+func TestDeclinePaymentRequest(t *testing.T) {
+	t.Run("green case", func(t *testing.T) {
+		var testDecline = generated.AnswerPaymentRequestResponse{
+			Result: struct {
+				Message string "json:\"message\""
+			}{
+				Message: "payment request declined successfully",
+			},
+			Status: "ok",
+		}
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().DeclinePaymentRequest(gomock.Any(), int64(3), int64(5)).Return(nil)
+
+		arg := bytes.NewBuffer([]byte(`{"payer":3, "request_id":5}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/payreq/decline", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.DeclinePaymentRequest(w, req)
+
+		body, err := ioutil.ReadAll(w.Result().Body)
+		assert.NoError(t, err)
+
+		js, err := json.Marshal(testDecline)
+		assert.NoError(t, err)
+
+		assert.Equal(t, string(js), string(body))
+	})
+
+	t.Run("request is finished", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().DeclinePaymentRequest(gomock.Any(), int64(3), int64(5)).Return(storage.ErrPaymentRequestFinished)
+
+		arg := bytes.NewBuffer([]byte(`{"payer":3, "request_id":5}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/payreq/decline", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.DeclinePaymentRequest(w, req)
+
+		body, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, "the payment request is already accepted, declined or expired", errorMessage(t, body))
+	})
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"http-avito-test/internal/auth"
+	"http-avito-test/internal/storage"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// noScope marks the operations that do not need the API key, like the callbacks signed by the payment provider
+const noScope auth.Scope = ""
+
+type apiKeyContextKey struct{}
+
+// APIKeyFromContext returns the API key the request is authenticated with
+func APIKeyFromContext(ctx context.Context) (storage.APIKey, bool) {
+	k, ok := ctx.Value(apiKeyContextKey{}).(storage.APIKey)
+	return k, ok
+}
+
+var errUnauthenticated = apiError{
+	status:  http.StatusUnauthorized,
+	code:    CodeUnauthenticated,
+	message: "missing or invalid API key",
+}
+
+// authenticated passes the request to the handler when its API key is valid and has the scope.
+// The key is sent in the "Authorization: Bearer <key>" header
+func (h *Handler) authenticated(scope auth.Scope, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !h.RequireAPIKeys || scope == noScope {
+			next(w, r)
+			return
+		}
+
+		w.Header().Set("WWW-Authenticate", "Bearer")
+
+		header := r.Header.Get("Authorization")
+		if !strings.HasPrefix(header, "Bearer ") {
+			h.writeError(w, r, errUnauthenticated)
+			return
+		}
+
+		key, err := auth.ParseKey(strings.TrimSpace(strings.TrimPrefix(header, "Bearer ")))
+		if err != nil {
+			h.writeError(w, r, errUnauthenticated)
+			return
+		}
+
+		stored, err := h.Store.ReadAPIKey(r.Context(), key.Prefix)
+		switch {
+		case errors.Is(err, storage.ErrNoAPIKey):
+			h.writeError(w, r, errUnauthenticated)
+			return
+		case err != nil:
+			h.internalError(w, r, "error reading API key")
+			return
+		}
+
+		if stored.RevokedAt.Valid || !key.Matches(stored.Hash) {
+			h.logger(r).Warn("rejected API key", zap.String("prefix", key.Prefix), zap.Bool("revoked", stored.RevokedAt.Valid))
+			h.writeError(w, r, errUnauthenticated)
+			return
+		}
+
+		if !auth.HasScope(stored.Scopes, scope) {
+			h.writeError(w, r, apiError{
+				status:  http.StatusForbidden,
+				code:    CodeForbiddenScope,
+				message: "the API key has no " + string(scope) + " scope",
+				args:    map[string]string{"scope": string(scope)},
+			})
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, stored)))
+	}
+}
+
+// serviceAllowed reports whether the client of the request is bound to the service of the order.
+// The denial is audited and written to the client. The requests without the API key are allowed,
+// they are possible only when the keys are not required
+func (h *Handler) serviceAllowed(w http.ResponseWriter, r *http.Request, operation string, userID, serviceID, orderID int64) bool {
+	key, ok := APIKeyFromContext(r.Context())
+	if !ok {
+		return true
+	}
+	for _, id := range key.ServiceIDs {
+		if id == serviceID {
+			return true
+		}
+	}
+
+	h.logger(r).Warn("operation on the service of another client is denied",
+		zap.String("client", key.Client),
+		zap.String("operation", operation),
+		zap.Int64("serviceID", serviceID),
+		zap.Int64("orderID", orderID))
+
+	err := h.Store.AuditDenial(r.Context(), storage.AuthorizationDenial{
+		Client:    key.Client,
+		KeyID:     key.ID,
+		Operation: operation,
+		AccountID: userID,
+		ServiceID: serviceID,
+		OrderID:   orderID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		h.logger(r).Error("failed to audit authorization denial", zap.Error(err))
+	}
+
+	h.writeError(w, r, apiError{
+		status:  http.StatusForbidden,
+		code:    CodeForbiddenService,
+		message: fmt.Sprintf("the client is not bound to service %d", serviceID),
+		args:    map[string]string{"service_id": strconv.FormatInt(serviceID, 10)},
+	})
+	return false
+}
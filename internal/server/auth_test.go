@@ -0,0 +1,282 @@
+package server
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"http-avito-test/internal/auth"
+	"http-avito-test/internal/money"
+	"http-avito-test/internal/storage"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestAuthenticated(t *testing.T) {
+	key, err := auth.NewKey()
+	require.NoError(t, err)
+
+	stored := storage.APIKey{
+		ID:     1,
+		Client: "billing",
+		Prefix: key.Prefix,
+		Hash:   key.Hash(),
+		Scopes: []auth.Scope{auth.ScopeBalanceRead, auth.ScopeFundsDeposit},
+	}
+
+	t.Run("key with the scope", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().ReadAPIKey(gomock.Any(), key.Prefix).Return(stored, nil)
+		m.EXPECT().Deposit(gomock.Any(), int64(2), money.New(10000, money.RUB)).Return(nil)
+
+		arg := bytes.NewBuffer([]byte(`{"user_id":2, "amount":"100.00"}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/api/1/accountdeposit", arg)
+		req.Header.Set("Authorization", "Bearer "+key.String())
+		w := httptest.NewRecorder()
+
+		h := Handler{
+			Logger:         zap.NewNop(),
+			Store:          m,
+			RequireAPIKeys: true,
+		}
+
+		h.Router(false).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("key without the scope", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().ReadAPIKey(gomock.Any(), key.Prefix).Return(stored, nil)
+
+		arg := bytes.NewBuffer([]byte(`{"user_id":2, "amount":"100.00"}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/api/1/accountwithdrawal", arg)
+		req.Header.Set("Authorization", "Bearer "+key.String())
+		w := httptest.NewRecorder()
+
+		h := Handler{
+			Logger:         zap.NewNop(),
+			Store:          m,
+			RequireAPIKeys: true,
+		}
+
+		h.Router(false).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "the API key has no funds:withdraw scope", errorMessage(t, w.Body.Bytes()))
+	})
+
+	t.Run("rejected keys", func(t *testing.T) {
+		other, err := auth.NewKey()
+		require.NoError(t, err)
+
+		revoked := stored
+		revoked.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+		var tests = []struct {
+			name   string
+			header string
+			stored storage.APIKey
+			err    error
+		}{
+			{"no header", "", storage.APIKey{}, nil},
+			{"not a bearer", "Basic " + key.String(), storage.APIKey{}, nil},
+			{"malformed key", "Bearer " + key.Prefix, storage.APIKey{}, nil},
+			{"unknown key", "Bearer " + key.String(), storage.APIKey{}, storage.ErrNoAPIKey},
+			{"wrong secret", "Bearer " + key.Prefix + "." + other.Secret, stored, nil},
+			{"revoked key", "Bearer " + key.String(), revoked, nil},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				m := NewMockStorager(ctrl)
+				if tt.stored.ID != 0 || tt.err != nil {
+					m.EXPECT().ReadAPIKey(gomock.Any(), key.Prefix).Return(tt.stored, tt.err)
+				}
+
+				arg := bytes.NewBuffer([]byte(`{"user_id":2, "amount":"100.00"}`))
+				req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/api/1/accountdeposit", arg)
+				if tt.header != "" {
+					req.Header.Set("Authorization", tt.header)
+				}
+				w := httptest.NewRecorder()
+
+				h := Handler{
+					Logger:         zap.NewNop(),
+					Store:          m,
+					RequireAPIKeys: true,
+				}
+
+				h.Router(false).ServeHTTP(w, req)
+
+				assert.Equal(t, http.StatusUnauthorized, w.Code)
+				assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+				assert.Equal(t, "missing or invalid API key", errorMessage(t, w.Body.Bytes()))
+			})
+		}
+	})
+
+	t.Run("error reading key", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().ReadAPIKey(gomock.Any(), key.Prefix).Return(storage.APIKey{}, errors.New(""))
+
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/api/1/readuser", nil)
+		req.Header.Set("Authorization", "Bearer "+key.String())
+		w := httptest.NewRecorder()
+
+		h := Handler{
+			Logger:         zap.NewNop(),
+			Store:          m,
+			RequireAPIKeys: true,
+		}
+
+		h.Router(false).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "error reading API key", errorMessage(t, w.Body.Bytes()))
+	})
+
+	t.Run("operation without scope", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// the callback is checked by its signature, not by the API key
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/api/1/depositcallback", nil)
+		w := httptest.NewRecorder()
+
+		h := Handler{
+			Logger:         zap.NewNop(),
+			Store:          NewMockStorager(ctrl),
+			RequireAPIKeys: true,
+		}
+
+		h.Router(false).ServeHTTP(w, req)
+
+		assert.NotEqual(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestServiceAllowed(t *testing.T) {
+	key, err := auth.NewKey()
+	require.NoError(t, err)
+
+	stored := storage.APIKey{
+		ID:         1,
+		Client:     "billing",
+		Prefix:     key.Prefix,
+		Hash:       key.Hash(),
+		Scopes:     []auth.Scope{auth.ScopeFundsReserve},
+		ServiceIDs: []int64{1},
+	}
+
+	t.Run("bound service", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().ReadAPIKey(gomock.Any(), key.Prefix).Return(stored, nil)
+		m.EXPECT().Reservation(gomock.Any(), int64(2), int64(1), int64(7), money.New(10000, money.RUB), gomock.Any()).Return(nil)
+
+		arg := bytes.NewBuffer([]byte(`{"user_id":2, "service_id":1, "order_id":7, "price":"100.00"}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/api/1/reservationoffunds", arg)
+		req.Header.Set("Authorization", "Bearer "+key.String())
+		w := httptest.NewRecorder()
+
+		h := Handler{
+			Logger:         zap.NewNop(),
+			Store:          m,
+			RequireAPIKeys: true,
+		}
+
+		h.Router(false).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	var tests = []struct {
+		path      string
+		body      string
+		operation string
+	}{
+		{"/reservationoffunds", `{"user_id":2, "service_id":2, "order_id":7, "price":"100.00"}`, "reservation"},
+		{"/revenuerecognition", `{"user_id":2, "service_id":2, "order_id":7, "sum":"100.00"}`, "revenue"},
+		{"/unreservationoffunds", `{"user_id":2, "service_id":2, "order_id":7}`, "unreservation"},
+	}
+
+	for _, tt := range tests {
+		t.Run("other service "+tt.operation, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := NewMockStorager(ctrl)
+			m.EXPECT().ReadAPIKey(gomock.Any(), key.Prefix).Return(stored, nil)
+			m.EXPECT().AuditDenial(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, d storage.AuthorizationDenial) error {
+					assert.Equal(t, "billing", d.Client)
+					assert.Equal(t, int64(1), d.KeyID)
+					assert.Equal(t, tt.operation, d.Operation)
+					assert.Equal(t, int64(2), d.AccountID)
+					assert.Equal(t, int64(2), d.ServiceID)
+					assert.Equal(t, int64(7), d.OrderID)
+					return nil
+				})
+
+			req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/api/1"+tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Authorization", "Bearer "+key.String())
+			w := httptest.NewRecorder()
+
+			h := Handler{
+				Logger:         zap.NewNop(),
+				Store:          m,
+				RequireAPIKeys: true,
+			}
+
+			h.Router(false).ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusForbidden, w.Code)
+			assert.Equal(t, "the client is not bound to service 2", errorMessage(t, w.Body.Bytes()))
+		})
+	}
+
+	t.Run("audit failure still denies", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().ReadAPIKey(gomock.Any(), key.Prefix).Return(stored, nil)
+		m.EXPECT().AuditDenial(gomock.Any(), gomock.Any()).Return(errors.New(""))
+
+		arg := bytes.NewBuffer([]byte(`{"user_id":2, "service_id":2, "order_id":7}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/api/1/unreservationoffunds", arg)
+		req.Header.Set("Authorization", "Bearer "+key.String())
+		w := httptest.NewRecorder()
+
+		h := Handler{
+			Logger:         zap.NewNop(),
+			Store:          m,
+			RequireAPIKeys: true,
+		}
+
+		h.Router(false).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"encoding/json"
+	"http-avito-test/internal/generated"
+	"io/ioutil"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+const CancelledTransferMessage = "transfer cancelled successfully"
+
+func (h *Handler) CancelTransfer(w http.ResponseWriter, r *http.Request) {
+	var hand *generated.CancelTransferRequest
+
+	body, _ := ioutil.ReadAll(r.Body)
+	err := json.Unmarshal(body, &hand)
+	if err != nil || hand == nil {
+		h.malformedBody(w, r)
+		return
+	}
+
+	switch {
+	case hand.Sender <= 0:
+		h.invalidField(w, r, "sender")
+		return
+	case hand.TransferId <= 0:
+		h.invalidField(w, r, "transfer_id")
+		return
+	}
+
+	err = h.Store.CancelTransfer(r.Context(), hand.Sender, hand.TransferId)
+	if err != nil {
+		h.writeStorageError(w, r, err, "error cancelling transfer")
+		return
+	}
+
+	result := generated.CancelTransferResponse{
+		Result: struct {
+			Message string "json:\"message\""
+		}{
+			Message: CancelledTransferMessage,
+		},
+		Status: "ok",
+	}
+
+	marshalledRequest, err := json.Marshal(result)
+	if err != nil {
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, writeErr := w.Write(marshalledRequest)
+	if writeErr != nil {
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
+		return
+	}
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"http-avito-test/internal/generated"
+	"http-avito-test/internal/storage"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCancelTransfer(t *testing.T) {
+	t.Run("green case", func(t *testing.T) {
+		var testCancel = generated.CancelTransferResponse{
+			Result: struct {
+				Message string "json:\"message\""
+			}{
+				Message: "transfer cancelled successfully",
+			},
+			Status: "ok",
+		}
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().CancelTransfer(gomock.Any(), int64(2), int64(7)).Return(nil)
+
+		arg := bytes.NewBuffer([]byte(`{"sender":2, "transfer_id":7}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/transf/cancel", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.CancelTransfer(w, req)
+
+		body, err := ioutil.ReadAll(w.Result().Body)
+		assert.NoError(t, err)
+
+		js, err := json.Marshal(testCancel)
+		assert.NoError(t, err)
+
+		assert.Equal(t, string(js), string(body))
+	})
+
+	t.Run("malformed request body", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/transf/cancel", nil)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.CancelTransfer(w, req)
+
+		body, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "malformed request body", errorMessage(t, body))
+	})
+
+	t.Run("wrong transfer id value", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+
+		arg := bytes.NewBuffer([]byte(`{"sender":2, "transfer_id":0}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/transf/cancel", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.CancelTransfer(w, req)
+
+		body, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "wrong value of \"transfer_id\"", errorMessage(t, body))
+	})
+
+	t.Run("cancel errors", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			err    error
+			status int
+			body   string
+		}{
+			{"transfer does not exist", storage.ErrNoPendingTransfer, http.StatusNotFound, "the transfer does not exist"},
+			{"transfer is finished", storage.ErrTransferFinished, http.StatusConflict, "the transfer is already settled or cancelled"},
+			{"undo window is over", storage.ErrUndoWindowExpired, http.StatusConflict, "the undo window of the transfer is over"},
+			{"error cancelling transfer", errors.New(""), http.StatusInternalServerError, "error cancelling transfer"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				m := NewMockStorager(ctrl)
+				m.EXPECT().CancelTransfer(gomock.Any(), int64(2), int64(7)).Return(tt.err)
+
+				arg := bytes.NewBuffer([]byte(`{"sender":2, "transfer_id":7}`))
+				req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/transf/cancel", arg)
+				w := httptest.NewRecorder()
+
+				s := Handler{
+					Store: m,
+				}
+
+				s.CancelTransfer(w, req)
+
+				body, err := ioutil.ReadAll(w.Body)
+				assert.NoError(t, err)
+
+				assert.Equal(t, tt.status, w.Code)
+				assert.Equal(t, tt.body, errorMessage(t, body))
+			})
+		}
+	})
+}
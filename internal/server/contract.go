@@ -2,24 +2,77 @@ package server
 
 import (
 	"context"
+	"http-avito-test/internal/money"
+	"http-avito-test/internal/payment"
+	"http-avito-test/internal/reconcile"
 	"http-avito-test/internal/storage"
+	"net/http"
+	"time"
 
 	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
+// Limiter takes a token of the bucket of the key, the bucket of burst tokens is refilled at rate tokens a second.
+// It returns whether the token is taken and the tokens left. ReturnToken gives the taken token back to the bucket
+// when the request is rejected by another bucket
+type Limiter interface {
+	TakeToken(ctx context.Context, key string, rate float64, burst int64) (bool, float64, error)
+	ReturnToken(ctx context.Context, key string, burst int64) error
+}
+
 type Storager interface {
 	ReadUserByID(context.Context, int64) (storage.User, error)
-	Deposit(context.Context, int64, decimal.Decimal) error
-	Withdrawal(context.Context, int64, decimal.Decimal, *string) error
-	Transfer(ctx context.Context, user_id1, user_id2 int64, amount decimal.Decimal, description *string, options ...storage.TxOption) (int64, int64, error)
+	ReadUsersByIDs(ctx context.Context, userIDs []int64) ([]storage.UserResult, error)
+	Deposit(context.Context, int64, money.Money) error
+	CreatePendingDeposit(ctx context.Context, userID int64, amount money.Money, provider string) (int64, error)
+	AttachDepositPayment(ctx context.Context, depositID int64, externalID string) error
+	ConfirmDeposit(ctx context.Context, depositID int64, externalID string) error
+	FailDeposit(ctx context.Context, depositID int64, externalID string, reason string) error
+	Withdrawal(ctx context.Context, userID int64, amount money.Money, description *string, options ...storage.TxOption) error
+	RequestWithdrawal(ctx context.Context, userID int64, amount money.Money, description *string) (int64, error)
+	ListWithdrawalRequests(ctx context.Context, status storage.WithdrawalStatus, limit, offset int64) ([]storage.WithdrawalRequest, error)
+	ApproveWithdrawal(ctx context.Context, requestID int64, operator, reason string) error
+	RejectWithdrawal(ctx context.Context, requestID int64, operator, reason string) error
+	ListFraudFlags(ctx context.Context, limit, offset int64) ([]storage.FraudFlag, error)
+	ReadAPIKey(ctx context.Context, prefix string) (storage.APIKey, error)
+	AuditDenial(ctx context.Context, d storage.AuthorizationDenial) error
+	ImportStatement(ctx context.Context, name string, lines []reconcile.Line, window time.Duration) (int64, error)
+	ReadReconciliation(ctx context.Context, statementID int64) (storage.Reconciliation, error)
+	ResolveStatementLine(ctx context.Context, lineID int64, postingID *int64, operator, note string) error
+	ResolveLedgerPosting(ctx context.Context, postingID int64, operator, note string) error
+	Transfer(ctx context.Context, user_id1, user_id2 int64, amount money.Money, description *string, options ...storage.TxOption) (int64, int64, error)
+	DelayedTransfer(ctx context.Context, sender, recipient int64, amount money.Money, description *string, settleAt time.Time) (int64, error)
+	CancelTransfer(ctx context.Context, sender, transferID int64) error
+	CreatePaymentRequest(ctx context.Context, requester, payer int64, amount money.Money, description *string, expiresAt time.Time) (int64, error)
+	ListPaymentRequests(ctx context.Context, userID int64, direction storage.PaymentRequestDirection, limit, offset int64) ([]storage.PaymentRequest, error)
+	AcceptPaymentRequest(ctx context.Context, payer, requestID int64) error
+	DeclinePaymentRequest(ctx context.Context, payer, requestID int64) error
+	CreateEscrow(ctx context.Context, payer, beneficiary int64, amount money.Money, description *string) (int64, error)
+	ReleaseEscrow(ctx context.Context, escrowID int64) error
+	RefundEscrow(ctx context.Context, escrowID int64) error
+	SplitEscrow(ctx context.Context, escrowID int64, beneficiaryShare money.Money) error
+	ReadEscrow(ctx context.Context, escrowID int64) (storage.Escrow, error)
+	GenerateVouchers(ctx context.Context, count int64, amount money.Money, maxRedemptions int64, expiresAt time.Time) (int64, []string, error)
+	RedeemVoucher(ctx context.Context, userID int64, code string) (money.Money, error)
+	GrantBonus(ctx context.Context, userID int64, amount money.Money, expiresAt time.Time) (int64, error)
+	ReadBalanceBuckets(ctx context.Context, userID int64) (storage.BalanceBuckets, error)
 	ReadUserHistoryList(ctx context.Context, user_id int64, order storage.OrdBy, limit, offset int64) ([]storage.ReadUserHistoryResult, error)
-	Reservation(ctx context.Context, UserId int64, ServiceId int64, OrderId int64, Price decimal.Decimal, description *string) error
-	Revenue(ctx context.Context, UserId int64, ServiceId int64, OrderId int64, Sum decimal.Decimal, description *string) error
+	Reservation(ctx context.Context, UserId int64, ServiceId int64, OrderId int64, Price money.Money, description *string, options ...storage.TxOption) error
+	Revenue(ctx context.Context, UserId int64, ServiceId int64, OrderId int64, Sum money.Money, description *string) error
 	Unreservation(ctx context.Context, UserId int64, ServiceId int64, OrderId int64, description *string) error
 	MonthlyReport(ctx context.Context, year int64, month int64) ([][]string, error)
+	QuoteWithdrawal(ctx context.Context, userID int64, amount money.Money, description *string) (storage.Quote, error)
+	QuoteTransfer(ctx context.Context, sender, recipient int64, amount money.Money, description *string) (storage.Quote, error)
+	QuoteReservation(ctx context.Context, UserId int64, ServiceId int64, OrderId int64, Price money.Money, description *string) (storage.Quote, error)
 }
 
 type Exchanger interface {
-	ExchangeRates(logger *zap.Logger, value decimal.Decimal, currency string) (decimal.Decimal, error)
+	ExchangeRates(ctx context.Context, logger *zap.Logger, value decimal.Decimal, currency string) (decimal.Decimal, error)
+}
+
+type PaymentProvider interface {
+	Name() string
+	CreatePayment(ctx context.Context, depositID int64, amount money.Money) (payment.Payment, error)
+	ParseCallback(r *http.Request) (payment.Callback, error)
 }
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"encoding/json"
+	"http-avito-test/internal/generated"
+	"io/ioutil"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+const EscrowMessage = "escrow created successfully"
+
+func (h *Handler) CreateEscrow(w http.ResponseWriter, r *http.Request) {
+	var hand *generated.CreateEscrowRequest
+
+	body, _ := ioutil.ReadAll(r.Body)
+	err := json.Unmarshal(body, &hand)
+	if err != nil || hand == nil {
+		h.malformedBody(w, r)
+		return
+	}
+
+	switch {
+	case hand.Payer <= 0:
+		h.invalidField(w, r, "payer")
+		return
+	case hand.Beneficiary <= 0 || hand.Beneficiary == hand.Payer:
+		h.invalidField(w, r, "beneficiary")
+		return
+	}
+
+	newAmount, err := parseAmount(w, hand.Amount)
+	if err != nil || !newAmount.IsPositive() {
+		h.invalidField(w, r, "amount")
+		return
+	}
+
+	if hand.Description == nil || *hand.Description == "" {
+		hand.Description = nil
+	}
+
+	id, err := h.Store.CreateEscrow(r.Context(), hand.Payer, hand.Beneficiary, newAmount, hand.Description)
+	if err != nil {
+		h.writeStorageError(w, r, err, "error creating escrow")
+		return
+	}
+
+	result := generated.CreateEscrowResponse{
+		Result: struct {
+			EscrowId int64  "json:\"escrow_id\""
+			Message  string "json:\"message\""
+		}{
+			EscrowId: id,
+			Message:  EscrowMessage,
+		},
+		Status: "ok",
+	}
+
+	marshalledRequest, err := json.Marshal(result)
+	if err != nil {
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, writeErr := w.Write(marshalledRequest)
+	if writeErr != nil {
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
+		return
+	}
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"http-avito-test/internal/generated"
+	"http-avito-test/internal/money"
+	"http-avito-test/internal/storage"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateEscrow(t *testing.T) {
+	t.Run("green case", func(t *testing.T) {
+		var testCreate = generated.CreateEscrowResponse{
+			Result: struct {
+				EscrowId int64  "json:\"escrow_id\""
+				Message  string "json:\"message\""
+			}{
+				EscrowId: 4,
+				Message:  "escrow created successfully",
+			},
+			Status: "ok",
+		}
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		var description = "deal"
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().CreateEscrow(gomock.Any(), int64(2), int64(3), money.New(10000, money.RUB), &description).Return(int64(4), nil)
+
+		arg := bytes.NewBuffer([]byte(`{"payer":2, "beneficiary":3, "amount":100, "description":"deal"}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/escrow", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.CreateEscrow(w, req)
+
+		body, err := ioutil.ReadAll(w.Result().Body)
+		assert.NoError(t, err)
+
+		js, err := json.Marshal(testCreate)
+		assert.NoError(t, err)
+
+		assert.Equal(t, string(js), string(body))
+	})
+
+	t.Run("wrong values", func(t *testing.T) {
+		tests := []struct {
+			name string
+			arg  string
+			body string
+		}{
+			{"malformed request body", ``, "malformed request body"},
+			{"wrong payer", `{"payer":0, "beneficiary":3, "amount":100}`, "wrong value of \"payer\""},
+			{"wrong beneficiary", `{"payer":2, "beneficiary":0, "amount":100}`, "wrong value of \"beneficiary\""},
+			{"beneficiary is payer", `{"payer":2, "beneficiary":2, "amount":100}`, "wrong value of \"beneficiary\""},
+			{"negative amount", `{"payer":2, "beneficiary":3, "amount":-100}`, "wrong value of \"amount\""},
+			{"too precise amount", `{"payer":2, "beneficiary":3, "amount":100.001}`, "wrong value of \"amount\""},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				m := NewMockStorager(ctrl)
+
+				arg := bytes.NewBuffer([]byte(tt.arg))
+				req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/escrow", arg)
+				w := httptest.NewRecorder()
+
+				s := Handler{
+					Store: m,
+				}
+
+				s.CreateEscrow(w, req)
+
+				body, err := ioutil.ReadAll(w.Body)
+				assert.NoError(t, err)
+
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, tt.body, errorMessage(t, body))
+			})
+		}
+	})
+
+	t.Run("create errors", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			err    error
+			status int
+			body   string
+		}{
+			{"not enough money", storage.ErrTransfer, http.StatusBadRequest, "not enough money in the account"},
+			{"payer does not exist", storage.ErrUserAvailability, http.StatusNotFound, "user does not exist"},
+			{"error creating escrow", errors.New(""), http.StatusInternalServerError, "error creating escrow"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				m := NewMockStorager(ctrl)
+				m.EXPECT().CreateEscrow(gomock.Any(), int64(2), int64(3), money.New(10000, money.RUB), nil).Return(int64(0), tt.err)
+
+				arg := bytes.NewBuffer([]byte(`{"payer":2, "beneficiary":3, "amount":100}`))
+				req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/escrow", arg)
+				w := httptest.NewRecorder()
+
+				s := Handler{
+					Store: m,
+				}
+
+				s.CreateEscrow(w, req)
+
+				body, err := ioutil.ReadAll(w.Body)
+				assert.NoError(t, err)
+
+				assert.Equal(t, tt.status, w.Code)
+				assert.Equal(t, tt.body, errorMessage(t, body))
+			})
+		}
+	})
+}
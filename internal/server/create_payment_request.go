@@ -0,0 +1,82 @@
+package server
+
+import (
+	"encoding/json"
+	"http-avito-test/internal/generated"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	PaymentRequestMessage = "payment request created successfully"
+
+	defaultPaymentRequestTTL = 72 * time.Hour
+)
+
+func (h *Handler) CreatePaymentRequest(w http.ResponseWriter, r *http.Request) {
+	var hand *generated.CreatePaymentRequestRequest
+
+	body, _ := ioutil.ReadAll(r.Body)
+	err := json.Unmarshal(body, &hand)
+	if err != nil || hand == nil {
+		h.malformedBody(w, r)
+		return
+	}
+
+	switch {
+	case hand.Requester <= 0:
+		h.invalidField(w, r, "requester")
+		return
+	case hand.Payer <= 0 || hand.Payer == hand.Requester:
+		h.invalidField(w, r, "payer")
+		return
+	}
+
+	newAmount, err := parseAmount(w, hand.Amount)
+	if err != nil || !newAmount.IsPositive() {
+		h.invalidField(w, r, "amount")
+		return
+	}
+
+	if hand.Description == nil || *hand.Description == "" {
+		hand.Description = nil
+	}
+
+	var ttl = h.PaymentRequestTTL
+	if ttl <= 0 {
+		ttl = defaultPaymentRequestTTL
+	}
+
+	id, err := h.Store.CreatePaymentRequest(r.Context(), hand.Requester, hand.Payer, newAmount, hand.Description, time.Now().Add(ttl))
+	if err != nil {
+		h.writeStorageError(w, r, err, "error creating payment request")
+		return
+	}
+
+	result := generated.CreatePaymentRequestResponse{
+		Result: struct {
+			Message   string "json:\"message\""
+			RequestId int64  "json:\"request_id\""
+		}{
+			Message:   PaymentRequestMessage,
+			RequestId: id,
+		},
+		Status: "ok",
+	}
+
+	marshalledRequest, err := json.Marshal(result)
+	if err != nil {
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, writeErr := w.Write(marshalledRequest)
+	if writeErr != nil {
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
+		return
+	}
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"http-avito-test/internal/generated"
+	"http-avito-test/internal/money"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreatePaymentRequest(t *testing.T) {
+	t.Run("green case", func(t *testing.T) {
+		var testCreate = generated.CreatePaymentRequestResponse{
+			Result: struct {
+				Message   string "json:\"message\""
+				RequestId int64  "json:\"request_id\""
+			}{
+				Message:   "payment request created successfully",
+				RequestId: 5,
+			},
+			Status: "ok",
+		}
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		var description = "dinner"
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().CreatePaymentRequest(gomock.Any(), int64(2), int64(3), money.New(15000, money.RUB), &description, gomock.Any()).
+			DoAndReturn(func(_, _, _, _, _ interface{}, expiresAt time.Time) (int64, error) {
+				assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Second)
+				return 5, nil
+			})
+
+		arg := bytes.NewBuffer([]byte(`{"requester":2, "payer":3, "amount":150, "description":"dinner"}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/payreq", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store:             m,
+			PaymentRequestTTL: time.Hour,
+		}
+
+		s.CreatePaymentRequest(w, req)
+
+		body, err := ioutil.ReadAll(w.Result().Body)
+		assert.NoError(t, err)
+
+		js, err := json.Marshal(testCreate)
+		assert.NoError(t, err)
+
+		assert.Equal(t, string(js), string(body))
+	})
+
+	t.Run("default expiration time", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().CreatePaymentRequest(gomock.Any(), int64(2), int64(3), money.New(15000, money.RUB), nil, gomock.Any()).
+			DoAndReturn(func(_, _, _, _, _ interface{}, expiresAt time.Time) (int64, error) {
+				assert.WithinDuration(t, time.Now().Add(72*time.Hour), expiresAt, time.Second)
+				return 5, nil
+			})
+
+		arg := bytes.NewBuffer([]byte(`{"requester":2, "payer":3, "amount":150}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/payreq", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.CreatePaymentRequest(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("wrong values", func(t *testing.T) {
+		tests := []struct {
+			name string
+			arg  string
+			body string
+		}{
+			{"malformed request body", ``, "malformed request body"},
+			{"wrong requester", `{"requester":0, "payer":3, "amount":150}`, "wrong value of \"requester\""},
+			{"wrong payer", `{"requester":2, "payer":0, "amount":150}`, "wrong value of \"payer\""},
+			{"payer is requester", `{"requester":2, "payer":2, "amount":150}`, "wrong value of \"payer\""},
+			{"negative amount", `{"requester":2, "payer":3, "amount":-150}`, "wrong value of \"amount\""},
+			{"too precise amount", `{"requester":2, "payer":3, "amount":150.001}`, "wrong value of \"amount\""},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				m := NewMockStorager(ctrl)
+
+				arg := bytes.NewBuffer([]byte(tt.arg))
+				req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/payreq", arg)
+				w := httptest.NewRecorder()
+
+				s := Handler{
+					Store: m,
+				}
+
+				s.CreatePaymentRequest(w, req)
+
+				body, err := ioutil.ReadAll(w.Body)
+				assert.NoError(t, err)
+
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, tt.body, errorMessage(t, body))
+			})
+		}
+	})
+
+	t.Run("error creating payment request", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().CreatePaymentRequest(gomock.Any(), int64(2), int64(3), money.New(15000, money.RUB), nil, gomock.Any()).Return(int64(0), errors.New(""))
+
+		arg := bytes.NewBuffer([]byte(`{"requester":2, "payer":3, "amount":150}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/payreq", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.CreatePaymentRequest(w, req)
+
+		body, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "error creating payment request", errorMessage(t, body))
+	})
+}
@@ -3,14 +3,17 @@ package server
 import (
 	"encoding/json"
 	"http-avito-test/internal/generated"
+	"http-avito-test/internal/money"
 	"io/ioutil"
 	"net/http"
 
-	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
-const ResultMessage = "balance updated successfully"
+const (
+	ResultMessage         = "balance updated successfully"
+	PendingDepositMessage = "deposit is pending confirmation"
+)
 
 func (h *Handler) AccountDeposit(w http.ResponseWriter, r *http.Request) {
 	var hand *generated.AccountDepositRequest
@@ -18,29 +21,29 @@ func (h *Handler) AccountDeposit(w http.ResponseWriter, r *http.Request) {
 	body, _ := ioutil.ReadAll(r.Body)
 	err := json.Unmarshal(body, &hand)
 	if err != nil {
-		http.Error(w, "malformed request body", http.StatusBadRequest)
+		h.malformedBody(w, r)
 		return
 	}
 
-	if hand.UserId <= 1 {
-		http.Error(w, "wrong value of \"User_id\"", http.StatusBadRequest)
+	if hand.UserId <= 0 {
+		h.invalidField(w, r, "user_id")
 		return
 	}
 
-	var newBalance = decimal.NewFromFloat32(hand.Amount).Mul(decimal.NewFromInt(100))
-
-	switch {
-	case newBalance.Exponent() < -2:
-		http.Error(w, "wrong value of \"Amount\"", http.StatusBadRequest)
+	newBalance, err := parseAmount(w, hand.Amount)
+	if err != nil || !newBalance.IsPositive() {
+		h.invalidField(w, r, "amount")
 		return
-	case newBalance.LessThanOrEqual(decimal.NewFromInt(int64(0))):
-		http.Error(w, "wrong value of \"Amount\"", http.StatusBadRequest)
+	}
+
+	if h.Payments != nil {
+		h.createPendingDeposit(w, r, hand.UserId, newBalance)
 		return
 	}
 
 	err = h.Store.Deposit(r.Context(), hand.UserId, newBalance)
 	if err != nil {
-		http.Error(w, "error updating balance", http.StatusInternalServerError)
+		h.writeStorageError(w, r, err, "error updating balance")
 		return
 	}
 
@@ -55,14 +58,62 @@ func (h *Handler) AccountDeposit(w http.ResponseWriter, r *http.Request) {
 
 	marshalledRequest, err := json.Marshal(result)
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 	_, writeErr := w.Write(marshalledRequest)
 	if err != nil {
-		h.Logger.Error("failed to write connection", zap.Error(writeErr))
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
+		return
+	}
+}
+
+// createPendingDeposit registers the deposit at the payment provider, the balance is credited only by its callback
+func (h *Handler) createPendingDeposit(w http.ResponseWriter, r *http.Request, userID int64, amount money.Money) {
+	depositID, err := h.Store.CreatePendingDeposit(r.Context(), userID, amount, h.Payments.Name())
+	if err != nil {
+		h.writeStorageError(w, r, err, "error creating deposit")
+		return
+	}
+
+	p, err := h.Payments.CreatePayment(r.Context(), depositID, amount)
+	if err != nil {
+		logger := h.logger(r).With(zap.Int64("deposit_id", depositID), zap.String("provider", h.Payments.Name()))
+		logger.Error("failed to create payment", zap.Error(err))
+		if failErr := h.Store.FailDeposit(r.Context(), depositID, "", "provider error"); failErr != nil {
+			logger.Error("failed to mark deposit as failed", zap.Error(failErr))
+		}
+		h.writeError(w, r, apiError{status: http.StatusBadGateway, code: CodePaymentProvider, message: "error creating payment"})
+		return
+	}
+
+	err = h.Store.AttachDepositPayment(r.Context(), depositID, p.ExternalID)
+	if err != nil {
+		h.writeStorageError(w, r, err, "error creating deposit")
+		return
+	}
+
+	result := generated.PendingDepositResponse{
+		Status: "ok",
+	}
+	result.Result.DepositId = depositID
+	result.Result.Message = PendingDepositMessage
+	if p.ConfirmationURL != "" {
+		result.Result.ConfirmationUrl = &p.ConfirmationURL
+	}
+
+	marshalledRequest, err := json.Marshal(result)
+	if err != nil {
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, writeErr := w.Write(marshalledRequest)
+	if writeErr != nil {
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
 		return
 	}
 }
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"http-avito-test/internal/generated"
+	"http-avito-test/internal/payment"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+func (h *Handler) DepositCallback(w http.ResponseWriter, r *http.Request) {
+	if h.Payments == nil {
+		h.writeError(w, r, errNotFound)
+		return
+	}
+
+	callback, err := h.Payments.ParseCallback(r)
+	switch {
+	case errors.Is(err, payment.ErrSignature):
+		h.writeError(w, r, apiError{status: http.StatusUnauthorized, code: CodeInvalidSignature, message: "invalid callback signature"})
+		return
+	case err != nil:
+		h.malformedBody(w, r)
+		return
+	}
+
+	var message string
+	switch callback.Status {
+	case payment.CallbackStatusSucceeded:
+		err = h.Store.ConfirmDeposit(r.Context(), callback.DepositID, callback.ExternalID)
+		message = "deposit confirmed successfully"
+	default:
+		err = h.Store.FailDeposit(r.Context(), callback.DepositID, callback.ExternalID, string(callback.Status))
+		message = "deposit marked as failed"
+	}
+
+	if err != nil {
+		h.writeStorageError(w, r, err, "error updating balance")
+		return
+	}
+
+	result := generated.DepositCallbackResponse{
+		Result: struct {
+			Message string "json:\"message\""
+		}{
+			Message: message,
+		},
+		Status: "ok",
+	}
+
+	marshalledRequest, err := json.Marshal(result)
+	if err != nil {
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, writeErr := w.Write(marshalledRequest)
+	if writeErr != nil {
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
+		return
+	}
+}
@@ -0,0 +1,173 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"http-avito-test/internal/generated"
+	"http-avito-test/internal/payment"
+	"http-avito-test/internal/storage"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDepositCallback(t *testing.T) {
+	provider := payment.NewFake("secret")
+
+	newRequest := func(body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/deposit/callback", bytes.NewBufferString(body))
+		req.Header.Set(payment.SignatureHeader, provider.Sign([]byte(body)))
+		return req
+	}
+
+	t.Run("deposit confirmed", func(t *testing.T) {
+		var testCallback = generated.DepositCallbackResponse{
+			Result: struct {
+				Message string "json:\"message\""
+			}{
+				Message: "deposit confirmed successfully",
+			},
+			Status: "ok",
+		}
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().ConfirmDeposit(gomock.Any(), int64(7), "fake-7").Return(nil)
+
+		req := newRequest(`{"deposit_id":7,"payment_id":"fake-7","status":"succeeded"}`)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store:    m,
+			Payments: provider,
+		}
+
+		s.DepositCallback(w, req)
+
+		resp := w.Result()
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+
+		js, err := json.Marshal(testCallback)
+		assert.NoError(t, err)
+
+		assert.Equal(t, string(js), string(body))
+	})
+
+	t.Run("deposit timed out", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().FailDeposit(gomock.Any(), int64(7), "fake-7", "timeout").Return(nil)
+
+		req := newRequest(`{"deposit_id":7,"payment_id":"fake-7","status":"timeout"}`)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store:    m,
+			Payments: provider,
+		}
+
+		s.DepositCallback(w, req)
+
+		resp := w.Result()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var tests = []struct {
+			name       string
+			body       string
+			signature  string
+			storageErr error
+			status     int
+			result     string
+		}{
+			{
+				name:      "invalid signature",
+				body:      `{"deposit_id":7,"payment_id":"fake-7","status":"succeeded"}`,
+				signature: "00",
+				status:    http.StatusUnauthorized,
+				result:    "invalid callback signature",
+			},
+			{
+				name:   "unknown status",
+				body:   `{"deposit_id":7,"payment_id":"fake-7","status":"refunded"}`,
+				status: http.StatusBadRequest,
+				result: "malformed request body",
+			},
+			{
+				name:       "deposit does not exist",
+				body:       `{"deposit_id":7,"payment_id":"fake-7","status":"succeeded"}`,
+				storageErr: storage.ErrNoPendingDeposit,
+				status:     http.StatusNotFound,
+				result:     "the deposit does not exist",
+			},
+			{
+				name:       "deposit already finished",
+				body:       `{"deposit_id":7,"payment_id":"fake-7","status":"succeeded"}`,
+				storageErr: storage.ErrDepositFinished,
+				status:     http.StatusConflict,
+				result:     "the deposit is already confirmed or failed",
+			},
+			{
+				name:       "error updating balance",
+				body:       `{"deposit_id":7,"payment_id":"fake-7","status":"succeeded"}`,
+				storageErr: errors.New("error updating balance"),
+				status:     http.StatusInternalServerError,
+				result:     "error updating balance",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				m := NewMockStorager(ctrl)
+				if tt.storageErr != nil {
+					m.EXPECT().ConfirmDeposit(gomock.Any(), int64(7), "fake-7").Return(tt.storageErr)
+				}
+
+				req := newRequest(tt.body)
+				if tt.signature != "" {
+					req.Header.Set(payment.SignatureHeader, tt.signature)
+				}
+				w := httptest.NewRecorder()
+
+				s := Handler{
+					Store:    m,
+					Payments: provider,
+				}
+
+				s.DepositCallback(w, req)
+
+				resp := w.Result()
+				body, err := ioutil.ReadAll(resp.Body)
+				assert.NoError(t, err)
+
+				assert.Equal(t, tt.status, resp.StatusCode)
+				assert.Equal(t, tt.result, errorMessage(t, body))
+			})
+		}
+	})
+
+	t.Run("provider is not configured", func(t *testing.T) {
+		req := newRequest(`{"deposit_id":7,"payment_id":"fake-7","status":"succeeded"}`)
+		w := httptest.NewRecorder()
+
+		s := Handler{}
+
+		s.DepositCallback(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+	})
+}
@@ -5,14 +5,16 @@ import (
 	"encoding/json"
 	"errors"
 	"http-avito-test/internal/generated"
+	"http-avito-test/internal/money"
+	"http-avito-test/internal/payment"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/golang/mock/gomock"
-	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
 )
 
 func TestAccountDeposit(t *testing.T) {
@@ -30,9 +32,9 @@ func TestAccountDeposit(t *testing.T) {
 		defer ctrl.Finish()
 
 		m := NewMockStorager(ctrl)
-		m.EXPECT().Deposit(gomock.Any(), int64(2), decimal.NewFromFloat32(100).Mul(decimal.NewFromInt(100))).Return(nil)
+		m.EXPECT().Deposit(gomock.Any(), int64(2), money.New(10000, money.RUB)).Return(nil)
 
-		arg := bytes.NewBuffer([]byte(`{"User_id":2, "Amount":100.00}`))
+		arg := bytes.NewBuffer([]byte(`{"User_id":2, "Amount":"100.00"}`))
 		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/deposit", arg)
 		w := httptest.NewRecorder()
 
@@ -50,6 +52,50 @@ func TestAccountDeposit(t *testing.T) {
 		assert.NoError(t, err)
 
 		assert.Equal(t, string(js), string(body))
+		assert.Empty(t, resp.Header.Get("Deprecation"))
+	})
+
+	t.Run("amount beyond float precision", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().Deposit(gomock.Any(), int64(2), money.New(12345678901234, money.RUB)).Return(nil)
+
+		arg := bytes.NewBuffer([]byte(`{"User_id":2, "Amount":"123456789012.34"}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/deposit", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.AccountDeposit(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	})
+
+	t.Run("numeric amount is deprecated", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().Deposit(gomock.Any(), int64(2), money.New(10000, money.RUB)).Return(nil)
+
+		arg := bytes.NewBuffer([]byte(`{"User_id":2, "Amount":100.00}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/deposit", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.AccountDeposit(w, req)
+
+		resp := w.Result()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "true", resp.Header.Get("Deprecation"))
+		assert.Equal(t, numericAmountWarning, resp.Header.Get("Warning"))
 	})
 
 	t.Run("empty request body", func(t *testing.T) {
@@ -70,7 +116,7 @@ func TestAccountDeposit(t *testing.T) {
 		body, err := ioutil.ReadAll(w.Body)
 		assert.NoError(t, err)
 
-		assert.Equal(t, "malformed request body\n", string(body))
+		assert.Equal(t, "malformed request body", errorMessage(t, body))
 	})
 
 	t.Run("wrong UserID value", func(t *testing.T) {
@@ -93,7 +139,7 @@ func TestAccountDeposit(t *testing.T) {
 		body, err := ioutil.ReadAll(w.Body)
 		assert.NoError(t, err)
 
-		assert.Equal(t, "wrong value of \"User_id\"\n", string(body))
+		assert.Equal(t, "wrong value of \"user_id\"", errorMessage(t, body))
 	})
 
 	t.Run("wrong amount value", func(t *testing.T) {
@@ -117,9 +163,31 @@ func TestAccountDeposit(t *testing.T) {
 			body, err := ioutil.ReadAll(resp.Body)
 			assert.NoError(t, err)
 
-			result := "wrong value of \"Amount\"\n"
+			result := "wrong value of \"amount\""
 
-			assert.Equal(t, result, string(body))
+			assert.Equal(t, result, errorMessage(t, body))
+		})
+
+		t.Run("malformed amount string", func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := NewMockStorager(ctrl)
+
+			arg := bytes.NewBuffer([]byte(`{"User_id":2, "Amount":"1e2"}`))
+			req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/deposit", arg)
+			w := httptest.NewRecorder()
+
+			s := Handler{
+				Store: m,
+			}
+
+			s.AccountDeposit(w, req)
+
+			body, err := ioutil.ReadAll(w.Body)
+			assert.NoError(t, err)
+
+			assert.Equal(t, "wrong value of \"amount\"", errorMessage(t, body))
 		})
 
 		t.Run("amount less than or equal to zero", func(t *testing.T) {
@@ -142,9 +210,9 @@ func TestAccountDeposit(t *testing.T) {
 			body, err := ioutil.ReadAll(resp.Body)
 			assert.NoError(t, err)
 
-			result := "wrong value of \"Amount\"\n"
+			result := "wrong value of \"amount\""
 
-			assert.Equal(t, result, string(body))
+			assert.Equal(t, result, errorMessage(t, body))
 		})
 	})
 
@@ -155,7 +223,7 @@ func TestAccountDeposit(t *testing.T) {
 		err := errors.New("error updating balance")
 
 		m := NewMockStorager(ctrl)
-		m.EXPECT().Deposit(gomock.Any(), int64(2), decimal.NewFromFloat32(100).Mul(decimal.NewFromInt(100))).Return(err)
+		m.EXPECT().Deposit(gomock.Any(), int64(2), money.New(10000, money.RUB)).Return(err)
 
 		arg := bytes.NewBuffer([]byte(`{"User_id":2, "Amount":100.00}`))
 		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/deposit", arg)
@@ -171,8 +239,79 @@ func TestAccountDeposit(t *testing.T) {
 		body, err := ioutil.ReadAll(resp.Body)
 		assert.NoError(t, err)
 
-		result := "error updating balance\n"
+		result := "error updating balance"
+
+		assert.Equal(t, result, errorMessage(t, body))
+	})
+
+	t.Run("pending deposit", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		amount := money.New(10000, money.RUB)
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().CreatePendingDeposit(gomock.Any(), int64(2), amount, payment.FakeProviderName).Return(int64(7), nil)
+		m.EXPECT().AttachDepositPayment(gomock.Any(), int64(7), "fake-7").Return(nil)
+
+		arg := bytes.NewBuffer([]byte(`{"User_id":2, "Amount":100.00}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/deposit", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store:    m,
+			Payments: payment.NewFake("secret"),
+		}
+
+		s.AccountDeposit(w, req)
+
+		resp := w.Result()
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+
+		var testDeposit = generated.PendingDepositResponse{
+			Status: "ok",
+		}
+		testDeposit.Result.DepositId = 7
+		testDeposit.Result.Message = "deposit is pending confirmation"
+
+		js, err := json.Marshal(testDeposit)
+		assert.NoError(t, err)
+
+		assert.Equal(t, string(js), string(body))
+	})
+
+	t.Run("error creating payment", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		amount := money.New(10000, money.RUB)
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().CreatePendingDeposit(gomock.Any(), int64(2), amount, "bank").Return(int64(7), nil)
+		m.EXPECT().FailDeposit(gomock.Any(), int64(7), "", "provider error").Return(nil)
+
+		p := NewMockPaymentProvider(ctrl)
+		p.EXPECT().Name().Return("bank").AnyTimes()
+		p.EXPECT().CreatePayment(gomock.Any(), int64(7), amount).Return(payment.Payment{}, errors.New("provider is unavailable"))
+
+		arg := bytes.NewBuffer([]byte(`{"User_id":2, "Amount":100.00}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/deposit", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Logger:   zap.NewNop(),
+			Store:    m,
+			Payments: p,
+		}
+
+		s.AccountDeposit(w, req)
+
+		resp := w.Result()
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
 
-		assert.Equal(t, result, string(body))
+		assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+		assert.Equal(t, "error creating payment", errorMessage(t, body))
 	})
 }
@@ -0,0 +1,198 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"http-avito-test/internal/generated"
+	"http-avito-test/internal/i18n"
+	"http-avito-test/internal/storage"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// ErrorCode is the stable code of the API error, clients rely on it instead of the message
+type ErrorCode string
+
+const (
+	CodeMalformedBody    ErrorCode = "MALFORMED_BODY"
+	CodeInvalidField     ErrorCode = "INVALID_FIELD"
+	CodeValidationFailed ErrorCode = "VALIDATION_FAILED"
+	CodeBodyTooLarge     ErrorCode = "BODY_TOO_LARGE"
+	CodeNotFound         ErrorCode = "NOT_FOUND"
+	CodeMethodNotAllowed ErrorCode = "METHOD_NOT_ALLOWED"
+	CodeInvalidSignature ErrorCode = "INVALID_SIGNATURE"
+	CodeInvalidCurrency  ErrorCode = "INVALID_CURRENCY"
+	CodePaymentProvider  ErrorCode = "PAYMENT_PROVIDER_ERROR"
+	CodeInvalidStatement ErrorCode = "INVALID_STATEMENT"
+	CodeInternal         ErrorCode = "INTERNAL"
+	CodeUnauthenticated  ErrorCode = "UNAUTHENTICATED"
+	CodeForbiddenScope   ErrorCode = "INSUFFICIENT_SCOPE"
+	CodeForbiddenService ErrorCode = "SERVICE_NOT_ALLOWED"
+	CodeRateLimited      ErrorCode = "RATE_LIMITED"
+
+	CodeInsufficientFunds       ErrorCode = "INSUFFICIENT_FUNDS"
+	CodeInsufficientReserve     ErrorCode = "INSUFFICIENT_RESERVE"
+	CodeUserNotFound            ErrorCode = "USER_NOT_FOUND"
+	CodeFraudBlocked            ErrorCode = "FRAUD_BLOCKED"
+	CodeSerializationFailure    ErrorCode = "SERIALIZATION_FAILURE"
+	CodeUnbalancedEntry         ErrorCode = "UNBALANCED_ENTRY"
+	CodeOrderExists             ErrorCode = "ORDER_EXISTS"
+	CodeOrderNotFound           ErrorCode = "ORDER_NOT_FOUND"
+	CodeOrderFinished           ErrorCode = "ORDER_FINISHED"
+	CodeReportNotFound          ErrorCode = "REPORT_NOT_FOUND"
+	CodeTransferNotFound        ErrorCode = "TRANSFER_NOT_FOUND"
+	CodeTransferFinished        ErrorCode = "TRANSFER_FINISHED"
+	CodeUndoWindowExpired       ErrorCode = "UNDO_WINDOW_EXPIRED"
+	CodePaymentRequestNotFound  ErrorCode = "PAYMENT_REQUEST_NOT_FOUND"
+	CodePaymentRequestFinished  ErrorCode = "PAYMENT_REQUEST_FINISHED"
+	CodeEscrowNotFound          ErrorCode = "ESCROW_NOT_FOUND"
+	CodeEscrowFinished          ErrorCode = "ESCROW_FINISHED"
+	CodeEscrowSplitExceeded     ErrorCode = "ESCROW_SPLIT_EXCEEDED"
+	CodeVoucherNotFound         ErrorCode = "VOUCHER_NOT_FOUND"
+	CodeVoucherExpired          ErrorCode = "VOUCHER_EXPIRED"
+	CodeVoucherUsedUp           ErrorCode = "VOUCHER_USED_UP"
+	CodeVoucherRedeemed         ErrorCode = "VOUCHER_ALREADY_REDEEMED"
+	CodeDepositNotFound         ErrorCode = "DEPOSIT_NOT_FOUND"
+	CodeDepositFinished         ErrorCode = "DEPOSIT_FINISHED"
+	CodeWithdrawalNotFound      ErrorCode = "WITHDRAWAL_REQUEST_NOT_FOUND"
+	CodeWithdrawalDecided       ErrorCode = "WITHDRAWAL_REQUEST_DECIDED"
+	CodeStatementNotFound       ErrorCode = "STATEMENT_NOT_FOUND"
+	CodeStatementLineNotFound   ErrorCode = "STATEMENT_LINE_NOT_FOUND"
+	CodeStatementLineReconciled ErrorCode = "STATEMENT_LINE_RECONCILED"
+	CodePostingNotFound         ErrorCode = "POSTING_NOT_FOUND"
+	CodePostingReconciled       ErrorCode = "POSTING_RECONCILED"
+)
+
+type storageError struct {
+	err     error
+	code    ErrorCode
+	status  int
+	message string
+}
+
+// storageErrors maps the errors of the storage to the API errors,
+// the first entry that matches the error with errors.Is is used
+var storageErrors = []storageError{
+	{storage.ErrTransfer, CodeInsufficientFunds, http.StatusBadRequest, "not enough money in the account"},
+	{storage.ErrWithdrawal, CodeInsufficientFunds, http.StatusBadRequest, "not enough money in the account"},
+	{storage.ErrRevenue, CodeInsufficientReserve, http.StatusBadRequest, "the sum is greater than the reserved amount"},
+	{storage.ErrUserAvailability, CodeUserNotFound, http.StatusNotFound, "user does not exist"},
+	{storage.ErrNoUser, CodeUserNotFound, http.StatusNotFound, "user does not exist"},
+	{storage.ErrFraudBlocked, CodeFraudBlocked, http.StatusForbidden, FraudBlockedMessage},
+	{storage.ErrSerialization, CodeSerializationFailure, http.StatusConflict, "the operation conflicted with a concurrent one, retry it"},
+	{storage.ErrUnbalancedEntry, CodeUnbalancedEntry, http.StatusInternalServerError, "the postings of the operation do not balance, it is not applied"},
+	{storage.ErrOrderId, CodeOrderExists, http.StatusConflict, "the order already exists"},
+	{storage.ErrReserveExist, CodeOrderNotFound, http.StatusNotFound, "the reserve order does not exist"},
+	{storage.ErrRecordExist, CodeOrderFinished, http.StatusConflict, "unreserve or consolidated report record already exists"},
+	{storage.ErrNoRecords, CodeReportNotFound, http.StatusNotFound, "records do not exist"},
+	{storage.ErrNoPendingTransfer, CodeTransferNotFound, http.StatusNotFound, "the transfer does not exist"},
+	{storage.ErrTransferFinished, CodeTransferFinished, http.StatusConflict, "the transfer is already settled or cancelled"},
+	{storage.ErrUndoWindowExpired, CodeUndoWindowExpired, http.StatusConflict, "the undo window of the transfer is over"},
+	{storage.ErrNoPaymentRequest, CodePaymentRequestNotFound, http.StatusNotFound, "the payment request does not exist"},
+	{storage.ErrPaymentRequestFinished, CodePaymentRequestFinished, http.StatusConflict, "the payment request is already accepted, declined or expired"},
+	{storage.ErrNoEscrow, CodeEscrowNotFound, http.StatusNotFound, "the escrow does not exist"},
+	{storage.ErrEscrowFinished, CodeEscrowFinished, http.StatusConflict, "the escrow is already released or refunded"},
+	{storage.ErrEscrowSplit, CodeEscrowSplitExceeded, http.StatusBadRequest, "the beneficiary share exceeds the escrow amount"},
+	{storage.ErrNoVoucher, CodeVoucherNotFound, http.StatusNotFound, "the voucher does not exist"},
+	{storage.ErrVoucherExpired, CodeVoucherExpired, http.StatusConflict, "the voucher is expired"},
+	{storage.ErrVoucherUsedUp, CodeVoucherUsedUp, http.StatusConflict, "the voucher redemption limit is reached"},
+	{storage.ErrVoucherRedeemed, CodeVoucherRedeemed, http.StatusConflict, "the voucher is already redeemed by the user"},
+	{storage.ErrNoPendingDeposit, CodeDepositNotFound, http.StatusNotFound, "the deposit does not exist"},
+	{storage.ErrDepositFinished, CodeDepositFinished, http.StatusConflict, "the deposit is already confirmed or failed"},
+	{storage.ErrNoWithdrawalRequest, CodeWithdrawalNotFound, http.StatusNotFound, "the withdrawal request does not exist"},
+	{storage.ErrWithdrawalDecided, CodeWithdrawalDecided, http.StatusConflict, "the withdrawal request is already approved or rejected"},
+	{storage.ErrNoStatement, CodeStatementNotFound, http.StatusNotFound, "the bank statement does not exist"},
+	{storage.ErrNoStatementLine, CodeStatementLineNotFound, http.StatusNotFound, "the bank statement line does not exist"},
+	{storage.ErrLineReconciled, CodeStatementLineReconciled, http.StatusConflict, "the bank statement line is already matched or resolved"},
+	{storage.ErrNoLedgerPosting, CodePostingNotFound, http.StatusNotFound, "the cash book posting does not exist"},
+	{storage.ErrPostingReconciled, CodePostingReconciled, http.StatusConflict, "the cash book posting is already matched or resolved"},
+}
+
+// apiError is the error written to the client. The message is English, it is replaced with
+// the translation of the code when the client accepts another language
+type apiError struct {
+	status  int
+	code    ErrorCode
+	message string
+	details []generated.Violation
+	// args fill the placeholders of the translated message
+	args map[string]string
+}
+
+var (
+	errNotFound         = apiError{status: http.StatusNotFound, code: CodeNotFound, message: "the path is not found"}
+	errMethodNotAllowed = apiError{status: http.StatusMethodNotAllowed, code: CodeMethodNotAllowed, message: "the method is not allowed"}
+)
+
+// writeError writes the error with its code, message in the language of the client and the request id
+func (h *Handler) writeError(w http.ResponseWriter, r *http.Request, e apiError) {
+	lang := i18n.FromAcceptLanguage(r.Header.Get("Accept-Language"))
+	message := e.message
+	if lang != i18n.English {
+		if translated, ok := i18n.Message(lang, string(e.code), e.args); ok {
+			message = translated
+		} else {
+			lang = i18n.English
+		}
+	}
+
+	result := generated.ErrorResponse{
+		Error: generated.Error{
+			Code:      string(e.code),
+			Message:   message,
+			RequestId: requestID(r),
+		},
+		Status: "error",
+	}
+	if len(e.details) > 0 {
+		result.Error.Details = &e.details
+	}
+
+	marshalledRequest, err := json.Marshal(result)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", string(lang))
+	w.WriteHeader(e.status)
+	_, writeErr := w.Write(marshalledRequest)
+	if writeErr != nil {
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
+		return
+	}
+}
+
+// writeStorageError writes the API error of the storage error, the errors missing from storageErrors
+// are internal and written with the message of the failed operation
+func (h *Handler) writeStorageError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	for _, e := range storageErrors {
+		if errors.Is(err, e.err) {
+			h.writeError(w, r, apiError{status: e.status, code: e.code, message: e.message})
+			return
+		}
+	}
+	h.writeError(w, r, apiError{status: http.StatusInternalServerError, code: CodeInternal, message: message})
+}
+
+func (h *Handler) malformedBody(w http.ResponseWriter, r *http.Request) {
+	h.writeError(w, r, apiError{status: http.StatusBadRequest, code: CodeMalformedBody, message: "malformed request body"})
+}
+
+// invalidField writes the error of the request field with the wrong value, field is its JSON name
+func (h *Handler) invalidField(w http.ResponseWriter, r *http.Request, field string) {
+	h.writeError(w, r, apiError{
+		status:  http.StatusBadRequest,
+		code:    CodeInvalidField,
+		message: fmt.Sprintf("wrong value of %q", field),
+		details: []generated.Violation{{Field: field, Message: "wrong value"}},
+		args:    map[string]string{"field": field},
+	})
+}
+
+func (h *Handler) internalError(w http.ResponseWriter, r *http.Request, message string) {
+	h.writeError(w, r, apiError{status: http.StatusInternalServerError, code: CodeInternal, message: message})
+}
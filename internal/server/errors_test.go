@@ -0,0 +1,154 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"http-avito-test/internal/generated"
+	"http-avito-test/internal/i18n"
+	"http-avito-test/internal/storage"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// errorMessage returns the message of the JSON error written by the handler
+func errorMessage(t *testing.T, body []byte) string {
+	t.Helper()
+
+	var resp generated.ErrorResponse
+	require.NoError(t, json.Unmarshal(body, &resp), string(body))
+	return resp.Error.Message
+}
+
+func TestWriteError(t *testing.T) {
+	t.Run("storage errors", func(t *testing.T) {
+		var tests = []struct {
+			name   string
+			err    error
+			status int
+			code   ErrorCode
+		}{
+			{"not enough money", fmt.Errorf("transfer: %w", storage.ErrTransfer), http.StatusBadRequest, CodeInsufficientFunds},
+			{"user does not exist", storage.ErrUserAvailability, http.StatusNotFound, CodeUserNotFound},
+			{"order exists", storage.ErrOrderId, http.StatusConflict, CodeOrderExists},
+			{"fraud", storage.ErrFraudBlocked, http.StatusForbidden, CodeFraudBlocked},
+			{"serialization failure", storage.ErrSerialization, http.StatusConflict, CodeSerializationFailure},
+			{"unbalanced entry", fmt.Errorf("commit: %w", storage.ErrUnbalancedEntry), http.StatusInternalServerError, CodeUnbalancedEntry},
+			{"unknown", errors.New("connection refused"), http.StatusInternalServerError, CodeInternal},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/deposit", nil)
+				req.Header.Set(RequestIDHeader, "test-request")
+				w := httptest.NewRecorder()
+
+				h := Handler{}
+				h.writeStorageError(w, req, tt.err, "error updating balance")
+
+				var resp generated.ErrorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+				assert.Equal(t, tt.status, w.Code)
+				assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+				assert.Equal(t, "error", resp.Status)
+				assert.Equal(t, string(tt.code), resp.Error.Code)
+				assert.Equal(t, "test-request", resp.Error.RequestId)
+			})
+		}
+	})
+
+	t.Run("every storage error has a code", func(t *testing.T) {
+		for _, e := range storageErrors {
+			assert.NotEmpty(t, e.code, e.err.Error())
+			assert.NotEmpty(t, e.message, e.err.Error())
+		}
+	})
+
+	t.Run("every code is translated", func(t *testing.T) {
+		codes := []ErrorCode{
+			CodeMalformedBody, CodeInvalidField, CodeValidationFailed, CodeBodyTooLarge, CodeNotFound,
+			CodeMethodNotAllowed, CodeInvalidSignature, CodeInvalidCurrency, CodePaymentProvider,
+			CodeInvalidStatement, CodeInternal, CodeUnauthenticated, CodeForbiddenScope, CodeForbiddenService,
+			CodeRateLimited,
+		}
+		for _, e := range storageErrors {
+			codes = append(codes, e.code)
+		}
+
+		for _, code := range codes {
+			_, ok := i18n.Message(i18n.Russian, string(code), nil)
+			assert.True(t, ok, code)
+		}
+	})
+
+	t.Run("localized message", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/deposit", nil)
+		req.Header.Set("Accept-Language", "ru-RU,ru;q=0.9,en;q=0.8")
+		w := httptest.NewRecorder()
+
+		h := Handler{}
+		h.invalidField(w, req, "user_id")
+
+		var resp generated.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+		assert.Equal(t, "ru", w.Header().Get("Content-Language"))
+		assert.Equal(t, string(CodeInvalidField), resp.Error.Code)
+		assert.Equal(t, "неверное значение \"user_id\"", resp.Error.Message)
+	})
+
+	t.Run("unsupported language", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/deposit", nil)
+		req.Header.Set("Accept-Language", "de")
+		w := httptest.NewRecorder()
+
+		h := Handler{}
+		h.writeStorageError(w, req, storage.ErrTransfer, "error updating balance")
+
+		assert.Equal(t, "en", w.Header().Get("Content-Language"))
+		assert.Equal(t, "not enough money in the account", errorMessage(t, w.Body.Bytes()))
+	})
+
+	t.Run("invalid field details", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/deposit", nil)
+		w := httptest.NewRecorder()
+
+		h := Handler{}
+		h.invalidField(w, req, "user_id")
+
+		var resp generated.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, string(CodeInvalidField), resp.Error.Code)
+		assert.Equal(t, &[]generated.Violation{{Field: "user_id", Message: "wrong value"}}, resp.Error.Details)
+		// the id is generated when the client does not send it
+		assert.Len(t, resp.Error.RequestId, 32)
+	})
+
+	t.Run("router errors", func(t *testing.T) {
+		h := Handler{}
+
+		req := httptest.NewRequest(http.MethodGet, "http://localhost:9090/api/1/accountdeposit", nil)
+		w := httptest.NewRecorder()
+		h.Router(false).ServeHTTP(w, req)
+
+		var resp generated.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, string(CodeMethodNotAllowed), resp.Error.Code)
+
+		req = httptest.NewRequest(http.MethodPost, "http://localhost:9090/api/1/unknown", nil)
+		w = httptest.NewRecorder()
+		h.Router(false).ServeHTTP(w, req)
+
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, string(CodeNotFound), resp.Error.Code)
+	})
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"http-avito-test/internal/generated"
+	"io/ioutil"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+const (
+	ReleasedEscrowMessage = "escrow released successfully"
+	RefundedEscrowMessage = "escrow refunded successfully"
+	SplitEscrowMessage    = "escrow split successfully"
+)
+
+func (h *Handler) ReleaseEscrow(w http.ResponseWriter, r *http.Request) {
+	h.finishEscrow(w, r, h.Store.ReleaseEscrow, ReleasedEscrowMessage)
+}
+
+func (h *Handler) RefundEscrow(w http.ResponseWriter, r *http.Request) {
+	h.finishEscrow(w, r, h.Store.RefundEscrow, RefundedEscrowMessage)
+}
+
+func (h *Handler) SplitEscrow(w http.ResponseWriter, r *http.Request) {
+	var hand *generated.SplitEscrowRequest
+
+	body, _ := ioutil.ReadAll(r.Body)
+	err := json.Unmarshal(body, &hand)
+	if err != nil || hand == nil {
+		h.malformedBody(w, r)
+		return
+	}
+
+	if hand.EscrowId <= 0 {
+		h.invalidField(w, r, "escrow_id")
+		return
+	}
+
+	newShare, err := parseAmount(w, hand.BeneficiaryShare)
+	if err != nil || !newShare.IsPositive() {
+		h.invalidField(w, r, "beneficiary_share")
+		return
+	}
+
+	err = h.Store.SplitEscrow(r.Context(), hand.EscrowId, newShare)
+	h.writeEscrowResult(w, r, err, SplitEscrowMessage)
+}
+
+func (h *Handler) finishEscrow(w http.ResponseWriter, r *http.Request, finish func(ctx context.Context, escrowID int64) error, message string) {
+	var hand *generated.EscrowCommandRequest
+
+	body, _ := ioutil.ReadAll(r.Body)
+	err := json.Unmarshal(body, &hand)
+	if err != nil || hand == nil {
+		h.malformedBody(w, r)
+		return
+	}
+
+	if hand.EscrowId <= 0 {
+		h.invalidField(w, r, "escrow_id")
+		return
+	}
+
+	err = finish(r.Context(), hand.EscrowId)
+	h.writeEscrowResult(w, r, err, message)
+}
+
+func (h *Handler) writeEscrowResult(w http.ResponseWriter, r *http.Request, err error, message string) {
+	if err != nil {
+		h.writeStorageError(w, r, err, "error finishing escrow")
+		return
+	}
+
+	result := generated.EscrowCommandResponse{
+		Result: struct {
+			Message string "json:\"message\""
+		}{
+			Message: message,
+		},
+		Status: "ok",
+	}
+
+	marshalledRequest, err := json.Marshal(result)
+	if err != nil {
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, writeErr := w.Write(marshalledRequest)
+	if writeErr != nil {
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
+		return
+	}
+}
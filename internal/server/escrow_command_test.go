@@ -0,0 +1,217 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"http-avito-test/internal/generated"
+	"http-avito-test/internal/money"
+	"http-avito-test/internal/storage"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func escrowCommandResponse(message string) generated.EscrowCommandResponse {
+	return generated.EscrowCommandResponse{
+		Result: struct {
+			Message string "json:\"message\""
+		}{
+			Message: message,
+		},
+		Status: "ok",
+	}
+}
+
+func TestReleaseEscrow(t *testing.T) {
+	t.Run("green case", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().ReleaseEscrow(gomock.Any(), int64(4)).Return(nil)
+
+		arg := bytes.NewBuffer([]byte(`{"escrow_id":4}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/escrow/release", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.ReleaseEscrow(w, req)
+
+		body, err := ioutil.ReadAll(w.Result().Body)
+		assert.NoError(t, err)
+
+		js, err := json.Marshal(escrowCommandResponse("escrow released successfully"))
+		assert.NoError(t, err)
+
+		assert.Equal(t, string(js), string(body))
+	})
+
+	t.Run("wrong escrow id value", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+
+		arg := bytes.NewBuffer([]byte(`{"escrow_id":0}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/escrow/release", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.ReleaseEscrow(w, req)
+
+		body, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "wrong value of \"escrow_id\"", errorMessage(t, body))
+	})
+
+	t.Run("release errors", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			err    error
+			status int
+			body   string
+		}{
+			{"escrow does not exist", storage.ErrNoEscrow, http.StatusNotFound, "the escrow does not exist"},
+			{"escrow is finished", storage.ErrEscrowFinished, http.StatusConflict, "the escrow is already released or refunded"},
+			{"error finishing escrow", errors.New(""), http.StatusInternalServerError, "error finishing escrow"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				m := NewMockStorager(ctrl)
+				m.EXPECT().ReleaseEscrow(gomock.Any(), int64(4)).Return(tt.err)
+
+				arg := bytes.NewBuffer([]byte(`{"escrow_id":4}`))
+				req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/escrow/release", arg)
+				w := httptest.NewRecorder()
+
+				s := Handler{
+					Store: m,
+				}
+
+				s.ReleaseEscrow(w, req)
+
+				body, err := ioutil.ReadAll(w.Body)
+				assert.NoError(t, err)
+
+				assert.Equal(t, tt.status, w.Code)
+				assert.Equal(t, tt.body, errorMessage(t, body))
+			})
+		}
+	})
+}
+
+func TestRefundEscrow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := NewMockStorager(ctrl)
+	m.EXPECT().RefundEscrow(gomock.Any(), int64(4)).Return(nil)
+
+	arg := bytes.NewBuffer([]byte(`{"escrow_id":4}`))
+	req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/escrow/refund", arg)
+	w := httptest.NewRecorder()
+
+	s := Handler{
+		Store: m,
+	}
+
+	s.RefundEscrow(w, req)
+
+	body, err := ioutil.ReadAll(w.Result().Body)
+	assert.NoError(t, err)
+
+	js, err := json.Marshal(escrowCommandResponse("escrow refunded successfully"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, string(js), string(body))
+}
+
+func TestSplitEscrow(t *testing.T) {
+	t.Run("green case", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().SplitEscrow(gomock.Any(), int64(4), money.New(3000, money.RUB)).Return(nil)
+
+		arg := bytes.NewBuffer([]byte(`{"escrow_id":4, "beneficiary_share":30}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/escrow/split", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.SplitEscrow(w, req)
+
+		body, err := ioutil.ReadAll(w.Result().Body)
+		assert.NoError(t, err)
+
+		js, err := json.Marshal(escrowCommandResponse("escrow split successfully"))
+		assert.NoError(t, err)
+
+		assert.Equal(t, string(js), string(body))
+	})
+
+	t.Run("wrong share value", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+
+		arg := bytes.NewBuffer([]byte(`{"escrow_id":4, "beneficiary_share":0}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/escrow/split", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.SplitEscrow(w, req)
+
+		body, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "wrong value of \"beneficiary_share\"", errorMessage(t, body))
+	})
+
+	t.Run("share exceeds the escrow amount", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().SplitEscrow(gomock.Any(), int64(4), money.New(30000, money.RUB)).Return(storage.ErrEscrowSplit)
+
+		arg := bytes.NewBuffer([]byte(`{"escrow_id":4, "beneficiary_share":300}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/escrow/split", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.SplitEscrow(w, req)
+
+		body, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "the beneficiary share exceeds the escrow amount", errorMessage(t, body))
+	})
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"encoding/json"
+	"http-avito-test/internal/generated"
+	"io/ioutil"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+const FraudBlockedMessage = "the operation is blocked by the fraud rules"
+
+func (h *Handler) ListFraudFlags(w http.ResponseWriter, r *http.Request) {
+	var hand *generated.ListFraudFlagsRequest
+
+	body, _ := ioutil.ReadAll(r.Body)
+	err := json.Unmarshal(body, &hand)
+	if err != nil || hand == nil {
+		h.malformedBody(w, r)
+		return
+	}
+
+	switch {
+	case hand.Limit <= 0:
+		h.invalidField(w, r, "limit")
+		return
+	case hand.Offset < 0:
+		h.invalidField(w, r, "offset")
+		return
+	}
+
+	flags, err := h.Store.ListFraudFlags(r.Context(), hand.Limit, hand.Offset)
+	if err != nil {
+		h.writeStorageError(w, r, err, "error reading fraud flags")
+		return
+	}
+
+	result := generated.ListFraudFlagsResponse{
+		Result: flags,
+		Status: "ok",
+	}
+
+	marshalledRequest, err := json.Marshal(result)
+	if err != nil {
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, writeErr := w.Write(marshalledRequest)
+	if writeErr != nil {
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
+		return
+	}
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"http-avito-test/internal/generated"
+	"http-avito-test/internal/money"
+	"http-avito-test/internal/storage"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListFraudFlags(t *testing.T) {
+	t.Run("green case", func(t *testing.T) {
+		var flags = []storage.FraudFlag{
+			{
+				ID:        1,
+				Rule:      "new account",
+				Action:    "block",
+				Operation: "withdrawal",
+				AccountID: 2,
+				Amount:    money.New(8000, money.RUB),
+				CreatedAt: time.Date(2022, time.November, 1, 12, 0, 0, 0, time.UTC),
+			},
+		}
+
+		var testList = generated.ListFraudFlagsResponse{
+			Result: flags,
+			Status: "ok",
+		}
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().ListFraudFlags(gomock.Any(), int64(10), int64(0)).Return(flags, nil)
+
+		arg := bytes.NewBuffer([]byte(`{"limit":10, "offset":0}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/admin/fraud/flags", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.ListFraudFlags(w, req)
+
+		body, err := ioutil.ReadAll(w.Result().Body)
+		assert.NoError(t, err)
+
+		js, err := json.Marshal(testList)
+		assert.NoError(t, err)
+
+		assert.Equal(t, string(js), string(body))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var tests = []struct {
+			name       string
+			body       string
+			storageErr error
+			result     string
+		}{
+			{name: "empty request body", body: ``, result: "malformed request body"},
+			{name: "wrong limit", body: `{"limit":0, "offset":0}`, result: "wrong value of \"limit\""},
+			{name: "wrong offset", body: `{"limit":10, "offset":-1}`, result: "wrong value of \"offset\""},
+			{name: "error reading", body: `{"limit":10, "offset":0}`, storageErr: errors.New("error reading"), result: "error reading fraud flags"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				m := NewMockStorager(ctrl)
+				if tt.storageErr != nil {
+					m.EXPECT().ListFraudFlags(gomock.Any(), int64(10), int64(0)).Return(nil, tt.storageErr)
+				}
+
+				req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/admin/fraud/flags", bytes.NewBufferString(tt.body))
+				w := httptest.NewRecorder()
+
+				s := Handler{
+					Store: m,
+				}
+
+				s.ListFraudFlags(w, req)
+
+				body, err := ioutil.ReadAll(w.Result().Body)
+				assert.NoError(t, err)
+
+				assert.Equal(t, tt.result, errorMessage(t, body))
+			})
+		}
+	})
+}
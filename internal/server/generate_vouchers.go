@@ -0,0 +1,72 @@
+package server
+
+import (
+	"encoding/json"
+	"http-avito-test/internal/generated"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const maxVoucherBatch = 10000
+
+func (h *Handler) GenerateVouchers(w http.ResponseWriter, r *http.Request) {
+	var hand *generated.GenerateVouchersRequest
+
+	body, _ := ioutil.ReadAll(r.Body)
+	err := json.Unmarshal(body, &hand)
+	if err != nil || hand == nil {
+		h.malformedBody(w, r)
+		return
+	}
+
+	switch {
+	case hand.Count <= 0 || hand.Count > maxVoucherBatch:
+		h.invalidField(w, r, "count")
+		return
+	case hand.MaxRedemptions <= 0:
+		h.invalidField(w, r, "max_redemptions")
+		return
+	case !hand.ExpiresAt.After(time.Now()):
+		h.invalidField(w, r, "expires_at")
+		return
+	}
+
+	newAmount, err := parseAmount(w, hand.Amount)
+	if err != nil || !newAmount.IsPositive() {
+		h.invalidField(w, r, "amount")
+		return
+	}
+
+	batchID, codes, err := h.Store.GenerateVouchers(r.Context(), hand.Count, newAmount, hand.MaxRedemptions, hand.ExpiresAt)
+	if err != nil {
+		h.writeStorageError(w, r, err, "error generating vouchers")
+		return
+	}
+
+	result := generated.GenerateVouchersResponse{
+		Result: struct {
+			BatchId int64    "json:\"batch_id\""
+			Codes   []string "json:\"codes\""
+		}{
+			BatchId: batchID,
+			Codes:   codes,
+		},
+		Status: "ok",
+	}
+
+	marshalledRequest, err := json.Marshal(result)
+	if err != nil {
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, writeErr := w.Write(marshalledRequest)
+	if writeErr != nil {
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
+		return
+	}
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"http-avito-test/internal/generated"
+	"http-avito-test/internal/money"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateVouchers(t *testing.T) {
+	var expiresAt = time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	t.Run("green case", func(t *testing.T) {
+		var testGenerate = generated.GenerateVouchersResponse{
+			Result: struct {
+				BatchId int64    "json:\"batch_id\""
+				Codes   []string "json:\"codes\""
+			}{
+				BatchId: 1,
+				Codes:   []string{"ABCDEFGHJKLM", "NPQRSTUVWXYZ"},
+			},
+			Status: "ok",
+		}
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().GenerateVouchers(gomock.Any(), int64(2), money.New(5000, money.RUB), int64(1), expiresAt).
+			Return(int64(1), []string{"ABCDEFGHJKLM", "NPQRSTUVWXYZ"}, nil)
+
+		arg := bytes.NewBuffer([]byte(fmt.Sprintf(`{"count":2, "amount":50, "max_redemptions":1, "expires_at":"%s"}`, expiresAt.Format(time.RFC3339))))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/voucher/generate", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.GenerateVouchers(w, req)
+
+		body, err := ioutil.ReadAll(w.Result().Body)
+		assert.NoError(t, err)
+
+		js, err := json.Marshal(testGenerate)
+		assert.NoError(t, err)
+
+		assert.Equal(t, string(js), string(body))
+	})
+
+	t.Run("wrong values", func(t *testing.T) {
+		var future = expiresAt.Format(time.RFC3339)
+		var past = time.Now().Add(-time.Hour).Format(time.RFC3339)
+
+		tests := []struct {
+			name string
+			arg  string
+			body string
+		}{
+			{"malformed request body", ``, "malformed request body"},
+			{"wrong count", fmt.Sprintf(`{"count":0, "amount":50, "max_redemptions":1, "expires_at":"%s"}`, future), "wrong value of \"count\""},
+			{"too large batch", fmt.Sprintf(`{"count":10001, "amount":50, "max_redemptions":1, "expires_at":"%s"}`, future), "wrong value of \"count\""},
+			{"wrong max redemptions", fmt.Sprintf(`{"count":2, "amount":50, "max_redemptions":0, "expires_at":"%s"}`, future), "wrong value of \"max_redemptions\""},
+			{"expiration in the past", fmt.Sprintf(`{"count":2, "amount":50, "max_redemptions":1, "expires_at":"%s"}`, past), "wrong value of \"expires_at\""},
+			{"negative amount", fmt.Sprintf(`{"count":2, "amount":-50, "max_redemptions":1, "expires_at":"%s"}`, future), "wrong value of \"amount\""},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				m := NewMockStorager(ctrl)
+
+				arg := bytes.NewBuffer([]byte(tt.arg))
+				req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/voucher/generate", arg)
+				w := httptest.NewRecorder()
+
+				s := Handler{
+					Store: m,
+				}
+
+				s.GenerateVouchers(w, req)
+
+				body, err := ioutil.ReadAll(w.Body)
+				assert.NoError(t, err)
+
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, tt.body, errorMessage(t, body))
+			})
+		}
+	})
+
+	t.Run("error generating vouchers", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().GenerateVouchers(gomock.Any(), int64(2), gomock.Any(), int64(1), expiresAt).Return(int64(0), nil, errors.New(""))
+
+		arg := bytes.NewBuffer([]byte(fmt.Sprintf(`{"count":2, "amount":50, "max_redemptions":1, "expires_at":"%s"}`, expiresAt.Format(time.RFC3339))))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/voucher/generate", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.GenerateVouchers(w, req)
+
+		body, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "error generating vouchers", errorMessage(t, body))
+	})
+}
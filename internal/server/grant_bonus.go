@@ -0,0 +1,69 @@
+package server
+
+import (
+	"encoding/json"
+	"http-avito-test/internal/generated"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const BonusMessage = "bonus granted successfully"
+
+func (h *Handler) GrantBonus(w http.ResponseWriter, r *http.Request) {
+	var hand *generated.GrantBonusRequest
+
+	body, _ := ioutil.ReadAll(r.Body)
+	err := json.Unmarshal(body, &hand)
+	if err != nil || hand == nil {
+		h.malformedBody(w, r)
+		return
+	}
+
+	switch {
+	case hand.UserId <= 0:
+		h.invalidField(w, r, "user_id")
+		return
+	case !hand.ExpiresAt.After(time.Now()):
+		h.invalidField(w, r, "expires_at")
+		return
+	}
+
+	newAmount, err := parseAmount(w, hand.Amount)
+	if err != nil || !newAmount.IsPositive() {
+		h.invalidField(w, r, "amount")
+		return
+	}
+
+	id, err := h.Store.GrantBonus(r.Context(), hand.UserId, newAmount, hand.ExpiresAt)
+	if err != nil {
+		h.writeStorageError(w, r, err, "error granting bonus")
+		return
+	}
+
+	result := generated.GrantBonusResponse{
+		Result: struct {
+			GrantId int64  "json:\"grant_id\""
+			Message string "json:\"message\""
+		}{
+			GrantId: id,
+			Message: BonusMessage,
+		},
+		Status: "ok",
+	}
+
+	marshalledRequest, err := json.Marshal(result)
+	if err != nil {
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, writeErr := w.Write(marshalledRequest)
+	if writeErr != nil {
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
+		return
+	}
+}
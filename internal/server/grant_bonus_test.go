@@ -0,0 +1,124 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"http-avito-test/internal/generated"
+	"http-avito-test/internal/money"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrantBonus(t *testing.T) {
+	var expiresAt = time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	t.Run("green case", func(t *testing.T) {
+		var testGrant = generated.GrantBonusResponse{
+			Result: struct {
+				GrantId int64  "json:\"grant_id\""
+				Message string "json:\"message\""
+			}{
+				GrantId: 3,
+				Message: "bonus granted successfully",
+			},
+			Status: "ok",
+		}
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().GrantBonus(gomock.Any(), int64(2), money.New(5000, money.RUB), expiresAt).Return(int64(3), nil)
+
+		arg := bytes.NewBuffer([]byte(fmt.Sprintf(`{"user_id":2, "amount":50, "expires_at":"%s"}`, expiresAt.Format(time.RFC3339))))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/bonus", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.GrantBonus(w, req)
+
+		body, err := ioutil.ReadAll(w.Result().Body)
+		assert.NoError(t, err)
+
+		js, err := json.Marshal(testGrant)
+		assert.NoError(t, err)
+
+		assert.Equal(t, string(js), string(body))
+	})
+
+	t.Run("wrong values", func(t *testing.T) {
+		var future = expiresAt.Format(time.RFC3339)
+		var past = time.Now().Add(-time.Hour).Format(time.RFC3339)
+
+		tests := []struct {
+			name string
+			arg  string
+			body string
+		}{
+			{"malformed request body", ``, "malformed request body"},
+			{"wrong user id", fmt.Sprintf(`{"user_id":0, "amount":50, "expires_at":"%s"}`, future), "wrong value of \"user_id\""},
+			{"expiration in the past", fmt.Sprintf(`{"user_id":2, "amount":50, "expires_at":"%s"}`, past), "wrong value of \"expires_at\""},
+			{"negative amount", fmt.Sprintf(`{"user_id":2, "amount":-50, "expires_at":"%s"}`, future), "wrong value of \"amount\""},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				m := NewMockStorager(ctrl)
+
+				arg := bytes.NewBuffer([]byte(tt.arg))
+				req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/bonus", arg)
+				w := httptest.NewRecorder()
+
+				s := Handler{
+					Store: m,
+				}
+
+				s.GrantBonus(w, req)
+
+				body, err := ioutil.ReadAll(w.Body)
+				assert.NoError(t, err)
+
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, tt.body, errorMessage(t, body))
+			})
+		}
+	})
+
+	t.Run("error granting bonus", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().GrantBonus(gomock.Any(), int64(2), gomock.Any(), expiresAt).Return(int64(0), errors.New(""))
+
+		arg := bytes.NewBuffer([]byte(fmt.Sprintf(`{"user_id":2, "amount":50, "expires_at":"%s"}`, expiresAt.Format(time.RFC3339))))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/bonus", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.GrantBonus(w, req)
+
+		body, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "error granting bonus", errorMessage(t, body))
+	})
+}
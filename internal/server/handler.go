@@ -1,11 +1,35 @@
 package server
 
 import (
+	"http-avito-test/internal/money"
+	"http-avito-test/internal/openapi"
+	"time"
+
 	"go.uber.org/zap"
 )
 
 type Handler struct {
-	Logger    *zap.Logger
-	Store     Storager
-	Exchanger Exchanger
+	Logger            *zap.Logger
+	Store             Storager
+	Exchanger         Exchanger
+	Payments          PaymentProvider
+	PaymentRequestTTL time.Duration
+	ReconcileWindow   time.Duration
+	// WithdrawalApprovalThreshold is the amount above which withdrawals wait for the operator approval,
+	// zero disables the approval queue
+	WithdrawalApprovalThreshold money.Money
+	// Spec holds the request schemas the bodies are validated against, nil disables the validation
+	Spec *openapi.Document
+	// MaxBodySize limits the request bodies, DefaultMaxBodySize is used when it is not set
+	MaxBodySize int64
+	// RequireAPIKeys rejects the requests without the API key that has the scope of the operation
+	RequireAPIKeys bool
+	// Limiter keeps the token buckets of AddressLimit, ClientLimits and AccountLimits, nil disables the rate limits
+	Limiter       Limiter
+	ClientLimits  RateLimits
+	AccountLimits RateLimits
+	// AddressLimit is checked before the authentication, the zero limit disables it
+	AddressLimit Limit
+	// ReportDir keeps the monthly reports served by the file server, DefaultReportDir is used when it is not set
+	ReportDir string
 }
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"encoding/json"
+	"http-avito-test/internal/generated"
+	"http-avito-test/internal/storage"
+	"io/ioutil"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+func (h *Handler) ListIncomingPaymentRequests(w http.ResponseWriter, r *http.Request) {
+	h.listPaymentRequests(w, r, storage.PaymentRequestsIncoming)
+}
+
+func (h *Handler) ListOutgoingPaymentRequests(w http.ResponseWriter, r *http.Request) {
+	h.listPaymentRequests(w, r, storage.PaymentRequestsOutgoing)
+}
+
+func (h *Handler) listPaymentRequests(w http.ResponseWriter, r *http.Request, direction storage.PaymentRequestDirection) {
+	var hand *generated.ListPaymentRequestsRequest
+
+	body, _ := ioutil.ReadAll(r.Body)
+	err := json.Unmarshal(body, &hand)
+	if err != nil || hand == nil {
+		h.malformedBody(w, r)
+		return
+	}
+
+	switch {
+	case hand.UserId <= 0:
+		h.invalidField(w, r, "user_id")
+		return
+	case hand.Limit <= 0:
+		h.invalidField(w, r, "limit")
+		return
+	case hand.Offset < 0:
+		h.invalidField(w, r, "offset")
+		return
+	}
+
+	requests, err := h.Store.ListPaymentRequests(r.Context(), hand.UserId, direction, hand.Limit, hand.Offset)
+	if err != nil {
+		h.writeStorageError(w, r, err, "error reading payment requests")
+		return
+	}
+
+	result := generated.ListPaymentRequestsResponse{
+		Result: requests,
+		Status: "ok",
+	}
+
+	marshalledRequest, err := json.Marshal(result)
+	if err != nil {
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, writeErr := w.Write(marshalledRequest)
+	if writeErr != nil {
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
+		return
+	}
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"http-avito-test/internal/generated"
+	"http-avito-test/internal/money"
+	"http-avito-test/internal/storage"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListPaymentRequests(t *testing.T) {
+	var now = time.Date(2022, time.November, 1, 12, 0, 0, 0, time.UTC)
+
+	var requests = []storage.PaymentRequest{
+		{
+			ID:        5,
+			Requester: 3,
+			Payer:     2,
+			Amount:    money.New(15000, money.RUB),
+			Status:    storage.PaymentRequestStatusPending,
+			CreatedAt: now,
+			ExpiresAt: now.Add(72 * time.Hour),
+		},
+	}
+
+	t.Run("incoming requests", func(t *testing.T) {
+		var testList = generated.ListPaymentRequestsResponse{
+			Result: requests,
+			Status: "ok",
+		}
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().ListPaymentRequests(gomock.Any(), int64(2), storage.PaymentRequestsIncoming, int64(10), int64(0)).Return(requests, nil)
+
+		arg := bytes.NewBuffer([]byte(`{"user_id":2, "limit":10, "offset":0}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/payreq/incoming", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.ListIncomingPaymentRequests(w, req)
+
+		body, err := ioutil.ReadAll(w.Result().Body)
+		assert.NoError(t, err)
+
+		js, err := json.Marshal(testList)
+		assert.NoError(t, err)
+
+		assert.Equal(t, string(js), string(body))
+	})
+
+	t.Run("outgoing requests", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().ListPaymentRequests(gomock.Any(), int64(3), storage.PaymentRequestsOutgoing, int64(10), int64(5)).Return(requests, nil)
+
+		arg := bytes.NewBuffer([]byte(`{"user_id":3, "limit":10, "offset":5}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/payreq/outgoing", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.ListOutgoingPaymentRequests(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("wrong values", func(t *testing.T) {
+		tests := []struct {
+			name string
+			arg  string
+			body string
+		}{
+			{"malformed request body", ``, "malformed request body"},
+			{"wrong user id", `{"user_id":0, "limit":10}`, "wrong value of \"user_id\""},
+			{"wrong limit", `{"user_id":2, "limit":0}`, "wrong value of \"limit\""},
+			{"wrong offset", `{"user_id":2, "limit":10, "offset":-1}`, "wrong value of \"offset\""},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				m := NewMockStorager(ctrl)
+
+				arg := bytes.NewBuffer([]byte(tt.arg))
+				req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/payreq/incoming", arg)
+				w := httptest.NewRecorder()
+
+				s := Handler{
+					Store: m,
+				}
+
+				s.ListIncomingPaymentRequests(w, req)
+
+				body, err := ioutil.ReadAll(w.Body)
+				assert.NoError(t, err)
+
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, tt.body, errorMessage(t, body))
+			})
+		}
+	})
+
+	t.Run("error reading payment requests", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().ListPaymentRequests(gomock.Any(), int64(2), storage.PaymentRequestsIncoming, int64(10), int64(0)).Return(nil, errors.New(""))
+
+		arg := bytes.NewBuffer([]byte(`{"user_id":2, "limit":10}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/payreq/incoming", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.ListIncomingPaymentRequests(w, req)
+
+		body, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "error reading payment requests", errorMessage(t, body))
+	})
+}
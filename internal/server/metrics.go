@@ -0,0 +1,394 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"http-avito-test/internal/exchanger"
+	"http-avito-test/internal/metrics"
+	"http-avito-test/internal/money"
+	"http-avito-test/internal/reconcile"
+	"http-avito-test/internal/storage"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// MetricsPath serves the metrics in the Prometheus text exposition format
+const MetricsPath = "/metrics"
+
+// unmatchedRoute is the route label of the requests of the unknown paths, their paths are not used as labels
+// to keep the number of the series bounded
+const unmatchedRoute = "unmatched"
+
+var metricsHandler = promhttp.HandlerFor(metrics.Default, promhttp.HandlerOpts{})
+
+// WithMetrics serves the metrics at MetricsPath and passes the other requests to next
+func WithMetrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == MetricsPath {
+			metricsHandler.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// statusRecorder keeps the status code written by the handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+// methodLabel is the method of the request, the non-standard methods are "other" to keep the number of the series bounded
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions:
+		return method
+	}
+	return "other"
+}
+
+// observeRequest counts the request served by the route and its latency
+func observeRequest(route, method string, status int, start time.Time) {
+	method = methodLabel(method)
+	code := strconv.Itoa(status)
+	metrics.HTTPRequests.WithLabelValues(route, method, code).Inc()
+	metrics.HTTPRequestDuration.WithLabelValues(route, method, code).Observe(time.Since(start).Seconds())
+}
+
+// storageOperation is the storage operation called by the handler
+type storageOperation struct {
+	name  string
+	start time.Time
+	span  trace.Span
+}
+
+// startStorage starts the span of the storage operation, the context carrying the span is passed to the storage,
+// so the spans of its nested operations and SQL statements are the children of the span
+func startStorage(ctx context.Context, name string) (context.Context, storageOperation) {
+	ctx, span := tracer.Start(ctx, "Storage."+name)
+	return ctx, storageOperation{name: name, start: time.Now(), span: span}
+}
+
+// end records the latency of the storage operation, counts its error by the storage error name and ends its span
+func (o storageOperation) end(err *error) {
+	metrics.StorageDuration.WithLabelValues(o.name).Observe(time.Since(o.start).Seconds())
+
+	if *err != nil {
+		name := storage.ErrorName(*err)
+		metrics.StorageErrors.WithLabelValues(o.name, name).Inc()
+		if storage.IsSerializationFailure(*err) {
+			metrics.SerializationFailures.WithLabelValues(o.name).Inc()
+		}
+
+		o.span.SetAttributes(attribute.String("error.name", name))
+		o.span.RecordError(*err)
+		o.span.SetStatus(codes.Error, (*err).Error())
+	}
+	o.span.End()
+}
+
+// MeasureExchanger records the latency and the errors of the exchange rate requests
+func MeasureExchanger(e Exchanger) Exchanger {
+	return measuredExchanger{e}
+}
+
+type measuredExchanger struct {
+	Exchanger
+}
+
+func (m measuredExchanger) ExchangeRates(ctx context.Context, logger *zap.Logger, value decimal.Decimal, currency string) (decimal.Decimal, error) {
+	start := time.Now()
+	result, err := m.Exchanger.ExchangeRates(ctx, logger, value, currency)
+	metrics.ExchangerDuration.Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		reason := "other"
+		if errors.Is(err, exchanger.ErrExchanger) {
+			reason = "invalid_currency"
+		}
+		metrics.ExchangerErrors.WithLabelValues(reason).Inc()
+	}
+	return result, err
+}
+
+// MeasureStorage records the latency, the errors and the spans of every storage operation called by the handlers,
+// TestMeasureStorageMethods fails when a method of Storager is not wrapped
+func MeasureStorage(s Storager) Storager {
+	return measuredStorage{s}
+}
+
+type measuredStorage struct {
+	Storager
+}
+
+func (m measuredStorage) ReadUserByID(ctx context.Context, userID int64) (_ storage.User, err error) {
+	ctx, op := startStorage(ctx, "ReadUserByID")
+	defer op.end(&err)
+	return m.Storager.ReadUserByID(ctx, userID)
+}
+
+func (m measuredStorage) ReadUsersByIDs(ctx context.Context, userIDs []int64) (_ []storage.UserResult, err error) {
+	ctx, op := startStorage(ctx, "ReadUsersByIDs")
+	defer op.end(&err)
+	return m.Storager.ReadUsersByIDs(ctx, userIDs)
+}
+
+func (m measuredStorage) Deposit(ctx context.Context, userID int64, amount money.Money) (err error) {
+	ctx, op := startStorage(ctx, "Deposit")
+	defer op.end(&err)
+	return m.Storager.Deposit(ctx, userID, amount)
+}
+
+func (m measuredStorage) CreatePendingDeposit(ctx context.Context, userID int64, amount money.Money, provider string) (_ int64, err error) {
+	ctx, op := startStorage(ctx, "CreatePendingDeposit")
+	defer op.end(&err)
+	return m.Storager.CreatePendingDeposit(ctx, userID, amount, provider)
+}
+
+func (m measuredStorage) AttachDepositPayment(ctx context.Context, depositID int64, externalID string) (err error) {
+	ctx, op := startStorage(ctx, "AttachDepositPayment")
+	defer op.end(&err)
+	return m.Storager.AttachDepositPayment(ctx, depositID, externalID)
+}
+
+func (m measuredStorage) ConfirmDeposit(ctx context.Context, depositID int64, externalID string) (err error) {
+	ctx, op := startStorage(ctx, "ConfirmDeposit")
+	defer op.end(&err)
+	return m.Storager.ConfirmDeposit(ctx, depositID, externalID)
+}
+
+func (m measuredStorage) FailDeposit(ctx context.Context, depositID int64, externalID string, reason string) (err error) {
+	ctx, op := startStorage(ctx, "FailDeposit")
+	defer op.end(&err)
+	return m.Storager.FailDeposit(ctx, depositID, externalID, reason)
+}
+
+func (m measuredStorage) Withdrawal(ctx context.Context, userID int64, amount money.Money, description *string, options ...storage.TxOption) (err error) {
+	ctx, op := startStorage(ctx, "Withdrawal")
+	defer op.end(&err)
+	return m.Storager.Withdrawal(ctx, userID, amount, description, options...)
+}
+
+func (m measuredStorage) RequestWithdrawal(ctx context.Context, userID int64, amount money.Money, description *string) (_ int64, err error) {
+	ctx, op := startStorage(ctx, "RequestWithdrawal")
+	defer op.end(&err)
+	return m.Storager.RequestWithdrawal(ctx, userID, amount, description)
+}
+
+func (m measuredStorage) ListWithdrawalRequests(ctx context.Context, status storage.WithdrawalStatus, limit int64, offset int64) (_ []storage.WithdrawalRequest, err error) {
+	ctx, op := startStorage(ctx, "ListWithdrawalRequests")
+	defer op.end(&err)
+	return m.Storager.ListWithdrawalRequests(ctx, status, limit, offset)
+}
+
+func (m measuredStorage) ApproveWithdrawal(ctx context.Context, requestID int64, operator string, reason string) (err error) {
+	ctx, op := startStorage(ctx, "ApproveWithdrawal")
+	defer op.end(&err)
+	return m.Storager.ApproveWithdrawal(ctx, requestID, operator, reason)
+}
+
+func (m measuredStorage) RejectWithdrawal(ctx context.Context, requestID int64, operator string, reason string) (err error) {
+	ctx, op := startStorage(ctx, "RejectWithdrawal")
+	defer op.end(&err)
+	return m.Storager.RejectWithdrawal(ctx, requestID, operator, reason)
+}
+
+func (m measuredStorage) ListFraudFlags(ctx context.Context, limit int64, offset int64) (_ []storage.FraudFlag, err error) {
+	ctx, op := startStorage(ctx, "ListFraudFlags")
+	defer op.end(&err)
+	return m.Storager.ListFraudFlags(ctx, limit, offset)
+}
+
+func (m measuredStorage) ReadAPIKey(ctx context.Context, prefix string) (_ storage.APIKey, err error) {
+	ctx, op := startStorage(ctx, "ReadAPIKey")
+	defer op.end(&err)
+	return m.Storager.ReadAPIKey(ctx, prefix)
+}
+
+func (m measuredStorage) AuditDenial(ctx context.Context, d storage.AuthorizationDenial) (err error) {
+	ctx, op := startStorage(ctx, "AuditDenial")
+	defer op.end(&err)
+	return m.Storager.AuditDenial(ctx, d)
+}
+
+func (m measuredStorage) ImportStatement(ctx context.Context, name string, lines []reconcile.Line, window time.Duration) (_ int64, err error) {
+	ctx, op := startStorage(ctx, "ImportStatement")
+	defer op.end(&err)
+	return m.Storager.ImportStatement(ctx, name, lines, window)
+}
+
+func (m measuredStorage) ReadReconciliation(ctx context.Context, statementID int64) (_ storage.Reconciliation, err error) {
+	ctx, op := startStorage(ctx, "ReadReconciliation")
+	defer op.end(&err)
+	return m.Storager.ReadReconciliation(ctx, statementID)
+}
+
+func (m measuredStorage) ResolveStatementLine(ctx context.Context, lineID int64, postingID *int64, operator string, note string) (err error) {
+	ctx, op := startStorage(ctx, "ResolveStatementLine")
+	defer op.end(&err)
+	return m.Storager.ResolveStatementLine(ctx, lineID, postingID, operator, note)
+}
+
+func (m measuredStorage) ResolveLedgerPosting(ctx context.Context, postingID int64, operator string, note string) (err error) {
+	ctx, op := startStorage(ctx, "ResolveLedgerPosting")
+	defer op.end(&err)
+	return m.Storager.ResolveLedgerPosting(ctx, postingID, operator, note)
+}
+
+func (m measuredStorage) Transfer(ctx context.Context, sender int64, recipient int64, amount money.Money, description *string, options ...storage.TxOption) (_ int64, _ int64, err error) {
+	ctx, op := startStorage(ctx, "Transfer")
+	defer op.end(&err)
+	return m.Storager.Transfer(ctx, sender, recipient, amount, description, options...)
+}
+
+func (m measuredStorage) DelayedTransfer(ctx context.Context, sender int64, recipient int64, amount money.Money, description *string, settleAt time.Time) (_ int64, err error) {
+	ctx, op := startStorage(ctx, "DelayedTransfer")
+	defer op.end(&err)
+	return m.Storager.DelayedTransfer(ctx, sender, recipient, amount, description, settleAt)
+}
+
+func (m measuredStorage) CancelTransfer(ctx context.Context, sender int64, transferID int64) (err error) {
+	ctx, op := startStorage(ctx, "CancelTransfer")
+	defer op.end(&err)
+	return m.Storager.CancelTransfer(ctx, sender, transferID)
+}
+
+func (m measuredStorage) CreatePaymentRequest(ctx context.Context, requester int64, payer int64, amount money.Money, description *string, expiresAt time.Time) (_ int64, err error) {
+	ctx, op := startStorage(ctx, "CreatePaymentRequest")
+	defer op.end(&err)
+	return m.Storager.CreatePaymentRequest(ctx, requester, payer, amount, description, expiresAt)
+}
+
+func (m measuredStorage) ListPaymentRequests(ctx context.Context, userID int64, direction storage.PaymentRequestDirection, limit int64, offset int64) (_ []storage.PaymentRequest, err error) {
+	ctx, op := startStorage(ctx, "ListPaymentRequests")
+	defer op.end(&err)
+	return m.Storager.ListPaymentRequests(ctx, userID, direction, limit, offset)
+}
+
+func (m measuredStorage) AcceptPaymentRequest(ctx context.Context, payer int64, requestID int64) (err error) {
+	ctx, op := startStorage(ctx, "AcceptPaymentRequest")
+	defer op.end(&err)
+	return m.Storager.AcceptPaymentRequest(ctx, payer, requestID)
+}
+
+func (m measuredStorage) DeclinePaymentRequest(ctx context.Context, payer int64, requestID int64) (err error) {
+	ctx, op := startStorage(ctx, "DeclinePaymentRequest")
+	defer op.end(&err)
+	return m.Storager.DeclinePaymentRequest(ctx, payer, requestID)
+}
+
+func (m measuredStorage) CreateEscrow(ctx context.Context, payer int64, beneficiary int64, amount money.Money, description *string) (_ int64, err error) {
+	ctx, op := startStorage(ctx, "CreateEscrow")
+	defer op.end(&err)
+	return m.Storager.CreateEscrow(ctx, payer, beneficiary, amount, description)
+}
+
+func (m measuredStorage) ReleaseEscrow(ctx context.Context, escrowID int64) (err error) {
+	ctx, op := startStorage(ctx, "ReleaseEscrow")
+	defer op.end(&err)
+	return m.Storager.ReleaseEscrow(ctx, escrowID)
+}
+
+func (m measuredStorage) RefundEscrow(ctx context.Context, escrowID int64) (err error) {
+	ctx, op := startStorage(ctx, "RefundEscrow")
+	defer op.end(&err)
+	return m.Storager.RefundEscrow(ctx, escrowID)
+}
+
+func (m measuredStorage) SplitEscrow(ctx context.Context, escrowID int64, beneficiaryShare money.Money) (err error) {
+	ctx, op := startStorage(ctx, "SplitEscrow")
+	defer op.end(&err)
+	return m.Storager.SplitEscrow(ctx, escrowID, beneficiaryShare)
+}
+
+func (m measuredStorage) ReadEscrow(ctx context.Context, escrowID int64) (_ storage.Escrow, err error) {
+	ctx, op := startStorage(ctx, "ReadEscrow")
+	defer op.end(&err)
+	return m.Storager.ReadEscrow(ctx, escrowID)
+}
+
+func (m measuredStorage) GenerateVouchers(ctx context.Context, count int64, amount money.Money, maxRedemptions int64, expiresAt time.Time) (_ int64, _ []string, err error) {
+	ctx, op := startStorage(ctx, "GenerateVouchers")
+	defer op.end(&err)
+	return m.Storager.GenerateVouchers(ctx, count, amount, maxRedemptions, expiresAt)
+}
+
+func (m measuredStorage) RedeemVoucher(ctx context.Context, userID int64, code string) (_ money.Money, err error) {
+	ctx, op := startStorage(ctx, "RedeemVoucher")
+	defer op.end(&err)
+	return m.Storager.RedeemVoucher(ctx, userID, code)
+}
+
+func (m measuredStorage) GrantBonus(ctx context.Context, userID int64, amount money.Money, expiresAt time.Time) (_ int64, err error) {
+	ctx, op := startStorage(ctx, "GrantBonus")
+	defer op.end(&err)
+	return m.Storager.GrantBonus(ctx, userID, amount, expiresAt)
+}
+
+func (m measuredStorage) ReadBalanceBuckets(ctx context.Context, userID int64) (_ storage.BalanceBuckets, err error) {
+	ctx, op := startStorage(ctx, "ReadBalanceBuckets")
+	defer op.end(&err)
+	return m.Storager.ReadBalanceBuckets(ctx, userID)
+}
+
+func (m measuredStorage) ReadUserHistoryList(ctx context.Context, userID int64, order storage.OrdBy, limit int64, offset int64) (_ []storage.ReadUserHistoryResult, err error) {
+	ctx, op := startStorage(ctx, "ReadUserHistoryList")
+	defer op.end(&err)
+	return m.Storager.ReadUserHistoryList(ctx, userID, order, limit, offset)
+}
+
+func (m measuredStorage) Reservation(ctx context.Context, userID int64, serviceID int64, orderID int64, price money.Money, description *string, options ...storage.TxOption) (err error) {
+	ctx, op := startStorage(ctx, "Reservation")
+	defer op.end(&err)
+	return m.Storager.Reservation(ctx, userID, serviceID, orderID, price, description, options...)
+}
+
+func (m measuredStorage) Revenue(ctx context.Context, userID int64, serviceID int64, orderID int64, sum money.Money, description *string) (err error) {
+	ctx, op := startStorage(ctx, "Revenue")
+	defer op.end(&err)
+	return m.Storager.Revenue(ctx, userID, serviceID, orderID, sum, description)
+}
+
+func (m measuredStorage) Unreservation(ctx context.Context, userID int64, serviceID int64, orderID int64, description *string) (err error) {
+	ctx, op := startStorage(ctx, "Unreservation")
+	defer op.end(&err)
+	return m.Storager.Unreservation(ctx, userID, serviceID, orderID, description)
+}
+
+func (m measuredStorage) MonthlyReport(ctx context.Context, year int64, month int64) (_ [][]string, err error) {
+	ctx, op := startStorage(ctx, "MonthlyReport")
+	defer op.end(&err)
+	return m.Storager.MonthlyReport(ctx, year, month)
+}
+
+func (m measuredStorage) QuoteWithdrawal(ctx context.Context, userID int64, amount money.Money, description *string) (_ storage.Quote, err error) {
+	ctx, op := startStorage(ctx, "QuoteWithdrawal")
+	defer op.end(&err)
+	return m.Storager.QuoteWithdrawal(ctx, userID, amount, description)
+}
+
+func (m measuredStorage) QuoteTransfer(ctx context.Context, sender int64, recipient int64, amount money.Money, description *string) (_ storage.Quote, err error) {
+	ctx, op := startStorage(ctx, "QuoteTransfer")
+	defer op.end(&err)
+	return m.Storager.QuoteTransfer(ctx, sender, recipient, amount, description)
+}
+
+func (m measuredStorage) QuoteReservation(ctx context.Context, userID int64, serviceID int64, orderID int64, price money.Money, description *string) (_ storage.Quote, err error) {
+	ctx, op := startStorage(ctx, "QuoteReservation")
+	defer op.end(&err)
+	return m.Storager.QuoteReservation(ctx, userID, serviceID, orderID, price, description)
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"http-avito-test/internal/exchanger"
+	"http-avito-test/internal/metrics"
+	"http-avito-test/internal/money"
+	"http-avito-test/internal/storage"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// observations returns the number of the values observed by the histogram
+func observations(h prometheus.Observer) uint64 {
+	var m dto.Metric
+	if err := h.(prometheus.Metric).Write(&m); err != nil {
+		panic(err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestRouterMetrics(t *testing.T) {
+	t.Run("matched route", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().Deposit(gomock.Any(), int64(2), money.New(10000, money.RUB)).Return(storage.ErrNoUser)
+
+		route := "/api/{version}/accountdeposit"
+		before := testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues(route, http.MethodPost, "404"))
+		observed := observations(metrics.HTTPRequestDuration.WithLabelValues(route, http.MethodPost, "404"))
+
+		arg := bytes.NewBuffer([]byte(`{"User_id":2, "Amount":"100.00"}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/api/1/accountdeposit", arg)
+		w := httptest.NewRecorder()
+
+		h := Handler{
+			Store: m,
+		}
+		h.Router(false).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, before+1, testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues(route, http.MethodPost, "404")))
+		assert.Equal(t, observed+1, observations(metrics.HTTPRequestDuration.WithLabelValues(route, http.MethodPost, "404")))
+	})
+
+	t.Run("unknown path", func(t *testing.T) {
+		before := testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues(unmatchedRoute, "other", "404"))
+
+		req := httptest.NewRequest("BREW", "http://localhost:9090/api/1/coffee", nil)
+		w := httptest.NewRecorder()
+
+		h := Handler{}
+		h.Router(false).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, before+1, testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues(unmatchedRoute, "other", "404")))
+	})
+}
+
+func TestMeasureStorage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := NewMockStorager(ctrl)
+	m.EXPECT().Withdrawal(gomock.Any(), int64(2), money.New(100, money.RUB), nil).Return(storage.ErrSerialization)
+	m.EXPECT().Withdrawal(gomock.Any(), int64(2), money.New(100, money.RUB), nil).Return(errors.New("connection reset"))
+	m.EXPECT().Withdrawal(gomock.Any(), int64(2), money.New(100, money.RUB), nil).Return(nil)
+
+	observed := observations(metrics.StorageDuration.WithLabelValues("Withdrawal"))
+	serialization := testutil.ToFloat64(metrics.StorageErrors.WithLabelValues("Withdrawal", "ErrSerialization"))
+	other := testutil.ToFloat64(metrics.StorageErrors.WithLabelValues("Withdrawal", "other"))
+	failures := testutil.ToFloat64(metrics.SerializationFailures.WithLabelValues("Withdrawal"))
+
+	s := MeasureStorage(m)
+	assert.ErrorIs(t, s.Withdrawal(context.Background(), 2, money.New(100, money.RUB), nil), storage.ErrSerialization)
+	assert.Error(t, s.Withdrawal(context.Background(), 2, money.New(100, money.RUB), nil))
+	assert.NoError(t, s.Withdrawal(context.Background(), 2, money.New(100, money.RUB), nil))
+
+	assert.Equal(t, observed+3, observations(metrics.StorageDuration.WithLabelValues("Withdrawal")))
+	assert.Equal(t, serialization+1, testutil.ToFloat64(metrics.StorageErrors.WithLabelValues("Withdrawal", "ErrSerialization")))
+	assert.Equal(t, other+1, testutil.ToFloat64(metrics.StorageErrors.WithLabelValues("Withdrawal", "other")))
+	assert.Equal(t, failures+1, testutil.ToFloat64(metrics.SerializationFailures.WithLabelValues("Withdrawal")))
+}
+
+func TestMeasureStorageMethods(t *testing.T) {
+	// the methods promoted from the embedded Storager are generated by the compiler,
+	// so they are not declared in a source file and would not be measured
+	measured := reflect.TypeOf(measuredStorage{})
+	storager := reflect.TypeOf((*Storager)(nil)).Elem()
+	for i := 0; i < storager.NumMethod(); i++ {
+		name := storager.Method(i).Name
+		method, ok := measured.MethodByName(name)
+		require.True(t, ok, name)
+
+		file, _ := runtime.FuncForPC(method.Func.Pointer()).FileLine(method.Func.Pointer())
+		assert.Equal(t, "metrics.go", filepath.Base(file), "measuredStorage does not wrap %s", name)
+	}
+}
+
+func TestMeasureExchanger(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := NewMockExchanger(ctrl)
+	m.EXPECT().ExchangeRates(gomock.Any(), gomock.Any(), decimal.NewFromInt(100), "XYZ").Return(decimal.Zero, exchanger.ErrExchanger)
+	m.EXPECT().ExchangeRates(gomock.Any(), gomock.Any(), decimal.NewFromInt(100), "USD").Return(decimal.NewFromInt(1), nil)
+
+	observed := observations(metrics.ExchangerDuration)
+	invalid := testutil.ToFloat64(metrics.ExchangerErrors.WithLabelValues("invalid_currency"))
+
+	e := MeasureExchanger(m)
+	_, err := e.ExchangeRates(context.Background(), nil, decimal.NewFromInt(100), "XYZ")
+	assert.ErrorIs(t, err, exchanger.ErrExchanger)
+	result, err := e.ExchangeRates(context.Background(), nil, decimal.NewFromInt(100), "USD")
+	assert.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(1).Equal(result))
+
+	assert.Equal(t, observed+2, observations(metrics.ExchangerDuration))
+	assert.Equal(t, invalid+1, testutil.ToFloat64(metrics.ExchangerErrors.WithLabelValues("invalid_currency")))
+}
+
+func TestWithMetrics(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	t.Run("metrics", func(t *testing.T) {
+		metrics.HTTPRequests.WithLabelValues(unmatchedRoute, "other", "404")
+
+		w := httptest.NewRecorder()
+		WithMetrics(next).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://localhost:9090/metrics", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+		assert.Contains(t, w.Body.String(), "# TYPE http_requests_total counter")
+	})
+
+	t.Run("other paths", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		WithMetrics(next).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "http://localhost:9090/api/1/readuser", nil))
+
+		assert.Equal(t, http.StatusTeapot, w.Code)
+	})
+}
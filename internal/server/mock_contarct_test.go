@@ -6,14 +6,72 @@ package server
 
 import (
 	context "context"
+	money "http-avito-test/internal/money"
+	payment "http-avito-test/internal/payment"
+	reconcile "http-avito-test/internal/reconcile"
 	storage "http-avito-test/internal/storage"
+	http "net/http"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	decimal "github.com/shopspring/decimal"
 	zap "go.uber.org/zap"
 )
 
+// MockLimiter is a mock of Limiter interface.
+type MockLimiter struct {
+	ctrl     *gomock.Controller
+	recorder *MockLimiterMockRecorder
+}
+
+// MockLimiterMockRecorder is the mock recorder for MockLimiter.
+type MockLimiterMockRecorder struct {
+	mock *MockLimiter
+}
+
+// NewMockLimiter creates a new mock instance.
+func NewMockLimiter(ctrl *gomock.Controller) *MockLimiter {
+	mock := &MockLimiter{ctrl: ctrl}
+	mock.recorder = &MockLimiterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLimiter) EXPECT() *MockLimiterMockRecorder {
+	return m.recorder
+}
+
+// ReturnToken mocks base method.
+func (m *MockLimiter) ReturnToken(ctx context.Context, key string, burst int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReturnToken", ctx, key, burst)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReturnToken indicates an expected call of ReturnToken.
+func (mr *MockLimiterMockRecorder) ReturnToken(ctx, key, burst interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReturnToken", reflect.TypeOf((*MockLimiter)(nil).ReturnToken), ctx, key, burst)
+}
+
+// TakeToken mocks base method.
+func (m *MockLimiter) TakeToken(ctx context.Context, key string, rate float64, burst int64) (bool, float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeToken", ctx, key, rate, burst)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(float64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// TakeToken indicates an expected call of TakeToken.
+func (mr *MockLimiterMockRecorder) TakeToken(ctx, key, rate, burst interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeToken", reflect.TypeOf((*MockLimiter)(nil).TakeToken), ctx, key, rate, burst)
+}
+
 // MockStorager is a mock of Storager interface.
 type MockStorager struct {
 	ctrl     *gomock.Controller
@@ -37,8 +95,166 @@ func (m *MockStorager) EXPECT() *MockStoragerMockRecorder {
 	return m.recorder
 }
 
+// AcceptPaymentRequest mocks base method.
+func (m *MockStorager) AcceptPaymentRequest(ctx context.Context, payer, requestID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptPaymentRequest", ctx, payer, requestID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AcceptPaymentRequest indicates an expected call of AcceptPaymentRequest.
+func (mr *MockStoragerMockRecorder) AcceptPaymentRequest(ctx, payer, requestID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptPaymentRequest", reflect.TypeOf((*MockStorager)(nil).AcceptPaymentRequest), ctx, payer, requestID)
+}
+
+// ApproveWithdrawal mocks base method.
+func (m *MockStorager) ApproveWithdrawal(ctx context.Context, requestID int64, operator, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApproveWithdrawal", ctx, requestID, operator, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ApproveWithdrawal indicates an expected call of ApproveWithdrawal.
+func (mr *MockStoragerMockRecorder) ApproveWithdrawal(ctx, requestID, operator, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApproveWithdrawal", reflect.TypeOf((*MockStorager)(nil).ApproveWithdrawal), ctx, requestID, operator, reason)
+}
+
+// AttachDepositPayment mocks base method.
+func (m *MockStorager) AttachDepositPayment(ctx context.Context, depositID int64, externalID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AttachDepositPayment", ctx, depositID, externalID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AttachDepositPayment indicates an expected call of AttachDepositPayment.
+func (mr *MockStoragerMockRecorder) AttachDepositPayment(ctx, depositID, externalID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AttachDepositPayment", reflect.TypeOf((*MockStorager)(nil).AttachDepositPayment), ctx, depositID, externalID)
+}
+
+// AuditDenial mocks base method.
+func (m *MockStorager) AuditDenial(ctx context.Context, d storage.AuthorizationDenial) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuditDenial", ctx, d)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuditDenial indicates an expected call of AuditDenial.
+func (mr *MockStoragerMockRecorder) AuditDenial(ctx, d interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuditDenial", reflect.TypeOf((*MockStorager)(nil).AuditDenial), ctx, d)
+}
+
+// CancelTransfer mocks base method.
+func (m *MockStorager) CancelTransfer(ctx context.Context, sender, transferID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelTransfer", ctx, sender, transferID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelTransfer indicates an expected call of CancelTransfer.
+func (mr *MockStoragerMockRecorder) CancelTransfer(ctx, sender, transferID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelTransfer", reflect.TypeOf((*MockStorager)(nil).CancelTransfer), ctx, sender, transferID)
+}
+
+// ConfirmDeposit mocks base method.
+func (m *MockStorager) ConfirmDeposit(ctx context.Context, depositID int64, externalID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmDeposit", ctx, depositID, externalID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConfirmDeposit indicates an expected call of ConfirmDeposit.
+func (mr *MockStoragerMockRecorder) ConfirmDeposit(ctx, depositID, externalID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmDeposit", reflect.TypeOf((*MockStorager)(nil).ConfirmDeposit), ctx, depositID, externalID)
+}
+
+// CreateEscrow mocks base method.
+func (m *MockStorager) CreateEscrow(ctx context.Context, payer, beneficiary int64, amount money.Money, description *string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEscrow", ctx, payer, beneficiary, amount, description)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateEscrow indicates an expected call of CreateEscrow.
+func (mr *MockStoragerMockRecorder) CreateEscrow(ctx, payer, beneficiary, amount, description interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEscrow", reflect.TypeOf((*MockStorager)(nil).CreateEscrow), ctx, payer, beneficiary, amount, description)
+}
+
+// CreatePaymentRequest mocks base method.
+func (m *MockStorager) CreatePaymentRequest(ctx context.Context, requester, payer int64, amount money.Money, description *string, expiresAt time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePaymentRequest", ctx, requester, payer, amount, description, expiresAt)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePaymentRequest indicates an expected call of CreatePaymentRequest.
+func (mr *MockStoragerMockRecorder) CreatePaymentRequest(ctx, requester, payer, amount, description, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePaymentRequest", reflect.TypeOf((*MockStorager)(nil).CreatePaymentRequest), ctx, requester, payer, amount, description, expiresAt)
+}
+
+// CreatePendingDeposit mocks base method.
+func (m *MockStorager) CreatePendingDeposit(ctx context.Context, userID int64, amount money.Money, provider string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePendingDeposit", ctx, userID, amount, provider)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePendingDeposit indicates an expected call of CreatePendingDeposit.
+func (mr *MockStoragerMockRecorder) CreatePendingDeposit(ctx, userID, amount, provider interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePendingDeposit", reflect.TypeOf((*MockStorager)(nil).CreatePendingDeposit), ctx, userID, amount, provider)
+}
+
+// DeclinePaymentRequest mocks base method.
+func (m *MockStorager) DeclinePaymentRequest(ctx context.Context, payer, requestID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeclinePaymentRequest", ctx, payer, requestID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeclinePaymentRequest indicates an expected call of DeclinePaymentRequest.
+func (mr *MockStoragerMockRecorder) DeclinePaymentRequest(ctx, payer, requestID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeclinePaymentRequest", reflect.TypeOf((*MockStorager)(nil).DeclinePaymentRequest), ctx, payer, requestID)
+}
+
+// DelayedTransfer mocks base method.
+func (m *MockStorager) DelayedTransfer(ctx context.Context, sender, recipient int64, amount money.Money, description *string, settleAt time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DelayedTransfer", ctx, sender, recipient, amount, description, settleAt)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DelayedTransfer indicates an expected call of DelayedTransfer.
+func (mr *MockStoragerMockRecorder) DelayedTransfer(ctx, sender, recipient, amount, description, settleAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DelayedTransfer", reflect.TypeOf((*MockStorager)(nil).DelayedTransfer), ctx, sender, recipient, amount, description, settleAt)
+}
+
 // Deposit mocks base method.
-func (m *MockStorager) Deposit(arg0 context.Context, arg1 int64, arg2 decimal.Decimal) error {
+func (m *MockStorager) Deposit(arg0 context.Context, arg1 int64, arg2 money.Money) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Deposit", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
@@ -51,6 +267,111 @@ func (mr *MockStoragerMockRecorder) Deposit(arg0, arg1, arg2 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Deposit", reflect.TypeOf((*MockStorager)(nil).Deposit), arg0, arg1, arg2)
 }
 
+// FailDeposit mocks base method.
+func (m *MockStorager) FailDeposit(ctx context.Context, depositID int64, externalID, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailDeposit", ctx, depositID, externalID, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FailDeposit indicates an expected call of FailDeposit.
+func (mr *MockStoragerMockRecorder) FailDeposit(ctx, depositID, externalID, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailDeposit", reflect.TypeOf((*MockStorager)(nil).FailDeposit), ctx, depositID, externalID, reason)
+}
+
+// GenerateVouchers mocks base method.
+func (m *MockStorager) GenerateVouchers(ctx context.Context, count int64, amount money.Money, maxRedemptions int64, expiresAt time.Time) (int64, []string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateVouchers", ctx, count, amount, maxRedemptions, expiresAt)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].([]string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GenerateVouchers indicates an expected call of GenerateVouchers.
+func (mr *MockStoragerMockRecorder) GenerateVouchers(ctx, count, amount, maxRedemptions, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateVouchers", reflect.TypeOf((*MockStorager)(nil).GenerateVouchers), ctx, count, amount, maxRedemptions, expiresAt)
+}
+
+// GrantBonus mocks base method.
+func (m *MockStorager) GrantBonus(ctx context.Context, userID int64, amount money.Money, expiresAt time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GrantBonus", ctx, userID, amount, expiresAt)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GrantBonus indicates an expected call of GrantBonus.
+func (mr *MockStoragerMockRecorder) GrantBonus(ctx, userID, amount, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GrantBonus", reflect.TypeOf((*MockStorager)(nil).GrantBonus), ctx, userID, amount, expiresAt)
+}
+
+// ImportStatement mocks base method.
+func (m *MockStorager) ImportStatement(ctx context.Context, name string, lines []reconcile.Line, window time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportStatement", ctx, name, lines, window)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportStatement indicates an expected call of ImportStatement.
+func (mr *MockStoragerMockRecorder) ImportStatement(ctx, name, lines, window interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportStatement", reflect.TypeOf((*MockStorager)(nil).ImportStatement), ctx, name, lines, window)
+}
+
+// ListFraudFlags mocks base method.
+func (m *MockStorager) ListFraudFlags(ctx context.Context, limit, offset int64) ([]storage.FraudFlag, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFraudFlags", ctx, limit, offset)
+	ret0, _ := ret[0].([]storage.FraudFlag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFraudFlags indicates an expected call of ListFraudFlags.
+func (mr *MockStoragerMockRecorder) ListFraudFlags(ctx, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFraudFlags", reflect.TypeOf((*MockStorager)(nil).ListFraudFlags), ctx, limit, offset)
+}
+
+// ListPaymentRequests mocks base method.
+func (m *MockStorager) ListPaymentRequests(ctx context.Context, userID int64, direction storage.PaymentRequestDirection, limit, offset int64) ([]storage.PaymentRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPaymentRequests", ctx, userID, direction, limit, offset)
+	ret0, _ := ret[0].([]storage.PaymentRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPaymentRequests indicates an expected call of ListPaymentRequests.
+func (mr *MockStoragerMockRecorder) ListPaymentRequests(ctx, userID, direction, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPaymentRequests", reflect.TypeOf((*MockStorager)(nil).ListPaymentRequests), ctx, userID, direction, limit, offset)
+}
+
+// ListWithdrawalRequests mocks base method.
+func (m *MockStorager) ListWithdrawalRequests(ctx context.Context, status storage.WithdrawalStatus, limit, offset int64) ([]storage.WithdrawalRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWithdrawalRequests", ctx, status, limit, offset)
+	ret0, _ := ret[0].([]storage.WithdrawalRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWithdrawalRequests indicates an expected call of ListWithdrawalRequests.
+func (mr *MockStoragerMockRecorder) ListWithdrawalRequests(ctx, status, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWithdrawalRequests", reflect.TypeOf((*MockStorager)(nil).ListWithdrawalRequests), ctx, status, limit, offset)
+}
+
 // MonthlyReport mocks base method.
 func (m *MockStorager) MonthlyReport(ctx context.Context, year, month int64) ([][]string, error) {
 	m.ctrl.T.Helper()
@@ -66,6 +387,111 @@ func (mr *MockStoragerMockRecorder) MonthlyReport(ctx, year, month interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MonthlyReport", reflect.TypeOf((*MockStorager)(nil).MonthlyReport), ctx, year, month)
 }
 
+// QuoteReservation mocks base method.
+func (m *MockStorager) QuoteReservation(ctx context.Context, UserId, ServiceId, OrderId int64, Price money.Money, description *string) (storage.Quote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QuoteReservation", ctx, UserId, ServiceId, OrderId, Price, description)
+	ret0, _ := ret[0].(storage.Quote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QuoteReservation indicates an expected call of QuoteReservation.
+func (mr *MockStoragerMockRecorder) QuoteReservation(ctx, UserId, ServiceId, OrderId, Price, description interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QuoteReservation", reflect.TypeOf((*MockStorager)(nil).QuoteReservation), ctx, UserId, ServiceId, OrderId, Price, description)
+}
+
+// QuoteTransfer mocks base method.
+func (m *MockStorager) QuoteTransfer(ctx context.Context, sender, recipient int64, amount money.Money, description *string) (storage.Quote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QuoteTransfer", ctx, sender, recipient, amount, description)
+	ret0, _ := ret[0].(storage.Quote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QuoteTransfer indicates an expected call of QuoteTransfer.
+func (mr *MockStoragerMockRecorder) QuoteTransfer(ctx, sender, recipient, amount, description interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QuoteTransfer", reflect.TypeOf((*MockStorager)(nil).QuoteTransfer), ctx, sender, recipient, amount, description)
+}
+
+// QuoteWithdrawal mocks base method.
+func (m *MockStorager) QuoteWithdrawal(ctx context.Context, userID int64, amount money.Money, description *string) (storage.Quote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QuoteWithdrawal", ctx, userID, amount, description)
+	ret0, _ := ret[0].(storage.Quote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QuoteWithdrawal indicates an expected call of QuoteWithdrawal.
+func (mr *MockStoragerMockRecorder) QuoteWithdrawal(ctx, userID, amount, description interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QuoteWithdrawal", reflect.TypeOf((*MockStorager)(nil).QuoteWithdrawal), ctx, userID, amount, description)
+}
+
+// ReadAPIKey mocks base method.
+func (m *MockStorager) ReadAPIKey(ctx context.Context, prefix string) (storage.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadAPIKey", ctx, prefix)
+	ret0, _ := ret[0].(storage.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadAPIKey indicates an expected call of ReadAPIKey.
+func (mr *MockStoragerMockRecorder) ReadAPIKey(ctx, prefix interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadAPIKey", reflect.TypeOf((*MockStorager)(nil).ReadAPIKey), ctx, prefix)
+}
+
+// ReadBalanceBuckets mocks base method.
+func (m *MockStorager) ReadBalanceBuckets(ctx context.Context, userID int64) (storage.BalanceBuckets, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadBalanceBuckets", ctx, userID)
+	ret0, _ := ret[0].(storage.BalanceBuckets)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadBalanceBuckets indicates an expected call of ReadBalanceBuckets.
+func (mr *MockStoragerMockRecorder) ReadBalanceBuckets(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadBalanceBuckets", reflect.TypeOf((*MockStorager)(nil).ReadBalanceBuckets), ctx, userID)
+}
+
+// ReadEscrow mocks base method.
+func (m *MockStorager) ReadEscrow(ctx context.Context, escrowID int64) (storage.Escrow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadEscrow", ctx, escrowID)
+	ret0, _ := ret[0].(storage.Escrow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadEscrow indicates an expected call of ReadEscrow.
+func (mr *MockStoragerMockRecorder) ReadEscrow(ctx, escrowID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadEscrow", reflect.TypeOf((*MockStorager)(nil).ReadEscrow), ctx, escrowID)
+}
+
+// ReadReconciliation mocks base method.
+func (m *MockStorager) ReadReconciliation(ctx context.Context, statementID int64) (storage.Reconciliation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadReconciliation", ctx, statementID)
+	ret0, _ := ret[0].(storage.Reconciliation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadReconciliation indicates an expected call of ReadReconciliation.
+func (mr *MockStoragerMockRecorder) ReadReconciliation(ctx, statementID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadReconciliation", reflect.TypeOf((*MockStorager)(nil).ReadReconciliation), ctx, statementID)
+}
+
 // ReadUserByID mocks base method.
 func (m *MockStorager) ReadUserByID(arg0 context.Context, arg1 int64) (storage.User, error) {
 	m.ctrl.T.Helper()
@@ -96,22 +522,142 @@ func (mr *MockStoragerMockRecorder) ReadUserHistoryList(ctx, user_id, order, lim
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadUserHistoryList", reflect.TypeOf((*MockStorager)(nil).ReadUserHistoryList), ctx, user_id, order, limit, offset)
 }
 
+// ReadUsersByIDs mocks base method.
+func (m *MockStorager) ReadUsersByIDs(ctx context.Context, userIDs []int64) ([]storage.UserResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadUsersByIDs", ctx, userIDs)
+	ret0, _ := ret[0].([]storage.UserResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadUsersByIDs indicates an expected call of ReadUsersByIDs.
+func (mr *MockStoragerMockRecorder) ReadUsersByIDs(ctx, userIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadUsersByIDs", reflect.TypeOf((*MockStorager)(nil).ReadUsersByIDs), ctx, userIDs)
+}
+
+// RedeemVoucher mocks base method.
+func (m *MockStorager) RedeemVoucher(ctx context.Context, userID int64, code string) (money.Money, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedeemVoucher", ctx, userID, code)
+	ret0, _ := ret[0].(money.Money)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RedeemVoucher indicates an expected call of RedeemVoucher.
+func (mr *MockStoragerMockRecorder) RedeemVoucher(ctx, userID, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedeemVoucher", reflect.TypeOf((*MockStorager)(nil).RedeemVoucher), ctx, userID, code)
+}
+
+// RefundEscrow mocks base method.
+func (m *MockStorager) RefundEscrow(ctx context.Context, escrowID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefundEscrow", ctx, escrowID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RefundEscrow indicates an expected call of RefundEscrow.
+func (mr *MockStoragerMockRecorder) RefundEscrow(ctx, escrowID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefundEscrow", reflect.TypeOf((*MockStorager)(nil).RefundEscrow), ctx, escrowID)
+}
+
+// RejectWithdrawal mocks base method.
+func (m *MockStorager) RejectWithdrawal(ctx context.Context, requestID int64, operator, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RejectWithdrawal", ctx, requestID, operator, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RejectWithdrawal indicates an expected call of RejectWithdrawal.
+func (mr *MockStoragerMockRecorder) RejectWithdrawal(ctx, requestID, operator, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectWithdrawal", reflect.TypeOf((*MockStorager)(nil).RejectWithdrawal), ctx, requestID, operator, reason)
+}
+
+// ReleaseEscrow mocks base method.
+func (m *MockStorager) ReleaseEscrow(ctx context.Context, escrowID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseEscrow", ctx, escrowID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseEscrow indicates an expected call of ReleaseEscrow.
+func (mr *MockStoragerMockRecorder) ReleaseEscrow(ctx, escrowID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseEscrow", reflect.TypeOf((*MockStorager)(nil).ReleaseEscrow), ctx, escrowID)
+}
+
+// RequestWithdrawal mocks base method.
+func (m *MockStorager) RequestWithdrawal(ctx context.Context, userID int64, amount money.Money, description *string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestWithdrawal", ctx, userID, amount, description)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequestWithdrawal indicates an expected call of RequestWithdrawal.
+func (mr *MockStoragerMockRecorder) RequestWithdrawal(ctx, userID, amount, description interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestWithdrawal", reflect.TypeOf((*MockStorager)(nil).RequestWithdrawal), ctx, userID, amount, description)
+}
+
 // Reservation mocks base method.
-func (m *MockStorager) Reservation(ctx context.Context, UserId, ServiceId, OrderId int64, Price decimal.Decimal, description *string) error {
+func (m *MockStorager) Reservation(ctx context.Context, UserId, ServiceId, OrderId int64, Price money.Money, description *string, options ...storage.TxOption) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Reservation", ctx, UserId, ServiceId, OrderId, Price, description)
+	varargs := []interface{}{ctx, UserId, ServiceId, OrderId, Price, description}
+	for _, a := range options {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Reservation", varargs...)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Reservation indicates an expected call of Reservation.
-func (mr *MockStoragerMockRecorder) Reservation(ctx, UserId, ServiceId, OrderId, Price, description interface{}) *gomock.Call {
+func (mr *MockStoragerMockRecorder) Reservation(ctx, UserId, ServiceId, OrderId, Price, description interface{}, options ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, UserId, ServiceId, OrderId, Price, description}, options...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reservation", reflect.TypeOf((*MockStorager)(nil).Reservation), varargs...)
+}
+
+// ResolveLedgerPosting mocks base method.
+func (m *MockStorager) ResolveLedgerPosting(ctx context.Context, postingID int64, operator, note string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveLedgerPosting", ctx, postingID, operator, note)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResolveLedgerPosting indicates an expected call of ResolveLedgerPosting.
+func (mr *MockStoragerMockRecorder) ResolveLedgerPosting(ctx, postingID, operator, note interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reservation", reflect.TypeOf((*MockStorager)(nil).Reservation), ctx, UserId, ServiceId, OrderId, Price, description)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveLedgerPosting", reflect.TypeOf((*MockStorager)(nil).ResolveLedgerPosting), ctx, postingID, operator, note)
+}
+
+// ResolveStatementLine mocks base method.
+func (m *MockStorager) ResolveStatementLine(ctx context.Context, lineID int64, postingID *int64, operator, note string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveStatementLine", ctx, lineID, postingID, operator, note)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResolveStatementLine indicates an expected call of ResolveStatementLine.
+func (mr *MockStoragerMockRecorder) ResolveStatementLine(ctx, lineID, postingID, operator, note interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveStatementLine", reflect.TypeOf((*MockStorager)(nil).ResolveStatementLine), ctx, lineID, postingID, operator, note)
 }
 
 // Revenue mocks base method.
-func (m *MockStorager) Revenue(ctx context.Context, UserId, ServiceId, OrderId int64, Sum decimal.Decimal, description *string) error {
+func (m *MockStorager) Revenue(ctx context.Context, UserId, ServiceId, OrderId int64, Sum money.Money, description *string) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Revenue", ctx, UserId, ServiceId, OrderId, Sum, description)
 	ret0, _ := ret[0].(error)
@@ -124,8 +670,22 @@ func (mr *MockStoragerMockRecorder) Revenue(ctx, UserId, ServiceId, OrderId, Sum
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revenue", reflect.TypeOf((*MockStorager)(nil).Revenue), ctx, UserId, ServiceId, OrderId, Sum, description)
 }
 
+// SplitEscrow mocks base method.
+func (m *MockStorager) SplitEscrow(ctx context.Context, escrowID int64, beneficiaryShare money.Money) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SplitEscrow", ctx, escrowID, beneficiaryShare)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SplitEscrow indicates an expected call of SplitEscrow.
+func (mr *MockStoragerMockRecorder) SplitEscrow(ctx, escrowID, beneficiaryShare interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SplitEscrow", reflect.TypeOf((*MockStorager)(nil).SplitEscrow), ctx, escrowID, beneficiaryShare)
+}
+
 // Transfer mocks base method.
-func (m *MockStorager) Transfer(ctx context.Context, user_id1, user_id2 int64, amount decimal.Decimal, description *string, options ...storage.TxOption) (int64, int64, error) {
+func (m *MockStorager) Transfer(ctx context.Context, user_id1, user_id2 int64, amount money.Money, description *string, options ...storage.TxOption) (int64, int64, error) {
 	m.ctrl.T.Helper()
 	varargs := []interface{}{ctx, user_id1, user_id2, amount, description}
 	for _, a := range options {
@@ -160,17 +720,22 @@ func (mr *MockStoragerMockRecorder) Unreservation(ctx, UserId, ServiceId, OrderI
 }
 
 // Withdrawal mocks base method.
-func (m *MockStorager) Withdrawal(arg0 context.Context, arg1 int64, arg2 decimal.Decimal, arg3 *string) error {
+func (m *MockStorager) Withdrawal(ctx context.Context, userID int64, amount money.Money, description *string, options ...storage.TxOption) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Withdrawal", arg0, arg1, arg2, arg3)
+	varargs := []interface{}{ctx, userID, amount, description}
+	for _, a := range options {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Withdrawal", varargs...)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Withdrawal indicates an expected call of Withdrawal.
-func (mr *MockStoragerMockRecorder) Withdrawal(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+func (mr *MockStoragerMockRecorder) Withdrawal(ctx, userID, amount, description interface{}, options ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Withdrawal", reflect.TypeOf((*MockStorager)(nil).Withdrawal), arg0, arg1, arg2, arg3)
+	varargs := append([]interface{}{ctx, userID, amount, description}, options...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Withdrawal", reflect.TypeOf((*MockStorager)(nil).Withdrawal), varargs...)
 }
 
 // MockExchanger is a mock of Exchanger interface.
@@ -197,16 +762,83 @@ func (m *MockExchanger) EXPECT() *MockExchangerMockRecorder {
 }
 
 // ExchangeRates mocks base method.
-func (m *MockExchanger) ExchangeRates(logger *zap.Logger, value decimal.Decimal, currency string) (decimal.Decimal, error) {
+func (m *MockExchanger) ExchangeRates(ctx context.Context, logger *zap.Logger, value decimal.Decimal, currency string) (decimal.Decimal, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ExchangeRates", logger, value, currency)
+	ret := m.ctrl.Call(m, "ExchangeRates", ctx, logger, value, currency)
 	ret0, _ := ret[0].(decimal.Decimal)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ExchangeRates indicates an expected call of ExchangeRates.
-func (mr *MockExchangerMockRecorder) ExchangeRates(logger, value, currency interface{}) *gomock.Call {
+func (mr *MockExchangerMockRecorder) ExchangeRates(ctx, logger, value, currency interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExchangeRates", reflect.TypeOf((*MockExchanger)(nil).ExchangeRates), ctx, logger, value, currency)
+}
+
+// MockPaymentProvider is a mock of PaymentProvider interface.
+type MockPaymentProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockPaymentProviderMockRecorder
+}
+
+// MockPaymentProviderMockRecorder is the mock recorder for MockPaymentProvider.
+type MockPaymentProviderMockRecorder struct {
+	mock *MockPaymentProvider
+}
+
+// NewMockPaymentProvider creates a new mock instance.
+func NewMockPaymentProvider(ctrl *gomock.Controller) *MockPaymentProvider {
+	mock := &MockPaymentProvider{ctrl: ctrl}
+	mock.recorder = &MockPaymentProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPaymentProvider) EXPECT() *MockPaymentProviderMockRecorder {
+	return m.recorder
+}
+
+// CreatePayment mocks base method.
+func (m *MockPaymentProvider) CreatePayment(ctx context.Context, depositID int64, amount money.Money) (payment.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePayment", ctx, depositID, amount)
+	ret0, _ := ret[0].(payment.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePayment indicates an expected call of CreatePayment.
+func (mr *MockPaymentProviderMockRecorder) CreatePayment(ctx, depositID, amount interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePayment", reflect.TypeOf((*MockPaymentProvider)(nil).CreatePayment), ctx, depositID, amount)
+}
+
+// Name mocks base method.
+func (m *MockPaymentProvider) Name() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Name indicates an expected call of Name.
+func (mr *MockPaymentProviderMockRecorder) Name() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockPaymentProvider)(nil).Name))
+}
+
+// ParseCallback mocks base method.
+func (m *MockPaymentProvider) ParseCallback(r *http.Request) (payment.Callback, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParseCallback", r)
+	ret0, _ := ret[0].(payment.Callback)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ParseCallback indicates an expected call of ParseCallback.
+func (mr *MockPaymentProviderMockRecorder) ParseCallback(r interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExchangeRates", reflect.TypeOf((*MockExchanger)(nil).ExchangeRates), logger, value, currency)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseCallback", reflect.TypeOf((*MockPaymentProvider)(nil).ParseCallback), r)
 }
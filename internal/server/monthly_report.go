@@ -3,10 +3,9 @@ package server
 import (
 	"encoding/csv"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"http-avito-test/internal/generated"
-	"http-avito-test/internal/storage"
+	"http-avito-test/internal/i18n"
 	"io/ioutil"
 	"net/http"
 	"os"
@@ -16,6 +15,9 @@ import (
 
 const reportLink = "http://localhost:4000"
 
+// DefaultReportDir keeps the monthly reports when the directory is not configured
+const DefaultReportDir = "../../file_storage"
+
 func (h *Handler) MonthlyReport(w http.ResponseWriter, r *http.Request) {
 	var hand *generated.MonthlyReportRequest
 
@@ -23,35 +25,50 @@ func (h *Handler) MonthlyReport(w http.ResponseWriter, r *http.Request) {
 
 	err := json.Unmarshal(body, &hand)
 	if err != nil {
-		http.Error(w, "malformed request body", http.StatusBadRequest)
+		h.malformedBody(w, r)
 		return
 	}
 
 	report, err := h.Store.MonthlyReport(r.Context(), int64(hand.Year), int64(hand.Month))
 	if err != nil {
-		if errors.Is(err, storage.ErrNoRecords) {
-			http.Error(w, "records do not exists", http.StatusBadRequest)
-			return
-		}
-		http.Error(w, "error reading monthly report", http.StatusInternalServerError)
+		h.writeStorageError(w, r, err, "error reading monthly report")
 		return
 	}
 
-	fileFormat := fmt.Sprintf(`../../file_storage/consolidated_report%d-%d.csv`, hand.Year, hand.Month)
+	lang := i18n.FromAcceptLanguage(r.Header.Get("Accept-Language"))
+	if len(report) > 0 {
+		report[0] = reportHeader(lang, report[0])
+	}
+
+	reportDir := DefaultReportDir
+	if h.ReportDir != "" {
+		reportDir = h.ReportDir
+	}
+
+	// every language has its own file, the report is written to a temporary file and renamed,
+	// so the concurrent requests never see or write a partial report
+	fileFormat := fmt.Sprintf(`%s/consolidated_report%d-%d.%s.csv`, reportDir, hand.Year, hand.Month, lang)
 
-	file, err := os.Create(fileFormat)
+	file, err := ioutil.TempFile(reportDir, "consolidated_report*.csv.tmp")
 	if err != nil {
-		h.Logger.Error("openinп CSV file error", zap.Error(err))
-		http.Error(w, "failed to open CSV file", http.StatusInternalServerError)
+		h.logger(r).Error("openinп CSV file error", zap.Error(err))
+		h.internalError(w, r, "failed to open CSV file")
 		return
 	}
+	defer os.Remove(file.Name())
 	defer file.Close()
 
 	write := csv.NewWriter(file)
 	err = write.WriteAll(report)
+	if err == nil {
+		err = file.Close()
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), fileFormat)
+	}
 	if err != nil {
-		h.Logger.Error("writing to CSV file error", zap.Error(err))
-		http.Error(w, "cannot write to CSV file", http.StatusInternalServerError)
+		h.logger(r).Error("writing to CSV file error", zap.Error(err))
+		h.internalError(w, r, "cannot write to CSV file")
 		return
 	}
 
@@ -66,14 +83,26 @@ func (h *Handler) MonthlyReport(w http.ResponseWriter, r *http.Request) {
 
 	marshalledRequest, err := json.Marshal(result)
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 	_, writeErr := w.Write(marshalledRequest)
 	if err != nil {
-		h.Logger.Error("failed to write connection", zap.Error(writeErr))
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
 		return
 	}
 }
+
+// reportHeader translates the column names of the report header, the unknown columns are kept as they are
+func reportHeader(lang i18n.Lang, header []string) []string {
+	translated := make([]string, 0, len(header))
+	for _, column := range header {
+		if name, ok := i18n.Message(lang, "report."+column, nil); ok {
+			column = name
+		}
+		translated = append(translated, column)
+	}
+	return translated
+}
@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"encoding/json"
 	"http-avito-test/internal/generated"
+	"http-avito-test/internal/i18n"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -34,8 +36,11 @@ func TestMonthlyReport(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/report", arg)
 		w := httptest.NewRecorder()
 
+		dir := t.TempDir()
+
 		h := Handler{
-			Store: m,
+			Store:     m,
+			ReportDir: dir,
 		}
 
 		h.MonthlyReport(w, req)
@@ -48,6 +53,7 @@ func TestMonthlyReport(t *testing.T) {
 		assert.NoError(t, err)
 
 		assert.Equal(t, string(js), string(body))
+		assert.FileExists(t, filepath.Join(dir, "consolidated_report2022-10.en.csv"))
 	})
 
 	t.Run("malformed request body", func(t *testing.T) {
@@ -68,7 +74,15 @@ func TestMonthlyReport(t *testing.T) {
 		body, err := ioutil.ReadAll(w.Body)
 		assert.NoError(t, err)
 
-		assert.Equal(t, "malformed request body\n", string(body))
+		assert.Equal(t, "malformed request body", errorMessage(t, body))
 	})
 
 }
+
+func TestReportHeader(t *testing.T) {
+	header := []string{"service_id", "total_revenue"}
+
+	assert.Equal(t, []string{"service_id", "total_revenue"}, reportHeader(i18n.English, header))
+	assert.Equal(t, []string{"id_услуги", "общая_выручка"}, reportHeader(i18n.Russian, header))
+	assert.Equal(t, []string{"unknown"}, reportHeader(i18n.Russian, []string{"unknown"}))
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"encoding/json"
+	"http-avito-test/internal/generated"
+	"http-avito-test/internal/storage"
+	"net/http"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+func isDryRun(dryRun *bool) bool {
+	return dryRun != nil && *dryRun
+}
+
+// writeQuote writes the projected outcome of a dry-run operation
+func (h *Handler) writeQuote(w http.ResponseWriter, r *http.Request, quote storage.Quote) {
+	result := generated.QuoteResponse{
+		Result: struct {
+			Balance decimal.Decimal "json:\"balance\""
+			Fee     decimal.Decimal "json:\"fee\""
+			UserId  int64           "json:\"user_id\""
+		}{
+			Balance: quote.Balance.Major(),
+			Fee:     quote.Fee.Major(),
+			UserId:  quote.AccountID,
+		},
+		Status: "ok",
+	}
+
+	marshalledRequest, err := json.Marshal(result)
+	if err != nil {
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, writeErr := w.Write(marshalledRequest)
+	if writeErr != nil {
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
+		return
+	}
+}
@@ -0,0 +1,360 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Limit is the token bucket of Burst tokens refilled at Rate tokens per second, every request takes a token
+type Limit struct {
+	Rate  float64
+	Burst int64
+}
+
+// UnmarshalText reads the limit like "50/1s:100" from the environment variables, the empty value disables the limit
+func (l *Limit) UnmarshalText(b []byte) error {
+	s := strings.TrimSpace(string(b))
+	if s == "" {
+		*l = Limit{}
+		return nil
+	}
+
+	limit, err := parseLimit(s)
+	if err != nil {
+		return fmt.Errorf("malformed rate limit %q: %w", s, err)
+	}
+	*l = limit
+	return nil
+}
+
+// defaultRateLimit is the key of the limit of the operations without their own one
+const defaultRateLimit = "default"
+
+// RateLimits are the limits by the operation path without the leading slash like "accountdeposit".
+// They are read from the list like "default=100/1s:200,accountdeposit=5/1m", where 5/1m is 5 requests
+// a minute and the optional :200 is the burst, the burst equals the number of requests when it is omitted
+type RateLimits map[string]Limit
+
+// UnmarshalText reads the limits from the environment variables
+func (l *RateLimits) UnmarshalText(b []byte) error {
+	limits := RateLimits{}
+	for _, entry := range strings.Split(string(b), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		i := strings.IndexByte(entry, '=')
+		if i <= 0 {
+			return fmt.Errorf("malformed rate limit %q", entry)
+		}
+		limit, err := parseLimit(entry[i+1:])
+		if err != nil {
+			return fmt.Errorf("malformed rate limit %q: %w", entry, err)
+		}
+		limits[strings.TrimPrefix(entry[:i], "/")] = limit
+	}
+	*l = limits
+	return nil
+}
+
+func parseLimit(s string) (Limit, error) {
+	var burst string
+	if i := strings.IndexByte(s, ':'); i >= 0 {
+		s, burst = s[:i], s[i+1:]
+	}
+
+	i := strings.IndexByte(s, '/')
+	if i <= 0 {
+		return Limit{}, fmt.Errorf("no period")
+	}
+	count, err := strconv.ParseInt(s[:i], 10, 64)
+	if err != nil || count <= 0 {
+		return Limit{}, fmt.Errorf("wrong number of requests %q", s[:i])
+	}
+	period, err := time.ParseDuration(s[i+1:])
+	if err != nil || period <= 0 {
+		return Limit{}, fmt.Errorf("wrong period %q", s[i+1:])
+	}
+
+	limit := Limit{Rate: float64(count) / period.Seconds(), Burst: count}
+	if burst != "" {
+		limit.Burst, err = strconv.ParseInt(burst, 10, 64)
+		if err != nil || limit.Burst <= 0 {
+			return Limit{}, fmt.Errorf("wrong burst %q", burst)
+		}
+	}
+	return limit, nil
+}
+
+// limit returns the limit of the operation, false means the operation is not limited
+func (l RateLimits) limit(path string) (Limit, bool) {
+	if limit, ok := l[strings.TrimPrefix(path, "/")]; ok {
+		return limit, true
+	}
+	limit, ok := l[defaultRateLimit]
+	return limit, ok
+}
+
+// MemoryLimiter keeps the token buckets of a single server instance
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	takes   int
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	// full is the time the bucket is refilled, the full buckets are swept
+	full time.Time
+}
+
+// sweepEvery is the number of the taken tokens between the sweeps of the full buckets
+const sweepEvery = 1000
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+func (m *MemoryLimiter) TakeToken(_ context.Context, key string, rate float64, burst int64) (bool, float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+
+	m.takes++
+	if m.takes >= sweepEvery {
+		m.takes = 0
+		for k, b := range m.buckets {
+			if !now.Before(b.full) {
+				delete(m.buckets, k)
+			}
+		}
+	}
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(burst), updated: now}
+		m.buckets[key] = b
+	}
+
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(burst), b.tokens+elapsed*rate)
+		b.updated = now
+	}
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	b.full = now.Add(time.Duration((float64(burst) - b.tokens) / rate * float64(time.Second)))
+	return allowed, b.tokens, nil
+}
+
+// ReturnToken gives the token back to the bucket of the key, the bucket is not filled above the burst
+func (m *MemoryLimiter) ReturnToken(_ context.Context, key string, burst int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if b, ok := m.buckets[key]; ok {
+		b.tokens = math.Min(float64(burst), b.tokens+1)
+	}
+	return nil
+}
+
+// accountFields are the request fields of the account the operation is rate limited by
+var accountFields = map[string]string{
+	"/readuser":                "user_id",
+	"/readuserhistory":         "user_id",
+	"/readbalancebuckets":      "user_id",
+	"/accountdeposit":          "user_id",
+	"/accountwithdrawal":       "user_id",
+	"/transfercommand":         "sender",
+	"/createpaymentrequest":    "requester",
+	"/incomingpaymentrequests": "user_id",
+	"/outgoingpaymentrequests": "user_id",
+	"/acceptpaymentrequest":    "payer",
+	"/declinepaymentrequest":   "payer",
+	"/createescrow":            "payer",
+	"/redeemvoucher":           "user_id",
+	"/grantbonus":              "user_id",
+	"/reservationoffunds":      "user_id",
+	"/revenuerecognition":      "user_id",
+	"/unreservationoffunds":    "user_id",
+}
+
+type limitedBucket struct {
+	key   string
+	limit Limit
+}
+
+// limitDecision is the outcome of the bucket that is the closest to its limit
+type limitDecision struct {
+	allowed    bool
+	limit      Limit
+	remaining  float64
+	retryAfter time.Duration
+}
+
+// rateLimited takes a token of the client bucket and of the account bucket of the operation before the handler
+// gets the request. The rejected request gets 429 with Retry-After, all responses carry the X-RateLimit headers
+// of the bucket that is the closest to its limit. The limiter errors let the requests through.
+// The body is read after the validation, so its size is limited already
+func (h *Handler) rateLimited(path string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.Limiter == nil {
+			next(w, r)
+			return
+		}
+
+		var buckets []limitedBucket
+		if limit, ok := h.ClientLimits.limit(path); ok {
+			buckets = append(buckets, limitedBucket{"client:" + clientKey(r) + ":" + path, limit})
+		}
+		if limit, ok := h.AccountLimits.limit(path); ok {
+			if account, ok := requestAccount(r, accountFields[path]); ok {
+				buckets = append(buckets, limitedBucket{"account:" + strconv.FormatInt(account, 10) + ":" + path, limit})
+			}
+		}
+
+		h.takeTokens(w, r, buckets, next)
+	}
+}
+
+// addressLimited takes a token of the bucket of the remote address before the request is authenticated,
+// so the requests with wrong API keys are limited too and the keys cannot be guessed at the cost of a key lookup each.
+// The bucket is shared by all operations
+func (h *Handler) addressLimited(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.Limiter == nil || h.AddressLimit.Rate <= 0 {
+			next(w, r)
+			return
+		}
+
+		h.takeTokens(w, r, []limitedBucket{{"address:" + remoteHost(r), h.AddressLimit}}, next)
+	}
+}
+
+// takeTokens takes a token of every bucket and calls next unless a bucket is exhausted. The tokens taken
+// from the other buckets of the rejected request are given back, so the request is counted by all its buckets or none
+func (h *Handler) takeTokens(w http.ResponseWriter, r *http.Request, buckets []limitedBucket, next http.HandlerFunc) {
+	var decision *limitDecision
+	var taken []limitedBucket
+	for _, b := range buckets {
+		allowed, tokens, err := h.Limiter.TakeToken(r.Context(), b.key, b.limit.Rate, b.limit.Burst)
+		if err != nil {
+			h.logger(r).Error("failed to take rate limit token", zap.String("key", b.key), zap.Error(err))
+			continue
+		}
+
+		d := limitDecision{allowed: allowed, limit: b.limit, remaining: tokens}
+		if !allowed {
+			d.retryAfter = time.Duration((1 - tokens) / b.limit.Rate * float64(time.Second))
+		}
+		if decision == nil || !d.allowed || (decision.allowed && d.remaining < decision.remaining) {
+			decision = &d
+		}
+		if !allowed {
+			h.returnTokens(r, taken)
+			break
+		}
+		taken = append(taken, b)
+	}
+
+	if decision == nil {
+		next(w, r)
+		return
+	}
+
+	reset := (float64(decision.limit.Burst) - decision.remaining) / decision.limit.Rate
+	w.Header().Set("X-RateLimit-Limit", strconv.FormatInt(decision.limit.Burst, 10))
+	w.Header().Set("X-RateLimit-Remaining", strconv.FormatInt(int64(decision.remaining), 10))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(int64(math.Ceil(reset)), 10))
+
+	if !decision.allowed {
+		retryAfter := strconv.FormatInt(int64(math.Ceil(decision.retryAfter.Seconds())), 10)
+		w.Header().Set("Retry-After", retryAfter)
+		h.writeError(w, r, apiError{
+			status:  http.StatusTooManyRequests,
+			code:    CodeRateLimited,
+			message: "too many requests, retry in " + retryAfter + " seconds",
+			args:    map[string]string{"retry_after": retryAfter},
+		})
+		return
+	}
+
+	next(w, r)
+}
+
+// returnTokens gives the taken tokens back to their buckets, the limiter errors are only logged
+func (h *Handler) returnTokens(r *http.Request, buckets []limitedBucket) {
+	for _, b := range buckets {
+		if err := h.Limiter.ReturnToken(r.Context(), b.key, b.limit.Burst); err != nil {
+			h.logger(r).Error("failed to return rate limit token", zap.String("key", b.key), zap.Error(err))
+		}
+	}
+}
+
+// clientKey is the client of the API key, or the remote address when the keys are not required
+func clientKey(r *http.Request) string {
+	if key, ok := APIKeyFromContext(r.Context()); ok {
+		return key.Client
+	}
+	return remoteHost(r)
+}
+
+// remoteHost is the address of the remote peer without the port
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// requestAccount reads the account of the operation from the request body and restores the body for the handler
+func requestAccount(r *http.Request, field string) (int64, bool) {
+	if field == "" || r.Body == nil {
+		return 0, false
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return 0, false
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return 0, false
+	}
+
+	// the field names are matched in any case like the JSON decoding does
+	for name, value := range fields {
+		if strings.EqualFold(name, field) {
+			var account int64
+			if err := json.Unmarshal(value, &account); err != nil {
+				return 0, false
+			}
+			return account, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,232 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"http-avito-test/internal/auth"
+	"http-avito-test/internal/money"
+	"http-avito-test/internal/storage"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRateLimits(t *testing.T) {
+	var limits RateLimits
+	require.NoError(t, limits.UnmarshalText([]byte("default=100/1s:200, /accountdeposit=5/1m")))
+
+	assert.Equal(t, RateLimits{
+		"default":        {Rate: 100, Burst: 200},
+		"accountdeposit": {Rate: 5.0 / 60, Burst: 5},
+	}, limits)
+
+	limit, ok := limits.limit("/accountdeposit")
+	assert.True(t, ok)
+	assert.Equal(t, int64(5), limit.Burst)
+
+	limit, ok = limits.limit("/readuser")
+	assert.True(t, ok)
+	assert.Equal(t, int64(200), limit.Burst)
+
+	_, ok = RateLimits{}.limit("/readuser")
+	assert.False(t, ok)
+
+	for _, s := range []string{"accountdeposit", "accountdeposit=5", "accountdeposit=0/1s", "accountdeposit=5/1x", "accountdeposit=5/1s:0"} {
+		assert.Error(t, limits.UnmarshalText([]byte(s)), s)
+	}
+}
+
+func TestLimit(t *testing.T) {
+	var limit Limit
+	require.NoError(t, limit.UnmarshalText([]byte("50/1s:100")))
+	assert.Equal(t, Limit{Rate: 50, Burst: 100}, limit)
+
+	require.NoError(t, limit.UnmarshalText([]byte("")))
+	assert.Equal(t, Limit{}, limit)
+
+	assert.Error(t, limit.UnmarshalText([]byte("50")))
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	now := time.Date(2022, 12, 1, 12, 0, 0, 0, time.UTC)
+
+	l := NewMemoryLimiter()
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		allowed, tokens, err := l.TakeToken(context.Background(), "key", 1, 2)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Equal(t, float64(1-i), tokens)
+	}
+
+	allowed, _, err := l.TakeToken(context.Background(), "key", 1, 2)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	// the other keys have their own buckets
+	allowed, _, err = l.TakeToken(context.Background(), "other", 1, 2)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	now = now.Add(1500 * time.Millisecond)
+
+	allowed, tokens, err := l.TakeToken(context.Background(), "key", 1, 2)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 0.5, tokens)
+
+	// the bucket is not refilled above the burst
+	now = now.Add(time.Hour)
+
+	_, tokens, err = l.TakeToken(context.Background(), "key", 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), tokens)
+
+	// the returned token is taken again, the bucket is not filled above the burst
+	require.NoError(t, l.ReturnToken(context.Background(), "key", 2))
+	require.NoError(t, l.ReturnToken(context.Background(), "key", 2))
+
+	_, tokens, err = l.TakeToken(context.Background(), "key", 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), tokens)
+}
+
+func TestRateLimited(t *testing.T) {
+	deposit := func(h *Handler, userID string) *httptest.ResponseRecorder {
+		arg := bytes.NewBuffer([]byte(`{"User_id":` + userID + `, "Amount":"100.00"}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/api/1/accountdeposit", arg)
+		w := httptest.NewRecorder()
+
+		h.Router(false).ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("client limit", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().Deposit(gomock.Any(), gomock.Any(), money.New(10000, money.RUB)).Return(nil).Times(2)
+
+		h := Handler{
+			Store:        m,
+			Limiter:      NewMemoryLimiter(),
+			ClientLimits: RateLimits{"accountdeposit": {Rate: 1.0 / 60, Burst: 2}},
+		}
+
+		w := deposit(&h, "2")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, "1", w.Header().Get("X-RateLimit-Remaining"))
+		assert.Equal(t, "60", w.Header().Get("X-RateLimit-Reset"))
+
+		// the client bucket is shared by the accounts
+		w = deposit(&h, "3")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+
+		w = deposit(&h, "4")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "60", w.Header().Get("Retry-After"))
+		assert.Equal(t, "too many requests, retry in 60 seconds", errorMessage(t, w.Body.Bytes()))
+	})
+
+	t.Run("account limit", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().Deposit(gomock.Any(), int64(2), money.New(10000, money.RUB)).Return(nil)
+		m.EXPECT().Deposit(gomock.Any(), int64(3), money.New(10000, money.RUB)).Return(nil)
+
+		h := Handler{
+			Store:         m,
+			Limiter:       NewMemoryLimiter(),
+			AccountLimits: RateLimits{"default": {Rate: 1, Burst: 1}},
+		}
+
+		assert.Equal(t, http.StatusOK, deposit(&h, "2").Code)
+		assert.Equal(t, http.StatusTooManyRequests, deposit(&h, "2").Code)
+		assert.Equal(t, http.StatusOK, deposit(&h, "3").Code)
+	})
+
+	t.Run("rejected request returns the client token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().Deposit(gomock.Any(), int64(2), money.New(10000, money.RUB)).Return(nil)
+		m.EXPECT().Deposit(gomock.Any(), int64(3), money.New(10000, money.RUB)).Return(nil)
+
+		h := Handler{
+			Store:         m,
+			Limiter:       NewMemoryLimiter(),
+			ClientLimits:  RateLimits{"default": {Rate: 1.0 / 60, Burst: 2}},
+			AccountLimits: RateLimits{"default": {Rate: 1.0 / 60, Burst: 1}},
+		}
+
+		assert.Equal(t, http.StatusOK, deposit(&h, "2").Code)
+		assert.Equal(t, http.StatusTooManyRequests, deposit(&h, "2").Code)
+		// the request rejected by the account bucket does not spend the client token
+		assert.Equal(t, http.StatusOK, deposit(&h, "3").Code)
+	})
+
+	t.Run("address limit before authentication", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		key, err := auth.NewKey()
+		require.NoError(t, err)
+
+		// the second guess is rejected before the key is looked up
+		m := NewMockStorager(ctrl)
+		m.EXPECT().ReadAPIKey(gomock.Any(), key.Prefix).Return(storage.APIKey{}, storage.ErrNoAPIKey)
+
+		h := Handler{
+			Logger:         zap.NewNop(),
+			Store:          m,
+			RequireAPIKeys: true,
+			Limiter:        NewMemoryLimiter(),
+			AddressLimit:   Limit{Rate: 1.0 / 60, Burst: 1},
+		}
+
+		for _, status := range []int{http.StatusUnauthorized, http.StatusTooManyRequests} {
+			req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/api/1/readuser", nil)
+			req.Header.Set("Authorization", "Bearer "+key.String())
+			w := httptest.NewRecorder()
+
+			h.Router(false).ServeHTTP(w, req)
+			assert.Equal(t, status, w.Code)
+		}
+	})
+
+	t.Run("limiter error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().Deposit(gomock.Any(), int64(2), money.New(10000, money.RUB)).Return(nil)
+
+		l := NewMockLimiter(ctrl)
+		l.EXPECT().TakeToken(gomock.Any(), "client:192.0.2.1:/accountdeposit", float64(1), int64(1)).Return(false, float64(0), errors.New(""))
+
+		h := Handler{
+			Logger:       zap.NewNop(),
+			Store:        m,
+			Limiter:      l,
+			ClientLimits: RateLimits{"default": {Rate: 1, Burst: 1}},
+		}
+
+		w := deposit(&h, "2")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("X-RateLimit-Limit"))
+	})
+}
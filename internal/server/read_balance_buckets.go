@@ -0,0 +1,50 @@
+package server
+
+import (
+	"encoding/json"
+	"http-avito-test/internal/generated"
+	"io/ioutil"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+func (h *Handler) ReadBalanceBuckets(w http.ResponseWriter, r *http.Request) {
+	var hand *generated.ReadBalanceBucketsRequest
+
+	body, _ := ioutil.ReadAll(r.Body)
+	err := json.Unmarshal(body, &hand)
+	if err != nil || hand == nil {
+		h.malformedBody(w, r)
+		return
+	}
+
+	if hand.UserId <= 0 {
+		h.invalidField(w, r, "user_id")
+		return
+	}
+
+	buckets, err := h.Store.ReadBalanceBuckets(r.Context(), hand.UserId)
+	if err != nil {
+		h.writeStorageError(w, r, err, "cannot read user with specified id")
+		return
+	}
+
+	result := generated.ReadBalanceBucketsResponse{
+		Result: buckets,
+		Status: "ok",
+	}
+
+	marshalledRequest, err := json.Marshal(result)
+	if err != nil {
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, writeErr := w.Write(marshalledRequest)
+	if writeErr != nil {
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
+		return
+	}
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"http-avito-test/internal/generated"
+	"http-avito-test/internal/money"
+	"http-avito-test/internal/storage"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadBalanceBuckets(t *testing.T) {
+	t.Run("green case", func(t *testing.T) {
+		var buckets = storage.BalanceBuckets{
+			AccountID: 2,
+			Real:      money.New(10000, money.RUB),
+			Bonus:     money.New(5000, money.RUB),
+			Grants: []storage.BonusGrant{
+				{
+					ID:        1,
+					Amount:    money.New(5000, money.RUB),
+					Remaining: money.New(5000, money.RUB),
+					ExpiresAt: time.Date(2022, time.December, 31, 0, 0, 0, 0, time.UTC),
+				},
+			},
+		}
+
+		var testRead = generated.ReadBalanceBucketsResponse{
+			Result: buckets,
+			Status: "ok",
+		}
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().ReadBalanceBuckets(gomock.Any(), int64(2)).Return(buckets, nil)
+
+		arg := bytes.NewBuffer([]byte(`{"user_id":2}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/read/buckets", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.ReadBalanceBuckets(w, req)
+
+		body, err := ioutil.ReadAll(w.Result().Body)
+		assert.NoError(t, err)
+
+		js, err := json.Marshal(testRead)
+		assert.NoError(t, err)
+
+		assert.Equal(t, string(js), string(body))
+	})
+
+	t.Run("wrong user id value", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+
+		arg := bytes.NewBuffer([]byte(`{"user_id":0}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/read/buckets", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.ReadBalanceBuckets(w, req)
+
+		body, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "wrong value of \"user_id\"", errorMessage(t, body))
+	})
+
+	t.Run("read errors", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			err    error
+			status int
+			body   string
+		}{
+			{"user does not exist", storage.ErrUserAvailability, http.StatusNotFound, "user does not exist"},
+			{"error reading user", errors.New(""), http.StatusInternalServerError, "cannot read user with specified id"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				m := NewMockStorager(ctrl)
+				m.EXPECT().ReadBalanceBuckets(gomock.Any(), int64(2)).Return(storage.BalanceBuckets{}, tt.err)
+
+				arg := bytes.NewBuffer([]byte(`{"user_id":2}`))
+				req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/read/buckets", arg)
+				w := httptest.NewRecorder()
+
+				s := Handler{
+					Store: m,
+				}
+
+				s.ReadBalanceBuckets(w, req)
+
+				body, err := ioutil.ReadAll(w.Body)
+				assert.NoError(t, err)
+
+				assert.Equal(t, tt.status, w.Code)
+				assert.Equal(t, tt.body, errorMessage(t, body))
+			})
+		}
+	})
+}
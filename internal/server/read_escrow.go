@@ -0,0 +1,50 @@
+package server
+
+import (
+	"encoding/json"
+	"http-avito-test/internal/generated"
+	"io/ioutil"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+func (h *Handler) ReadEscrow(w http.ResponseWriter, r *http.Request) {
+	var hand *generated.EscrowCommandRequest
+
+	body, _ := ioutil.ReadAll(r.Body)
+	err := json.Unmarshal(body, &hand)
+	if err != nil || hand == nil {
+		h.malformedBody(w, r)
+		return
+	}
+
+	if hand.EscrowId <= 0 {
+		h.invalidField(w, r, "escrow_id")
+		return
+	}
+
+	escrow, err := h.Store.ReadEscrow(r.Context(), hand.EscrowId)
+	if err != nil {
+		h.writeStorageError(w, r, err, "error reading escrow")
+		return
+	}
+
+	result := generated.ReadEscrowResponse{
+		Result: escrow,
+		Status: "ok",
+	}
+
+	marshalledRequest, err := json.Marshal(result)
+	if err != nil {
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, writeErr := w.Write(marshalledRequest)
+	if writeErr != nil {
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
+		return
+	}
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"http-avito-test/internal/generated"
+	"http-avito-test/internal/money"
+	"http-avito-test/internal/storage"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadEscrow(t *testing.T) {
+	t.Run("green case", func(t *testing.T) {
+		var escrow = storage.Escrow{
+			ID:          4,
+			Payer:       2,
+			Beneficiary: 3,
+			Amount:      money.New(10000, money.RUB),
+			Released:    money.Money{},
+			Refunded:    money.Money{},
+			Status:      storage.EscrowStatusHeld,
+			CreatedAt:   time.Date(2022, time.November, 1, 12, 0, 0, 0, time.UTC),
+		}
+
+		var testRead = generated.ReadEscrowResponse{
+			Result: escrow,
+			Status: "ok",
+		}
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().ReadEscrow(gomock.Any(), int64(4)).Return(escrow, nil)
+
+		arg := bytes.NewBuffer([]byte(`{"escrow_id":4}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/escrow/status", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.ReadEscrow(w, req)
+
+		body, err := ioutil.ReadAll(w.Result().Body)
+		assert.NoError(t, err)
+
+		js, err := json.Marshal(testRead)
+		assert.NoError(t, err)
+
+		assert.Equal(t, string(js), string(body))
+	})
+
+	t.Run("read errors", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			err    error
+			status int
+			body   string
+		}{
+			{"escrow does not exist", storage.ErrNoEscrow, http.StatusNotFound, "the escrow does not exist"},
+			{"error reading escrow", errors.New(""), http.StatusInternalServerError, "error reading escrow"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				m := NewMockStorager(ctrl)
+				m.EXPECT().ReadEscrow(gomock.Any(), int64(4)).Return(storage.Escrow{}, tt.err)
+
+				arg := bytes.NewBuffer([]byte(`{"escrow_id":4}`))
+				req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/escrow/status", arg)
+				w := httptest.NewRecorder()
+
+				s := Handler{
+					Store: m,
+				}
+
+				s.ReadEscrow(w, req)
+
+				body, err := ioutil.ReadAll(w.Body)
+				assert.NoError(t, err)
+
+				assert.Equal(t, tt.status, w.Code)
+				assert.Equal(t, tt.body, errorMessage(t, body))
+			})
+		}
+	})
+}
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"http-avito-test/internal/exchanger"
 	"http-avito-test/internal/generated"
-	"http-avito-test/internal/storage"
 	"io/ioutil"
 	"net/http"
 
@@ -25,12 +24,12 @@ func (h *Handler) ReadUser(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 	err := json.Unmarshal(body, &hand)
 	if err != nil {
-		http.Error(w, "malformed request body", http.StatusBadRequest)
+		h.malformedBody(w, r)
 		return
 	}
 
-	if hand.UserId <= 1 {
-		http.Error(w, "wrong value of \"User_id\"", http.StatusBadRequest)
+	if hand.UserId <= 0 {
+		h.invalidField(w, r, "user_id")
 		return
 	}
 
@@ -40,36 +39,32 @@ func (h *Handler) ReadUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if hand.Currency != nil && *hand.Currency == "" {
-		http.Error(w, "incorrect currency code value", http.StatusBadRequest)
+		h.writeError(w, r, apiError{status: http.StatusBadRequest, code: CodeInvalidCurrency, message: "incorrect currency code value"})
 		return
 	}
 
 	user, err := h.Store.ReadUserByID(r.Context(), int64(hand.UserId))
 	if err != nil {
-		if errors.Is(err, storage.ErrUserAvailability) {
-			http.Error(w, "user does not exist", http.StatusBadRequest)
-			return
-		}
-		http.Error(w, "cannot read user with specified id", http.StatusInternalServerError)
+		h.writeStorageError(w, r, err, "cannot read user with specified id")
 		return
 	}
 
 	var newBalance decimal.Decimal
 
-	expBalance := decimal.New(user.Balance.IntPart(), int32(-2))
+	expBalance := user.Balance.Major()
 
 	if *hand.Currency == oldRubleCurrensyCode || *hand.Currency == rubleCurrencyCode {
 		newBalance = expBalance
 	} else {
 		var newCurrency = *hand.Currency
-		exchval, err := h.Exchanger.ExchangeRates(h.Logger, expBalance, newCurrency)
+		exchval, err := h.Exchanger.ExchangeRates(r.Context(), h.logger(r), expBalance, newCurrency)
 		if err != nil {
 			if errors.Is(err, exchanger.ErrExchanger) {
-				http.Error(w, "incorrect currency code value", http.StatusBadRequest)
+				h.writeError(w, r, apiError{status: http.StatusBadRequest, code: CodeInvalidCurrency, message: "incorrect currency code value"})
 				return
 			}
 
-			http.Error(w, "cannot convert the value to the specified currency", http.StatusInternalServerError)
+			h.internalError(w, r, "cannot convert the value to the specified currency")
 			return
 		}
 		newBalance = exchval
@@ -88,14 +83,14 @@ func (h *Handler) ReadUser(w http.ResponseWriter, r *http.Request) {
 
 	marshalledRequest, err := json.Marshal(result)
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 	_, writeErr := w.Write(marshalledRequest)
 	if err != nil {
-		h.Logger.Error("failed to write connection", zap.Error(writeErr))
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
 		return
 	}
 }
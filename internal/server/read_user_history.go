@@ -20,36 +20,32 @@ func (h *Handler) ReadUserHistory(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 
 		if errors.Is(err, storage.ErrBadOrderType) {
-			http.Error(w, "wrong value of \"ordBy\" type", http.StatusBadRequest)
+			h.invalidField(w, r, "order")
 			return
 		} else {
-			http.Error(w, "malformed request body", http.StatusBadRequest)
+			h.malformedBody(w, r)
 			return
 		}
 	}
 
 	if hand.UserId <= 0 {
-		http.Error(w, "wrong value of \"User_id\"", http.StatusBadRequest)
+		h.invalidField(w, r, "user_id")
 		return
 	}
 
 	if hand.Order == "" {
-		http.Error(w, "wrong value of \"ordBy\" type", http.StatusBadRequest)
+		h.invalidField(w, r, "order")
 		return
 	}
 
 	user, err := h.Store.ReadUserHistoryList(r.Context(), hand.UserId, hand.Order, hand.Limit, hand.Offset)
 	if err != nil {
-		if errors.Is(err, storage.ErrNoUser) {
-			http.Error(w, "user does not exist", http.StatusBadRequest)
-			return
-		}
-		http.Error(w, "error reading user history", http.StatusInternalServerError)
+		h.writeStorageError(w, r, err, "error reading user history")
 		return
 	}
 
 	if user == nil {
-		http.Error(w, "wrong \"Offset\" value", http.StatusBadRequest)
+		h.invalidField(w, r, "offset")
 		return
 	}
 
@@ -60,13 +56,13 @@ func (h *Handler) ReadUserHistory(w http.ResponseWriter, r *http.Request) {
 
 	marshalledRequest, err := json.Marshal(result)
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
 		return
 	}
 	w.WriteHeader(http.StatusOK)
 	_, writeErr := w.Write(marshalledRequest)
 	if err != nil {
-		h.Logger.Error("failed to write connection", zap.Error(writeErr))
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
 		return
 	}
 }
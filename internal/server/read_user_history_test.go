@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"http-avito-test/internal/generated"
+	"http-avito-test/internal/money"
 	"http-avito-test/internal/storage"
 	"io/ioutil"
 	"net/http"
@@ -14,7 +15,6 @@ import (
 	"time"
 
 	"github.com/golang/mock/gomock"
-	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -25,19 +25,19 @@ func TestReadUserHostory(t *testing.T) {
 				{
 					AccountID: 2,
 					CashBook:  "deposit",
-					Amount:    decimal.NewFromInt(100),
+					Amount:    money.New(10000, money.RUB),
 					Date:      time.Date(2022, time.May, 05, 1, 0, 0, 0, time.UTC),
 				},
 				{
 					AccountID: 2,
 					CashBook:  "deposit",
-					Amount:    decimal.NewFromInt(120),
+					Amount:    money.New(12000, money.RUB),
 					Date:      time.Date(2022, time.May, 05, 2, 0, 0, 0, time.UTC),
 				},
 				{
 					AccountID: 2,
 					CashBook:  "deposit",
-					Amount:    decimal.NewFromInt(130),
+					Amount:    money.New(13000, money.RUB),
 					Date:      time.Date(2022, time.May, 05, 3, 0, 0, 0, time.UTC),
 				},
 			},
@@ -52,19 +52,19 @@ func TestReadUserHostory(t *testing.T) {
 			{
 				AccountID: 2,
 				CashBook:  "deposit",
-				Amount:    decimal.NewFromInt(100),
+				Amount:    money.New(10000, money.RUB),
 				Date:      time.Date(2022, time.May, 05, 1, 0, 0, 0, time.UTC),
 			},
 			{
 				AccountID: 2,
 				CashBook:  "deposit",
-				Amount:    decimal.NewFromInt(120),
+				Amount:    money.New(12000, money.RUB),
 				Date:      time.Date(2022, time.May, 05, 2, 0, 0, 0, time.UTC),
 			},
 			{
 				AccountID: 2,
 				CashBook:  "deposit",
-				Amount:    decimal.NewFromInt(130),
+				Amount:    money.New(13000, money.RUB),
 				Date:      time.Date(2022, time.May, 05, 3, 0, 0, 0, time.UTC),
 			},
 		},
@@ -112,7 +112,7 @@ func TestReadUserHostory(t *testing.T) {
 			body, err := ioutil.ReadAll(w.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "malformed request body\n", string(body))
+			assert.Equal(t, "malformed request body", errorMessage(t, body))
 		})
 
 		t.Run("wrong value of ordBy type", func(t *testing.T) {
@@ -135,7 +135,7 @@ func TestReadUserHostory(t *testing.T) {
 			body, err := ioutil.ReadAll(w.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "wrong value of \"ordBy\" type\n", string(body))
+			assert.Equal(t, "wrong value of \"order\"", errorMessage(t, body))
 		})
 	})
 
@@ -159,7 +159,7 @@ func TestReadUserHostory(t *testing.T) {
 		body, err := ioutil.ReadAll(w.Body)
 		assert.NoError(t, err)
 
-		assert.Equal(t, "wrong value of \"User_id\"\n", string(body))
+		assert.Equal(t, "wrong value of \"user_id\"", errorMessage(t, body))
 	})
 
 	t.Run("reading user error", func(t *testing.T) {
@@ -187,9 +187,9 @@ func TestReadUserHostory(t *testing.T) {
 		body, err := ioutil.ReadAll(resp.Body)
 		assert.NoError(t, err)
 
-		result := "error reading user history\n"
+		result := "error reading user history"
 
-		assert.Equal(t, result, string(body))
+		assert.Equal(t, result, errorMessage(t, body))
 
 	})
 
@@ -215,9 +215,9 @@ func TestReadUserHostory(t *testing.T) {
 		body, err := ioutil.ReadAll(resp.Body)
 		assert.NoError(t, err)
 
-		result := "user does not exist\n"
+		result := "user does not exist"
 
-		assert.Equal(t, result, string(body))
+		assert.Equal(t, result, errorMessage(t, body))
 	})
 
 	t.Run("wrong offset value", func(t *testing.T) {
@@ -242,8 +242,8 @@ func TestReadUserHostory(t *testing.T) {
 		body, err := ioutil.ReadAll(resp.Body)
 		assert.NoError(t, err)
 
-		result := "wrong \"Offset\" value\n"
+		result := "wrong value of \"offset\""
 
-		assert.Equal(t, result, string(body))
+		assert.Equal(t, result, errorMessage(t, body))
 	})
 }
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"http-avito-test/internal/exchanger"
+	"http-avito-test/internal/money"
 	"http-avito-test/internal/storage"
 	"io/ioutil"
 	"net/http"
@@ -26,7 +27,7 @@ func TestReadUser(t *testing.T) {
 
 		m.EXPECT().ReadUserByID(context.Background(), int64(2)).Return(storage.User{
 			AccountID: 2,
-			Balance:   decimal.NewFromInt(10000),
+			Balance:   money.New(10000, money.RUB),
 		}, nil)
 
 		arg := bytes.NewBuffer([]byte(`{"User_id":2, "Currency":"RUB"}`))
@@ -62,7 +63,7 @@ func TestReadUser(t *testing.T) {
 		body, err := ioutil.ReadAll(w.Body)
 		assert.NoError(t, err)
 
-		assert.Equal(t, "malformed request body\n", string(body))
+		assert.Equal(t, "malformed request body", errorMessage(t, body))
 	})
 
 	t.Run("wrong User_id value", func(t *testing.T) {
@@ -83,7 +84,7 @@ func TestReadUser(t *testing.T) {
 		body, err := ioutil.ReadAll(w.Body)
 		assert.NoError(t, err)
 
-		assert.Equal(t, "wrong value of \"User_id\"\n", string(body))
+		assert.Equal(t, "wrong value of \"user_id\"", errorMessage(t, body))
 	})
 
 	t.Run("empty Currency value", func(t *testing.T) {
@@ -101,11 +102,11 @@ func TestReadUser(t *testing.T) {
 		}
 
 		s.ReadUser(w, req)
-		resptest := "incorrect currency code value\n"
+		resptest := "incorrect currency code value"
 		body, err := ioutil.ReadAll(w.Body)
 		assert.NoError(t, err)
 
-		assert.Equal(t, resptest, string(body))
+		assert.Equal(t, resptest, errorMessage(t, body))
 	})
 
 	t.Run("user does not exist", func(t *testing.T) {
@@ -131,8 +132,8 @@ func TestReadUser(t *testing.T) {
 		body, err := ioutil.ReadAll(resp.Body)
 		assert.NoError(t, err)
 
-		resptest := "user does not exist\n"
-		assert.Equal(t, resptest, string(body))
+		resptest := "user does not exist"
+		assert.Equal(t, resptest, errorMessage(t, body))
 	})
 
 	t.Run("cannot read user with specified id", func(t *testing.T) {
@@ -158,8 +159,8 @@ func TestReadUser(t *testing.T) {
 		body, err := ioutil.ReadAll(resp.Body)
 		assert.NoError(t, err)
 
-		resptest := "cannot read user with specified id\n"
-		assert.Equal(t, resptest, string(body))
+		resptest := "cannot read user with specified id"
+		assert.Equal(t, resptest, errorMessage(t, body))
 	})
 
 	t.Run("exchanger errors", func(t *testing.T) {
@@ -171,7 +172,7 @@ func TestReadUser(t *testing.T) {
 
 			newStorage := storage.User{
 				AccountID: 2,
-				Balance:   decimal.NewFromInt(10000),
+				Balance:   money.New(10000, money.RUB),
 			}
 
 			m := NewMockStorager(ctrl)
@@ -180,7 +181,7 @@ func TestReadUser(t *testing.T) {
 				nil)
 
 			e := NewMockExchanger(ctrl)
-			e.EXPECT().ExchangeRates(logger, decimal.New(newStorage.Balance.IntPart(), int32(-2)), "RUBBB").Return(decimal.NewFromInt(0),
+			e.EXPECT().ExchangeRates(gomock.Any(), logger, newStorage.Balance.Major(), "RUBBB").Return(decimal.NewFromInt(0),
 				exchanger.ErrExchanger)
 
 			arg := bytes.NewBuffer([]byte(`{"User_id":2, "Currency":"RUBBB"}`))
@@ -198,8 +199,8 @@ func TestReadUser(t *testing.T) {
 			body, err := ioutil.ReadAll(resp.Body)
 			assert.NoError(t, err)
 
-			resptest := "incorrect currency code value\n"
-			assert.Equal(t, resptest, string(body))
+			resptest := "incorrect currency code value"
+			assert.Equal(t, resptest, errorMessage(t, body))
 		})
 
 		t.Run("cannot convert the value to the specified currency", func(t *testing.T) {
@@ -210,7 +211,7 @@ func TestReadUser(t *testing.T) {
 
 			newStorage := storage.User{
 				AccountID: 2,
-				Balance:   decimal.NewFromInt(10000),
+				Balance:   money.New(10000, money.RUB),
 			}
 
 			m := NewMockStorager(ctrl)
@@ -219,7 +220,7 @@ func TestReadUser(t *testing.T) {
 				nil)
 
 			e := NewMockExchanger(ctrl)
-			e.EXPECT().ExchangeRates(logger, decimal.New(newStorage.Balance.IntPart(), int32(-2)), "EUR").Return(decimal.NewFromInt(0),
+			e.EXPECT().ExchangeRates(gomock.Any(), logger, newStorage.Balance.Major(), "EUR").Return(decimal.NewFromInt(0),
 				errors.New(""))
 
 			arg := bytes.NewBuffer([]byte(`{"User_id":2, "Currency":"EUR"}`))
@@ -237,8 +238,8 @@ func TestReadUser(t *testing.T) {
 			body, err := ioutil.ReadAll(resp.Body)
 			assert.NoError(t, err)
 
-			resptest := "cannot convert the value to the specified currency\n"
-			assert.Equal(t, resptest, string(body))
+			resptest := "cannot convert the value to the specified currency"
+			assert.Equal(t, resptest, errorMessage(t, body))
 		})
 	})
 }
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"encoding/json"
+	"http-avito-test/internal/generated"
+	"io/ioutil"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// maxReadUsersBatch limits the number of users that can be read in one request
+const maxReadUsersBatch = 1000
+
+func (h *Handler) ReadUsers(w http.ResponseWriter, r *http.Request) {
+	var hand *generated.ReadUsersRequest
+
+	body, _ := ioutil.ReadAll(r.Body)
+	defer r.Body.Close()
+	err := json.Unmarshal(body, &hand)
+	if err != nil || hand == nil {
+		h.malformedBody(w, r)
+		return
+	}
+
+	if len(hand.UserIds) == 0 || len(hand.UserIds) > maxReadUsersBatch {
+		h.invalidField(w, r, "user_ids")
+		return
+	}
+
+	for _, id := range hand.UserIds {
+		if id <= 0 {
+			h.invalidField(w, r, "user_ids")
+			return
+		}
+	}
+
+	users, err := h.Store.ReadUsersByIDs(r.Context(), hand.UserIds)
+	if err != nil {
+		h.writeStorageError(w, r, err, "cannot read users with specified ids")
+		return
+	}
+
+	var items = make([]generated.ReadUsersResult, 0, len(users))
+	for _, user := range users {
+		item := generated.ReadUsersResult{
+			UserId: user.AccountID,
+		}
+		if user.Err != nil {
+			var message = "user does not exist"
+			item.Error = &message
+		} else {
+			balance := user.Balance.Major()
+			item.Balance = &balance
+		}
+		items = append(items, item)
+	}
+
+	result := generated.ReadUsersResponse{
+		Result: items,
+		Status: "ok",
+	}
+
+	marshalledRequest, err := json.Marshal(result)
+	if err != nil {
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, writeErr := w.Write(marshalledRequest)
+	if writeErr != nil {
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
+		return
+	}
+}
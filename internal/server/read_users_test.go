@@ -0,0 +1,132 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"http-avito-test/internal/money"
+	"http-avito-test/internal/storage"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadUsers(t *testing.T) {
+	t.Run("green case", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().ReadUsersByIDs(gomock.Any(), []int64{2, 3}).Return([]storage.UserResult{
+			{User: storage.User{AccountID: 2, Balance: money.New(10000, money.RUB)}},
+			{User: storage.User{AccountID: 3}, Err: storage.ErrUserAvailability},
+		}, nil)
+
+		arg := bytes.NewBuffer([]byte(`{"user_ids":[2, 3]}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/readbatch", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.ReadUsers(w, req)
+
+		resptest := "{\"result\":[{\"balance\":\"100\",\"user_id\":2},{\"error\":\"user does not exist\",\"user_id\":3}],\"status\":\"ok\"}"
+		body, err := ioutil.ReadAll(w.Result().Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, resptest, string(body))
+	})
+
+	t.Run("malformed request body", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/readbatch", nil)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.ReadUsers(w, req)
+
+		body, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "malformed request body", errorMessage(t, body))
+	})
+
+	t.Run("empty list of users", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+
+		arg := bytes.NewBuffer([]byte(`{"user_ids":[]}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/readbatch", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.ReadUsers(w, req)
+
+		body, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "wrong value of \"user_ids\"", errorMessage(t, body))
+	})
+
+	t.Run("wrong User_ids value", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+
+		arg := bytes.NewBuffer([]byte(`{"user_ids":[2, 0]}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/readbatch", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.ReadUsers(w, req)
+
+		body, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "wrong value of \"user_ids\"", errorMessage(t, body))
+	})
+
+	t.Run("error reading users", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().ReadUsersByIDs(gomock.Any(), []int64{2}).Return(nil, errors.New(""))
+
+		arg := bytes.NewBuffer([]byte(`{"user_ids":[2]}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/readbatch", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.ReadUsers(w, req)
+
+		body, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "cannot read users with specified ids", errorMessage(t, body))
+	})
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"http-avito-test/internal/generated"
+	"http-avito-test/internal/reconcile"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const ResolvedReconciliationMessage = "reconciliation resolved successfully"
+
+// ImportStatement accepts the CSV bank statement as the request body and returns the result of its reconciliation
+func (h *Handler) ImportStatement(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+
+	lines, err := reconcile.ParseCSV(bytes.NewReader(body))
+	if err != nil {
+		h.writeError(w, r, apiError{
+			status:  http.StatusBadRequest,
+			code:    CodeInvalidStatement,
+			message: err.Error(),
+			args:    map[string]string{"reason": err.Error()},
+		})
+		return
+	}
+
+	var name = strings.TrimSpace(r.URL.Query().Get("name"))
+	if name == "" {
+		name = "statement"
+	}
+
+	statementID, err := h.Store.ImportStatement(r.Context(), name, lines, h.ReconcileWindow)
+	if err != nil {
+		h.writeStorageError(w, r, err, "error importing bank statement")
+		return
+	}
+
+	h.writeReconciliation(w, r, statementID)
+}
+
+func (h *Handler) ReadReconciliation(w http.ResponseWriter, r *http.Request) {
+	var hand *generated.ReconciliationRequest
+
+	body, _ := ioutil.ReadAll(r.Body)
+	err := json.Unmarshal(body, &hand)
+	if err != nil || hand == nil {
+		h.malformedBody(w, r)
+		return
+	}
+
+	if hand.StatementId <= 0 {
+		h.invalidField(w, r, "statement_id")
+		return
+	}
+
+	h.writeReconciliation(w, r, hand.StatementId)
+}
+
+func (h *Handler) writeReconciliation(w http.ResponseWriter, r *http.Request, statementID int64) {
+	rec, err := h.Store.ReadReconciliation(r.Context(), statementID)
+	if err != nil {
+		h.writeStorageError(w, r, err, "error reading reconciliation")
+		return
+	}
+
+	result := generated.ReconciliationResponse{
+		Result: rec,
+		Status: "ok",
+	}
+
+	marshalledRequest, err := json.Marshal(result)
+	if err != nil {
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, writeErr := w.Write(marshalledRequest)
+	if writeErr != nil {
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
+		return
+	}
+}
+
+func (h *Handler) ResolveReconciliation(w http.ResponseWriter, r *http.Request) {
+	var hand *generated.ResolveReconciliationRequest
+
+	body, _ := ioutil.ReadAll(r.Body)
+	err := json.Unmarshal(body, &hand)
+	if err != nil || hand == nil {
+		h.malformedBody(w, r)
+		return
+	}
+
+	hand.Operator = strings.TrimSpace(hand.Operator)
+
+	switch {
+	case hand.LineId != nil && *hand.LineId <= 0:
+		h.invalidField(w, r, "line_id")
+		return
+	case hand.PostingId != nil && *hand.PostingId <= 0,
+		hand.LineId == nil && hand.PostingId == nil:
+		h.invalidField(w, r, "posting_id")
+		return
+	case hand.Operator == "":
+		h.invalidField(w, r, "operator")
+		return
+	}
+
+	var note string
+	if hand.Note != nil {
+		note = *hand.Note
+	}
+
+	if hand.LineId != nil {
+		err = h.Store.ResolveStatementLine(r.Context(), *hand.LineId, hand.PostingId, hand.Operator, note)
+	} else {
+		err = h.Store.ResolveLedgerPosting(r.Context(), *hand.PostingId, hand.Operator, note)
+	}
+	if err != nil {
+		h.writeStorageError(w, r, err, "error resolving reconciliation")
+		return
+	}
+
+	result := generated.ResolveReconciliationResponse{
+		Result: struct {
+			Message string "json:\"message\""
+		}{
+			Message: ResolvedReconciliationMessage,
+		},
+		Status: "ok",
+	}
+
+	marshalledRequest, err := json.Marshal(result)
+	if err != nil {
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, writeErr := w.Write(marshalledRequest)
+	if writeErr != nil {
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
+		return
+	}
+}
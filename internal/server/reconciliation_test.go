@@ -0,0 +1,268 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"http-avito-test/internal/generated"
+	"http-avito-test/internal/money"
+	"http-avito-test/internal/reconcile"
+	"http-avito-test/internal/storage"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportStatement(t *testing.T) {
+	var rec = storage.Reconciliation{
+		StatementID:       3,
+		Matched:           []storage.StatementLine{},
+		UnmatchedInBank:   []storage.StatementLine{},
+		UnmatchedInLedger: []storage.LedgerPosting{},
+	}
+
+	t.Run("green case", func(t *testing.T) {
+		var testReconciliation = generated.ReconciliationResponse{
+			Result: rec,
+			Status: "ok",
+		}
+
+		lines := []reconcile.Line{
+			{
+				Number: 2,
+				Date:   time.Date(2022, time.November, 1, 0, 0, 0, 0, time.UTC),
+				Amount: money.New(150050, money.RUB),
+			},
+		}
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().ImportStatement(gomock.Any(), "november.csv", gomock.Any(), 72*time.Hour).DoAndReturn(
+			func(_ interface{}, _ string, got []reconcile.Line, _ time.Duration) (int64, error) {
+				assert.Len(t, got, 1)
+				assert.Equal(t, lines[0].Date, got[0].Date)
+				assert.True(t, lines[0].Amount.Equal(got[0].Amount))
+				return 3, nil
+			})
+		m.EXPECT().ReadReconciliation(gomock.Any(), int64(3)).Return(rec, nil)
+
+		arg := bytes.NewBufferString("date,amount,reference\n2022-11-01,1500.50,\n")
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/reconcile/import?name=november.csv", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store:           m,
+			ReconcileWindow: 72 * time.Hour,
+		}
+
+		s.ImportStatement(w, req)
+
+		body, err := ioutil.ReadAll(w.Result().Body)
+		assert.NoError(t, err)
+
+		js, err := json.Marshal(testReconciliation)
+		assert.NoError(t, err)
+
+		assert.Equal(t, string(js), string(body))
+	})
+
+	t.Run("malformed statement", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+
+		arg := bytes.NewBufferString("date,amount,reference\n2022-11-01,abc,\n")
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/reconcile/import", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.ImportStatement(w, req)
+
+		body, err := ioutil.ReadAll(w.Result().Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+		assert.Equal(t, "malformed bank statement: line 2: wrong amount \"abc\"", errorMessage(t, body))
+	})
+}
+
+func TestReadReconciliation(t *testing.T) {
+	var tests = []struct {
+		name       string
+		body       string
+		storageErr error
+		status     int
+		result     string
+	}{
+		{name: "empty request body", body: ``, status: http.StatusBadRequest, result: "malformed request body"},
+		{name: "wrong statement id", body: `{"statement_id":0}`, status: http.StatusBadRequest, result: "wrong value of \"statement_id\""},
+		{
+			name:       "statement does not exist",
+			body:       `{"statement_id":3}`,
+			storageErr: storage.ErrNoStatement,
+			status:     http.StatusNotFound,
+			result:     "the bank statement does not exist",
+		},
+		{
+			name:       "error reading",
+			body:       `{"statement_id":3}`,
+			storageErr: errors.New("error reading"),
+			status:     http.StatusInternalServerError,
+			result:     "error reading reconciliation",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := NewMockStorager(ctrl)
+			if tt.storageErr != nil {
+				m.EXPECT().ReadReconciliation(gomock.Any(), int64(3)).Return(storage.Reconciliation{}, tt.storageErr)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/reconcile/report", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+
+			s := Handler{
+				Store: m,
+			}
+
+			s.ReadReconciliation(w, req)
+
+			body, err := ioutil.ReadAll(w.Result().Body)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.status, w.Result().StatusCode)
+			assert.Equal(t, tt.result, errorMessage(t, body))
+		})
+	}
+}
+
+func TestResolveReconciliation(t *testing.T) {
+	t.Run("statement line matched to posting", func(t *testing.T) {
+		var testResolve = generated.ResolveReconciliationResponse{
+			Result: struct {
+				Message string "json:\"message\""
+			}{
+				Message: "reconciliation resolved successfully",
+			},
+			Status: "ok",
+		}
+
+		postingID := int64(10)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().ResolveStatementLine(gomock.Any(), int64(4), &postingID, "operator", "late deposit").Return(nil)
+
+		arg := bytes.NewBufferString(`{"line_id":4, "posting_id":10, "operator":"operator", "note":"late deposit"}`)
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/reconcile/resolve", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.ResolveReconciliation(w, req)
+
+		body, err := ioutil.ReadAll(w.Result().Body)
+		assert.NoError(t, err)
+
+		js, err := json.Marshal(testResolve)
+		assert.NoError(t, err)
+
+		assert.Equal(t, string(js), string(body))
+	})
+
+	t.Run("ledger posting", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().ResolveLedgerPosting(gomock.Any(), int64(10), "operator", "").Return(nil)
+
+		arg := bytes.NewBufferString(`{"posting_id":10, "operator":"operator"}`)
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/reconcile/resolve", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.ResolveReconciliation(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var tests = []struct {
+			name       string
+			body       string
+			storageErr error
+			result     string
+		}{
+			{name: "nothing to resolve", body: `{"operator":"operator"}`, result: "wrong value of \"posting_id\""},
+			{name: "wrong line id", body: `{"line_id":-1, "operator":"operator"}`, result: "wrong value of \"line_id\""},
+			{name: "empty operator", body: `{"line_id":4}`, result: "wrong value of \"operator\""},
+			{
+				name:       "line does not exist",
+				body:       `{"line_id":4, "operator":"operator"}`,
+				storageErr: storage.ErrNoStatementLine,
+				result:     "the bank statement line does not exist",
+			},
+			{
+				name:       "line already reconciled",
+				body:       `{"line_id":4, "operator":"operator"}`,
+				storageErr: storage.ErrLineReconciled,
+				result:     "the bank statement line is already matched or resolved",
+			},
+			{
+				name:       "posting already reconciled",
+				body:       `{"line_id":4, "operator":"operator"}`,
+				storageErr: storage.ErrPostingReconciled,
+				result:     "the cash book posting is already matched or resolved",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				m := NewMockStorager(ctrl)
+				if tt.storageErr != nil {
+					m.EXPECT().ResolveStatementLine(gomock.Any(), int64(4), nil, "operator", "").Return(tt.storageErr)
+				}
+
+				req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/reconcile/resolve", bytes.NewBufferString(tt.body))
+				w := httptest.NewRecorder()
+
+				s := Handler{
+					Store: m,
+				}
+
+				s.ResolveReconciliation(w, req)
+
+				body, err := ioutil.ReadAll(w.Result().Body)
+				assert.NoError(t, err)
+
+				assert.Equal(t, tt.result, errorMessage(t, body))
+			})
+		}
+	})
+}
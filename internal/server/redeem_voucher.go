@@ -0,0 +1,66 @@
+package server
+
+import (
+	"encoding/json"
+	"http-avito-test/internal/generated"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const RedeemedVoucherMessage = "voucher redeemed successfully"
+
+func (h *Handler) RedeemVoucher(w http.ResponseWriter, r *http.Request) {
+	var hand *generated.RedeemVoucherRequest
+
+	body, _ := ioutil.ReadAll(r.Body)
+	err := json.Unmarshal(body, &hand)
+	if err != nil || hand == nil {
+		h.malformedBody(w, r)
+		return
+	}
+
+	var code = strings.ToUpper(strings.TrimSpace(hand.Code))
+
+	switch {
+	case hand.UserId <= 0:
+		h.invalidField(w, r, "user_id")
+		return
+	case code == "":
+		h.invalidField(w, r, "code")
+		return
+	}
+
+	amount, err := h.Store.RedeemVoucher(r.Context(), hand.UserId, code)
+	if err != nil {
+		h.writeStorageError(w, r, err, "error redeeming voucher")
+		return
+	}
+
+	result := generated.RedeemVoucherResponse{
+		Result: struct {
+			Amount  decimal.Decimal "json:\"amount\""
+			Message string          "json:\"message\""
+		}{
+			Amount:  amount.Major(),
+			Message: RedeemedVoucherMessage,
+		},
+		Status: "ok",
+	}
+
+	marshalledRequest, err := json.Marshal(result)
+	if err != nil {
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, writeErr := w.Write(marshalledRequest)
+	if writeErr != nil {
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
+		return
+	}
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"http-avito-test/internal/generated"
+	"http-avito-test/internal/money"
+	"http-avito-test/internal/storage"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedeemVoucher(t *testing.T) {
+	t.Run("green case", func(t *testing.T) {
+		var testRedeem = generated.RedeemVoucherResponse{
+			Result: struct {
+				Amount  decimal.Decimal "json:\"amount\""
+				Message string          "json:\"message\""
+			}{
+				Amount:  decimal.NewFromInt(50),
+				Message: "voucher redeemed successfully",
+			},
+			Status: "ok",
+		}
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().RedeemVoucher(gomock.Any(), int64(2), "ABCDEFGHJKLM").Return(money.New(5000, money.RUB), nil)
+
+		arg := bytes.NewBuffer([]byte(`{"user_id":2, "code":" abcdefghjklm "}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/voucher/redeem", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.RedeemVoucher(w, req)
+
+		body, err := ioutil.ReadAll(w.Result().Body)
+		assert.NoError(t, err)
+
+		js, err := json.Marshal(testRedeem)
+		assert.NoError(t, err)
+
+		assert.Equal(t, string(js), string(body))
+	})
+
+	t.Run("wrong values", func(t *testing.T) {
+		tests := []struct {
+			name string
+			arg  string
+			body string
+		}{
+			{"malformed request body", ``, "malformed request body"},
+			{"wrong user id", `{"user_id":0, "code":"ABCDEFGHJKLM"}`, "wrong value of \"user_id\""},
+			{"empty code", `{"user_id":2, "code":" "}`, "wrong value of \"code\""},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				m := NewMockStorager(ctrl)
+
+				arg := bytes.NewBuffer([]byte(tt.arg))
+				req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/voucher/redeem", arg)
+				w := httptest.NewRecorder()
+
+				s := Handler{
+					Store: m,
+				}
+
+				s.RedeemVoucher(w, req)
+
+				body, err := ioutil.ReadAll(w.Body)
+				assert.NoError(t, err)
+
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, tt.body, errorMessage(t, body))
+			})
+		}
+	})
+
+	t.Run("redeem errors", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			err    error
+			status int
+			body   string
+		}{
+			{"voucher does not exist", storage.ErrNoVoucher, http.StatusNotFound, "the voucher does not exist"},
+			{"voucher is expired", storage.ErrVoucherExpired, http.StatusConflict, "the voucher is expired"},
+			{"voucher is used up", storage.ErrVoucherUsedUp, http.StatusConflict, "the voucher redemption limit is reached"},
+			{"voucher is redeemed", storage.ErrVoucherRedeemed, http.StatusConflict, "the voucher is already redeemed by the user"},
+			{"error redeeming voucher", errors.New(""), http.StatusInternalServerError, "error redeeming voucher"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				m := NewMockStorager(ctrl)
+				m.EXPECT().RedeemVoucher(gomock.Any(), int64(2), "ABCDEFGHJKLM").Return(money.Money{}, tt.err)
+
+				arg := bytes.NewBuffer([]byte(`{"user_id":2, "code":"ABCDEFGHJKLM"}`))
+				req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/voucher/redeem", arg)
+				w := httptest.NewRecorder()
+
+				s := Handler{
+					Store: m,
+				}
+
+				s.RedeemVoucher(w, req)
+
+				body, err := ioutil.ReadAll(w.Body)
+				assert.NoError(t, err)
+
+				assert.Equal(t, tt.status, w.Code)
+				assert.Equal(t, tt.body, errorMessage(t, body))
+			})
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"http-avito-test/internal/requestid"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// RequestIDHeader carries the id of the request, it is returned in the response header and in the error body
+const RequestIDHeader = requestid.Header
+
+// WithRequestID accepts the id of the request sent by the client or generates a new one, puts it into
+// the request context for the log lines of the handlers and the storage and returns it in the response header
+func WithRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(requestid.NewContext(r.Context(), id)))
+	})
+}
+
+// requestID returns the id of the request put into the context by WithRequestID. The requests served without
+// the middleware use the id sent by the client or a new random one
+func requestID(r *http.Request) string {
+	if id, ok := requestid.FromContext(r.Context()); ok {
+		return id
+	}
+	if id := r.Header.Get(RequestIDHeader); requestid.Valid(id) {
+		return id
+	}
+	return requestid.New()
+}
+
+// logger returns the logger of the handler that writes the id of the request
+func (h *Handler) logger(r *http.Request) *zap.Logger {
+	return requestid.Logger(r.Context(), h.Logger)
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"http-avito-test/internal/generated"
+	"http-avito-test/internal/money"
+	"http-avito-test/internal/requestid"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWithRequestID(t *testing.T) {
+	t.Run("id of the client", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().Deposit(gomock.Any(), int64(2), money.New(10000, money.RUB)).DoAndReturn(
+			func(ctx context.Context, _ int64, _ money.Money) error {
+				// the storage derives its logger from the context
+				id, ok := requestid.FromContext(ctx)
+				assert.True(t, ok)
+				assert.Equal(t, "client-request-1", id)
+				return errors.New("error updating balance")
+			})
+
+		arg := bytes.NewBuffer([]byte(`{"user_id":2, "amount":"100.00"}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/api/1/accountdeposit", arg)
+		req.Header.Set(RequestIDHeader, "client-request-1")
+		w := httptest.NewRecorder()
+
+		h := Handler{
+			Store: m,
+		}
+
+		WithRequestID(h.Router(false)).ServeHTTP(w, req)
+
+		var resp generated.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+		assert.Equal(t, "client-request-1", w.Header().Get(RequestIDHeader))
+		assert.Equal(t, "client-request-1", resp.Error.RequestId)
+	})
+
+	t.Run("handler logger", func(t *testing.T) {
+		core, logs := observer.New(zap.InfoLevel)
+
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/api/1/accountdeposit", nil)
+		req = req.WithContext(requestid.NewContext(req.Context(), "client-request-1"))
+
+		h := Handler{
+			Logger: zap.New(core),
+		}
+		h.logger(req).Info("test")
+
+		assert.Equal(t, "client-request-1", logs.AllUntimed()[0].ContextMap()["requestID"])
+	})
+
+	t.Run("generated id", func(t *testing.T) {
+		for _, id := range []string{"", "bad id"} {
+			req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/api/1/unknown", nil)
+			if id != "" {
+				req.Header.Set(RequestIDHeader, id)
+			}
+			w := httptest.NewRecorder()
+
+			h := Handler{}
+			WithRequestID(h.Router(false)).ServeHTTP(w, req)
+
+			var resp generated.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+			assert.Len(t, w.Header().Get(RequestIDHeader), 32, id)
+			assert.Equal(t, w.Header().Get(RequestIDHeader), resp.Error.RequestId, id)
+		}
+	})
+}
@@ -2,14 +2,12 @@ package server
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"http-avito-test/internal/generated"
 	"http-avito-test/internal/storage"
 	"io/ioutil"
 	"net/http"
 
-	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
@@ -19,54 +17,48 @@ func (h *Handler) ReservationOfFunds(w http.ResponseWriter, r *http.Request) {
 	body, _ := ioutil.ReadAll(r.Body)
 	err := json.Unmarshal(body, &hand)
 	if err != nil {
-		http.Error(w, "malformed request body", http.StatusBadRequest)
+		h.malformedBody(w, r)
 		return
 	}
 
 	switch {
-	case hand.UserId <= 1:
-		http.Error(w, "wrong value of \"UserId\"", http.StatusBadRequest)
+	case hand.UserId <= 0:
+		h.invalidField(w, r, "user_id")
 		return
 	case hand.ServiceId <= 0:
-		http.Error(w, "wrong value of \"ServiceId\"", http.StatusBadRequest)
+		h.invalidField(w, r, "service_id")
 		return
 	case hand.OrderId <= 0:
-		http.Error(w, "wrong value of \"OrderId\"", http.StatusBadRequest)
+		h.invalidField(w, r, "order_id")
 		return
 	}
 
-	var newPrice = decimal.NewFromFloat32(hand.Price).Mul(decimal.NewFromInt(100))
-
-	switch {
-	case newPrice.Exponent() < -2:
-		http.Error(w, "wrong value of \"Price\"", http.StatusBadRequest)
+	if !h.serviceAllowed(w, r, "reservation", hand.UserId, hand.ServiceId, hand.OrderId) {
 		return
-	case newPrice.LessThanOrEqual(decimal.NewFromInt(int64(0))):
-		http.Error(w, "wrong value of \"Price\"", http.StatusBadRequest)
+	}
+
+	newPrice, err := parseAmount(w, hand.Price)
+	if err != nil || !newPrice.IsPositive() {
+		h.invalidField(w, r, "price")
 		return
 	}
 
-	var description = fmt.Sprintf(`Order number %d; Purchase of service %d by user %d in the price of %f`, hand.OrderId, hand.ServiceId, hand.UserId, hand.Price)
+	var description = fmt.Sprintf(`Order number %d; Purchase of service %d by user %d in the price of %s`, hand.OrderId, hand.ServiceId, hand.UserId, newPrice.Format())
 
-	err = h.Store.Reservation(r.Context(), hand.UserId, hand.ServiceId, hand.OrderId, newPrice, &description)
+	var quote storage.Quote
+	if isDryRun(hand.DryRun) {
+		quote, err = h.Store.QuoteReservation(r.Context(), hand.UserId, hand.ServiceId, hand.OrderId, newPrice, &description)
+	} else {
+		err = h.Store.Reservation(r.Context(), hand.UserId, hand.ServiceId, hand.OrderId, newPrice, &description)
+	}
 	if err != nil {
-		switch {
-		case errors.Is(err, storage.ErrSerialization):
-			http.Error(w, "error updating balance", http.StatusInternalServerError)
-			return
-		case errors.Is(err, storage.ErrTransfer):
-			http.Error(w, "not enough money in the account", http.StatusBadRequest)
-			return
-		case errors.Is(err, storage.ErrUserAvailability):
-			http.Error(w, "sender does not exist", http.StatusBadRequest)
-			return
-		case errors.Is(err, storage.ErrOrderId):
-			http.Error(w, "thе order already exists", http.StatusBadRequest)
-			return
-		default:
-			http.Error(w, "reservation error", http.StatusInternalServerError)
-			return
-		}
+		h.writeStorageError(w, r, err, "reservation error")
+		return
+	}
+
+	if isDryRun(hand.DryRun) {
+		h.writeQuote(w, r, quote)
+		return
 	}
 
 	result := generated.ReservationOfFundsResponse{
@@ -80,14 +72,14 @@ func (h *Handler) ReservationOfFunds(w http.ResponseWriter, r *http.Request) {
 
 	marshalledRequest, err := json.Marshal(result)
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 	_, writeErr := w.Write(marshalledRequest)
 	if err != nil {
-		h.Logger.Error("failed to write connection", zap.Error(writeErr))
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
 		return
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"http-avito-test/internal/generated"
+	"http-avito-test/internal/money"
 	"http-avito-test/internal/storage"
 	"io/ioutil"
 	"net/http"
@@ -12,7 +13,6 @@ import (
 	"testing"
 
 	"github.com/golang/mock/gomock"
-	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -30,12 +30,12 @@ func TestReservationOfFunds(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
-		description := "Order number 1; Purchase of service 1 by user 2 in the price of 100.000000"
+		description := "Order number 1; Purchase of service 1 by user 2 in the price of 100.00"
 
 		m := NewMockStorager(ctrl)
-		m.EXPECT().Reservation(gomock.Any(), int64(2), int64(1), int64(1), decimal.NewFromFloat32(100).Mul(decimal.NewFromInt(100)), &description).Return(nil)
+		m.EXPECT().Reservation(gomock.Any(), int64(2), int64(1), int64(1), money.New(10000, money.RUB), &description).Return(nil)
 
-		arg := bytes.NewBuffer([]byte(`{"user_id":2, "service_id":1, "order_id":1, "price":100.00}`))
+		arg := bytes.NewBuffer([]byte(`{"user_id":2, "service_id":1, "order_id":1, "price":"100.00"}`))
 		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/reserv", arg)
 		w := httptest.NewRecorder()
 
@@ -55,6 +55,35 @@ func TestReservationOfFunds(t *testing.T) {
 		assert.Equal(t, string(js), string(body))
 	})
 
+	t.Run("dry run", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		description := "Order number 1; Purchase of service 1 by user 2 in the price of 100.00"
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().QuoteReservation(gomock.Any(), int64(2), int64(1), int64(1), money.New(10000, money.RUB), &description).Return(storage.Quote{
+			AccountID: 2,
+			Balance:   money.New(12345, money.RUB),
+		}, nil)
+
+		arg := bytes.NewBuffer([]byte(`{"user_id":2, "service_id":1, "order_id":1, "price":100.00, "dry_run":true}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/reserve", arg)
+		w := httptest.NewRecorder()
+
+		h := Handler{
+			Store: m,
+		}
+
+		h.ReservationOfFunds(w, req)
+
+		resp := w.Result()
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "{\"result\":{\"balance\":\"123.45\",\"fee\":\"0\",\"user_id\":2},\"status\":\"ok\"}", string(body))
+	})
+
 	t.Run("malformed request body", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
@@ -73,7 +102,7 @@ func TestReservationOfFunds(t *testing.T) {
 		body, err := ioutil.ReadAll(w.Body)
 		assert.NoError(t, err)
 
-		assert.Equal(t, "malformed request body\n", string(body))
+		assert.Equal(t, "malformed request body", errorMessage(t, body))
 	})
 
 	t.Run("wrong incoming values", func(t *testing.T) {
@@ -97,7 +126,7 @@ func TestReservationOfFunds(t *testing.T) {
 			body, err := ioutil.ReadAll(w.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "wrong value of \"UserId\"\n", string(body))
+			assert.Equal(t, "wrong value of \"user_id\"", errorMessage(t, body))
 		})
 
 		t.Run("wrong service_id", func(t *testing.T) {
@@ -120,7 +149,7 @@ func TestReservationOfFunds(t *testing.T) {
 			body, err := ioutil.ReadAll(w.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "wrong value of \"ServiceId\"\n", string(body))
+			assert.Equal(t, "wrong value of \"service_id\"", errorMessage(t, body))
 		})
 
 		t.Run("wrong order_id", func(t *testing.T) {
@@ -143,7 +172,7 @@ func TestReservationOfFunds(t *testing.T) {
 			body, err := ioutil.ReadAll(w.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "wrong value of \"OrderId\"\n", string(body))
+			assert.Equal(t, "wrong value of \"order_id\"", errorMessage(t, body))
 		})
 	})
 
@@ -168,7 +197,7 @@ func TestReservationOfFunds(t *testing.T) {
 			body, err := ioutil.ReadAll(w.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "wrong value of \"Price\"\n", string(body))
+			assert.Equal(t, "wrong value of \"price\"", errorMessage(t, body))
 		})
 
 		t.Run("price less than or equal to zero", func(t *testing.T) {
@@ -191,7 +220,7 @@ func TestReservationOfFunds(t *testing.T) {
 			body, err := ioutil.ReadAll(w.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "wrong value of \"Price\"\n", string(body))
+			assert.Equal(t, "wrong value of \"price\"", errorMessage(t, body))
 		})
 	})
 
@@ -200,12 +229,12 @@ func TestReservationOfFunds(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
-			description := "Order number 1; Purchase of service 1 by user 2 in the price of 100.000000"
+			description := "Order number 1; Purchase of service 1 by user 2 in the price of 100.00"
 
 			err := storage.ErrSerialization
 
 			m := NewMockStorager(ctrl)
-			m.EXPECT().Reservation(gomock.Any(), int64(2), int64(1), int64(1), decimal.NewFromFloat32(100).Mul(decimal.NewFromInt(100)), &description).Return(err)
+			m.EXPECT().Reservation(gomock.Any(), int64(2), int64(1), int64(1), money.New(10000, money.RUB), &description).Return(err)
 
 			arg := bytes.NewBuffer([]byte(`{"user_id":2, "service_id":1, "order_id":1, "price":100.00}`))
 			req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/reserv", arg)
@@ -221,17 +250,18 @@ func TestReservationOfFunds(t *testing.T) {
 			body, err := ioutil.ReadAll(resp.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "error updating balance\n", string(body))
+			assert.Equal(t, http.StatusConflict, resp.StatusCode)
+			assert.Equal(t, "the operation conflicted with a concurrent one, retry it", errorMessage(t, body))
 		})
 
 		t.Run("not enough money in the account", func(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
-			description := "Order number 1; Purchase of service 1 by user 2 in the price of 100.000000"
+			description := "Order number 1; Purchase of service 1 by user 2 in the price of 100.00"
 
 			m := NewMockStorager(ctrl)
-			m.EXPECT().Reservation(gomock.Any(), int64(2), int64(1), int64(1), decimal.NewFromFloat32(100).Mul(decimal.NewFromInt(100)), &description).Return(storage.ErrTransfer)
+			m.EXPECT().Reservation(gomock.Any(), int64(2), int64(1), int64(1), money.New(10000, money.RUB), &description).Return(storage.ErrTransfer)
 			arg := bytes.NewBuffer([]byte(`{"user_id":2, "service_id":1, "order_id":1, "price":100.00}`))
 			req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/reserv", arg)
 			w := httptest.NewRecorder()
@@ -246,17 +276,17 @@ func TestReservationOfFunds(t *testing.T) {
 			body, err := ioutil.ReadAll(resp.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "not enough money in the account\n", string(body))
+			assert.Equal(t, "not enough money in the account", errorMessage(t, body))
 		})
 
 		t.Run("sender does not exist", func(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
-			description := "Order number 1; Purchase of service 1 by user 2 in the price of 100.000000"
+			description := "Order number 1; Purchase of service 1 by user 2 in the price of 100.00"
 
 			m := NewMockStorager(ctrl)
-			m.EXPECT().Reservation(gomock.Any(), int64(2), int64(1), int64(1), decimal.NewFromFloat32(100).Mul(decimal.NewFromInt(100)), &description).Return(storage.ErrUserAvailability)
+			m.EXPECT().Reservation(gomock.Any(), int64(2), int64(1), int64(1), money.New(10000, money.RUB), &description).Return(storage.ErrUserAvailability)
 			arg := bytes.NewBuffer([]byte(`{"user_id":2, "service_id":1, "order_id":1, "price":100.00}`))
 			req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/reserv", arg)
 			w := httptest.NewRecorder()
@@ -271,17 +301,17 @@ func TestReservationOfFunds(t *testing.T) {
 			body, err := ioutil.ReadAll(resp.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "sender does not exist\n", string(body))
+			assert.Equal(t, "user does not exist", errorMessage(t, body))
 		})
 
-		t.Run("thе order already exists", func(t *testing.T) {
+		t.Run("the order already exists", func(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
-			description := "Order number 1; Purchase of service 1 by user 2 in the price of 100.000000"
+			description := "Order number 1; Purchase of service 1 by user 2 in the price of 100.00"
 
 			m := NewMockStorager(ctrl)
-			m.EXPECT().Reservation(gomock.Any(), int64(2), int64(1), int64(1), decimal.NewFromFloat32(100).Mul(decimal.NewFromInt(100)), &description).Return(storage.ErrOrderId)
+			m.EXPECT().Reservation(gomock.Any(), int64(2), int64(1), int64(1), money.New(10000, money.RUB), &description).Return(storage.ErrOrderId)
 			arg := bytes.NewBuffer([]byte(`{"user_id":2, "service_id":1, "order_id":1, "price":100.00}`))
 			req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/reserv", arg)
 			w := httptest.NewRecorder()
@@ -296,17 +326,17 @@ func TestReservationOfFunds(t *testing.T) {
 			body, err := ioutil.ReadAll(resp.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "thе order already exists\n", string(body))
+			assert.Equal(t, "the order already exists", errorMessage(t, body))
 		})
 
 		t.Run("reservation error", func(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
-			description := "Order number 1; Purchase of service 1 by user 2 in the price of 100.000000"
+			description := "Order number 1; Purchase of service 1 by user 2 in the price of 100.00"
 
 			m := NewMockStorager(ctrl)
-			m.EXPECT().Reservation(gomock.Any(), int64(2), int64(1), int64(1), decimal.NewFromFloat32(100).Mul(decimal.NewFromInt(100)), &description).Return(errors.New(""))
+			m.EXPECT().Reservation(gomock.Any(), int64(2), int64(1), int64(1), money.New(10000, money.RUB), &description).Return(errors.New(""))
 			arg := bytes.NewBuffer([]byte(`{"user_id":2, "service_id":1, "order_id":1, "price":100.00}`))
 			req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/reserv", arg)
 			w := httptest.NewRecorder()
@@ -321,7 +351,7 @@ func TestReservationOfFunds(t *testing.T) {
 			body, err := ioutil.ReadAll(resp.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "reservation error\n", string(body))
+			assert.Equal(t, "reservation error", errorMessage(t, body))
 		})
 	})
 }
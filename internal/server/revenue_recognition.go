@@ -2,14 +2,11 @@ package server
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"http-avito-test/internal/generated"
-	"http-avito-test/internal/storage"
 	"io/ioutil"
 	"net/http"
 
-	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
@@ -19,60 +16,38 @@ func (h *Handler) RevenueRecognition(w http.ResponseWriter, r *http.Request) {
 	body, _ := ioutil.ReadAll(r.Body)
 	err := json.Unmarshal(body, &hand)
 	if err != nil {
-		http.Error(w, "malformed request body", http.StatusBadRequest)
+		h.malformedBody(w, r)
 		return
 	}
 
 	switch {
-	case hand.UserId <= 1:
-		http.Error(w, "wrong value of \"UserId\"", http.StatusBadRequest)
+	case hand.UserId <= 0:
+		h.invalidField(w, r, "user_id")
 		return
 	case hand.ServiceId <= 0:
-		http.Error(w, "wrong value of \"ServiceId\"", http.StatusBadRequest)
+		h.invalidField(w, r, "service_id")
 		return
 	case hand.OrderId <= 0:
-		http.Error(w, "wrong value of \"OrderId\"", http.StatusBadRequest)
+		h.invalidField(w, r, "order_id")
 		return
 	}
 
-	var newSum = decimal.NewFromFloat32(hand.Sum).Mul(decimal.NewFromInt(100))
-
-	switch {
-	case newSum.Exponent() < -2:
-		http.Error(w, "wrong value of \"Price\"", http.StatusBadRequest)
+	if !h.serviceAllowed(w, r, "revenue", hand.UserId, hand.ServiceId, hand.OrderId) {
 		return
-	case newSum.LessThanOrEqual(decimal.NewFromInt(int64(0))):
-		http.Error(w, "wrong value of \"Price\"", http.StatusBadRequest)
+	}
+
+	newSum, err := parseAmount(w, hand.Sum)
+	if err != nil || !newSum.IsPositive() {
+		h.invalidField(w, r, "sum")
 		return
 	}
 
-	var description = fmt.Sprintf(`Order number %d; Transferring money for the service %d from a reserve account to a company account in the sum %f`, hand.OrderId, hand.ServiceId, hand.Sum)
+	var description = fmt.Sprintf(`Order number %d; Transferring money for the service %d from a reserve account to a company account in the sum %s`, hand.OrderId, hand.ServiceId, newSum.Format())
 
 	err = h.Store.Revenue(r.Context(), hand.UserId, hand.ServiceId, hand.OrderId, newSum, &description)
 	if err != nil {
-		switch {
-		case errors.Is(err, storage.ErrSerialization):
-			http.Error(w, "error updating balance", http.StatusInternalServerError)
-			return
-		case errors.Is(err, storage.ErrTransfer):
-			http.Error(w, "not enough money in the account", http.StatusBadRequest)
-			return
-		case errors.Is(err, storage.ErrUserAvailability):
-			http.Error(w, "sender does not exist", http.StatusBadRequest)
-			return
-		case errors.Is(err, storage.ErrReserveExist):
-			http.Error(w, "thе order does not exist", http.StatusBadRequest)
-			return
-		case errors.Is(err, storage.ErrRevenue):
-			http.Error(w, "the sum greater then amount on the deferred expenses", http.StatusBadRequest)
-			return
-		case errors.Is(err, storage.ErrRecordExist):
-			http.Error(w, "unreserve or consolidated report record already exists", http.StatusBadRequest)
-			return
-		default:
-			http.Error(w, "recognition error", http.StatusInternalServerError)
-			return
-		}
+		h.writeStorageError(w, r, err, "recognition error")
+		return
 	}
 
 	result := generated.RevenueRecognitionResponse{
@@ -86,14 +61,14 @@ func (h *Handler) RevenueRecognition(w http.ResponseWriter, r *http.Request) {
 
 	marshalledRequest, err := json.Marshal(result)
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 	_, writeErr := w.Write(marshalledRequest)
 	if err != nil {
-		h.Logger.Error("failed to write connection", zap.Error(writeErr))
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
 		return
 	}
 }
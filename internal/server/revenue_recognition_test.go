@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"http-avito-test/internal/generated"
+	"http-avito-test/internal/money"
 	"http-avito-test/internal/storage"
 	"io/ioutil"
 	"net/http"
@@ -12,7 +13,6 @@ import (
 	"testing"
 
 	"github.com/golang/mock/gomock"
-	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -30,12 +30,12 @@ func TestRevenueRecognition(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
-		description := "Order number 1; Transferring money for the service 1 from a reserve account to a company account in the sum 100.000000"
+		description := "Order number 1; Transferring money for the service 1 from a reserve account to a company account in the sum 100.00"
 
 		m := NewMockStorager(ctrl)
-		m.EXPECT().Revenue(gomock.Any(), int64(2), int64(1), int64(1), decimal.NewFromFloat32(100).Mul(decimal.NewFromInt(100)), &description).Return(nil)
+		m.EXPECT().Revenue(gomock.Any(), int64(2), int64(1), int64(1), money.New(10000, money.RUB), &description).Return(nil)
 
-		arg := bytes.NewBuffer([]byte(`{"user_id":2, "service_id":1, "order_id":1, "sum":100.00}`))
+		arg := bytes.NewBuffer([]byte(`{"user_id":2, "service_id":1, "order_id":1, "sum":"100.00"}`))
 		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/revenue", arg)
 		w := httptest.NewRecorder()
 
@@ -76,7 +76,7 @@ func TestRevenueRecognition(t *testing.T) {
 			body, err := ioutil.ReadAll(w.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "wrong value of \"UserId\"\n", string(body))
+			assert.Equal(t, "wrong value of \"user_id\"", errorMessage(t, body))
 		})
 
 		t.Run("wrong service_id", func(t *testing.T) {
@@ -99,7 +99,7 @@ func TestRevenueRecognition(t *testing.T) {
 			body, err := ioutil.ReadAll(w.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "wrong value of \"ServiceId\"\n", string(body))
+			assert.Equal(t, "wrong value of \"service_id\"", errorMessage(t, body))
 		})
 
 		t.Run("wrong order_id", func(t *testing.T) {
@@ -122,7 +122,7 @@ func TestRevenueRecognition(t *testing.T) {
 			body, err := ioutil.ReadAll(w.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "wrong value of \"OrderId\"\n", string(body))
+			assert.Equal(t, "wrong value of \"order_id\"", errorMessage(t, body))
 		})
 	})
 
@@ -147,7 +147,7 @@ func TestRevenueRecognition(t *testing.T) {
 			body, err := ioutil.ReadAll(w.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "wrong value of \"Price\"\n", string(body))
+			assert.Equal(t, "wrong value of \"sum\"", errorMessage(t, body))
 		})
 
 		t.Run("price less than or equal to zero", func(t *testing.T) {
@@ -170,7 +170,7 @@ func TestRevenueRecognition(t *testing.T) {
 			body, err := ioutil.ReadAll(w.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "wrong value of \"Price\"\n", string(body))
+			assert.Equal(t, "wrong value of \"sum\"", errorMessage(t, body))
 		})
 
 		t.Run("revenue recognition errors", func(t *testing.T) {
@@ -178,12 +178,12 @@ func TestRevenueRecognition(t *testing.T) {
 				ctrl := gomock.NewController(t)
 				defer ctrl.Finish()
 
-				description := "Order number 1; Transferring money for the service 1 from a reserve account to a company account in the sum 100.000000"
+				description := "Order number 1; Transferring money for the service 1 from a reserve account to a company account in the sum 100.00"
 
 				err := storage.ErrSerialization
 
 				m := NewMockStorager(ctrl)
-				m.EXPECT().Revenue(gomock.Any(), int64(2), int64(1), int64(1), decimal.NewFromFloat32(100).Mul(decimal.NewFromInt(100)), &description).Return(err)
+				m.EXPECT().Revenue(gomock.Any(), int64(2), int64(1), int64(1), money.New(10000, money.RUB), &description).Return(err)
 
 				arg := bytes.NewBuffer([]byte(`{"user_id":2, "service_id":1, "order_id":1, "sum":100.00}`))
 				req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/revenue", arg)
@@ -199,17 +199,17 @@ func TestRevenueRecognition(t *testing.T) {
 				body, err := ioutil.ReadAll(resp.Body)
 				assert.NoError(t, err)
 
-				assert.Equal(t, "error updating balance\n", string(body))
+				assert.Equal(t, "the operation conflicted with a concurrent one, retry it", errorMessage(t, body))
 			})
 
 			t.Run("not enough money in the account", func(t *testing.T) {
 				ctrl := gomock.NewController(t)
 				defer ctrl.Finish()
 
-				description := "Order number 1; Transferring money for the service 1 from a reserve account to a company account in the sum 100.000000"
+				description := "Order number 1; Transferring money for the service 1 from a reserve account to a company account in the sum 100.00"
 
 				m := NewMockStorager(ctrl)
-				m.EXPECT().Revenue(gomock.Any(), int64(2), int64(1), int64(1), decimal.NewFromFloat32(100).Mul(decimal.NewFromInt(100)), &description).Return(storage.ErrTransfer)
+				m.EXPECT().Revenue(gomock.Any(), int64(2), int64(1), int64(1), money.New(10000, money.RUB), &description).Return(storage.ErrTransfer)
 				arg := bytes.NewBuffer([]byte(`{"user_id":2, "service_id":1, "order_id":1, "sum":100.00}`))
 				req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/revenue", arg)
 				w := httptest.NewRecorder()
@@ -224,17 +224,17 @@ func TestRevenueRecognition(t *testing.T) {
 				body, err := ioutil.ReadAll(resp.Body)
 				assert.NoError(t, err)
 
-				assert.Equal(t, "not enough money in the account\n", string(body))
+				assert.Equal(t, "not enough money in the account", errorMessage(t, body))
 			})
 
 			t.Run("sender does not exist", func(t *testing.T) {
 				ctrl := gomock.NewController(t)
 				defer ctrl.Finish()
 
-				description := "Order number 1; Transferring money for the service 1 from a reserve account to a company account in the sum 100.000000"
+				description := "Order number 1; Transferring money for the service 1 from a reserve account to a company account in the sum 100.00"
 
 				m := NewMockStorager(ctrl)
-				m.EXPECT().Revenue(gomock.Any(), int64(2), int64(1), int64(1), decimal.NewFromFloat32(100).Mul(decimal.NewFromInt(100)), &description).Return(storage.ErrUserAvailability)
+				m.EXPECT().Revenue(gomock.Any(), int64(2), int64(1), int64(1), money.New(10000, money.RUB), &description).Return(storage.ErrUserAvailability)
 				arg := bytes.NewBuffer([]byte(`{"user_id":2, "service_id":1, "order_id":1, "sum":100.00}`))
 				req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/revenue", arg)
 				w := httptest.NewRecorder()
@@ -249,17 +249,17 @@ func TestRevenueRecognition(t *testing.T) {
 				body, err := ioutil.ReadAll(resp.Body)
 				assert.NoError(t, err)
 
-				assert.Equal(t, "sender does not exist\n", string(body))
+				assert.Equal(t, "user does not exist", errorMessage(t, body))
 			})
 
 			t.Run("order does not exist", func(t *testing.T) {
 				ctrl := gomock.NewController(t)
 				defer ctrl.Finish()
 
-				description := "Order number 1; Transferring money for the service 1 from a reserve account to a company account in the sum 100.000000"
+				description := "Order number 1; Transferring money for the service 1 from a reserve account to a company account in the sum 100.00"
 
 				m := NewMockStorager(ctrl)
-				m.EXPECT().Revenue(gomock.Any(), int64(2), int64(1), int64(1), decimal.NewFromFloat32(100).Mul(decimal.NewFromInt(100)), &description).Return(storage.ErrReserveExist)
+				m.EXPECT().Revenue(gomock.Any(), int64(2), int64(1), int64(1), money.New(10000, money.RUB), &description).Return(storage.ErrReserveExist)
 				arg := bytes.NewBuffer([]byte(`{"user_id":2, "service_id":1, "order_id":1, "sum":100.00}`))
 				req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/revenue", arg)
 				w := httptest.NewRecorder()
@@ -274,17 +274,17 @@ func TestRevenueRecognition(t *testing.T) {
 				body, err := ioutil.ReadAll(resp.Body)
 				assert.NoError(t, err)
 
-				assert.Equal(t, "thе order does not exist\n", string(body))
+				assert.Equal(t, "the reserve order does not exist", errorMessage(t, body))
 			})
 
 			t.Run("amount on the deferred expenses error", func(t *testing.T) {
 				ctrl := gomock.NewController(t)
 				defer ctrl.Finish()
 
-				description := "Order number 1; Transferring money for the service 1 from a reserve account to a company account in the sum 100.000000"
+				description := "Order number 1; Transferring money for the service 1 from a reserve account to a company account in the sum 100.00"
 
 				m := NewMockStorager(ctrl)
-				m.EXPECT().Revenue(gomock.Any(), int64(2), int64(1), int64(1), decimal.NewFromFloat32(100).Mul(decimal.NewFromInt(100)), &description).Return(storage.ErrRevenue)
+				m.EXPECT().Revenue(gomock.Any(), int64(2), int64(1), int64(1), money.New(10000, money.RUB), &description).Return(storage.ErrRevenue)
 				arg := bytes.NewBuffer([]byte(`{"user_id":2, "service_id":1, "order_id":1, "sum":100.00}`))
 				req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/revenue", arg)
 				w := httptest.NewRecorder()
@@ -299,17 +299,17 @@ func TestRevenueRecognition(t *testing.T) {
 				body, err := ioutil.ReadAll(resp.Body)
 				assert.NoError(t, err)
 
-				assert.Equal(t, "the sum greater then amount on the deferred expenses\n", string(body))
+				assert.Equal(t, "the sum is greater than the reserved amount", errorMessage(t, body))
 			})
 
 			t.Run("unreserve or consolidated report record error", func(t *testing.T) {
 				ctrl := gomock.NewController(t)
 				defer ctrl.Finish()
 
-				description := "Order number 1; Transferring money for the service 1 from a reserve account to a company account in the sum 100.000000"
+				description := "Order number 1; Transferring money for the service 1 from a reserve account to a company account in the sum 100.00"
 
 				m := NewMockStorager(ctrl)
-				m.EXPECT().Revenue(gomock.Any(), int64(2), int64(1), int64(1), decimal.NewFromFloat32(100).Mul(decimal.NewFromInt(100)), &description).Return(storage.ErrRecordExist)
+				m.EXPECT().Revenue(gomock.Any(), int64(2), int64(1), int64(1), money.New(10000, money.RUB), &description).Return(storage.ErrRecordExist)
 				arg := bytes.NewBuffer([]byte(`{"user_id":2, "service_id":1, "order_id":1, "sum":100.00}`))
 				req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/revenue", arg)
 				w := httptest.NewRecorder()
@@ -324,17 +324,17 @@ func TestRevenueRecognition(t *testing.T) {
 				body, err := ioutil.ReadAll(resp.Body)
 				assert.NoError(t, err)
 
-				assert.Equal(t, "unreserve or consolidated report record already exists\n", string(body))
+				assert.Equal(t, "unreserve or consolidated report record already exists", errorMessage(t, body))
 			})
 
 			t.Run("revenue recognition error", func(t *testing.T) {
 				ctrl := gomock.NewController(t)
 				defer ctrl.Finish()
 
-				description := "Order number 1; Transferring money for the service 1 from a reserve account to a company account in the sum 100.000000"
+				description := "Order number 1; Transferring money for the service 1 from a reserve account to a company account in the sum 100.00"
 
 				m := NewMockStorager(ctrl)
-				m.EXPECT().Revenue(gomock.Any(), int64(2), int64(1), int64(1), decimal.NewFromFloat32(100).Mul(decimal.NewFromInt(100)), &description).Return(errors.New(""))
+				m.EXPECT().Revenue(gomock.Any(), int64(2), int64(1), int64(1), money.New(10000, money.RUB), &description).Return(errors.New(""))
 				arg := bytes.NewBuffer([]byte(`{"user_id":2, "service_id":1, "order_id":1, "sum":100.00}`))
 				req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/revenue", arg)
 				w := httptest.NewRecorder()
@@ -349,7 +349,7 @@ func TestRevenueRecognition(t *testing.T) {
 				body, err := ioutil.ReadAll(resp.Body)
 				assert.NoError(t, err)
 
-				assert.Equal(t, "recognition error\n", string(body))
+				assert.Equal(t, "recognition error", errorMessage(t, body))
 			})
 		})
 	})
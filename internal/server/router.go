@@ -0,0 +1,187 @@
+package server
+
+import (
+	"http-avito-test/internal/auth"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// versionedPrefix is the prefix of the operation paths in api/schema.yaml
+const versionedPrefix = "/api/{version}"
+
+// APIVersions holds the versions of the API served under /api/{version}
+var APIVersions = []int64{1}
+
+// Router serves the operations of api/schema.yaml under /api/{version}/ and, when enabled,
+// under the paths used before the API was versioned.
+// A known path requested with another method gets 405 with the Allow header, an unknown path gets 404
+type Router struct {
+	versions map[int64]bool
+	// routes maps the path to the handlers of its methods
+	routes map[string]map[string]http.HandlerFunc
+	legacy map[string]map[string]http.HandlerFunc
+	// writeError writes 404 and 405, the plain text errors are written when it is not set
+	writeError func(w http.ResponseWriter, r *http.Request, e apiError)
+}
+
+func NewRouter(versions ...int64) *Router {
+	rt := &Router{
+		versions: make(map[int64]bool),
+		routes:   make(map[string]map[string]http.HandlerFunc),
+		legacy:   make(map[string]map[string]http.HandlerFunc),
+	}
+	for _, v := range versions {
+		rt.versions[v] = true
+	}
+	return rt
+}
+
+// Handle registers the handler of the operation path like "/readuser" served as /api/{version}/readuser
+func (rt *Router) Handle(method, path string, handler http.HandlerFunc) {
+	addRoute(rt.routes, method, path, handler)
+}
+
+// HandleLegacy registers the handler of the unversioned path like "/read"
+func (rt *Router) HandleLegacy(method, path string, handler http.HandlerFunc) {
+	addRoute(rt.legacy, method, path, handler)
+}
+
+func addRoute(routes map[string]map[string]http.HandlerFunc, method, path string, handler http.HandlerFunc) {
+	if routes[path] == nil {
+		routes[path] = make(map[string]http.HandlerFunc)
+	}
+	routes[path][method] = handler
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	methods, route, ok := rt.match(r.URL.Path)
+
+	start := time.Now()
+	ctx, span := startRequest(r, route)
+	r = r.WithContext(ctx)
+
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	defer func() {
+		observeRequest(route, r.Method, rec.status, start)
+		endRequest(span, rec.status)
+	}()
+	w = rec
+
+	if !ok {
+		rt.fail(w, r, errNotFound)
+		return
+	}
+
+	handler, ok := methods[r.Method]
+	if !ok {
+		allowed := make([]string, 0, len(methods))
+		for m := range methods {
+			allowed = append(allowed, m)
+		}
+		sort.Strings(allowed)
+
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		rt.fail(w, r, errMethodNotAllowed)
+		return
+	}
+
+	handler(w, r)
+}
+
+func (rt *Router) fail(w http.ResponseWriter, r *http.Request, e apiError) {
+	if rt.writeError != nil {
+		rt.writeError(w, r, e)
+		return
+	}
+	http.Error(w, e.message, e.status)
+}
+
+// match finds the methods of the path and the route label of the metrics, the versioned path must have
+// a served version
+func (rt *Router) match(path string) (map[string]http.HandlerFunc, string, bool) {
+	if rest := strings.TrimPrefix(path, "/api/"); rest != path {
+		i := strings.IndexByte(rest, '/')
+		if i < 0 {
+			return nil, unmatchedRoute, false
+		}
+
+		version, err := strconv.ParseInt(rest[:i], 10, 64)
+		if err != nil || !rt.versions[version] {
+			return nil, unmatchedRoute, false
+		}
+
+		methods, ok := rt.routes[rest[i:]]
+		if !ok {
+			return nil, unmatchedRoute, false
+		}
+		return methods, versionedPrefix + rest[i:], true
+	}
+
+	methods, ok := rt.legacy[path]
+	if !ok {
+		return nil, unmatchedRoute, false
+	}
+	return methods, path, true
+}
+
+// Router returns the router of the handler operations, legacy enables the unversioned paths
+func (h *Handler) Router(legacy bool) *Router {
+	var routes = []struct {
+		path       string
+		legacyPath string
+		scope      auth.Scope
+		handler    http.HandlerFunc
+	}{
+		{"/readuser", "/read", auth.ScopeBalanceRead, h.ReadUser},
+		{"/readusers", "/readbatch", auth.ScopeBalanceRead, h.ReadUsers},
+		{"/accountdeposit", "/deposit", auth.ScopeFundsDeposit, h.AccountDeposit},
+		{"/depositcallback", "/deposit/callback", noScope, h.DepositCallback},
+		{"/transfercommand", "/transf", auth.ScopeFundsTransfer, h.TransferCommand},
+		{"/canceltransfer", "/transf/cancel", auth.ScopeFundsTransfer, h.CancelTransfer},
+		{"/readuserhistory", "/history", auth.ScopeBalanceRead, h.ReadUserHistory},
+		{"/createpaymentrequest", "/payreq", auth.ScopeFundsTransfer, h.CreatePaymentRequest},
+		{"/incomingpaymentrequests", "/payreq/incoming", auth.ScopeBalanceRead, h.ListIncomingPaymentRequests},
+		{"/outgoingpaymentrequests", "/payreq/outgoing", auth.ScopeBalanceRead, h.ListOutgoingPaymentRequests},
+		{"/acceptpaymentrequest", "/payreq/accept", auth.ScopeFundsTransfer, h.AcceptPaymentRequest},
+		{"/declinepaymentrequest", "/payreq/decline", auth.ScopeFundsTransfer, h.DeclinePaymentRequest},
+		{"/createescrow", "/escrow", auth.ScopeFundsTransfer, h.CreateEscrow},
+		{"/releaseescrow", "/escrow/release", auth.ScopeFundsTransfer, h.ReleaseEscrow},
+		{"/refundescrow", "/escrow/refund", auth.ScopeFundsTransfer, h.RefundEscrow},
+		{"/splitescrow", "/escrow/split", auth.ScopeFundsTransfer, h.SplitEscrow},
+		{"/readescrow", "/escrow/status", auth.ScopeBalanceRead, h.ReadEscrow},
+		{"/grantbonus", "/bonus", auth.ScopeAdmin, h.GrantBonus},
+		{"/readbalancebuckets", "/read/buckets", auth.ScopeBalanceRead, h.ReadBalanceBuckets},
+		{"/generatevouchers", "/voucher/generate", auth.ScopeAdmin, h.GenerateVouchers},
+		{"/redeemvoucher", "/voucher/redeem", auth.ScopeFundsDeposit, h.RedeemVoucher},
+		{"/accountwithdrawal", "/withdrawal", auth.ScopeFundsWithdraw, h.AccountWithdrawal},
+		{"/listwithdrawalrequests", "/admin/withdrawals", auth.ScopeAdmin, h.ListWithdrawalRequests},
+		{"/approvewithdrawal", "/admin/withdrawals/approve", auth.ScopeAdmin, h.ApproveWithdrawal},
+		{"/rejectwithdrawal", "/admin/withdrawals/reject", auth.ScopeAdmin, h.RejectWithdrawal},
+		{"/listfraudflags", "/admin/fraud/flags", auth.ScopeAdmin, h.ListFraudFlags},
+		{"/importstatement", "/reconcile/import", auth.ScopeAdmin, h.ImportStatement},
+		{"/readreconciliation", "/reconcile/report", auth.ScopeReportsRead, h.ReadReconciliation},
+		{"/resolvereconciliation", "/reconcile/resolve", auth.ScopeAdmin, h.ResolveReconciliation},
+		{"/reservationoffunds", "/reserve", auth.ScopeFundsReserve, h.ReservationOfFunds},
+		{"/revenuerecognition", "/revenue", auth.ScopeFundsReserve, h.RevenueRecognition},
+		{"/unreservationoffunds", "/unreserve", auth.ScopeFundsReserve, h.UnreservationOfFunds},
+		{"/monthlyreport", "/report", auth.ScopeReportsRead, h.MonthlyReport},
+	}
+
+	rt := NewRouter(APIVersions...)
+	rt.writeError = h.writeError
+	for _, route := range routes {
+		handler := h.addressLimited(
+			h.authenticated(route.scope,
+				h.validated(http.MethodPost, versionedPrefix+route.path,
+					h.rateLimited(route.path, route.handler))))
+
+		rt.Handle(http.MethodPost, route.path, handler)
+		if legacy {
+			rt.HandleLegacy(http.MethodPost, route.legacyPath, handler)
+		}
+	}
+	return rt
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"bytes"
+	"http-avito-test/internal/money"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouter(t *testing.T) {
+	t.Run("versioned path", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().Deposit(gomock.Any(), int64(2), money.New(10000, money.RUB)).Return(nil)
+
+		arg := bytes.NewBuffer([]byte(`{"User_id":2, "Amount":"100.00"}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/api/1/accountdeposit", arg)
+		w := httptest.NewRecorder()
+
+		h := Handler{
+			Store: m,
+		}
+
+		h.Router(false).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	})
+
+	t.Run("legacy path", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().Deposit(gomock.Any(), int64(2), money.New(10000, money.RUB)).Return(nil)
+
+		arg := bytes.NewBuffer([]byte(`{"User_id":2, "Amount":"100.00"}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/deposit", arg)
+		w := httptest.NewRecorder()
+
+		h := Handler{
+			Store: m,
+		}
+
+		h.Router(true).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	})
+
+	t.Run("legacy paths disabled", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		h := Handler{
+			Store: NewMockStorager(ctrl),
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/deposit", nil)
+		w := httptest.NewRecorder()
+
+		h.Router(false).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		h := Handler{
+			Store: NewMockStorager(ctrl),
+		}
+
+		for _, path := range []string{"/api/1/accountdeposit", "/deposit"} {
+			req := httptest.NewRequest(http.MethodGet, "http://localhost:9090"+path, nil)
+			w := httptest.NewRecorder()
+
+			h.Router(true).ServeHTTP(w, req)
+
+			resp := w.Result()
+			assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode, path)
+			assert.Equal(t, http.MethodPost, resp.Header.Get("Allow"), path)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		h := Handler{
+			Store: NewMockStorager(ctrl),
+		}
+
+		for _, path := range []string{"/api/2/accountdeposit", "/api/v1/accountdeposit", "/api/1/unknown", "/api/1", "/unknown"} {
+			req := httptest.NewRequest(http.MethodPost, "http://localhost:9090"+path, nil)
+			w := httptest.NewRecorder()
+
+			h.Router(true).ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusNotFound, w.Result().StatusCode, path)
+		}
+	})
+}
@@ -4,6 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"http-avito-test/api"
+	"http-avito-test/internal/money"
+	"http-avito-test/internal/openapi"
+	"http-avito-test/internal/payment"
 	"http-avito-test/internal/storage"
 	"net/http"
 	"os"
@@ -22,8 +26,33 @@ type Server struct {
 }
 
 type ServerConfig struct {
-	Host string `env:"ADDR_HOST"`
-	Port int    `env:"ADDR_PORT"`
+	Host              string        `env:"ADDR_HOST"`
+	Port              int           `env:"ADDR_PORT"`
+	PaymentRequestTTL time.Duration `env:"PAYMENT_REQUEST_TTL" envDefault:"72h"`
+	PaymentProvider   string        `env:"PAYMENT_PROVIDER"`
+	PaymentSecret     string        `env:"PAYMENT_PROVIDER_SECRET"`
+	ReconcileWindow   time.Duration `env:"RECONCILE_DATE_WINDOW" envDefault:"72h"`
+
+	WithdrawalApprovalThreshold money.Money `env:"WITHDRAWAL_APPROVAL_THRESHOLD"`
+	MaxBodySize                 int64       `env:"MAX_BODY_SIZE" envDefault:"1048576"`
+	// LegacyRoutes keeps serving the unversioned paths like /read next to /api/{version}/readuser
+	LegacyRoutes bool `env:"LEGACY_ROUTES" envDefault:"true"`
+	// RequireAPIKeys can be disabled for local development only
+	RequireAPIKeys bool `env:"REQUIRE_API_KEYS" envDefault:"true"`
+	// RateLimitClient and RateLimitAccount are the limits like "default=100/1s,accountdeposit=5/1m:10"
+	// by the API client and by the account of the operation, the empty list disables the limits
+	RateLimitClient  RateLimits `env:"RATE_LIMIT_CLIENT"`
+	RateLimitAccount RateLimits `env:"RATE_LIMIT_ACCOUNT"`
+	// RateLimitAddress limits the requests of a remote address before their API keys are checked,
+	// the limit is disabled unless it is set, since the clients behind a proxy share its address
+	RateLimitAddress Limit `env:"RATE_LIMIT_ADDRESS"`
+	// RateLimitShared keeps the token buckets in Postgres, so the limits are shared by the server instances
+	RateLimitShared bool `env:"RATE_LIMIT_SHARED" envDefault:"false"`
+	// MetricsEnabled serves the Prometheus metrics at /metrics without the API key, the scrapes should be
+	// limited to the internal network
+	MetricsEnabled bool `env:"METRICS_ENABLED" envDefault:"true"`
+	// ReportDir keeps the monthly reports, it is created at the start and served by the file server
+	ReportDir string `env:"REPORT_DIR" envDefault:"../../file_storage"`
 }
 
 func New(logger *zap.Logger, storage *storage.Storage, afterShutdown func(), e Exchanger) (*Server, error) {
@@ -38,26 +67,65 @@ func New(logger *zap.Logger, storage *storage.Storage, afterShutdown func(), e E
 
 	defer logger.Sync()
 
-	mux := http.NewServeMux()
+	var payments PaymentProvider
+	switch cfg.PaymentProvider {
+	case "":
+	case payment.FakeProviderName:
+		payments = payment.NewFake(cfg.PaymentSecret)
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", cfg.PaymentProvider)
+	}
+
+	// the callbacks are not authenticated by the API keys,
+	// the signature is the only proof that they come from the provider
+	if payments != nil && cfg.PaymentSecret == "" {
+		return nil, fmt.Errorf("payment provider %q requires PAYMENT_PROVIDER_SECRET", cfg.PaymentProvider)
+	}
+
+	if err := os.MkdirAll(cfg.ReportDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create the report directory: %w", err)
+	}
+
+	spec, err := openapi.Load(api.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the API spec: %w", err)
+	}
+
+	var limiter Limiter
+	if len(cfg.RateLimitClient) > 0 || len(cfg.RateLimitAccount) > 0 || cfg.RateLimitAddress.Rate > 0 {
+		if cfg.RateLimitShared {
+			limiter = storage
+		} else {
+			limiter = NewMemoryLimiter()
+		}
+	}
 
 	h := Handler{
-		Logger:    logger,
-		Store:     storage,
-		Exchanger: e,
+		Logger:            logger,
+		Store:             MeasureStorage(storage),
+		Exchanger:         MeasureExchanger(e),
+		Payments:          payments,
+		PaymentRequestTTL: cfg.PaymentRequestTTL,
+		ReconcileWindow:   cfg.ReconcileWindow,
+
+		WithdrawalApprovalThreshold: cfg.WithdrawalApprovalThreshold,
+		Spec:                        spec,
+		MaxBodySize:                 cfg.MaxBodySize,
+		RequireAPIKeys:              cfg.RequireAPIKeys,
+		Limiter:                     limiter,
+		ClientLimits:                cfg.RateLimitClient,
+		AccountLimits:               cfg.RateLimitAccount,
+		AddressLimit:                cfg.RateLimitAddress,
+		ReportDir:                   cfg.ReportDir,
 	}
 
-	mux.HandleFunc("/read", h.ReadUser)
-	mux.HandleFunc("/deposit", h.AccountDeposit)
-	mux.HandleFunc("/transf", h.TransferCommand)
-	mux.HandleFunc("/history", h.ReadUserHistory)
-	mux.HandleFunc("/withdrawal", h.AccountWithdrawal)
-	mux.HandleFunc("/reserve", h.ReservationOfFunds)
-	mux.HandleFunc("/revenue", h.RevenueRecognition)
-	mux.HandleFunc("/unreserve", h.UnreservationOfFunds)
-	mux.HandleFunc("/report", h.MonthlyReport)
+	var handler http.Handler = WithRequestID(h.Router(cfg.LegacyRoutes))
+	if cfg.MetricsEnabled {
+		handler = WithMetrics(handler)
+	}
 
 	httpServer := http.Server{
-		Handler:      mux,
+		Handler:      handler,
 		Addr:         fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
 		ReadTimeout:  5 * time.Second,
 		WriteTimeout: 10 * time.Second,
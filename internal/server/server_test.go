@@ -0,0 +1,26 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestNewRequiresPaymentSecret(t *testing.T) {
+	t.Setenv("PAYMENT_PROVIDER", "fake")
+	t.Setenv("PAYMENT_PROVIDER_SECRET", "")
+
+	_, err := New(zap.NewNop(), nil, nil, nil)
+	assert.EqualError(t, err, `payment provider "fake" requires PAYMENT_PROVIDER_SECRET`)
+}
+
+func TestNewCreatesReportDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "reports")
+	t.Setenv("REPORT_DIR", dir)
+
+	_, err := New(zap.NewNop(), nil, nil, nil)
+	assert.NoError(t, err)
+	assert.DirExists(t, dir)
+}
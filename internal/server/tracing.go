@@ -0,0 +1,46 @@
+package server
+
+import (
+	"context"
+	"http-avito-test/internal/requestid"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("http-avito-test/internal/server")
+
+// propagator reads the W3C traceparent and tracestate headers of the incoming requests, the context is not
+// propagated by the global propagator, so it is not sent to the third-party services
+var propagator = propagation.TraceContext{}
+
+// startRequest starts the server span of the request, the child of the span of the caller sent in
+// the W3C traceparent header. The span is named by the route, so the paths of the same operation share the name
+func startRequest(r *http.Request, route string) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String("http.method", r.Method),
+		attribute.String("http.route", route),
+		attribute.String("http.target", r.URL.Path),
+	}
+	if id, ok := requestid.FromContext(r.Context()); ok {
+		attrs = append(attrs, attribute.String("request.id", id))
+	}
+
+	ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	return tracer.Start(ctx, methodLabel(r.Method)+" "+route,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attrs...))
+}
+
+// endRequest ends the span of the request, the server errors fail the span
+func endRequest(span trace.Span, status int) {
+	span.SetAttributes(attribute.Int("http.status_code", status))
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+	span.End()
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"http-avito-test/internal/money"
+	"http-avito-test/internal/storage"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestRequestTracing(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.NeverSample())),
+		sdktrace.WithSpanProcessor(rec),
+	)
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := NewMockStorager(ctrl)
+	m.EXPECT().Deposit(gomock.Any(), int64(2), money.New(10000, money.RUB)).DoAndReturn(
+		func(ctx context.Context, _ int64, _ money.Money) error {
+			// the storage gets the span of the operation
+			assert.True(t, trace.SpanContextFromContext(ctx).IsValid())
+			return storage.ErrNoUser
+		})
+
+	arg := bytes.NewBuffer([]byte(`{"User_id":2, "Amount":"100.00"}`))
+	req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/api/1/accountdeposit", arg)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+
+	h := Handler{
+		Store: MeasureStorage(m),
+	}
+	WithRequestID(h.Router(false)).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	require.NoError(t, provider.Shutdown(context.Background()))
+	spans := rec.Ended()
+	require.Len(t, spans, 2)
+
+	operation, request := spans[0], spans[1]
+
+	assert.Equal(t, "POST /api/{version}/accountdeposit", request.Name())
+	assert.Equal(t, trace.SpanKindServer, request.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", request.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", request.Parent().SpanID().String())
+	assert.Contains(t, request.Attributes(), attribute.Int("http.status_code", http.StatusNotFound))
+	assert.Contains(t, request.Attributes(), attribute.String("request.id", w.Header().Get(RequestIDHeader)))
+	assert.Equal(t, codes.Unset, request.Status().Code)
+
+	assert.Equal(t, "Storage.Deposit", operation.Name())
+	assert.Equal(t, request.SpanContext().TraceID(), operation.SpanContext().TraceID())
+	assert.Equal(t, request.SpanContext().SpanID(), operation.Parent().SpanID())
+	assert.Contains(t, operation.Attributes(), attribute.String("error.name", "ErrNoUser"))
+	assert.Equal(t, sdktrace.Status{Code: codes.Error, Description: storage.ErrNoUser.Error()}, operation.Status())
+}
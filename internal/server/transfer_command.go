@@ -2,43 +2,47 @@ package server
 
 import (
 	"encoding/json"
-	"errors"
 	"http-avito-test/internal/generated"
 	"http-avito-test/internal/storage"
 	"io/ioutil"
 	"net/http"
+	"time"
 
-	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
+const DelayedTransferMessage = "transfer will be settled after the undo window"
+
+// maxUndoWindow is the longest undo window of a delayed transfer in minutes
+const maxUndoWindow = 24 * 60
+
 func (h *Handler) TransferCommand(w http.ResponseWriter, r *http.Request) {
 	var hand *generated.TransferCommandRequest
 
 	body, _ := ioutil.ReadAll(r.Body)
 	err := json.Unmarshal(body, &hand)
 	if err != nil {
-		http.Error(w, "malformed request body", http.StatusBadRequest)
+		h.malformedBody(w, r)
 		return
 	}
 
 	switch {
-	case hand.Sender <= 1:
-		http.Error(w, "wrong value of \"Sender\"", http.StatusBadRequest)
+	case hand.Sender <= 0:
+		h.invalidField(w, r, "sender")
 		return
-	case hand.Recipient <= 1:
-		http.Error(w, "wrong value of \"Recipient\"", http.StatusBadRequest)
+	case hand.Recipient <= 0:
+		h.invalidField(w, r, "recipient")
 		return
 	}
 
-	var newBalance = decimal.NewFromFloat32(hand.Amount).Mul(decimal.NewFromInt(100))
-
-	switch {
-	case newBalance.Exponent() < -2:
-		http.Error(w, "wrong value of \"Amount\"", http.StatusBadRequest)
+	newBalance, err := parseAmount(w, hand.Amount)
+	if err != nil || !newBalance.IsPositive() {
+		h.invalidField(w, r, "amount")
 		return
-	case newBalance.LessThanOrEqual(decimal.NewFromInt(int64(0))):
-		http.Error(w, "wrong value of \"Amount\"", http.StatusBadRequest)
+	}
+
+	if hand.UndoWindow != nil && (*hand.UndoWindow <= 0 || *hand.UndoWindow > maxUndoWindow) {
+		h.invalidField(w, r, "undo_window")
 		return
 	}
 
@@ -46,21 +50,29 @@ func (h *Handler) TransferCommand(w http.ResponseWriter, r *http.Request) {
 		hand.Description = nil
 	}
 
-	_, _, err = h.Store.Transfer(r.Context(), hand.Sender, hand.Recipient, newBalance, hand.Description)
+	var quote storage.Quote
+	var transferID int64
+	switch {
+	case isDryRun(hand.DryRun):
+		quote, err = h.Store.QuoteTransfer(r.Context(), hand.Sender, hand.Recipient, newBalance, hand.Description)
+	case hand.UndoWindow != nil:
+		settleAt := time.Now().Add(time.Duration(*hand.UndoWindow) * time.Minute)
+		transferID, err = h.Store.DelayedTransfer(r.Context(), hand.Sender, hand.Recipient, newBalance, hand.Description, settleAt)
+	default:
+		_, _, err = h.Store.Transfer(r.Context(), hand.Sender, hand.Recipient, newBalance, hand.Description)
+	}
 	if err != nil {
-		if errors.Is(err, storage.ErrSerialization) {
-			http.Error(w, "error updating balance", http.StatusInternalServerError)
-			return
-		}
-		if errors.Is(err, storage.ErrTransfer) {
-			http.Error(w, "not enough money in the account", http.StatusBadRequest)
-			return
-		}
-		if errors.Is(err, storage.ErrUserAvailability) {
-			http.Error(w, "sender does not exist", http.StatusBadRequest)
-			return
-		}
-		http.Error(w, "error updating balance", http.StatusInternalServerError)
+		h.writeStorageError(w, r, err, "error updating balance")
+		return
+	}
+
+	if isDryRun(hand.DryRun) {
+		h.writeQuote(w, r, quote)
+		return
+	}
+
+	if hand.UndoWindow != nil {
+		h.writeDelayedTransfer(w, r, transferID)
 		return
 	}
 
@@ -75,14 +87,40 @@ func (h *Handler) TransferCommand(w http.ResponseWriter, r *http.Request) {
 
 	marshalledRequest, err := json.Marshal(result)
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 	_, writeErr := w.Write(marshalledRequest)
 	if err != nil {
-		h.Logger.Error("failed to write connection", zap.Error(writeErr))
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
+		return
+	}
+}
+
+func (h *Handler) writeDelayedTransfer(w http.ResponseWriter, r *http.Request, transferID int64) {
+	result := generated.DelayedTransferResponse{
+		Result: struct {
+			Message    string "json:\"message\""
+			TransferId int64  "json:\"transfer_id\""
+		}{
+			Message:    DelayedTransferMessage,
+			TransferId: transferID,
+		},
+		Status: "ok",
+	}
+
+	marshalledRequest, err := json.Marshal(result)
+	if err != nil {
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, writeErr := w.Write(marshalledRequest)
+	if writeErr != nil {
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
 		return
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"http-avito-test/internal/generated"
+	"http-avito-test/internal/money"
 	"http-avito-test/internal/storage"
 	"io/ioutil"
 	"net/http"
@@ -12,7 +13,6 @@ import (
 	"testing"
 
 	"github.com/golang/mock/gomock"
-	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -33,9 +33,9 @@ func TestTransferCommand(t *testing.T) {
 		description := "test"
 
 		m := NewMockStorager(ctrl)
-		m.EXPECT().Transfer(gomock.Any(), int64(2), int64(3), decimal.NewFromFloat32(100).Mul(decimal.NewFromInt(100)), &description).Return(int64(2), int64(2), nil)
+		m.EXPECT().Transfer(gomock.Any(), int64(2), int64(3), money.New(10000, money.RUB), &description).Return(int64(2), int64(2), nil)
 
-		arg := bytes.NewBuffer([]byte(`{"Sender":2, "Recipient":3, "Amount":100.00, "Description":"test"}`))
+		arg := bytes.NewBuffer([]byte(`{"Sender":2, "Recipient":3, "Amount":"100.00", "Description":"test"}`))
 		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/transf", arg)
 		w := httptest.NewRecorder()
 
@@ -55,6 +55,96 @@ func TestTransferCommand(t *testing.T) {
 		assert.Equal(t, string(js), string(body))
 	})
 
+	t.Run("dry run", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		description := "test"
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().QuoteTransfer(gomock.Any(), int64(2), int64(3), money.New(10000, money.RUB), &description).Return(storage.Quote{
+			AccountID: 2,
+			Balance:   money.New(0, money.RUB),
+		}, nil)
+
+		arg := bytes.NewBuffer([]byte(`{"Sender":2, "Recipient":3, "Amount":100.00, "Description":"test", "dry_run":true}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/transf", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.TransferCommand(w, req)
+
+		resp := w.Result()
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "{\"result\":{\"balance\":\"0\",\"fee\":\"0\",\"user_id\":2},\"status\":\"ok\"}", string(body))
+	})
+
+	t.Run("delayed transfer", func(t *testing.T) {
+		var testTransfer = generated.DelayedTransferResponse{
+			Result: struct {
+				Message    string "json:\"message\""
+				TransferId int64  "json:\"transfer_id\""
+			}{
+				Message:    "transfer will be settled after the undo window",
+				TransferId: 7,
+			},
+			Status: "ok",
+		}
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		description := "test"
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().DelayedTransfer(gomock.Any(), int64(2), int64(3), money.New(10000, money.RUB), &description, gomock.Any()).Return(int64(7), nil)
+
+		arg := bytes.NewBuffer([]byte(`{"Sender":2, "Recipient":3, "Amount":100.00, "Description":"test", "undo_window":10}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/transf", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.TransferCommand(w, req)
+
+		body, err := ioutil.ReadAll(w.Result().Body)
+		assert.NoError(t, err)
+
+		js, err := json.Marshal(testTransfer)
+		assert.NoError(t, err)
+
+		assert.Equal(t, string(js), string(body))
+	})
+
+	t.Run("wrong undo window value", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+
+		arg := bytes.NewBuffer([]byte(`{"Sender":2, "Recipient":3, "Amount":100.00, "Description":"test", "undo_window":0}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/transf", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.TransferCommand(w, req)
+
+		body, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "wrong value of \"undo_window\"", errorMessage(t, body))
+	})
+
 	t.Run("malformed request body", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
@@ -73,7 +163,7 @@ func TestTransferCommand(t *testing.T) {
 		body, err := ioutil.ReadAll(w.Body)
 		assert.NoError(t, err)
 
-		assert.Equal(t, "malformed request body\n", string(body))
+		assert.Equal(t, "malformed request body", errorMessage(t, body))
 	})
 
 	t.Run("wrong incoming values", func(t *testing.T) {
@@ -97,7 +187,7 @@ func TestTransferCommand(t *testing.T) {
 			body, err := ioutil.ReadAll(w.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "wrong value of \"Sender\"\n", string(body))
+			assert.Equal(t, "wrong value of \"sender\"", errorMessage(t, body))
 		})
 
 		t.Run("wrong recipient value", func(t *testing.T) {
@@ -120,7 +210,7 @@ func TestTransferCommand(t *testing.T) {
 			body, err := ioutil.ReadAll(w.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "wrong value of \"Recipient\"\n", string(body))
+			assert.Equal(t, "wrong value of \"recipient\"", errorMessage(t, body))
 		})
 	})
 
@@ -145,7 +235,7 @@ func TestTransferCommand(t *testing.T) {
 			body, err := ioutil.ReadAll(w.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "wrong value of \"Amount\"\n", string(body))
+			assert.Equal(t, "wrong value of \"amount\"", errorMessage(t, body))
 		})
 
 		t.Run("amount less than or equal to zero", func(t *testing.T) {
@@ -168,7 +258,7 @@ func TestTransferCommand(t *testing.T) {
 			body, err := ioutil.ReadAll(w.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "wrong value of \"Amount\"\n", string(body))
+			assert.Equal(t, "wrong value of \"amount\"", errorMessage(t, body))
 		})
 	})
 
@@ -180,7 +270,7 @@ func TestTransferCommand(t *testing.T) {
 			description := "test"
 
 			m := NewMockStorager(ctrl)
-			m.EXPECT().Transfer(gomock.Any(), int64(2), int64(3), decimal.NewFromFloat32(100).Mul(decimal.NewFromInt(100)), &description).Return(int64(0), int64(0), storage.ErrTransfer)
+			m.EXPECT().Transfer(gomock.Any(), int64(2), int64(3), money.New(10000, money.RUB), &description).Return(int64(0), int64(0), storage.ErrTransfer)
 
 			arg := bytes.NewBuffer([]byte(`{"Sender":2, "Recipient":3, "Amount":100.00, "Description":"test"}`))
 			req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/transf", arg)
@@ -196,7 +286,7 @@ func TestTransferCommand(t *testing.T) {
 			body, err := ioutil.ReadAll(resp.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "not enough money in the account\n", string(body))
+			assert.Equal(t, "not enough money in the account", errorMessage(t, body))
 		})
 
 		t.Run("sender does not exist", func(t *testing.T) {
@@ -206,7 +296,7 @@ func TestTransferCommand(t *testing.T) {
 			description := "test"
 
 			m := NewMockStorager(ctrl)
-			m.EXPECT().Transfer(gomock.Any(), int64(1000000), int64(2), decimal.NewFromFloat32(100).Mul(decimal.NewFromInt(100)), &description).Return(int64(0), int64(0), storage.ErrUserAvailability)
+			m.EXPECT().Transfer(gomock.Any(), int64(1000000), int64(2), money.New(10000, money.RUB), &description).Return(int64(0), int64(0), storage.ErrUserAvailability)
 
 			arg := bytes.NewBuffer([]byte(`{"Sender":1000000, "Recipient":2, "Amount":100.00, "Description":"test"}`))
 			req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/transf", arg)
@@ -222,7 +312,7 @@ func TestTransferCommand(t *testing.T) {
 			body, err := ioutil.ReadAll(resp.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "sender does not exist\n", string(body))
+			assert.Equal(t, "user does not exist", errorMessage(t, body))
 		})
 
 		t.Run("error updating balance", func(t *testing.T) {
@@ -232,7 +322,7 @@ func TestTransferCommand(t *testing.T) {
 			description := "test"
 
 			m := NewMockStorager(ctrl)
-			m.EXPECT().Transfer(gomock.Any(), int64(1000000), int64(2), decimal.NewFromFloat32(100).Mul(decimal.NewFromInt(100)), &description).Return(int64(0), int64(0), errors.New(""))
+			m.EXPECT().Transfer(gomock.Any(), int64(1000000), int64(2), money.New(10000, money.RUB), &description).Return(int64(0), int64(0), errors.New(""))
 
 			arg := bytes.NewBuffer([]byte(`{"Sender":1000000, "Recipient":2, "Amount":100.00, "Description":"test"}`))
 			req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/transf", arg)
@@ -248,7 +338,7 @@ func TestTransferCommand(t *testing.T) {
 			body, err := ioutil.ReadAll(resp.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "error updating balance\n", string(body))
+			assert.Equal(t, "error updating balance", errorMessage(t, body))
 		})
 		t.Run("isolation level error", func(t *testing.T) {
 			ctrl := gomock.NewController(t)
@@ -259,7 +349,7 @@ func TestTransferCommand(t *testing.T) {
 			err := storage.ErrSerialization
 
 			m := NewMockStorager(ctrl)
-			m.EXPECT().Transfer(gomock.Any(), int64(1000000), int64(2), decimal.NewFromFloat32(100).Mul(decimal.NewFromInt(100)), &description).Return(int64(0), int64(0), err)
+			m.EXPECT().Transfer(gomock.Any(), int64(1000000), int64(2), money.New(10000, money.RUB), &description).Return(int64(0), int64(0), err)
 
 			arg := bytes.NewBuffer([]byte(`{"Sender":1000000, "Recipient":2, "Amount":100.00, "Description":"test"}`))
 			req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/transf", arg)
@@ -275,7 +365,7 @@ func TestTransferCommand(t *testing.T) {
 			body, err := ioutil.ReadAll(resp.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "error updating balance\n", string(body))
+			assert.Equal(t, "the operation conflicted with a concurrent one, retry it", errorMessage(t, body))
 		})
 	})
 }
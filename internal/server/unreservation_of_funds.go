@@ -18,19 +18,23 @@ func (h *Handler) UnreservationOfFunds(w http.ResponseWriter, r *http.Request) {
 	body, _ := ioutil.ReadAll(r.Body)
 	err := json.Unmarshal(body, &hand)
 	if err != nil {
-		http.Error(w, "malformed request body", http.StatusBadRequest)
+		h.malformedBody(w, r)
 		return
 	}
 
 	switch {
-	case hand.UserId <= 1:
-		http.Error(w, "wrong value of \"UserId\"", http.StatusBadRequest)
+	case hand.UserId <= 0:
+		h.invalidField(w, r, "user_id")
 		return
 	case hand.ServiceId <= 0:
-		http.Error(w, "wrong value of \"ServiceId\"", http.StatusBadRequest)
+		h.invalidField(w, r, "service_id")
 		return
 	case hand.OrderId <= 0:
-		http.Error(w, "wrong value of \"OrderId\"", http.StatusBadRequest)
+		h.invalidField(w, r, "order_id")
+		return
+	}
+
+	if !h.serviceAllowed(w, r, "unreservation", hand.UserId, hand.ServiceId, hand.OrderId) {
 		return
 	}
 
@@ -38,23 +42,13 @@ func (h *Handler) UnreservationOfFunds(w http.ResponseWriter, r *http.Request) {
 
 	err = h.Store.Unreservation(r.Context(), hand.UserId, hand.ServiceId, hand.OrderId, &description)
 	if err != nil {
-		switch {
-		case errors.Is(err, storage.ErrSerialization):
-			http.Error(w, "error updating balance", http.StatusInternalServerError)
-			return
-		case errors.Is(err, storage.ErrTransfer):
-			http.Error(w, "not enough money in the reserve account", http.StatusInternalServerError)
-			return
-		case errors.Is(err, storage.ErrReserveExist):
-			http.Error(w, "the reserve order does not exist", http.StatusBadRequest)
-			return
-		case errors.Is(err, storage.ErrRecordExist):
-			http.Error(w, "unreserve or consolidated report record already exists", http.StatusBadRequest)
-			return
-		default:
-			http.Error(w, "unreservation error", http.StatusInternalServerError)
+		// the reserve account always holds the reserved money, so the shortage is an internal error
+		if errors.Is(err, storage.ErrTransfer) {
+			h.internalError(w, r, "not enough money in the reserve account")
 			return
 		}
+		h.writeStorageError(w, r, err, "unreservation error")
+		return
 	}
 
 	result := generated.UnreservationOfFundsResponse{
@@ -68,14 +62,14 @@ func (h *Handler) UnreservationOfFunds(w http.ResponseWriter, r *http.Request) {
 
 	marshalledRequest, err := json.Marshal(result)
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 	_, writeErr := w.Write(marshalledRequest)
 	if err != nil {
-		h.Logger.Error("failed to write connection", zap.Error(writeErr))
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
 		return
 	}
 }
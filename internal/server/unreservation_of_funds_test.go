@@ -72,7 +72,7 @@ func TestUnreservationOfFunds(t *testing.T) {
 		body, err := ioutil.ReadAll(w.Body)
 		assert.NoError(t, err)
 
-		assert.Equal(t, "malformed request body\n", string(body))
+		assert.Equal(t, "malformed request body", errorMessage(t, body))
 	})
 
 	t.Run("wrong incoming values", func(t *testing.T) {
@@ -96,7 +96,7 @@ func TestUnreservationOfFunds(t *testing.T) {
 			body, err := ioutil.ReadAll(w.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "wrong value of \"UserId\"\n", string(body))
+			assert.Equal(t, "wrong value of \"user_id\"", errorMessage(t, body))
 		})
 
 		t.Run("wrong service_id", func(t *testing.T) {
@@ -119,7 +119,7 @@ func TestUnreservationOfFunds(t *testing.T) {
 			body, err := ioutil.ReadAll(w.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "wrong value of \"ServiceId\"\n", string(body))
+			assert.Equal(t, "wrong value of \"service_id\"", errorMessage(t, body))
 		})
 
 		t.Run("wrong order_id", func(t *testing.T) {
@@ -142,7 +142,7 @@ func TestUnreservationOfFunds(t *testing.T) {
 			body, err := ioutil.ReadAll(w.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "wrong value of \"OrderId\"\n", string(body))
+			assert.Equal(t, "wrong value of \"order_id\"", errorMessage(t, body))
 		})
 	})
 
@@ -172,7 +172,7 @@ func TestUnreservationOfFunds(t *testing.T) {
 			body, err := ioutil.ReadAll(resp.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "error updating balance\n", string(body))
+			assert.Equal(t, "the operation conflicted with a concurrent one, retry it", errorMessage(t, body))
 		})
 
 		t.Run("not enough money in the account", func(t *testing.T) {
@@ -197,7 +197,7 @@ func TestUnreservationOfFunds(t *testing.T) {
 			body, err := ioutil.ReadAll(resp.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "not enough money in the reserve account\n", string(body))
+			assert.Equal(t, "not enough money in the reserve account", errorMessage(t, body))
 		})
 
 		t.Run("the reserve order error", func(t *testing.T) {
@@ -222,7 +222,7 @@ func TestUnreservationOfFunds(t *testing.T) {
 			body, err := ioutil.ReadAll(resp.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "the reserve order does not exist\n", string(body))
+			assert.Equal(t, "the reserve order does not exist", errorMessage(t, body))
 		})
 
 		t.Run("unreservation or consolidated report error", func(t *testing.T) {
@@ -247,7 +247,7 @@ func TestUnreservationOfFunds(t *testing.T) {
 			body, err := ioutil.ReadAll(resp.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "unreserve or consolidated report record already exists\n", string(body))
+			assert.Equal(t, "unreserve or consolidated report record already exists", errorMessage(t, body))
 		})
 
 		t.Run("unreservation error", func(t *testing.T) {
@@ -272,7 +272,7 @@ func TestUnreservationOfFunds(t *testing.T) {
 			body, err := ioutil.ReadAll(resp.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "unreservation error\n", string(body))
+			assert.Equal(t, "unreservation error", errorMessage(t, body))
 		})
 	})
 }
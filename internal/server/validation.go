@@ -0,0 +1,69 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"http-avito-test/internal/generated"
+	"http-avito-test/internal/openapi"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+)
+
+// DefaultMaxBodySize limits the request bodies when the limit is not configured
+const DefaultMaxBodySize = 1 << 20
+
+// validated reads the request body of the spec operation and checks it against the operation schema
+// before the handler gets it. The body larger than MaxBodySize is rejected with 413, the body that does not
+// match the schema is rejected with 400 and the list of violations
+func (h *Handler) validated(method, path string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var maxBodySize int64 = DefaultMaxBodySize
+		if h.MaxBodySize > 0 {
+			maxBodySize = h.MaxBodySize
+		}
+
+		// one byte over the limit tells the body that is too large from the body of the limit size
+		body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+		if err != nil {
+			h.malformedBody(w, r)
+			return
+		}
+		if int64(len(body)) > maxBodySize {
+			h.writeError(w, r, apiError{
+				status:  http.StatusRequestEntityTooLarge,
+				code:    CodeBodyTooLarge,
+				message: fmt.Sprintf("request body is larger than %d bytes", maxBodySize),
+				args:    map[string]string{"limit": strconv.FormatInt(maxBodySize, 10)},
+			})
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		if h.Spec != nil {
+			if schema, ok := h.Spec.RequestSchema(method, path); ok {
+				if violations := schema.Validate(body); len(violations) > 0 {
+					h.writeViolations(w, r, violations)
+					return
+				}
+			}
+		}
+
+		next(w, r)
+	}
+}
+
+func (h *Handler) writeViolations(w http.ResponseWriter, r *http.Request, violations []openapi.Violation) {
+	details := make([]generated.Violation, 0, len(violations))
+	for _, v := range violations {
+		details = append(details, generated.Violation{Field: v.Field, Message: v.Message})
+	}
+
+	h.writeError(w, r, apiError{
+		status:  http.StatusBadRequest,
+		code:    CodeValidationFailed,
+		message: "the request body does not match the schema",
+		details: details,
+	})
+}
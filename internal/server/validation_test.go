@@ -0,0 +1,121 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"http-avito-test/api"
+	"http-avito-test/internal/generated"
+	"http-avito-test/internal/money"
+	"http-avito-test/internal/openapi"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidation(t *testing.T) {
+	spec, err := openapi.Load(api.Schema)
+	require.NoError(t, err)
+
+	t.Run("valid body", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().Deposit(gomock.Any(), int64(2), money.New(10000, money.RUB)).Return(nil)
+
+		arg := bytes.NewBuffer([]byte(`{"User_id":2, "Amount":"100.00"}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/api/1/accountdeposit", arg)
+		w := httptest.NewRecorder()
+
+		h := Handler{
+			Store: m,
+			Spec:  spec,
+		}
+
+		h.Router(false).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	})
+
+	t.Run("violations", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		arg := bytes.NewBuffer([]byte(`{"user_id":0, "amount":"1.005", "comment":"test"}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/deposit", arg)
+		req.Header.Set(RequestIDHeader, "test-request")
+		w := httptest.NewRecorder()
+
+		h := Handler{
+			Store: NewMockStorager(ctrl),
+			Spec:  spec,
+		}
+
+		h.Router(true).ServeHTTP(w, req)
+
+		resp := w.Result()
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+
+		js, err := json.Marshal(generated.ErrorResponse{
+			Error: generated.Error{
+				Code: string(CodeValidationFailed),
+				Details: &[]generated.Violation{
+					{Field: "amount", Message: "must be a decimal like 100.50"},
+					{Field: "comment", Message: "unknown field"},
+					{Field: "user_id", Message: "must be greater than or equal to 1"},
+				},
+				Message:   "the request body does not match the schema",
+				RequestId: "test-request",
+			},
+			Status: "error",
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		assert.Equal(t, string(js), string(body))
+	})
+
+	t.Run("body too large", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		arg := bytes.NewBuffer([]byte(`{"user_id":2, "amount":"100.00", "description":"` + strings.Repeat("a", 64) + `"}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/api/1/accountwithdrawal", arg)
+		w := httptest.NewRecorder()
+
+		h := Handler{
+			Store:       NewMockStorager(ctrl),
+			Spec:        spec,
+			MaxBodySize: 64,
+		}
+
+		h.Router(false).ServeHTTP(w, req)
+
+		resp := w.Result()
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+		assert.Contains(t, string(body), "request body is larger than 64 bytes")
+	})
+
+	t.Run("every JSON operation has a schema", func(t *testing.T) {
+		h := Handler{}
+
+		for path := range h.Router(false).routes {
+			if path == "/importstatement" {
+				continue
+			}
+			_, ok := spec.RequestSchema(http.MethodPost, versionedPrefix+path)
+			assert.True(t, ok, path)
+		}
+	})
+}
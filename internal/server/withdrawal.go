@@ -2,39 +2,35 @@ package server
 
 import (
 	"encoding/json"
-	"errors"
 	"http-avito-test/internal/generated"
+	"http-avito-test/internal/money"
 	"http-avito-test/internal/storage"
 	"io/ioutil"
 	"net/http"
 
-	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
+const QueuedWithdrawalMessage = "withdrawal is waiting for approval"
+
 func (h *Handler) AccountWithdrawal(w http.ResponseWriter, r *http.Request) {
 	var hand *generated.AccountWithdrawalRequest
 
 	body, _ := ioutil.ReadAll(r.Body)
 	err := json.Unmarshal(body, &hand)
 	if err != nil {
-		http.Error(w, "malformed request body", http.StatusBadRequest)
+		h.malformedBody(w, r)
 		return
 	}
 
-	if hand.UserId <= 1 {
-		http.Error(w, "wrong value of \"User_id\"", http.StatusBadRequest)
+	if hand.UserId <= 0 {
+		h.invalidField(w, r, "user_id")
 		return
 	}
 
-	var newBalance = decimal.NewFromFloat32(hand.Amount).Mul(decimal.NewFromInt(100))
-
-	switch {
-	case newBalance.Exponent() < -2:
-		http.Error(w, "wrong value of \"Amount\"", http.StatusBadRequest)
-		return
-	case newBalance.LessThanOrEqual(decimal.NewFromInt(int64(0))):
-		http.Error(w, "wrong value of \"Amount\"", http.StatusBadRequest)
+	newBalance, err := parseAmount(w, hand.Amount)
+	if err != nil || !newBalance.IsPositive() {
+		h.invalidField(w, r, "amount")
 		return
 	}
 
@@ -42,21 +38,29 @@ func (h *Handler) AccountWithdrawal(w http.ResponseWriter, r *http.Request) {
 		hand.Description = nil
 	}
 
-	newErr := h.Store.Withdrawal(r.Context(), hand.UserId, newBalance, hand.Description)
+	var quote storage.Quote
+	var requestID int64
+	var newErr error
+	switch {
+	case isDryRun(hand.DryRun):
+		quote, newErr = h.Store.QuoteWithdrawal(r.Context(), hand.UserId, newBalance, hand.Description)
+	case h.needsApproval(newBalance):
+		requestID, newErr = h.Store.RequestWithdrawal(r.Context(), hand.UserId, newBalance, hand.Description)
+	default:
+		newErr = h.Store.Withdrawal(r.Context(), hand.UserId, newBalance, hand.Description)
+	}
 	if newErr != nil {
-		if errors.Is(newErr, storage.ErrSerialization) {
-			http.Error(w, "error updating balance", http.StatusInternalServerError)
-			return
-		}
-		if errors.Is(newErr, storage.ErrWithdrawal) {
-			http.Error(w, "not enough money in the account", http.StatusBadRequest)
-			return
-		}
-		if errors.Is(newErr, storage.ErrUserAvailability) {
-			http.Error(w, "user does not exist", http.StatusBadRequest)
-			return
-		}
-		http.Error(w, "error updating balance", http.StatusInternalServerError)
+		h.writeStorageError(w, r, newErr, "error updating balance")
+		return
+	}
+
+	if isDryRun(hand.DryRun) {
+		h.writeQuote(w, r, quote)
+		return
+	}
+
+	if requestID != 0 {
+		h.writeQueuedWithdrawal(w, r, requestID)
 		return
 	}
 
@@ -71,14 +75,40 @@ func (h *Handler) AccountWithdrawal(w http.ResponseWriter, r *http.Request) {
 
 	marshalledRequest, err := json.Marshal(result)
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 	_, writeErr := w.Write(marshalledRequest)
 	if err != nil {
-		h.Logger.Error("failed to write connection", zap.Error(writeErr))
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
+		return
+	}
+}
+
+// needsApproval reports whether the withdrawal of the amount has to wait for the operator approval
+func (h *Handler) needsApproval(amount money.Money) bool {
+	return h.WithdrawalApprovalThreshold.IsPositive() && amount.GreaterThan(h.WithdrawalApprovalThreshold)
+}
+
+func (h *Handler) writeQueuedWithdrawal(w http.ResponseWriter, r *http.Request, requestID int64) {
+	result := generated.QueuedWithdrawalResponse{
+		Status: "ok",
+	}
+	result.Result.Message = QueuedWithdrawalMessage
+	result.Result.RequestId = requestID
+
+	marshalledRequest, err := json.Marshal(result)
+	if err != nil {
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, writeErr := w.Write(marshalledRequest)
+	if writeErr != nil {
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
 		return
 	}
 }
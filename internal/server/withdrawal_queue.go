@@ -0,0 +1,139 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"http-avito-test/internal/generated"
+	"http-avito-test/internal/storage"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const (
+	ApprovedWithdrawalMessage = "withdrawal approved successfully"
+	RejectedWithdrawalMessage = "withdrawal rejected successfully"
+)
+
+func (h *Handler) ListWithdrawalRequests(w http.ResponseWriter, r *http.Request) {
+	var hand *generated.ListWithdrawalRequestsRequest
+
+	body, _ := ioutil.ReadAll(r.Body)
+	err := json.Unmarshal(body, &hand)
+	if err != nil || hand == nil {
+		h.malformedBody(w, r)
+		return
+	}
+
+	var status = storage.WithdrawalStatusPending
+	if hand.Status != nil {
+		status = storage.WithdrawalStatus(*hand.Status)
+	}
+
+	switch {
+	case status != storage.WithdrawalStatusPending &&
+		status != storage.WithdrawalStatusApproved &&
+		status != storage.WithdrawalStatusRejected:
+		h.invalidField(w, r, "status")
+		return
+	case hand.Limit <= 0:
+		h.invalidField(w, r, "limit")
+		return
+	case hand.Offset < 0:
+		h.invalidField(w, r, "offset")
+		return
+	}
+
+	requests, err := h.Store.ListWithdrawalRequests(r.Context(), status, hand.Limit, hand.Offset)
+	if err != nil {
+		h.writeStorageError(w, r, err, "error reading withdrawal requests")
+		return
+	}
+
+	result := generated.ListWithdrawalRequestsResponse{
+		Result: requests,
+		Status: "ok",
+	}
+
+	marshalledRequest, err := json.Marshal(result)
+	if err != nil {
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, writeErr := w.Write(marshalledRequest)
+	if writeErr != nil {
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
+		return
+	}
+}
+
+func (h *Handler) ApproveWithdrawal(w http.ResponseWriter, r *http.Request) {
+	h.decideWithdrawal(w, r, h.Store.ApproveWithdrawal, ApprovedWithdrawalMessage)
+}
+
+func (h *Handler) RejectWithdrawal(w http.ResponseWriter, r *http.Request) {
+	h.decideWithdrawal(w, r, h.Store.RejectWithdrawal, RejectedWithdrawalMessage)
+}
+
+func (h *Handler) decideWithdrawal(
+	w http.ResponseWriter,
+	r *http.Request,
+	decide func(ctx context.Context, requestID int64, operator, reason string) error,
+	message string) {
+	var hand *generated.DecideWithdrawalRequest
+
+	body, _ := ioutil.ReadAll(r.Body)
+	err := json.Unmarshal(body, &hand)
+	if err != nil || hand == nil {
+		h.malformedBody(w, r)
+		return
+	}
+
+	hand.Operator = strings.TrimSpace(hand.Operator)
+
+	switch {
+	case hand.RequestId <= 0:
+		h.invalidField(w, r, "request_id")
+		return
+	case hand.Operator == "":
+		h.invalidField(w, r, "operator")
+		return
+	}
+
+	var reason string
+	if hand.Reason != nil {
+		reason = *hand.Reason
+	}
+
+	err = decide(r.Context(), hand.RequestId, hand.Operator, reason)
+	if err != nil {
+		h.writeStorageError(w, r, err, "error deciding withdrawal request")
+		return
+	}
+
+	result := generated.DecideWithdrawalResponse{
+		Result: struct {
+			Message string "json:\"message\""
+		}{
+			Message: message,
+		},
+		Status: "ok",
+	}
+
+	marshalledRequest, err := json.Marshal(result)
+	if err != nil {
+		h.internalError(w, r, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, writeErr := w.Write(marshalledRequest)
+	if writeErr != nil {
+		h.logger(r).Error("failed to write connection", zap.Error(writeErr))
+		return
+	}
+}
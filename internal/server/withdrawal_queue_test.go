@@ -0,0 +1,218 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"http-avito-test/internal/generated"
+	"http-avito-test/internal/money"
+	"http-avito-test/internal/storage"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListWithdrawalRequests(t *testing.T) {
+	var requests = []storage.WithdrawalRequest{
+		{
+			ID:        4,
+			AccountID: 2,
+			Amount:    money.New(500000, money.RUB),
+			Status:    storage.WithdrawalStatusPending,
+			CreatedAt: time.Date(2022, time.November, 1, 12, 0, 0, 0, time.UTC),
+		},
+	}
+
+	t.Run("pending requests by default", func(t *testing.T) {
+		var testList = generated.ListWithdrawalRequestsResponse{
+			Result: requests,
+			Status: "ok",
+		}
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().ListWithdrawalRequests(gomock.Any(), storage.WithdrawalStatusPending, int64(10), int64(0)).Return(requests, nil)
+
+		arg := bytes.NewBuffer([]byte(`{"limit":10, "offset":0}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/admin/withdrawals", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.ListWithdrawalRequests(w, req)
+
+		body, err := ioutil.ReadAll(w.Result().Body)
+		assert.NoError(t, err)
+
+		js, err := json.Marshal(testList)
+		assert.NoError(t, err)
+
+		assert.Equal(t, string(js), string(body))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var tests = []struct {
+			name   string
+			body   string
+			result string
+		}{
+			{name: "empty request body", body: ``, result: "malformed request body"},
+			{name: "wrong status", body: `{"status":"unknown", "limit":10, "offset":0}`, result: "wrong value of \"status\""},
+			{name: "wrong limit", body: `{"limit":0, "offset":0}`, result: "wrong value of \"limit\""},
+			{name: "wrong offset", body: `{"limit":10, "offset":-1}`, result: "wrong value of \"offset\""},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				m := NewMockStorager(ctrl)
+
+				req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/admin/withdrawals", bytes.NewBufferString(tt.body))
+				w := httptest.NewRecorder()
+
+				s := Handler{
+					Store: m,
+				}
+
+				s.ListWithdrawalRequests(w, req)
+
+				body, err := ioutil.ReadAll(w.Result().Body)
+				assert.NoError(t, err)
+
+				assert.Equal(t, tt.result, errorMessage(t, body))
+			})
+		}
+	})
+}
+
+func TestDecideWithdrawal(t *testing.T) {
+	t.Run("approve", func(t *testing.T) {
+		var testDecision = generated.DecideWithdrawalResponse{
+			Result: struct {
+				Message string "json:\"message\""
+			}{
+				Message: "withdrawal approved successfully",
+			},
+			Status: "ok",
+		}
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().ApproveWithdrawal(gomock.Any(), int64(4), "operator", "verified").Return(nil)
+
+		arg := bytes.NewBuffer([]byte(`{"request_id":4, "operator":"operator", "reason":"verified"}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/admin/withdrawals/approve", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.ApproveWithdrawal(w, req)
+
+		body, err := ioutil.ReadAll(w.Result().Body)
+		assert.NoError(t, err)
+
+		js, err := json.Marshal(testDecision)
+		assert.NoError(t, err)
+
+		assert.Equal(t, string(js), string(body))
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().RejectWithdrawal(gomock.Any(), int64(4), "operator", "").Return(nil)
+
+		arg := bytes.NewBuffer([]byte(`{"request_id":4, "operator":" operator "}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/admin/withdrawals/reject", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.RejectWithdrawal(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var tests = []struct {
+			name       string
+			body       string
+			storageErr error
+			result     string
+		}{
+			{
+				name:   "wrong request id",
+				body:   `{"request_id":0, "operator":"operator"}`,
+				result: "wrong value of \"request_id\"",
+			},
+			{
+				name:   "empty operator",
+				body:   `{"request_id":4, "operator":" "}`,
+				result: "wrong value of \"operator\"",
+			},
+			{
+				name:       "request does not exist",
+				body:       `{"request_id":4, "operator":"operator"}`,
+				storageErr: storage.ErrNoWithdrawalRequest,
+				result:     "the withdrawal request does not exist",
+			},
+			{
+				name:       "request already decided",
+				body:       `{"request_id":4, "operator":"operator"}`,
+				storageErr: storage.ErrWithdrawalDecided,
+				result:     "the withdrawal request is already approved or rejected",
+			},
+			{
+				name:       "error deciding",
+				body:       `{"request_id":4, "operator":"operator"}`,
+				storageErr: errors.New("error deciding"),
+				result:     "error deciding withdrawal request",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				m := NewMockStorager(ctrl)
+				if tt.storageErr != nil {
+					m.EXPECT().ApproveWithdrawal(gomock.Any(), int64(4), "operator", "").Return(tt.storageErr)
+				}
+
+				req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/admin/withdrawals/approve", bytes.NewBufferString(tt.body))
+				w := httptest.NewRecorder()
+
+				s := Handler{
+					Store: m,
+				}
+
+				s.ApproveWithdrawal(w, req)
+
+				body, err := ioutil.ReadAll(w.Result().Body)
+				assert.NoError(t, err)
+
+				assert.Equal(t, tt.result, errorMessage(t, body))
+			})
+		}
+	})
+}
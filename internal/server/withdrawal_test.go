@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"http-avito-test/internal/generated"
+	"http-avito-test/internal/money"
 	"http-avito-test/internal/storage"
 	"io/ioutil"
 	"net/http"
@@ -12,7 +13,6 @@ import (
 	"testing"
 
 	"github.com/golang/mock/gomock"
-	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -33,7 +33,7 @@ func TestAccountWithdrawal(t *testing.T) {
 		description := "test"
 
 		m := NewMockStorager(ctrl)
-		m.EXPECT().Withdrawal(gomock.Any(), int64(2), decimal.NewFromFloat32(100).Mul(decimal.NewFromInt(100)), &description).Return(nil)
+		m.EXPECT().Withdrawal(gomock.Any(), int64(2), money.New(10000, money.RUB), &description).Return(nil)
 
 		arg := bytes.NewBuffer([]byte(`{"User_id":2, "Amount":100.00, "Description":"test"}`))
 		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/withdrawal", arg)
@@ -55,6 +55,60 @@ func TestAccountWithdrawal(t *testing.T) {
 		assert.Equal(t, string(js), string(body))
 	})
 
+	t.Run("dry run", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		description := "test"
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().QuoteWithdrawal(gomock.Any(), int64(2), money.New(10000, money.RUB), &description).Return(storage.Quote{
+			AccountID: 2,
+			Balance:   money.New(5000, money.RUB),
+		}, nil)
+
+		arg := bytes.NewBuffer([]byte(`{"User_id":2, "Amount":100.00, "Description":"test", "dry_run":true}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/withdrawal", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.AccountWithdrawal(w, req)
+
+		resp := w.Result()
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "{\"result\":{\"balance\":\"50\",\"fee\":\"0\",\"user_id\":2},\"status\":\"ok\"}", string(body))
+	})
+
+	t.Run("dry run with not enough money", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		description := "test"
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().QuoteWithdrawal(gomock.Any(), int64(2), money.New(10000, money.RUB), &description).Return(storage.Quote{}, storage.ErrWithdrawal)
+
+		arg := bytes.NewBuffer([]byte(`{"User_id":2, "Amount":100.00, "Description":"test", "dry_run":true}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/withdrawal", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.AccountWithdrawal(w, req)
+
+		body, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "not enough money in the account", errorMessage(t, body))
+	})
+
 	t.Run("empty request body", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
@@ -73,7 +127,7 @@ func TestAccountWithdrawal(t *testing.T) {
 		body, err := ioutil.ReadAll(w.Body)
 		assert.NoError(t, err)
 
-		assert.Equal(t, "malformed request body\n", string(body))
+		assert.Equal(t, "malformed request body", errorMessage(t, body))
 	})
 
 	t.Run("wrong UserID value", func(t *testing.T) {
@@ -96,7 +150,7 @@ func TestAccountWithdrawal(t *testing.T) {
 		body, err := ioutil.ReadAll(w.Body)
 		assert.NoError(t, err)
 
-		assert.Equal(t, "wrong value of \"User_id\"\n", string(body))
+		assert.Equal(t, "wrong value of \"user_id\"", errorMessage(t, body))
 	})
 
 	t.Run("wrong amount value", func(t *testing.T) {
@@ -120,9 +174,9 @@ func TestAccountWithdrawal(t *testing.T) {
 			body, err := ioutil.ReadAll(resp.Body)
 			assert.NoError(t, err)
 
-			result := "wrong value of \"Amount\"\n"
+			result := "wrong value of \"amount\""
 
-			assert.Equal(t, result, string(body))
+			assert.Equal(t, result, errorMessage(t, body))
 		})
 
 		t.Run("amount less than or equal to zero", func(t *testing.T) {
@@ -145,9 +199,9 @@ func TestAccountWithdrawal(t *testing.T) {
 			body, err := ioutil.ReadAll(resp.Body)
 			assert.NoError(t, err)
 
-			result := "wrong value of \"Amount\"\n"
+			result := "wrong value of \"amount\""
 
-			assert.Equal(t, result, string(body))
+			assert.Equal(t, result, errorMessage(t, body))
 		})
 	})
 
@@ -159,7 +213,7 @@ func TestAccountWithdrawal(t *testing.T) {
 			description := "test"
 
 			m := NewMockStorager(ctrl)
-			m.EXPECT().Withdrawal(gomock.Any(), int64(2), decimal.NewFromFloat32(100).Mul(decimal.NewFromInt(100)), &description).Return(storage.ErrWithdrawal)
+			m.EXPECT().Withdrawal(gomock.Any(), int64(2), money.New(10000, money.RUB), &description).Return(storage.ErrWithdrawal)
 
 			arg := bytes.NewBuffer([]byte(`{"User_id":2, "Amount":100.00, "Description":"test"}`))
 			req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/withdrawal", arg)
@@ -175,7 +229,7 @@ func TestAccountWithdrawal(t *testing.T) {
 			body, err := ioutil.ReadAll(resp.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "not enough money in the account\n", string(body))
+			assert.Equal(t, "not enough money in the account", errorMessage(t, body))
 		})
 
 		t.Run("user does not exist", func(t *testing.T) {
@@ -185,7 +239,7 @@ func TestAccountWithdrawal(t *testing.T) {
 			description := "test"
 
 			m := NewMockStorager(ctrl)
-			m.EXPECT().Withdrawal(gomock.Any(), int64(2), decimal.NewFromFloat32(100).Mul(decimal.NewFromInt(100)), &description).Return(storage.ErrUserAvailability)
+			m.EXPECT().Withdrawal(gomock.Any(), int64(2), money.New(10000, money.RUB), &description).Return(storage.ErrUserAvailability)
 
 			arg := bytes.NewBuffer([]byte(`{"User_id":2, "Amount":100.00, "Description":"test"}`))
 			req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/withdrawal", arg)
@@ -201,7 +255,7 @@ func TestAccountWithdrawal(t *testing.T) {
 			body, err := ioutil.ReadAll(resp.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "user does not exist\n", string(body))
+			assert.Equal(t, "user does not exist", errorMessage(t, body))
 		})
 
 		t.Run("error updating balance", func(t *testing.T) {
@@ -213,7 +267,7 @@ func TestAccountWithdrawal(t *testing.T) {
 			description := "test"
 
 			m := NewMockStorager(ctrl)
-			m.EXPECT().Withdrawal(gomock.Any(), int64(2), decimal.NewFromFloat32(100).Mul(decimal.NewFromInt(100)), &description).Return(err)
+			m.EXPECT().Withdrawal(gomock.Any(), int64(2), money.New(10000, money.RUB), &description).Return(err)
 
 			arg := bytes.NewBuffer([]byte(`{"User_id":2, "Amount":100.00, "Description":"test"}`))
 			req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/withdrawal", arg)
@@ -229,7 +283,7 @@ func TestAccountWithdrawal(t *testing.T) {
 			body, err := ioutil.ReadAll(resp.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "error updating balance\n", string(body))
+			assert.Equal(t, "error updating balance", errorMessage(t, body))
 		})
 		t.Run("level isolation error", func(t *testing.T) {
 			ctrl := gomock.NewController(t)
@@ -240,7 +294,7 @@ func TestAccountWithdrawal(t *testing.T) {
 			description := "test"
 
 			m := NewMockStorager(ctrl)
-			m.EXPECT().Withdrawal(gomock.Any(), int64(2), decimal.NewFromFloat32(100).Mul(decimal.NewFromInt(100)), &description).Return(err)
+			m.EXPECT().Withdrawal(gomock.Any(), int64(2), money.New(10000, money.RUB), &description).Return(err)
 
 			arg := bytes.NewBuffer([]byte(`{"User_id":2, "Amount":100.00, "Description":"test"}`))
 			req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/withdrawal", arg)
@@ -256,8 +310,87 @@ func TestAccountWithdrawal(t *testing.T) {
 			body, err := ioutil.ReadAll(resp.Body)
 			assert.NoError(t, err)
 
-			assert.Equal(t, "error updating balance\n", string(body))
+			assert.Equal(t, "the operation conflicted with a concurrent one, retry it", errorMessage(t, body))
 		})
 	})
 
+	t.Run("queued for approval", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().RequestWithdrawal(gomock.Any(), int64(2), money.New(500000, money.RUB), nil).Return(int64(4), nil)
+
+		arg := bytes.NewBuffer([]byte(`{"User_id":2, "Amount":5000.00}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/withdrawal", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store:                       m,
+			WithdrawalApprovalThreshold: money.New(100000, money.RUB),
+		}
+
+		s.AccountWithdrawal(w, req)
+
+		resp := w.Result()
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+
+		var testWithdrawal = generated.QueuedWithdrawalResponse{
+			Status: "ok",
+		}
+		testWithdrawal.Result.Message = "withdrawal is waiting for approval"
+		testWithdrawal.Result.RequestId = 4
+
+		js, err := json.Marshal(testWithdrawal)
+		assert.NoError(t, err)
+
+		assert.Equal(t, string(js), string(body))
+	})
+
+	t.Run("below approval threshold", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().Withdrawal(gomock.Any(), int64(2), money.New(100000, money.RUB), nil).Return(nil)
+
+		arg := bytes.NewBuffer([]byte(`{"User_id":2, "Amount":1000.00}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/withdrawal", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store:                       m,
+			WithdrawalApprovalThreshold: money.New(100000, money.RUB),
+		}
+
+		s.AccountWithdrawal(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	})
+
+	t.Run("blocked by fraud rules", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := NewMockStorager(ctrl)
+		m.EXPECT().Withdrawal(gomock.Any(), int64(2), money.New(10000, money.RUB), nil).Return(storage.ErrFraudBlocked)
+
+		arg := bytes.NewBuffer([]byte(`{"User_id":2, "Amount":100.00}`))
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:9090/withdrawal", arg)
+		w := httptest.NewRecorder()
+
+		s := Handler{
+			Store: m,
+		}
+
+		s.AccountWithdrawal(w, req)
+
+		resp := w.Result()
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Equal(t, "the operation is blocked by the fraud rules", errorMessage(t, body))
+	})
 }
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// AccountRole defines the purpose of the system account in the chart of accounts
+type AccountRole string
+
+const (
+	AccountRoleCashBook   AccountRole = "cash_book"
+	AccountRoleReserve    AccountRole = "reserve"
+	AccountRoleEscrow     AccountRole = "escrow"
+	AccountRolePromotions AccountRole = "promotions"
+	AccountRoleRevenue    AccountRole = "revenue"
+)
+
+// SystemAccounts holds the ids of the system accounts resolved by their roles in the chart of accounts.
+// System accounts have negative ids, so they never collide with the user accounts
+type SystemAccounts struct {
+	CashBook   int64
+	Reserve    int64
+	Escrow     int64
+	Promotions int64
+	Revenue    int64
+}
+
+// isUserAccount reports whether the account belongs to a user and not to the chart of accounts
+func isUserAccount(id int64) bool {
+	return id > 0
+}
+
+// loadSystemAccounts reads the chart of accounts and resolves the accounts of every role used by the storage operations
+func loadSystemAccounts(ctx context.Context, db *pgxpool.Pool) (SystemAccounts, error) {
+	selectQuery := `SELECT role, id FROM chart_of_accounts WHERE role IS NOT NULL;`
+
+	rows, err := db.Query(ctx, selectQuery)
+	if err != nil {
+		return SystemAccounts{}, err
+	}
+	defer rows.Close()
+
+	var ids = make(map[AccountRole]int64)
+	for rows.Next() {
+		var role AccountRole
+		var id int64
+		if err := rows.Scan(&role, &id); err != nil {
+			return SystemAccounts{}, err
+		}
+		ids[role] = id
+	}
+	if err := rows.Err(); err != nil {
+		return SystemAccounts{}, err
+	}
+
+	var accounts SystemAccounts
+	for role, id := range map[AccountRole]*int64{
+		AccountRoleCashBook:   &accounts.CashBook,
+		AccountRoleReserve:    &accounts.Reserve,
+		AccountRoleEscrow:     &accounts.Escrow,
+		AccountRolePromotions: &accounts.Promotions,
+		AccountRoleRevenue:    &accounts.Revenue,
+	} {
+		v, ok := ids[role]
+		if !ok {
+			return SystemAccounts{}, fmt.Errorf("chart of accounts has no account with role %q", role)
+		}
+		*id = v
+	}
+	return accounts, nil
+}
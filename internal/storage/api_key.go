@@ -0,0 +1,280 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"http-avito-test/internal/auth"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNoAPIKey      = errors.New("API key does not exist")
+	ErrAPIKeyRevoked = errors.New("API key is revoked")
+)
+
+// CreateAPIKey stores the hash of the new key of the client with the scopes
+func (s *Storage) CreateAPIKey(ctx context.Context, client string, key auth.Key, scopes []auth.Scope) (int64, error) {
+	logger := s.logger(ctx).With(zap.String("client", client), zap.String("prefix", key.Prefix))
+	logger.Debug("creating API key")
+
+	var id int64
+
+	insertQuery := `INSERT INTO api_keys (client, prefix, hash, scopes, created_at)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id;`
+
+	err := s.DB.QueryRow(ctx, insertQuery, client, key.Prefix, key.Hash(), scopeStrings(scopes), time.Now()).Scan(&id)
+	if err != nil {
+		logger.Error("failed to insert record", zap.Error(err))
+		return 0, err
+	}
+	return id, nil
+}
+
+// ReadAPIKey returns the key with the prefix and the services of its client, revoked keys are returned too
+func (s *Storage) ReadAPIKey(ctx context.Context, prefix string) (APIKey, error) {
+	logger := s.logger(ctx).With(zap.String("prefix", prefix))
+
+	selectQuery := `SELECT id, client, prefix, hash, scopes, created_at, revoked_at, rotated_from,
+		array(SELECT service_id FROM client_services cs WHERE cs.client = api_keys.client ORDER BY service_id)
+		FROM api_keys WHERE prefix = $1;`
+
+	k, err := scanAPIKey(s.DB.QueryRow(ctx, selectQuery, prefix))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return APIKey{}, ErrNoAPIKey
+		}
+		logger.Error("Query error", zap.Error(err))
+		return APIKey{}, err
+	}
+	return k, nil
+}
+
+// ListAPIKeys returns all keys starting from the newest
+func (s *Storage) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	logger := s.logger(ctx)
+	logger.Debug("reading API keys")
+
+	selectQuery := `SELECT id, client, prefix, hash, scopes, created_at, revoked_at, rotated_from,
+		array(SELECT service_id FROM client_services cs WHERE cs.client = api_keys.client ORDER BY service_id)
+		FROM api_keys ORDER BY id DESC;`
+
+	rows, err := s.DB.Query(ctx, selectQuery)
+	if err != nil {
+		logger.Error("Query error", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var kk = make([]APIKey, 0)
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			logger.Error("scanning row error", zap.Error(err))
+			return nil, err
+		}
+		kk = append(kk, k)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error("Query error", zap.Error(err))
+		return nil, err
+	}
+	return kk, nil
+}
+
+// RotateAPIKey revokes the key and stores the new one of the same client and scopes in its place
+func (s *Storage) RotateAPIKey(ctx context.Context, id int64, key auth.Key) (newID int64, err error) {
+	logger := s.logger(ctx).With(zap.Int64("keyID", id))
+	logger.Debug("rotating API key")
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(ctx); errRollback != nil {
+				logger.Error("error rolls back the transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	selectQuery := `SELECT id, client, prefix, hash, scopes, created_at, revoked_at, rotated_from,
+		array(SELECT service_id FROM client_services cs WHERE cs.client = api_keys.client ORDER BY service_id)
+		FROM api_keys WHERE id = $1 FOR UPDATE;`
+
+	old, err := scanAPIKey(tx.QueryRow(ctx, selectQuery, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			logger.Error("API key does not exist", zap.Error(ErrNoAPIKey))
+			return 0, ErrNoAPIKey
+		}
+		logger.Error("Query error", zap.Error(err))
+		return 0, err
+	}
+	if old.RevokedAt.Valid {
+		logger.Error("API key is revoked", zap.Error(ErrAPIKeyRevoked))
+		return 0, ErrAPIKeyRevoked
+	}
+
+	now := time.Now()
+
+	updateExec := `UPDATE api_keys SET revoked_at = $2 WHERE id = $1;`
+
+	_, err = tx.Exec(ctx, updateExec, id, now)
+	if err != nil {
+		logger.Error("failed to update record", zap.Error(err))
+		return 0, err
+	}
+
+	insertQuery := `INSERT INTO api_keys (client, prefix, hash, scopes, created_at, rotated_from)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id;`
+
+	err = tx.QueryRow(ctx, insertQuery, old.Client, key.Prefix, key.Hash(), scopeStrings(old.Scopes), now, id).Scan(&newID)
+	if err != nil {
+		logger.Error("failed to insert record", zap.Error(err))
+		return 0, err
+	}
+
+	err = commit(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+	return newID, nil
+}
+
+// RevokeAPIKey stops accepting the key
+func (s *Storage) RevokeAPIKey(ctx context.Context, id int64) error {
+	logger := s.logger(ctx).With(zap.Int64("keyID", id))
+	logger.Debug("revoking API key")
+
+	var revokedAt *time.Time
+
+	// the previous revocation time tells the revoked key from the missing one
+	updateQuery := `WITH k AS (SELECT id, revoked_at FROM api_keys WHERE id = $1 FOR UPDATE)
+		UPDATE api_keys SET revoked_at = coalesce(k.revoked_at, $2) FROM k
+		WHERE api_keys.id = k.id RETURNING k.revoked_at;`
+
+	err := s.DB.QueryRow(ctx, updateQuery, id, time.Now()).Scan(&revokedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			logger.Error("API key does not exist", zap.Error(ErrNoAPIKey))
+			return ErrNoAPIKey
+		}
+		logger.Error("failed to update record", zap.Error(err))
+		return err
+	}
+	if revokedAt != nil {
+		logger.Error("API key is revoked", zap.Error(ErrAPIKeyRevoked))
+		return ErrAPIKeyRevoked
+	}
+	return nil
+}
+
+// BindServices allows the client to operate the orders of the services, the services bound already are skipped
+func (s *Storage) BindServices(ctx context.Context, client string, serviceIDs []int64) error {
+	logger := s.logger(ctx).With(zap.String("client", client), zap.Int64s("serviceIDs", serviceIDs))
+	logger.Debug("binding services")
+
+	insertExec := `INSERT INTO client_services (client, service_id)
+		SELECT $1, service_id FROM unnest($2::bigint[]) AS service_id
+		ON CONFLICT DO NOTHING;`
+
+	_, err := s.DB.Exec(ctx, insertExec, client, serviceIDs)
+	if err != nil {
+		logger.Error("failed to insert records", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// UnbindServices takes the services away from the client
+func (s *Storage) UnbindServices(ctx context.Context, client string, serviceIDs []int64) error {
+	logger := s.logger(ctx).With(zap.String("client", client), zap.Int64s("serviceIDs", serviceIDs))
+	logger.Debug("unbinding services")
+
+	deleteExec := `DELETE FROM client_services WHERE client = $1 AND service_id = ANY($2::bigint[]);`
+
+	_, err := s.DB.Exec(ctx, deleteExec, client, serviceIDs)
+	if err != nil {
+		logger.Error("failed to delete records", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// AuditDenial stores the operation rejected because the client is not bound to its service
+func (s *Storage) AuditDenial(ctx context.Context, d AuthorizationDenial) error {
+	logger := s.logger(ctx).With(zap.String("client", d.Client), zap.Int64("serviceID", d.ServiceID))
+
+	insertExec := `INSERT INTO authorization_denials (client, key_id, operation, account_id, service_id, order_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7);`
+
+	_, err := s.DB.Exec(ctx, insertExec, d.Client, d.KeyID, d.Operation, d.AccountID, d.ServiceID, d.OrderID, d.CreatedAt)
+	if err != nil {
+		logger.Error("failed to insert record", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// ListAuthorizationDenials returns the audited denials starting from the newest
+func (s *Storage) ListAuthorizationDenials(ctx context.Context, limit, offset int64) ([]AuthorizationDenial, error) {
+	logger := s.logger(ctx)
+	logger.Debug("reading authorization denials", zap.Int64("limit", limit), zap.Int64("offset", offset))
+
+	selectQuery := `SELECT id, client, key_id, operation, account_id, service_id, order_id, created_at
+		FROM authorization_denials ORDER BY created_at DESC, id DESC LIMIT $1 OFFSET $2;`
+
+	rows, err := s.DB.Query(ctx, selectQuery, limit, offset)
+	if err != nil {
+		logger.Error("Query error", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dd = make([]AuthorizationDenial, 0)
+	for rows.Next() {
+		var d AuthorizationDenial
+		err := rows.Scan(&d.ID, &d.Client, &d.KeyID, &d.Operation, &d.AccountID, &d.ServiceID, &d.OrderID, &d.CreatedAt)
+		if err != nil {
+			logger.Error("scanning row error", zap.Error(err))
+			return nil, err
+		}
+		dd = append(dd, d)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error("Query error", zap.Error(err))
+		return nil, err
+	}
+	return dd, nil
+}
+
+func scanAPIKey(row pgx.Row) (APIKey, error) {
+	var (
+		k      APIKey
+		scopes []string
+	)
+
+	err := row.Scan(&k.ID, &k.Client, &k.Prefix, &k.Hash, &scopes, &k.CreatedAt, &k.RevokedAt, &k.RotatedFrom, &k.ServiceIDs)
+	if err != nil {
+		return APIKey{}, err
+	}
+
+	k.Scopes = make([]auth.Scope, 0, len(scopes))
+	for _, scope := range scopes {
+		k.Scopes = append(k.Scopes, auth.Scope(scope))
+	}
+	return k, nil
+}
+
+func scopeStrings(scopes []auth.Scope) []string {
+	ss := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		ss = append(ss, string(scope))
+	}
+	return ss
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"http-avito-test/internal/auth"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeys(t *testing.T) {
+	s := bootstrap(t)
+
+	_, err := s.DB.Exec(context.Background(), `TRUNCATE api_keys, client_services CASCADE;`)
+	require.NoError(t, err)
+
+	key, err := auth.NewKey()
+	require.NoError(t, err)
+
+	id, err := s.CreateAPIKey(context.Background(), "billing", key, []auth.Scope{auth.ScopeBalanceRead, auth.ScopeFundsDeposit})
+	require.NoError(t, err)
+
+	stored, err := s.ReadAPIKey(context.Background(), key.Prefix)
+	require.NoError(t, err)
+	assert.Equal(t, "billing", stored.Client)
+	assert.Equal(t, []auth.Scope{auth.ScopeBalanceRead, auth.ScopeFundsDeposit}, stored.Scopes)
+	assert.True(t, key.Matches(stored.Hash))
+	assert.False(t, stored.RevokedAt.Valid)
+
+	rotated, err := auth.NewKey()
+	require.NoError(t, err)
+
+	newID, err := s.RotateAPIKey(context.Background(), id, rotated)
+	require.NoError(t, err)
+
+	stored, err = s.ReadAPIKey(context.Background(), key.Prefix)
+	require.NoError(t, err)
+	assert.True(t, stored.RevokedAt.Valid)
+
+	stored, err = s.ReadAPIKey(context.Background(), rotated.Prefix)
+	require.NoError(t, err)
+	assert.Equal(t, "billing", stored.Client)
+	assert.Equal(t, []auth.Scope{auth.ScopeBalanceRead, auth.ScopeFundsDeposit}, stored.Scopes)
+	require.NotNil(t, stored.RotatedFrom)
+	assert.Equal(t, id, *stored.RotatedFrom)
+
+	_, err = s.RotateAPIKey(context.Background(), id, rotated)
+	assert.ErrorIs(t, err, ErrAPIKeyRevoked)
+
+	require.NoError(t, s.RevokeAPIKey(context.Background(), newID))
+	assert.ErrorIs(t, s.RevokeAPIKey(context.Background(), newID), ErrAPIKeyRevoked)
+	assert.ErrorIs(t, s.RevokeAPIKey(context.Background(), newID+1), ErrNoAPIKey)
+
+	_, err = s.ReadAPIKey(context.Background(), "unknown")
+	assert.ErrorIs(t, err, ErrNoAPIKey)
+
+	keys, err := s.ListAPIKeys(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, keys, 2)
+}
+
+func TestServiceBindings(t *testing.T) {
+	s := bootstrap(t)
+
+	_, err := s.DB.Exec(context.Background(), `TRUNCATE api_keys, client_services CASCADE;`)
+	require.NoError(t, err)
+
+	key, err := auth.NewKey()
+	require.NoError(t, err)
+
+	id, err := s.CreateAPIKey(context.Background(), "billing", key, []auth.Scope{auth.ScopeFundsReserve})
+	require.NoError(t, err)
+
+	require.NoError(t, s.BindServices(context.Background(), "billing", []int64{3, 1}))
+	require.NoError(t, s.BindServices(context.Background(), "billing", []int64{1}))
+	require.NoError(t, s.BindServices(context.Background(), "delivery", []int64{2}))
+
+	stored, err := s.ReadAPIKey(context.Background(), key.Prefix)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 3}, stored.ServiceIDs)
+
+	require.NoError(t, s.UnbindServices(context.Background(), "billing", []int64{3}))
+
+	stored, err = s.ReadAPIKey(context.Background(), key.Prefix)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1}, stored.ServiceIDs)
+
+	err = s.AuditDenial(context.Background(), AuthorizationDenial{
+		Client:    "billing",
+		KeyID:     id,
+		Operation: "reservation",
+		AccountID: 2,
+		ServiceID: 2,
+		OrderID:   7,
+		CreatedAt: time.Now(),
+	})
+	require.NoError(t, err)
+
+	denials, err := s.ListAuthorizationDenials(context.Background(), 10, 0)
+	require.NoError(t, err)
+	require.Len(t, denials, 1)
+	assert.Equal(t, "reservation", denials[0].Operation)
+	assert.Equal(t, int64(2), denials[0].ServiceID)
+}
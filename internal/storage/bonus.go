@@ -0,0 +1,359 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"http-avito-test/internal/money"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/zap"
+)
+
+// BonusOrder defines which balance bucket Reservation and Transfer spend first
+type BonusOrder string
+
+const (
+	BonusOrderBonusFirst BonusOrder = "bonus_first"
+	BonusOrderRealFirst  BonusOrder = "real_first"
+)
+
+var ErrNoBonusGrant = errors.New("bonus grant does not exist")
+
+// GrantBonus credits promotional money from the promotions account to the user's bonus bucket until expiresAt
+func (s *Storage) GrantBonus(ctx context.Context, userID int64, amount money.Money, expiresAt time.Time) (grantID int64, err error) {
+	logger := s.logger(ctx).With(zap.Int64("userID", userID))
+	logger.Debug("granting bonus", zap.Time("expiresAt", expiresAt))
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(ctx); errRollback != nil {
+				logger.Error("error rolls back the transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	insertQuery := `INSERT INTO bonus_grants (account_id, amount, remaining, expires_at, created_at)
+			VALUES ($1, $2, $2, $3, $4) RETURNING id;`
+
+	err = tx.QueryRow(ctx, insertQuery, userID, amount, expiresAt, time.Now()).Scan(&grantID)
+	if err != nil {
+		logger.Error("failed to insert record", zap.Error(err))
+		return 0, err
+	}
+
+	var description = fmt.Sprintf(`Bonus grant %d`, grantID)
+
+	err = postDeposit(ctx, tx, s.Accounts.Promotions, userID, amount, &description)
+	if err != nil {
+		logger.Error("failed to insert record", zap.Error(err))
+		return 0, err
+	}
+
+	err = commit(ctx, tx)
+	observeMoved("bonus", amount, err)
+	return grantID, err
+}
+
+// ReadBalanceBuckets splits the user's balance into real money and unexpired bonus grants
+func (s *Storage) ReadBalanceBuckets(ctx context.Context, userID int64) (b BalanceBuckets, err error) {
+	logger := s.logger(ctx).With(zap.Int64("user_ID", userID))
+	logger.Debug("reading the balance buckets")
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return BalanceBuckets{}, err
+	}
+
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(ctx); errRollback != nil {
+				logger.Error("error rolls back the transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	var balance money.Money
+	err = tx.QueryRow(ctx, updateRollUpTable, userID).Scan(&balance)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.NotNullViolation {
+			logger.Error("error returning user balance with specified id: user does not exist", zap.Error(err))
+			return BalanceBuckets{}, ErrUserAvailability
+		}
+		logger.Error("error returning user balance with specified id", zap.Error(err))
+		return BalanceBuckets{}, err
+	}
+
+	selectQuery := `SELECT id, amount, remaining, expires_at FROM bonus_grants
+		WHERE account_id = $1 AND remaining > 0 ORDER BY expires_at, id;`
+
+	rows, err := tx.Query(ctx, selectQuery, userID)
+	if err != nil {
+		logger.Error("Query error", zap.Error(err))
+		return BalanceBuckets{}, err
+	}
+
+	var now = time.Now()
+	var expired money.Money
+
+	b.Grants = make([]BonusGrant, 0)
+	for rows.Next() {
+		var g BonusGrant
+		err = rows.Scan(&g.ID, &g.Amount, &g.Remaining, &g.ExpiresAt)
+		if err != nil {
+			rows.Close()
+			logger.Error("scanning row error", zap.Error(err))
+			return BalanceBuckets{}, err
+		}
+
+		// expired grants are no longer available to the user even before the expiry job collects them
+		if !now.Before(g.ExpiresAt) {
+			expired = expired.Add(g.Remaining)
+			continue
+		}
+
+		b.Bonus = b.Bonus.Add(g.Remaining)
+		b.Grants = append(b.Grants, g)
+	}
+	if err = rows.Err(); err != nil {
+		logger.Error("Query error", zap.Error(err))
+		return BalanceBuckets{}, err
+	}
+
+	b.AccountID = userID
+	b.Real = balance.Sub(expired).Sub(b.Bonus)
+
+	err = commit(ctx, tx)
+	return b, err
+}
+
+// ExpireBonuses posts the remaining money of the bonus grants expired at now back to the promotions account
+// and returns the number of expired grants
+func (s *Storage) ExpireBonuses(ctx context.Context, now time.Time) (int, error) {
+	logger := s.logger(ctx).With(zap.Time("now", now))
+	logger.Debug("expiring bonus grants")
+
+	selectQuery := `SELECT id FROM bonus_grants WHERE remaining > 0 AND expires_at <= $1 ORDER BY expires_at;`
+
+	rows, err := s.DB.Query(ctx, selectQuery, now)
+	if err != nil {
+		logger.Error("Query error", zap.Error(err))
+		return 0, err
+	}
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		err := rows.Scan(&id)
+		if err != nil {
+			rows.Close()
+			logger.Error("scanning row error", zap.Error(err))
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error("Query error", zap.Error(err))
+		return 0, err
+	}
+
+	var expired int
+	for _, id := range ids {
+		err := s.expireBonusGrant(ctx, id, now)
+		if err != nil {
+			if errors.Is(err, ErrNoBonusGrant) {
+				continue
+			}
+			logger.Error("failed to expire the bonus grant", zap.Int64("grantID", id), zap.Error(err))
+			return expired, err
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+func (s *Storage) expireBonusGrant(ctx context.Context, grantID int64, now time.Time) (err error) {
+	logger := s.logger(ctx).With(zap.Int64("grantID", grantID))
+	logger.Debug("expiring the bonus grant")
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(ctx); errRollback != nil {
+				logger.Error("error rolls back the transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	var (
+		accountID int64
+		remaining money.Money
+	)
+
+	// the grant could have been spent or expired after it was selected
+	selectQuery := `SELECT account_id, remaining FROM bonus_grants
+		WHERE id = $1 AND remaining > 0 AND expires_at <= $2 FOR UPDATE;`
+
+	err = tx.QueryRow(ctx, selectQuery, grantID, now).Scan(&accountID, &remaining)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = ErrNoBonusGrant
+		}
+		return err
+	}
+
+	var description = fmt.Sprintf(`Expiry of bonus grant %d`, grantID)
+
+	id, _, err := s.Transfer(ctx, accountID, s.Accounts.Promotions, remaining, &description, asNestedTo(tx), withoutBonus())
+	if err != nil {
+		return err
+	}
+
+	updateExec := `UPDATE bonus_grants SET remaining = 0, expiry_tx_id = $2 WHERE id = $1;`
+
+	_, err = tx.Exec(ctx, updateExec, grantID, id)
+	if err != nil {
+		return err
+	}
+
+	err = commit(ctx, tx)
+	observeMoved("bonus_expiry", remaining, err)
+	return err
+}
+
+// bonusBalance returns the sums of the account's bonus grants that are still active at now and that are already expired
+// but not yet collected by the expiry job
+func bonusBalance(ctx context.Context, tx pgx.Tx, accountID int64, now time.Time) (active, expired money.Money, err error) {
+	selectQuery := `SELECT coalesce(sum(remaining) FILTER (WHERE expires_at > $2), 0),
+		coalesce(sum(remaining) FILTER (WHERE expires_at <= $2), 0)
+		FROM bonus_grants WHERE account_id = $1 AND remaining > 0;`
+
+	err = tx.QueryRow(ctx, selectQuery, accountID, now).Scan(&active, &expired)
+	return active, expired, err
+}
+
+// spendBonus decides how much of the amount debited from the account by the posting txID is taken from its active
+// bonus grants according to the order and reduces the grants starting from the earliest expiring one.
+// The spent amounts are kept per posting, so a refund of the debit can give them back to the grants.
+// It returns the amounts spent from the grants and ErrTransfer when the amount exceeds the balance without
+// the expired bonuses
+func spendBonus(ctx context.Context, tx pgx.Tx, accountID, txID int64, balance, amount money.Money, order BonusOrder, now time.Time) ([]bonusSpending, error) {
+	active, expired, err := bonusBalance(ctx, tx, accountID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if amount.GreaterThan(balance.Sub(expired)) {
+		return nil, ErrTransfer
+	}
+
+	var fromBonus money.Money
+	switch order {
+	case BonusOrderRealFirst:
+		var real = balance.Sub(expired).Sub(active)
+		fromBonus = money.Max(amount.Sub(real), money.Money{})
+	default:
+		fromBonus = money.Min(amount, active)
+	}
+
+	if !fromBonus.IsPositive() {
+		return nil, nil
+	}
+
+	selectQuery := `SELECT id, remaining, expires_at FROM bonus_grants
+		WHERE account_id = $1 AND remaining > 0 AND expires_at > $2 ORDER BY expires_at, id FOR UPDATE;`
+
+	rows, err := tx.Query(ctx, selectQuery, accountID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	type grant struct {
+		id        int64
+		remaining money.Money
+		expiresAt time.Time
+	}
+
+	var grants []grant
+	for rows.Next() {
+		var g grant
+		err := rows.Scan(&g.id, &g.remaining, &g.expiresAt)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		grants = append(grants, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	updateExec := `UPDATE bonus_grants SET remaining = remaining - $2 WHERE id = $1;`
+	insertExec := `INSERT INTO bonus_spendings (grant_id, tx_id, amount) VALUES ($1, $2, $3);`
+
+	var spendings []bonusSpending
+	for _, g := range grants {
+		if !fromBonus.IsPositive() {
+			break
+		}
+
+		var spent = money.Min(fromBonus, g.remaining)
+		_, err := tx.Exec(ctx, updateExec, g.id, spent)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(ctx, insertExec, g.id, txID, spent)
+		if err != nil {
+			return nil, err
+		}
+		spendings = append(spendings, bonusSpending{amount: spent, expiresAt: g.expiresAt})
+		fromBonus = fromBonus.Sub(spent)
+	}
+	return spendings, nil
+}
+
+// bonusSpending is the amount spent from a bonus grant expiring at expiresAt
+type bonusSpending struct {
+	amount    money.Money
+	expiresAt time.Time
+}
+
+// passBonus grants the bonus spent on a transfer to the recipient with the expiry of the spent grants,
+// so the promotional money paid to another user stays promotional and can not be withdrawn
+func passBonus(ctx context.Context, tx pgx.Tx, recipient int64, spendings []bonusSpending, now time.Time) error {
+	insertExec := `INSERT INTO bonus_grants (account_id, amount, remaining, expires_at, created_at)
+			VALUES ($1, $2, $2, $3, $4);`
+
+	for _, sp := range spendings {
+		_, err := tx.Exec(ctx, insertExec, recipient, sp.amount, sp.expiresAt, now)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreBonus gives the bonus spent by the posting txID back to its grants when the debit is refunded.
+// The grants expired in the meantime are collected by the next run of the expiry job
+func restoreBonus(ctx context.Context, tx pgx.Tx, txID int64) error {
+	updateExec := `WITH restored AS (DELETE FROM bonus_spendings WHERE tx_id = $1 RETURNING grant_id, amount)
+		UPDATE bonus_grants g SET remaining = g.remaining + r.amount
+		FROM (SELECT grant_id, sum(amount) AS amount FROM restored GROUP BY grant_id) r
+		WHERE g.id = r.grant_id;`
+
+	_, err := tx.Exec(ctx, updateExec, txID)
+	return err
+}
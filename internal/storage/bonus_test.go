@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"context"
+	"http-avito-test/internal/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithdrawalUsesOnlyRealMoney(t *testing.T) {
+	s := bootstrap(t)
+
+	err := s.Deposit(context.Background(), 2, money.New(1000, money.RUB))
+	require.NoError(t, err)
+
+	_, err = s.GrantBonus(context.Background(), 2, money.New(500, money.RUB), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	err = s.Withdrawal(context.Background(), 2, money.New(1500, money.RUB), nil)
+	assert.ErrorIs(t, err, ErrWithdrawal)
+
+	err = s.Withdrawal(context.Background(), 2, money.New(1000, money.RUB), nil)
+	require.NoError(t, err)
+
+	buckets, err := s.ReadBalanceBuckets(context.Background(), 2)
+	require.NoError(t, err)
+	assert.True(t, buckets.Real.IsZero())
+	assert.True(t, money.New(500, money.RUB).Equal(buckets.Bonus))
+}
+
+func TestReservationSpendsBonusFirst(t *testing.T) {
+	s := bootstrap(t)
+
+	err := s.Deposit(context.Background(), 2, money.New(1000, money.RUB))
+	require.NoError(t, err)
+
+	_, err = s.GrantBonus(context.Background(), 2, money.New(300, money.RUB), time.Now().Add(2*time.Hour))
+	require.NoError(t, err)
+	_, err = s.GrantBonus(context.Background(), 2, money.New(200, money.RUB), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	err = s.Reservation(context.Background(), 2, 1, 1, money.New(400, money.RUB), nil)
+	require.NoError(t, err)
+
+	buckets, err := s.ReadBalanceBuckets(context.Background(), 2)
+	require.NoError(t, err)
+	assert.True(t, money.New(1000, money.RUB).Equal(buckets.Real))
+	assert.True(t, money.New(100, money.RUB).Equal(buckets.Bonus))
+	require.Len(t, buckets.Grants, 1)
+	assert.True(t, money.New(100, money.RUB).Equal(buckets.Grants[0].Remaining))
+}
+
+func TestReservationSpendsRealFirst(t *testing.T) {
+	s := bootstrap(t)
+	s.BonusOrder = BonusOrderRealFirst
+
+	err := s.Deposit(context.Background(), 2, money.New(1000, money.RUB))
+	require.NoError(t, err)
+
+	_, err = s.GrantBonus(context.Background(), 2, money.New(500, money.RUB), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	err = s.Reservation(context.Background(), 2, 1, 1, money.New(1200, money.RUB), nil)
+	require.NoError(t, err)
+
+	buckets, err := s.ReadBalanceBuckets(context.Background(), 2)
+	require.NoError(t, err)
+	assert.True(t, buckets.Real.IsZero())
+	assert.True(t, money.New(300, money.RUB).Equal(buckets.Bonus))
+}
+
+func TestTransferFollowsBonusOrder(t *testing.T) {
+	s := bootstrap(t)
+
+	err := s.Deposit(context.Background(), 2, money.New(1000, money.RUB))
+	require.NoError(t, err)
+
+	_, err = s.GrantBonus(context.Background(), 2, money.New(500, money.RUB), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	_, _, err = s.Transfer(context.Background(), 2, 3, money.New(1200, money.RUB), nil)
+	require.NoError(t, err)
+
+	sender, err := s.ReadBalanceBuckets(context.Background(), 2)
+	require.NoError(t, err)
+	assert.True(t, money.New(300, money.RUB).Equal(sender.Real))
+	assert.True(t, sender.Bonus.IsZero())
+
+	// the recipient gets the spent bonus as a bonus with the same expiry
+	recipient, err := s.ReadBalanceBuckets(context.Background(), 3)
+	require.NoError(t, err)
+	assert.True(t, money.New(700, money.RUB).Equal(recipient.Real))
+	assert.True(t, money.New(500, money.RUB).Equal(recipient.Bonus))
+
+	err = s.Withdrawal(context.Background(), 3, money.New(1200, money.RUB), nil)
+	assert.ErrorIs(t, err, ErrWithdrawal)
+
+	s.BonusOrder = BonusOrderRealFirst
+
+	_, _, err = s.Transfer(context.Background(), 3, 2, money.New(800, money.RUB), nil)
+	require.NoError(t, err)
+
+	recipient, err = s.ReadBalanceBuckets(context.Background(), 3)
+	require.NoError(t, err)
+	assert.True(t, recipient.Real.IsZero())
+	assert.True(t, money.New(400, money.RUB).Equal(recipient.Bonus))
+}
+
+func TestHoldsUseOnlyRealMoney(t *testing.T) {
+	s := bootstrap(t)
+
+	err := s.Deposit(context.Background(), 2, money.New(1000, money.RUB))
+	require.NoError(t, err)
+
+	_, err = s.GrantBonus(context.Background(), 2, money.New(500, money.RUB), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	_, err = s.CreateEscrow(context.Background(), 2, 3, money.New(1200, money.RUB), nil)
+	assert.ErrorIs(t, err, ErrTransfer)
+
+	_, err = s.DelayedTransfer(context.Background(), 2, 3, money.New(1200, money.RUB), nil, time.Now().Add(time.Minute))
+	assert.ErrorIs(t, err, ErrTransfer)
+
+	_, err = s.DelayedTransfer(context.Background(), 2, 3, money.New(1000, money.RUB), nil, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+
+	buckets, err := s.ReadBalanceBuckets(context.Background(), 2)
+	require.NoError(t, err)
+	assert.True(t, buckets.Real.IsZero())
+	assert.True(t, money.New(500, money.RUB).Equal(buckets.Bonus))
+}
+
+func TestUnreservationRestoresBonus(t *testing.T) {
+	s := bootstrap(t)
+
+	err := s.Deposit(context.Background(), 2, money.New(1000, money.RUB))
+	require.NoError(t, err)
+
+	_, err = s.GrantBonus(context.Background(), 2, money.New(500, money.RUB), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	err = s.Reservation(context.Background(), 2, 1, 1, money.New(800, money.RUB), nil)
+	require.NoError(t, err)
+
+	err = s.Unreservation(context.Background(), 2, 1, 1, nil)
+	require.NoError(t, err)
+
+	buckets, err := s.ReadBalanceBuckets(context.Background(), 2)
+	require.NoError(t, err)
+	assert.True(t, money.New(1000, money.RUB).Equal(buckets.Real))
+	assert.True(t, money.New(500, money.RUB).Equal(buckets.Bonus))
+
+	// the bonus part of the refunded reservation stays non-withdrawable
+	err = s.Withdrawal(context.Background(), 2, money.New(1500, money.RUB), nil)
+	assert.ErrorIs(t, err, ErrWithdrawal)
+
+	err = s.Withdrawal(context.Background(), 2, money.New(1000, money.RUB), nil)
+	require.NoError(t, err)
+}
+
+func TestExpireBonuses(t *testing.T) {
+	s := bootstrap(t)
+
+	err := s.Deposit(context.Background(), 2, money.New(1000, money.RUB))
+	require.NoError(t, err)
+
+	expiresAt := time.Now().Add(time.Minute)
+	_, err = s.GrantBonus(context.Background(), 2, money.New(500, money.RUB), expiresAt)
+	require.NoError(t, err)
+
+	expired, err := s.ExpireBonuses(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 0, expired)
+
+	expired, err = s.ExpireBonuses(context.Background(), expiresAt)
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+
+	user, err := s.ReadUserByID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.True(t, money.New(1000, money.RUB).Equal(user.Balance))
+
+	promotions, err := s.ReadUserByID(context.Background(), s.Accounts.Promotions)
+	require.NoError(t, err)
+	assert.True(t, promotions.Balance.IsZero())
+}
+
+func TestExpiredBonusCannotBeSpent(t *testing.T) {
+	s := bootstrap(t)
+
+	err := s.Deposit(context.Background(), 2, money.New(1000, money.RUB))
+	require.NoError(t, err)
+
+	_, err = s.GrantBonus(context.Background(), 2, money.New(500, money.RUB), time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+
+	_, _, err = s.Transfer(context.Background(), 2, 3, money.New(1200, money.RUB), nil)
+	assert.ErrorIs(t, err, ErrTransfer)
+
+	buckets, err := s.ReadBalanceBuckets(context.Background(), 2)
+	require.NoError(t, err)
+	assert.True(t, money.New(1000, money.RUB).Equal(buckets.Real))
+	assert.True(t, buckets.Bonus.IsZero())
+}
@@ -2,23 +2,37 @@ package storage
 
 import (
 	"database/sql"
+	"http-avito-test/internal/auth"
+	"http-avito-test/internal/money"
 	"time"
-
-	"github.com/shopspring/decimal"
 )
 
 type User struct {
-	AccountID int64           `json:"userID"`
-	Balance   decimal.Decimal `json:"balance"`
+	AccountID int64       `json:"userID"`
+	Balance   money.Money `json:"balance"`
+}
+
+// UserResult is a single item of the bulk balance read
+type UserResult struct {
+	User
+	Err error `json:"-"`
+}
+
+// Quote is the projected outcome of an operation executed in dry-run mode
+type Quote struct {
+	AccountID int64
+	Balance   money.Money
+	Fee       money.Money
 }
 
 type ReadUserHistoryResult struct {
-	AccountID   int64           `json:"userID"`
-	CashBook    OperationType   `json:"cashebook"`
-	Amount      decimal.Decimal `json:"amount"`
-	Date        time.Time       `json:"date"`
-	Addressee   sql.NullInt64   `json:"addressee"`
-	Description sql.NullString  `json:"description"`
+	AccountID   int64          `json:"userID"`
+	CashBook    OperationType  `json:"cashebook"`
+	Amount      money.Money    `json:"amount"`
+	Date        time.Time      `json:"date"`
+	Addressee   sql.NullInt64  `json:"addressee"`
+	Description sql.NullString `json:"description"`
+	Status      sql.NullString `json:"status"`
 }
 
 type OperationType string
@@ -42,3 +56,175 @@ const (
 	ExpensesTypeReservation   ExpensesType = "reservation"
 	ExpensesTypeUnreservation ExpensesType = "unreservation"
 )
+
+type TransferStatus string
+
+const (
+	TransferStatusPending   TransferStatus = "pending"
+	TransferStatusSettled   TransferStatus = "settled"
+	TransferStatusCancelled TransferStatus = "cancelled"
+)
+
+type PaymentRequest struct {
+	ID          int64                `json:"id"`
+	Requester   int64                `json:"requester"`
+	Payer       int64                `json:"payer"`
+	Amount      money.Money          `json:"amount"`
+	Description sql.NullString       `json:"description"`
+	Status      PaymentRequestStatus `json:"status"`
+	CreatedAt   time.Time            `json:"created_at"`
+	ExpiresAt   time.Time            `json:"expires_at"`
+}
+
+type PaymentRequestStatus string
+
+const (
+	PaymentRequestStatusPending  PaymentRequestStatus = "pending"
+	PaymentRequestStatusAccepted PaymentRequestStatus = "accepted"
+	PaymentRequestStatusDeclined PaymentRequestStatus = "declined"
+	PaymentRequestStatusExpired  PaymentRequestStatus = "expired"
+)
+
+type PaymentRequestDirection string
+
+const (
+	PaymentRequestsIncoming PaymentRequestDirection = "incoming"
+	PaymentRequestsOutgoing PaymentRequestDirection = "outgoing"
+)
+
+type Escrow struct {
+	ID          int64          `json:"id"`
+	Payer       int64          `json:"payer"`
+	Beneficiary int64          `json:"beneficiary"`
+	Amount      money.Money    `json:"amount"`
+	Released    money.Money    `json:"released"`
+	Refunded    money.Money    `json:"refunded"`
+	Description sql.NullString `json:"description"`
+	Status      EscrowStatus   `json:"status"`
+	CreatedAt   time.Time      `json:"created_at"`
+	FinishedAt  sql.NullTime   `json:"finished_at"`
+}
+
+type EscrowStatus string
+
+const (
+	EscrowStatusHeld     EscrowStatus = "held"
+	EscrowStatusReleased EscrowStatus = "released"
+	EscrowStatusRefunded EscrowStatus = "refunded"
+	EscrowStatusSplit    EscrowStatus = "split"
+)
+
+type BalanceBuckets struct {
+	AccountID int64        `json:"user_id"`
+	Real      money.Money  `json:"real"`
+	Bonus     money.Money  `json:"bonus"`
+	Grants    []BonusGrant `json:"grants"`
+}
+
+type BonusGrant struct {
+	ID        int64       `json:"id"`
+	Amount    money.Money `json:"amount"`
+	Remaining money.Money `json:"remaining"`
+	ExpiresAt time.Time   `json:"expires_at"`
+}
+
+type DepositStatus string
+
+const (
+	DepositStatusPending   DepositStatus = "pending"
+	DepositStatusSucceeded DepositStatus = "succeeded"
+	DepositStatusFailed    DepositStatus = "failed"
+)
+
+type WithdrawalRequest struct {
+	ID          int64            `json:"id"`
+	AccountID   int64            `json:"user_id"`
+	Amount      money.Money      `json:"amount"`
+	Description sql.NullString   `json:"description"`
+	Status      WithdrawalStatus `json:"status"`
+	CreatedAt   time.Time        `json:"created_at"`
+	DecidedAt   sql.NullTime     `json:"decided_at"`
+	Operator    sql.NullString   `json:"operator"`
+	Reason      sql.NullString   `json:"reason"`
+}
+
+type WithdrawalStatus string
+
+const (
+	WithdrawalStatusPending  WithdrawalStatus = "pending"
+	WithdrawalStatusApproved WithdrawalStatus = "approved"
+	WithdrawalStatusRejected WithdrawalStatus = "rejected"
+)
+
+type FraudFlag struct {
+	ID           int64         `json:"id"`
+	Rule         string        `json:"rule"`
+	Action       string        `json:"action"`
+	Operation    string        `json:"operation"`
+	AccountID    int64         `json:"user_id"`
+	Counterparty sql.NullInt64 `json:"counterparty"`
+	Amount       money.Money   `json:"amount"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+// APIKey is the stored key of the calling service, Hash is the hash of its secret
+type APIKey struct {
+	ID          int64        `json:"id"`
+	Client      string       `json:"client"`
+	Prefix      string       `json:"prefix"`
+	Hash        []byte       `json:"-"`
+	Scopes      []auth.Scope `json:"scopes"`
+	CreatedAt   time.Time    `json:"created_at"`
+	RevokedAt   sql.NullTime `json:"revoked_at"`
+	RotatedFrom *int64       `json:"rotated_from"`
+	// ServiceIDs are the services the client is bound to, it may operate the orders of these services only
+	ServiceIDs []int64 `json:"service_ids"`
+}
+
+// AuthorizationDenial is the audit record of the operation rejected because the client is not bound to its service
+type AuthorizationDenial struct {
+	ID        int64     `json:"id"`
+	Client    string    `json:"client"`
+	KeyID     int64     `json:"key_id"`
+	Operation string    `json:"operation"`
+	AccountID int64     `json:"user_id"`
+	ServiceID int64     `json:"service_id"`
+	OrderID   int64     `json:"order_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Reconciliation is the result of matching the bank statement to the cash book postings
+type Reconciliation struct {
+	StatementID       int64           `json:"statement_id"`
+	Matched           []StatementLine `json:"matched"`
+	UnmatchedInBank   []StatementLine `json:"unmatched_in_bank"`
+	UnmatchedInLedger []LedgerPosting `json:"unmatched_in_ledger"`
+}
+
+type StatementLine struct {
+	ID         int64               `json:"id"`
+	LineNo     int                 `json:"line_no"`
+	Date       time.Time           `json:"date"`
+	Amount     money.Money         `json:"amount"`
+	Reference  sql.NullString      `json:"reference"`
+	Status     StatementLineStatus `json:"status"`
+	PostingID  sql.NullInt64       `json:"posting_id"`
+	ResolvedBy sql.NullString      `json:"resolved_by"`
+	Note       sql.NullString      `json:"note"`
+}
+
+type StatementLineStatus string
+
+const (
+	StatementLineStatusUnmatched StatementLineStatus = "unmatched"
+	StatementLineStatusMatched   StatementLineStatus = "matched"
+	StatementLineStatusResolved  StatementLineStatus = "resolved"
+)
+
+// LedgerPosting is a cash book posting, its amount is shown from the side of the bank account like the statement lines
+type LedgerPosting struct {
+	ID       int64         `json:"id"`
+	CashBook OperationType `json:"cashebook"`
+	Amount   money.Money   `json:"amount"`
+	Date     time.Time     `json:"date"`
+}
@@ -0,0 +1,255 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"http-avito-test/internal/fraud"
+	"http-avito-test/internal/money"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNoEscrow       = errors.New("escrow does not exist")
+	ErrEscrowFinished = errors.New("escrow is already released or refunded")
+	ErrEscrowSplit    = errors.New("split share exceeds the escrow amount")
+)
+
+// CreateEscrow deducts money from the payer and holds it on the escrow account on behalf of the beneficiary.
+// Only real money of the payer can be held, since it ends up on the beneficiary's account
+func (s *Storage) CreateEscrow(ctx context.Context, payer, beneficiary int64, amount money.Money, description *string) (escrowID int64, err error) {
+	logger := s.logger(ctx).With(zap.Int64("payerID", payer), zap.Int64("beneficiaryID", beneficiary))
+	logger.Debug("creating escrow")
+
+	tx, err := s.DB.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(ctx); errRollback != nil {
+				logger.Error("error rolls back the transaction", zap.Error(err))
+			}
+			err = serializationError(err)
+		}
+	}()
+
+	holdID, _, err := s.Transfer(ctx, payer, s.Accounts.Escrow, amount, description, asNestedTo(tx), withRealMoney())
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrSerialization):
+			logger.Warn("transaction isolation level error", zap.Error(err))
+			return 0, ErrSerialization
+		case errors.Is(err, ErrTransfer):
+			logger.Error("insufficient funds on the payer's account", zap.Error(ErrTransfer))
+			return 0, ErrTransfer
+		case errors.Is(err, ErrUserAvailability):
+			logger.Error("error returning user balance with specified id: user does not exist", zap.Error(err))
+			return 0, ErrUserAvailability
+		default:
+			logger.Error("error updating balance", zap.Error(err))
+			return 0, err
+		}
+	}
+
+	insertQuery := `INSERT INTO escrows (payer, beneficiary, amount, description, status, created_at, hold_tx_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id;`
+
+	err = tx.QueryRow(
+		ctx,
+		insertQuery,
+		payer,
+		beneficiary,
+		amount,
+		description,
+		EscrowStatusHeld,
+		time.Now(),
+		holdID,
+	).Scan(&escrowID)
+	if err != nil {
+		logger.Error("failed to insert record", zap.Error(err))
+		return 0, err
+	}
+
+	// the escrow is checked after it is stored, so the fraud rules count it among the payer's transfers
+	err = s.checkFraud(ctx, tx, fraud.Operation{
+		Type:         fraud.OperationTransfer,
+		AccountID:    payer,
+		Counterparty: beneficiary,
+		Amount:       amount,
+		At:           time.Now(),
+	}, false)
+	if err != nil {
+		return 0, err
+	}
+
+	err = commit(ctx, tx)
+	observeMoved("escrow_hold", amount, err)
+	return escrowID, err
+}
+
+// ReleaseEscrow pays the whole held amount out to the beneficiary
+func (s *Storage) ReleaseEscrow(ctx context.Context, escrowID int64) error {
+	return s.finishEscrow(ctx, escrowID, nil)
+}
+
+// RefundEscrow returns the whole held amount back to the payer
+func (s *Storage) RefundEscrow(ctx context.Context, escrowID int64) error {
+	var share money.Money
+	return s.finishEscrow(ctx, escrowID, &share)
+}
+
+// SplitEscrow pays the share out to the beneficiary and returns the rest of the held amount back to the payer
+func (s *Storage) SplitEscrow(ctx context.Context, escrowID int64, beneficiaryShare money.Money) error {
+	return s.finishEscrow(ctx, escrowID, &beneficiaryShare)
+}
+
+// ReadEscrow returns the escrow with its current status and paid out amounts
+func (s *Storage) ReadEscrow(ctx context.Context, escrowID int64) (Escrow, error) {
+	logger := s.logger(ctx).With(zap.Int64("escrowID", escrowID))
+	logger.Debug("reading escrow")
+
+	var e Escrow
+
+	selectQuery := `SELECT id, payer, beneficiary, amount, released, refunded, description, status, created_at, finished_at
+		FROM escrows WHERE id = $1;`
+
+	err := s.DB.QueryRow(ctx, selectQuery, escrowID).Scan(
+		&e.ID,
+		&e.Payer,
+		&e.Beneficiary,
+		&e.Amount,
+		&e.Released,
+		&e.Refunded,
+		&e.Description,
+		&e.Status,
+		&e.CreatedAt,
+		&e.FinishedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			logger.Error("escrow does not exist", zap.Error(ErrNoEscrow))
+			return Escrow{}, ErrNoEscrow
+		}
+		logger.Error("error returning escrow", zap.Error(err))
+		return Escrow{}, err
+	}
+
+	return e, nil
+}
+
+// finishEscrow moves the held money from the escrow account to the beneficiary and the payer.
+// A nil share releases the whole amount to the beneficiary
+func (s *Storage) finishEscrow(ctx context.Context, escrowID int64, beneficiaryShare *money.Money) (err error) {
+	logger := s.logger(ctx).With(zap.Int64("escrowID", escrowID))
+	logger.Debug("finishing escrow")
+
+	tx, err := s.DB.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(ctx); errRollback != nil {
+				logger.Error("error rolls back the transaction", zap.Error(err))
+			}
+			err = serializationError(err)
+		}
+	}()
+
+	e, err := selectHeldEscrow(ctx, tx, escrowID)
+	if err != nil {
+		logger.Error("error returning escrow", zap.Error(err))
+		return err
+	}
+
+	var released = e.amount
+	if beneficiaryShare != nil {
+		released = *beneficiaryShare
+	}
+
+	if released.IsNegative() || released.GreaterThan(e.amount) {
+		logger.Error("wrong share of the escrow", zap.Error(ErrEscrowSplit))
+		err = ErrEscrowSplit
+		return err
+	}
+	var refunded = e.amount.Sub(released)
+
+	var status EscrowStatus
+	switch {
+	case refunded.IsZero():
+		status = EscrowStatusReleased
+	case released.IsZero():
+		status = EscrowStatusRefunded
+	default:
+		status = EscrowStatusSplit
+	}
+
+	var releaseID, refundID *int64
+
+	if released.IsPositive() {
+		_, id, err := s.Transfer(ctx, s.Accounts.Escrow, e.beneficiary, released, e.description, asNestedTo(tx), onBehalfOf(e.payer, e.beneficiary))
+		if err != nil {
+			logger.Error("error paying out money to the beneficiary", zap.Error(err))
+			return err
+		}
+		releaseID = &id
+	}
+
+	if refunded.IsPositive() {
+		var description = fmt.Sprintf(`Refund of escrow %d`, escrowID)
+
+		_, id, err := s.Transfer(ctx, s.Accounts.Escrow, e.payer, refunded, &description, asNestedTo(tx))
+		if err != nil {
+			logger.Error("error returning money to the payer", zap.Error(err))
+			return err
+		}
+		refundID = &id
+	}
+
+	updateExec := `UPDATE escrows SET status = $2, released = $3, refunded = $4, finished_at = $5, release_tx_id = $6, refund_tx_id = $7
+		WHERE id = $1;`
+
+	_, err = tx.Exec(ctx, updateExec, escrowID, status, released, refunded, time.Now(), releaseID, refundID)
+	if err != nil {
+		logger.Error("failed to update record", zap.Error(err))
+		return err
+	}
+
+	err = commit(ctx, tx)
+	observeMoved("escrow_release", released, err)
+	observeMoved("escrow_refund", refunded, err)
+	return err
+}
+
+type heldEscrow struct {
+	payer       int64
+	beneficiary int64
+	amount      money.Money
+	description *string
+}
+
+// selectHeldEscrow reads the escrow that still holds money and locks it until the end of the transaction
+func selectHeldEscrow(ctx context.Context, tx pgx.Tx, escrowID int64) (e heldEscrow, err error) {
+	var status EscrowStatus
+
+	selectQuery := `SELECT payer, beneficiary, amount, description, status FROM escrows WHERE id = $1 FOR UPDATE;`
+
+	err = tx.QueryRow(ctx, selectQuery, escrowID).Scan(&e.payer, &e.beneficiary, &e.amount, &e.description, &status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return heldEscrow{}, ErrNoEscrow
+		}
+		return heldEscrow{}, err
+	}
+
+	if status != EscrowStatusHeld {
+		return heldEscrow{}, ErrEscrowFinished
+	}
+	return e, nil
+}
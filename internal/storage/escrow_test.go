@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"http-avito-test/internal/money"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReleaseEscrow(t *testing.T) {
+	s := bootstrap(t)
+
+	err := s.Deposit(context.Background(), 2, money.New(10000, money.RUB))
+	require.NoError(t, err)
+
+	description := "test"
+	id, err := s.CreateEscrow(context.Background(), 2, 3, money.New(4000, money.RUB), &description)
+	require.NoError(t, err)
+
+	payer, err := s.ReadUserByID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.True(t, money.New(6000, money.RUB).Equal(payer.Balance))
+
+	err = s.ReleaseEscrow(context.Background(), id)
+	require.NoError(t, err)
+
+	err = s.RefundEscrow(context.Background(), id)
+	assert.ErrorIs(t, err, ErrEscrowFinished)
+
+	beneficiary, err := s.ReadUserByID(context.Background(), 3)
+	require.NoError(t, err)
+	assert.True(t, money.New(4000, money.RUB).Equal(beneficiary.Balance))
+
+	escrow, err := s.ReadEscrow(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, EscrowStatusReleased, escrow.Status)
+	assert.True(t, money.New(4000, money.RUB).Equal(escrow.Released))
+	assert.True(t, escrow.Refunded.IsZero())
+	assert.True(t, escrow.FinishedAt.Valid)
+}
+
+func TestRefundEscrow(t *testing.T) {
+	s := bootstrap(t)
+
+	err := s.Deposit(context.Background(), 2, money.New(10000, money.RUB))
+	require.NoError(t, err)
+
+	id, err := s.CreateEscrow(context.Background(), 2, 3, money.New(4000, money.RUB), nil)
+	require.NoError(t, err)
+
+	err = s.RefundEscrow(context.Background(), id)
+	require.NoError(t, err)
+
+	payer, err := s.ReadUserByID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.True(t, money.New(10000, money.RUB).Equal(payer.Balance))
+
+	escrow, err := s.ReadEscrow(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, EscrowStatusRefunded, escrow.Status)
+	assert.True(t, money.New(4000, money.RUB).Equal(escrow.Refunded))
+}
+
+func TestSplitEscrow(t *testing.T) {
+	s := bootstrap(t)
+
+	err := s.Deposit(context.Background(), 2, money.New(10000, money.RUB))
+	require.NoError(t, err)
+
+	id, err := s.CreateEscrow(context.Background(), 2, 3, money.New(4000, money.RUB), nil)
+	require.NoError(t, err)
+
+	err = s.SplitEscrow(context.Background(), id, money.New(5000, money.RUB))
+	assert.ErrorIs(t, err, ErrEscrowSplit)
+
+	err = s.SplitEscrow(context.Background(), id, money.New(1000, money.RUB))
+	require.NoError(t, err)
+
+	payer, err := s.ReadUserByID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.True(t, money.New(9000, money.RUB).Equal(payer.Balance))
+
+	beneficiary, err := s.ReadUserByID(context.Background(), 3)
+	require.NoError(t, err)
+	assert.True(t, money.New(1000, money.RUB).Equal(beneficiary.Balance))
+
+	escrow, err := s.ReadEscrow(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, EscrowStatusSplit, escrow.Status)
+	assert.True(t, money.New(1000, money.RUB).Equal(escrow.Released))
+	assert.True(t, money.New(3000, money.RUB).Equal(escrow.Refunded))
+}
+
+func TestEscrowErrors(t *testing.T) {
+	s := bootstrap(t)
+
+	_, err := s.CreateEscrow(context.Background(), 2, 3, money.New(4000, money.RUB), nil)
+	assert.ErrorIs(t, err, ErrUserAvailability)
+
+	err = s.Deposit(context.Background(), 2, money.New(1000, money.RUB))
+	require.NoError(t, err)
+
+	_, err = s.CreateEscrow(context.Background(), 2, 3, money.New(4000, money.RUB), nil)
+	assert.ErrorIs(t, err, ErrTransfer)
+
+	err = s.ReleaseEscrow(context.Background(), 1000000)
+	assert.ErrorIs(t, err, ErrNoEscrow)
+
+	_, err = s.ReadEscrow(context.Background(), 1000000)
+	assert.ErrorIs(t, err, ErrNoEscrow)
+}
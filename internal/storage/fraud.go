@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"http-avito-test/internal/fraud"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/zap"
+)
+
+var ErrFraudBlocked = errors.New("operation is blocked by the fraud rules")
+
+// txHistory reads the history of the accounts for the fraud rules inside the transaction of the checked operation
+type txHistory struct {
+	tx pgx.Tx
+}
+
+func (h txHistory) FirstActivity(ctx context.Context, accountID int64) (time.Time, bool, error) {
+	var first sql.NullTime
+
+	selectQuery := `SELECT min(date) FROM posting WHERE account_id = $1;`
+
+	err := h.tx.QueryRow(ctx, selectQuery, accountID).Scan(&first)
+	return first.Time, first.Valid, err
+}
+
+// userTransfers selects the sender and the recipient of the money moved between the users since $1:
+// the direct transfers and the delayed transfers and escrows, whose money is held on the system accounts
+// until it is paid out. The cancelled transfers and refunded escrows never reach the recipient
+const userTransfers = `SELECT account_id AS sender, addressee AS recipient FROM posting
+		WHERE cb_journal = $2 AND amount < 0 AND addressee > 0 AND date >= $1
+	UNION ALL
+	SELECT t.sender, t.recipient FROM pending_transfers t JOIN posting p ON p.id = t.hold_tx_id
+		WHERE t.status <> $3 AND p.date >= $1
+	UNION ALL
+	SELECT payer, beneficiary FROM escrows WHERE status <> $4 AND created_at >= $1`
+
+func (h txHistory) DistinctRecipients(ctx context.Context, accountID int64, since time.Time) (int64, error) {
+	var count int64
+
+	selectQuery := `SELECT count(DISTINCT recipient) FROM (` + userTransfers + `) t WHERE sender = $5;`
+
+	err := h.tx.QueryRow(
+		ctx,
+		selectQuery,
+		since,
+		OperationTypeTransfer,
+		TransferStatusCancelled,
+		EscrowStatusRefunded,
+		accountID,
+	).Scan(&count)
+	return count, err
+}
+
+func (h txHistory) HasTransfer(ctx context.Context, sender, recipient int64, since time.Time) (bool, error) {
+	var exists bool
+
+	selectQuery := `SELECT exists (SELECT 1 FROM (` + userTransfers + `) t WHERE sender = $5 AND recipient = $6);`
+
+	err := h.tx.QueryRow(
+		ctx,
+		selectQuery,
+		since,
+		OperationTypeTransfer,
+		TransferStatusCancelled,
+		EscrowStatusRefunded,
+		sender,
+		recipient,
+	).Scan(&exists)
+	return exists, err
+}
+
+// ListFraudFlags returns the stored matches of the flag and block fraud rules starting from the newest
+func (s *Storage) ListFraudFlags(ctx context.Context, limit, offset int64) ([]FraudFlag, error) {
+	logger := s.logger(ctx)
+	logger.Debug("reading fraud flags", zap.Int64("limit", limit), zap.Int64("offset", offset))
+
+	selectQuery := `SELECT id, rule, action, operation, account_id, counterparty, amount, created_at
+		FROM fraud_flags ORDER BY created_at DESC, id DESC LIMIT $1 OFFSET $2;`
+
+	rows, err := s.DB.Query(ctx, selectQuery, limit, offset)
+	if err != nil {
+		logger.Error("Query error", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ff = make([]FraudFlag, 0)
+	for rows.Next() {
+		var f FraudFlag
+		err := rows.Scan(&f.ID, &f.Rule, &f.Action, &f.Operation, &f.AccountID, &f.Counterparty, &f.Amount, &f.CreatedAt)
+		if err != nil {
+			logger.Error("scanning row error", zap.Error(err))
+			return nil, err
+		}
+		ff = append(ff, f)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error("Query error", zap.Error(err))
+		return nil, err
+	}
+	return ff, nil
+}
+
+// checkFraud runs the fraud rules against the operation before its transaction is committed.
+// Flags are stored within the transaction, blocks are stored apart from it since the transaction is rolled back.
+// Nothing is stored for dry-run operations
+func (s *Storage) checkFraud(ctx context.Context, tx pgx.Tx, op fraud.Operation, dryRun bool) error {
+	if s.Fraud == nil {
+		return nil
+	}
+
+	logger := s.logger(ctx).With(zap.String("operation", string(op.Type)), zap.Int64("accountID", op.AccountID))
+
+	verdict, err := s.Fraud.Check(ctx, txHistory{tx: tx}, op)
+	if err != nil {
+		logger.Error("error checking fraud rules", zap.Error(err))
+		return err
+	}
+
+	insertExec := `INSERT INTO fraud_flags (rule, action, operation, account_id, counterparty, amount, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7);`
+
+	var counterparty *int64
+	if op.Counterparty != 0 {
+		counterparty = &op.Counterparty
+	}
+
+	for _, hit := range verdict.Hits {
+		if dryRun || hit.Action == fraud.ActionAllow {
+			continue
+		}
+
+		logger.Warn("fraud rule matched", zap.String("rule", hit.Rule), zap.String("action", string(hit.Action)))
+
+		if hit.Action == fraud.ActionBlock {
+			_, err = s.DB.Exec(ctx, insertExec, hit.Rule, hit.Action, op.Type, op.AccountID, counterparty, op.Amount, op.At)
+		} else {
+			_, err = tx.Exec(ctx, insertExec, hit.Rule, hit.Action, op.Type, op.AccountID, counterparty, op.Amount, op.At)
+		}
+		if err != nil {
+			logger.Error("failed to insert record", zap.Error(err))
+			return err
+		}
+	}
+
+	if verdict.Action == fraud.ActionBlock {
+		return ErrFraudBlocked
+	}
+	return nil
+}
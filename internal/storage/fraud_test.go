@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"http-avito-test/internal/fraud"
+	"http-avito-test/internal/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFraudChecks(t *testing.T) {
+	s := bootstrap(t)
+
+	_, err := s.DB.Exec(context.Background(), `TRUNCATE fraud_flags;`)
+	require.NoError(t, err)
+
+	s.Fraud, err = fraud.NewEngine(
+		fraud.RuleConfig{
+			Name:       "new account",
+			Kind:       fraud.KindNewAccountAmount,
+			Action:     fraud.ActionBlock,
+			Operations: []fraud.OperationType{fraud.OperationWithdrawal},
+			MaxAge:     fraud.Duration{Duration: time.Hour},
+			Amount:     money.New(50, money.RUB),
+		},
+		fraud.RuleConfig{
+			Name:   "round trip",
+			Kind:   fraud.KindRoundTrip,
+			Action: fraud.ActionFlag,
+			Window: fraud.Duration{Duration: time.Hour},
+		},
+	)
+	require.NoError(t, err)
+
+	err = s.Deposit(context.Background(), 2, money.New(10000, money.RUB))
+	require.NoError(t, err)
+
+	err = s.Withdrawal(context.Background(), 2, money.New(8000, money.RUB), nil)
+	assert.ErrorIs(t, err, ErrFraudBlocked)
+
+	_, err = s.QuoteWithdrawal(context.Background(), 2, money.New(8000, money.RUB), nil)
+	assert.ErrorIs(t, err, ErrFraudBlocked)
+
+	err = s.Withdrawal(context.Background(), 2, money.New(1000, money.RUB), nil)
+	require.NoError(t, err)
+
+	_, _, err = s.Transfer(context.Background(), 2, 3, money.New(4000, money.RUB), nil)
+	require.NoError(t, err)
+
+	_, _, err = s.Transfer(context.Background(), 3, 2, money.New(1000, money.RUB), nil)
+	require.NoError(t, err)
+
+	user, err := s.ReadUserByID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.True(t, money.New(6000, money.RUB).Equal(user.Balance))
+
+	flags, err := s.ListFraudFlags(context.Background(), 10, 0)
+	require.NoError(t, err)
+	require.Len(t, flags, 2)
+
+	assert.Equal(t, "round trip", flags[0].Rule)
+	assert.Equal(t, string(fraud.ActionFlag), flags[0].Action)
+	assert.Equal(t, int64(3), flags[0].AccountID)
+	assert.Equal(t, int64(2), flags[0].Counterparty.Int64)
+
+	assert.Equal(t, "new account", flags[1].Rule)
+	assert.Equal(t, string(fraud.ActionBlock), flags[1].Action)
+	assert.True(t, money.New(8000, money.RUB).Equal(flags[1].Amount))
+}
+
+func TestFraudChecksNestedTransfers(t *testing.T) {
+	s := bootstrap(t)
+
+	_, err := s.DB.Exec(context.Background(), `TRUNCATE fraud_flags;`)
+	require.NoError(t, err)
+
+	s.Fraud, err = fraud.NewEngine(fraud.RuleConfig{
+		Name:   "mule",
+		Kind:   fraud.KindDistinctRecipients,
+		Action: fraud.ActionBlock,
+		Window: fraud.Duration{Duration: time.Hour},
+		Max:    1,
+	})
+	require.NoError(t, err)
+
+	err = s.Deposit(context.Background(), 2, money.New(10000, money.RUB))
+	require.NoError(t, err)
+
+	// the delayed transfer is counted though its money is held on the reserve account
+	_, err = s.DelayedTransfer(context.Background(), 2, 3, money.New(1000, money.RUB), nil, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+
+	_, _, err = s.Transfer(context.Background(), 2, 4, money.New(1000, money.RUB), nil)
+	assert.ErrorIs(t, err, ErrFraudBlocked)
+
+	_, err = s.CreateEscrow(context.Background(), 2, 4, money.New(1000, money.RUB), nil)
+	assert.ErrorIs(t, err, ErrFraudBlocked)
+
+	requestID, err := s.CreatePaymentRequest(context.Background(), 4, 2, money.New(1000, money.RUB), nil, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	err = s.AcceptPaymentRequest(context.Background(), 2, requestID)
+	assert.ErrorIs(t, err, ErrFraudBlocked)
+
+	user, err := s.ReadUserByID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.True(t, money.New(9000, money.RUB).Equal(user.Balance))
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"http-avito-test/internal/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertBalancedEntries checks that the postings of every journal entry sum to zero
+func assertBalancedEntries(t *testing.T, s *Storage) {
+	var unbalanced int64
+
+	selectQuery := `SELECT count(*) FROM (
+		SELECT journal_tx FROM posting GROUP BY journal_tx HAVING sum(amount) <> 0
+		) e;`
+
+	err := s.DB.QueryRow(context.Background(), selectQuery).Scan(&unbalanced)
+	require.NoError(t, err)
+	assert.Zero(t, unbalanced)
+}
+
+func TestUnbalancedEntry(t *testing.T) {
+	s := bootstrap(t)
+	ctx := context.Background()
+
+	tx, err := s.DB.Begin(ctx)
+	require.NoError(t, err)
+
+	insertExec := `INSERT INTO posting (account_id, cb_journal, accounting_period, amount, date)
+			VALUES ($1, $2, $3, $4, $3);`
+
+	_, err = tx.Exec(ctx, insertExec, 2, OperationTypeDeposit, time.Now(), 10000)
+	require.NoError(t, err)
+
+	err = commit(ctx, tx)
+	assert.ErrorIs(t, err, ErrUnbalancedEntry)
+
+	_, err = s.ReadUserByID(ctx, 2)
+	assert.ErrorIs(t, err, ErrUserAvailability)
+}
+
+func TestStorageOperationsBalanced(t *testing.T) {
+	s := bootstrap(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	err := s.Deposit(ctx, 2, money.New(100000, money.RUB))
+	require.NoError(t, err)
+
+	depositID, err := s.CreatePendingDeposit(ctx, 3, money.New(10000, money.RUB), "fake")
+	require.NoError(t, err)
+	externalID := fmt.Sprintf("fake-%d", depositID)
+	require.NoError(t, s.AttachDepositPayment(ctx, depositID, externalID))
+	require.NoError(t, s.ConfirmDeposit(ctx, depositID, externalID))
+
+	require.NoError(t, s.Withdrawal(ctx, 2, money.New(1000, money.RUB), nil))
+
+	_, _, err = s.Transfer(ctx, 2, 3, money.New(1000, money.RUB), nil)
+	require.NoError(t, err)
+
+	transferID, err := s.DelayedTransfer(ctx, 2, 3, money.New(1000, money.RUB), nil, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.NoError(t, s.CancelTransfer(ctx, 2, transferID))
+	_, err = s.DelayedTransfer(ctx, 2, 3, money.New(1000, money.RUB), nil, now.Add(time.Minute))
+	require.NoError(t, err)
+	_, err = s.SettleDueTransfers(ctx, now.Add(time.Minute))
+	require.NoError(t, err)
+
+	requestID, err := s.CreatePaymentRequest(ctx, 3, 2, money.New(1000, money.RUB), nil, now.Add(time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, s.AcceptPaymentRequest(ctx, 2, requestID))
+
+	escrowID, err := s.CreateEscrow(ctx, 2, 3, money.New(4000, money.RUB), nil)
+	require.NoError(t, err)
+	require.NoError(t, s.SplitEscrow(ctx, escrowID, money.New(1000, money.RUB)))
+
+	_, codes, err := s.GenerateVouchers(ctx, 1, money.New(500, money.RUB), 1, now.Add(time.Hour))
+	require.NoError(t, err)
+	_, err = s.RedeemVoucher(ctx, 3, codes[0])
+	require.NoError(t, err)
+
+	_, err = s.GrantBonus(ctx, 3, money.New(500, money.RUB), now.Add(time.Minute))
+	require.NoError(t, err)
+	_, err = s.ExpireBonuses(ctx, now.Add(time.Minute))
+	require.NoError(t, err)
+
+	require.NoError(t, s.Reservation(ctx, 2, 1, 1, money.New(2000, money.RUB), nil))
+	require.NoError(t, s.Revenue(ctx, 2, 1, 1, money.New(2000, money.RUB), nil))
+	require.NoError(t, s.Reservation(ctx, 2, 1, 2, money.New(2000, money.RUB), nil))
+	require.NoError(t, s.Unreservation(ctx, 2, 1, 2, nil))
+
+	withdrawalID, err := s.RequestWithdrawal(ctx, 2, money.New(3000, money.RUB), nil)
+	require.NoError(t, err)
+	require.NoError(t, s.ApproveWithdrawal(ctx, withdrawalID, "ivanov", "checked"))
+	withdrawalID, err = s.RequestWithdrawal(ctx, 2, money.New(3000, money.RUB), nil)
+	require.NoError(t, err)
+	require.NoError(t, s.RejectWithdrawal(ctx, withdrawalID, "ivanov", "declined"))
+
+	assertBalancedEntries(t, s)
+
+	// the entries are deleted once they are checked at commit
+	var entries int64
+	err = s.DB.QueryRow(ctx, `SELECT count(*) FROM journal_entries;`).Scan(&entries)
+	require.NoError(t, err)
+	assert.Zero(t, entries)
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"errors"
+	"http-avito-test/internal/metrics"
+	"http-avito-test/internal/money"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// namedErrors are the errors of the storage reported by the metrics
+var namedErrors = []struct {
+	name string
+	err  error
+}{
+	{"ErrNoRecords", ErrNoRecords},
+	{"ErrRecordExist", ErrRecordExist},
+	{"ErrReserveExist", ErrReserveExist},
+	{"ErrRevenue", ErrRevenue},
+	{"ErrUserAvailability", ErrUserAvailability},
+	{"ErrOrderId", ErrOrderId},
+	{"ErrWithdrawal", ErrWithdrawal},
+	{"ErrTransfer", ErrTransfer},
+	{"ErrNoUser", ErrNoUser},
+	{"ErrSerialization", ErrSerialization},
+	{"ErrUnbalancedEntry", ErrUnbalancedEntry},
+	{"ErrBadOrderType", ErrBadOrderType},
+	{"ErrNoAPIKey", ErrNoAPIKey},
+	{"ErrAPIKeyRevoked", ErrAPIKeyRevoked},
+	{"ErrNoBonusGrant", ErrNoBonusGrant},
+	{"ErrNoEscrow", ErrNoEscrow},
+	{"ErrEscrowFinished", ErrEscrowFinished},
+	{"ErrEscrowSplit", ErrEscrowSplit},
+	{"ErrFraudBlocked", ErrFraudBlocked},
+	{"ErrNoPaymentRequest", ErrNoPaymentRequest},
+	{"ErrPaymentRequestFinished", ErrPaymentRequestFinished},
+	{"ErrNoPendingDeposit", ErrNoPendingDeposit},
+	{"ErrDepositFinished", ErrDepositFinished},
+	{"ErrNoPendingTransfer", ErrNoPendingTransfer},
+	{"ErrTransferFinished", ErrTransferFinished},
+	{"ErrUndoWindowExpired", ErrUndoWindowExpired},
+	{"ErrNoStatement", ErrNoStatement},
+	{"ErrNoStatementLine", ErrNoStatementLine},
+	{"ErrLineReconciled", ErrLineReconciled},
+	{"ErrNoLedgerPosting", ErrNoLedgerPosting},
+	{"ErrPostingReconciled", ErrPostingReconciled},
+	{"ErrNoVoucher", ErrNoVoucher},
+	{"ErrVoucherExpired", ErrVoucherExpired},
+	{"ErrVoucherUsedUp", ErrVoucherUsedUp},
+	{"ErrVoucherRedeemed", ErrVoucherRedeemed},
+	{"ErrNoWithdrawalRequest", ErrNoWithdrawalRequest},
+	{"ErrWithdrawalDecided", ErrWithdrawalDecided},
+}
+
+// ErrorName returns the name of the storage error like "ErrWithdrawal", the serialization failures
+// not mapped to ErrSerialization get its name too and the other errors are "other"
+func ErrorName(err error) string {
+	for _, e := range namedErrors {
+		if errors.Is(err, e.err) {
+			return e.name
+		}
+	}
+	if IsSerializationFailure(err) {
+		return "ErrSerialization"
+	}
+	return "other"
+}
+
+// IsSerializationFailure reports whether the serializable transaction failed by the conflict with another one,
+// such operations can be retried
+func IsSerializationFailure(err error) bool {
+	if errors.Is(err, ErrSerialization) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgerrcode.SerializationFailure
+}
+
+// observeMoved counts the amount moved by the committed operation
+func observeMoved(operation string, amount money.Money, err error) {
+	if err != nil || !amount.IsPositive() {
+		return
+	}
+	major, _ := amount.Major().Float64()
+	metrics.AmountMoved.WithLabelValues(operation, string(amount.Currency())).Add(major)
+}
+
+// poolCollector reports the statistics of the connection pool of the last storage
+type poolCollector struct{}
+
+var (
+	registerPool sync.Once
+	currentPool  atomic.Value // *pgxpool.Pool
+)
+
+// poolMetrics are the statistics of the connection pool, the counters are cumulative since the pool is created
+var poolMetrics = []struct {
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+	value     func(*pgxpool.Stat) float64
+}{
+	{prometheus.NewDesc("pgxpool_acquired_conns", "Number of the connections acquired from the pool.", nil, nil),
+		prometheus.GaugeValue, func(s *pgxpool.Stat) float64 { return float64(s.AcquiredConns()) }},
+	{prometheus.NewDesc("pgxpool_idle_conns", "Number of the idle connections of the pool.", nil, nil),
+		prometheus.GaugeValue, func(s *pgxpool.Stat) float64 { return float64(s.IdleConns()) }},
+	{prometheus.NewDesc("pgxpool_constructing_conns", "Number of the connections being established.", nil, nil),
+		prometheus.GaugeValue, func(s *pgxpool.Stat) float64 { return float64(s.ConstructingConns()) }},
+	{prometheus.NewDesc("pgxpool_total_conns", "Number of the connections of the pool.", nil, nil),
+		prometheus.GaugeValue, func(s *pgxpool.Stat) float64 { return float64(s.TotalConns()) }},
+	{prometheus.NewDesc("pgxpool_max_conns", "Maximum number of the connections of the pool.", nil, nil),
+		prometheus.GaugeValue, func(s *pgxpool.Stat) float64 { return float64(s.MaxConns()) }},
+	{prometheus.NewDesc("pgxpool_acquire_total", "Number of the successful connection acquires.", nil, nil),
+		prometheus.CounterValue, func(s *pgxpool.Stat) float64 { return float64(s.AcquireCount()) }},
+	{prometheus.NewDesc("pgxpool_acquire_duration_seconds_total", "Total time spent acquiring the connections.", nil, nil),
+		prometheus.CounterValue, func(s *pgxpool.Stat) float64 { return s.AcquireDuration().Seconds() }},
+	{prometheus.NewDesc("pgxpool_canceled_acquire_total", "Number of the connection acquires cancelled by the context.", nil, nil),
+		prometheus.CounterValue, func(s *pgxpool.Stat) float64 { return float64(s.CanceledAcquireCount()) }},
+	{prometheus.NewDesc("pgxpool_empty_acquire_total", "Number of the connection acquires waiting for a connection of the empty pool.", nil, nil),
+		prometheus.CounterValue, func(s *pgxpool.Stat) float64 { return float64(s.EmptyAcquireCount()) }},
+}
+
+func (poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range poolMetrics {
+		ch <- m.desc
+	}
+}
+
+func (poolCollector) Collect(ch chan<- prometheus.Metric) {
+	pool, _ := currentPool.Load().(*pgxpool.Pool)
+	if pool == nil {
+		return
+	}
+	stat := pool.Stat()
+	for _, m := range poolMetrics {
+		ch <- prometheus.MustNewConstMetric(m.desc, m.valueType, m.value(stat))
+	}
+}
+
+// registerPoolMetrics reports the statistics of the connection pool, the pool of the last storage is reported
+func registerPoolMetrics(pool *pgxpool.Pool) {
+	currentPool.Store(pool)
+	registerPool.Do(func() {
+		metrics.Default.MustRegister(poolCollector{})
+	})
+}
@@ -0,0 +1,24 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorName(t *testing.T) {
+	serialization := &pgconn.PgError{Code: pgerrcode.SerializationFailure}
+
+	assert.Equal(t, "ErrWithdrawal", ErrorName(ErrWithdrawal))
+	assert.Equal(t, "ErrNoEscrow", ErrorName(fmt.Errorf("releasing escrow: %w", ErrNoEscrow)))
+	assert.Equal(t, "ErrSerialization", ErrorName(serialization))
+	assert.Equal(t, "other", ErrorName(errors.New("connection reset")))
+
+	assert.True(t, IsSerializationFailure(ErrSerialization))
+	assert.True(t, IsSerializationFailure(fmt.Errorf("commit: %w", serialization)))
+	assert.False(t, IsSerializationFailure(ErrTransfer))
+}
@@ -0,0 +1,229 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"http-avito-test/internal/money"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNoPaymentRequest       = errors.New("payment request does not exist")
+	ErrPaymentRequestFinished = errors.New("payment request is already accepted, declined or expired")
+)
+
+// CreatePaymentRequest stores the request of the requester to receive the amount from the payer
+func (s *Storage) CreatePaymentRequest(ctx context.Context, requester, payer int64, amount money.Money, description *string, expiresAt time.Time) (int64, error) {
+	logger := s.logger(ctx).With(zap.Int64("requesterID", requester), zap.Int64("payerID", payer))
+	logger.Debug("creating payment request")
+
+	var id int64
+
+	insertQuery := `INSERT INTO payment_requests (requester, payer, amount, description, status, created_at, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id;`
+
+	err := s.DB.QueryRow(
+		ctx,
+		insertQuery,
+		requester,
+		payer,
+		amount,
+		description,
+		PaymentRequestStatusPending,
+		time.Now(),
+		expiresAt,
+	).Scan(&id)
+	if err != nil {
+		logger.Error("failed to insert record", zap.Error(err))
+		return 0, err
+	}
+	return id, nil
+}
+
+// ListPaymentRequests returns the user's incoming or outgoing payment requests starting from the newest.
+// Pending requests whose expiration time has passed are returned as expired
+func (s *Storage) ListPaymentRequests(ctx context.Context, userID int64, direction PaymentRequestDirection, limit, offset int64) ([]PaymentRequest, error) {
+	logger := s.logger(ctx).With(zap.Int64("user_ID", userID))
+	logger.Debug("reading payment requests", zap.String("direction", string(direction)), zap.Int64("limit", limit), zap.Int64("offset", offset))
+
+	var sql string
+
+	incomingQuery := `SELECT id, requester, payer, amount, description,
+		CASE WHEN status = 'pending' AND expires_at <= now() THEN 'expired' ELSE status END, created_at, expires_at
+		FROM payment_requests WHERE payer = $1 ORDER BY created_at DESC, id DESC LIMIT $2 OFFSET $3;`
+
+	outgoingQuery := `SELECT id, requester, payer, amount, description,
+		CASE WHEN status = 'pending' AND expires_at <= now() THEN 'expired' ELSE status END, created_at, expires_at
+		FROM payment_requests WHERE requester = $1 ORDER BY created_at DESC, id DESC LIMIT $2 OFFSET $3;`
+
+	switch direction {
+	case PaymentRequestsIncoming:
+		sql = incomingQuery
+	case PaymentRequestsOutgoing:
+		sql = outgoingQuery
+	}
+
+	rows, err := s.DB.Query(ctx, sql, userID, limit, offset)
+	if err != nil {
+		logger.Error("Query error", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pp = make([]PaymentRequest, 0)
+	for rows.Next() {
+		var p PaymentRequest
+		err := rows.Scan(&p.ID, &p.Requester, &p.Payer, &p.Amount, &p.Description, &p.Status, &p.CreatedAt, &p.ExpiresAt)
+		if err != nil {
+			logger.Error("scanning row error", zap.Error(err))
+			return nil, err
+		}
+		pp = append(pp, p)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error("Query error", zap.Error(err))
+		return nil, err
+	}
+	return pp, nil
+}
+
+// AcceptPaymentRequest transfers the requested amount from the payer to the requester
+func (s *Storage) AcceptPaymentRequest(ctx context.Context, payer, requestID int64) (err error) {
+	logger := s.logger(ctx).With(zap.Int64("payerID", payer), zap.Int64("requestID", requestID))
+	logger.Debug("accepting payment request")
+
+	tx, err := s.DB.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(ctx); errRollback != nil {
+				logger.Error("error rolls back the transaction", zap.Error(err))
+			}
+			err = serializationError(err)
+		}
+	}()
+
+	p, err := selectPendingPaymentRequest(ctx, tx, payer, requestID)
+	if err != nil {
+		logger.Error("error returning payment request", zap.Error(err))
+		return err
+	}
+
+	id, _, err := s.Transfer(ctx, payer, p.Requester, p.Amount, nullStringPtr(p.Description), asNestedTo(tx))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrSerialization):
+			logger.Warn("transaction isolation level error", zap.Error(err))
+			return ErrSerialization
+		case errors.Is(err, ErrTransfer):
+			logger.Error("insufficient funds on the payer's account", zap.Error(ErrTransfer))
+			return ErrTransfer
+		case errors.Is(err, ErrUserAvailability):
+			logger.Error("error returning user balance with specified id: user does not exist", zap.Error(err))
+			return ErrUserAvailability
+		default:
+			logger.Error("error updating balance", zap.Error(err))
+			return err
+		}
+	}
+
+	updateExec := `UPDATE payment_requests SET status = $2, tx_id = $3 WHERE id = $1;`
+
+	_, err = tx.Exec(ctx, updateExec, requestID, PaymentRequestStatusAccepted, id)
+	if err != nil {
+		logger.Error("failed to update record", zap.Error(err))
+		return err
+	}
+
+	err = commit(ctx, tx)
+	observeMoved("payment_request", p.Amount, err)
+	return err
+}
+
+// DeclinePaymentRequest marks the payment request as declined by the payer
+func (s *Storage) DeclinePaymentRequest(ctx context.Context, payer, requestID int64) (err error) {
+	logger := s.logger(ctx).With(zap.Int64("payerID", payer), zap.Int64("requestID", requestID))
+	logger.Debug("declining payment request")
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(ctx); errRollback != nil {
+				logger.Error("error rolls back the transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	_, err = selectPendingPaymentRequest(ctx, tx, payer, requestID)
+	if err != nil {
+		logger.Error("error returning payment request", zap.Error(err))
+		return err
+	}
+
+	updateExec := `UPDATE payment_requests SET status = $2 WHERE id = $1;`
+
+	_, err = tx.Exec(ctx, updateExec, requestID, PaymentRequestStatusDeclined)
+	if err != nil {
+		logger.Error("failed to update record", zap.Error(err))
+		return err
+	}
+
+	err = commit(ctx, tx)
+	return err
+}
+
+// ExpirePaymentRequests marks pending payment requests whose expiration time has passed at now as expired
+// and returns the number of expired requests
+func (s *Storage) ExpirePaymentRequests(ctx context.Context, now time.Time) (int64, error) {
+	logger := s.logger(ctx).With(zap.Time("now", now))
+	logger.Debug("expiring payment requests")
+
+	updateExec := `UPDATE payment_requests SET status = $1 WHERE status = $2 AND expires_at <= $3;`
+
+	tag, err := s.DB.Exec(ctx, updateExec, PaymentRequestStatusExpired, PaymentRequestStatusPending, now)
+	if err != nil {
+		logger.Error("failed to update records", zap.Error(err))
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// selectPendingPaymentRequest reads the payment request addressed to the payer and locks it until the end of the transaction
+func selectPendingPaymentRequest(ctx context.Context, tx pgx.Tx, payer, requestID int64) (p PaymentRequest, err error) {
+	selectQuery := `SELECT id, requester, payer, amount, description, status, created_at, expires_at
+		FROM payment_requests WHERE id = $1 FOR UPDATE;`
+
+	err = tx.QueryRow(ctx, selectQuery, requestID).Scan(&p.ID, &p.Requester, &p.Payer, &p.Amount, &p.Description, &p.Status, &p.CreatedAt, &p.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return PaymentRequest{}, ErrNoPaymentRequest
+		}
+		return PaymentRequest{}, err
+	}
+
+	switch {
+	case p.Payer != payer:
+		return PaymentRequest{}, ErrNoPaymentRequest
+	case p.Status != PaymentRequestStatusPending || !time.Now().Before(p.ExpiresAt):
+		return PaymentRequest{}, ErrPaymentRequestFinished
+	}
+	return p, nil
+}
+
+func nullStringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
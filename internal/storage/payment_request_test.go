@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"http-avito-test/internal/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcceptPaymentRequest(t *testing.T) {
+	s := bootstrap(t)
+
+	err := s.Deposit(context.Background(), 3, money.New(10000, money.RUB))
+	require.NoError(t, err)
+
+	description := "dinner"
+	id, err := s.CreatePaymentRequest(context.Background(), 2, 3, money.New(2500, money.RUB), &description, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	incoming, err := s.ListPaymentRequests(context.Background(), 3, PaymentRequestsIncoming, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, incoming, 1)
+	assert.Equal(t, id, incoming[0].ID)
+	assert.Equal(t, PaymentRequestStatusPending, incoming[0].Status)
+	assert.True(t, money.New(2500, money.RUB).Equal(incoming[0].Amount))
+
+	err = s.AcceptPaymentRequest(context.Background(), 2, id)
+	assert.ErrorIs(t, err, ErrNoPaymentRequest)
+
+	err = s.AcceptPaymentRequest(context.Background(), 3, id)
+	require.NoError(t, err)
+
+	err = s.DeclinePaymentRequest(context.Background(), 3, id)
+	assert.ErrorIs(t, err, ErrPaymentRequestFinished)
+
+	requester, err := s.ReadUserByID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.True(t, money.New(2500, money.RUB).Equal(requester.Balance))
+
+	outgoing, err := s.ListPaymentRequests(context.Background(), 2, PaymentRequestsOutgoing, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, outgoing, 1)
+	assert.Equal(t, PaymentRequestStatusAccepted, outgoing[0].Status)
+}
+
+func TestAcceptPaymentRequestNotEnoughMoney(t *testing.T) {
+	s := bootstrap(t)
+
+	err := s.Deposit(context.Background(), 3, money.New(1000, money.RUB))
+	require.NoError(t, err)
+
+	id, err := s.CreatePaymentRequest(context.Background(), 2, 3, money.New(2500, money.RUB), nil, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	err = s.AcceptPaymentRequest(context.Background(), 3, id)
+	assert.ErrorIs(t, err, ErrTransfer)
+
+	incoming, err := s.ListPaymentRequests(context.Background(), 3, PaymentRequestsIncoming, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, incoming, 1)
+	assert.Equal(t, PaymentRequestStatusPending, incoming[0].Status)
+}
+
+func TestExpirePaymentRequests(t *testing.T) {
+	s := bootstrap(t)
+
+	expiresAt := time.Now().Add(time.Minute)
+	id, err := s.CreatePaymentRequest(context.Background(), 2, 3, money.New(2500, money.RUB), nil, expiresAt)
+	require.NoError(t, err)
+
+	expired, err := s.ExpirePaymentRequests(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), expired)
+
+	expired, err = s.ExpirePaymentRequests(context.Background(), expiresAt)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), expired)
+
+	err = s.AcceptPaymentRequest(context.Background(), 3, id)
+	assert.ErrorIs(t, err, ErrPaymentRequestFinished)
+}
@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"http-avito-test/internal/money"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNoPendingDeposit = errors.New("pending deposit does not exist")
+	ErrDepositFinished  = errors.New("deposit is already confirmed or failed")
+)
+
+// CreatePendingDeposit stores the deposit that is credited to the user only after the provider confirms the payment.
+// Pending deposits are not posted, so they do not count toward the balance
+func (s *Storage) CreatePendingDeposit(ctx context.Context, userID int64, amount money.Money, provider string) (int64, error) {
+	logger := s.logger(ctx).With(zap.Int64("user_ID", userID), zap.String("provider", provider))
+	logger.Debug("creating pending deposit")
+
+	var id int64
+
+	insertQuery := `INSERT INTO pending_deposits (account_id, amount, provider, status, created_at)
+			VALUES ($1, $2, $3, $4, $5) RETURNING id;`
+
+	err := s.DB.QueryRow(ctx, insertQuery, userID, amount, provider, DepositStatusPending, time.Now()).Scan(&id)
+	if err != nil {
+		logger.Error("failed to insert record", zap.Error(err))
+		return 0, err
+	}
+	return id, nil
+}
+
+// AttachDepositPayment saves the id of the payment created by the provider for the pending deposit
+func (s *Storage) AttachDepositPayment(ctx context.Context, depositID int64, externalID string) error {
+	logger := s.logger(ctx).With(zap.Int64("depositID", depositID))
+	logger.Debug("attaching provider payment to the deposit", zap.String("externalID", externalID))
+
+	updateExec := `UPDATE pending_deposits SET external_id = $2 WHERE id = $1 AND status = $3;`
+
+	tag, err := s.DB.Exec(ctx, updateExec, depositID, externalID, DepositStatusPending)
+	if err != nil {
+		logger.Error("failed to update record", zap.Error(err))
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		logger.Error("pending deposit does not exist", zap.Error(ErrNoPendingDeposit))
+		return ErrNoPendingDeposit
+	}
+	return nil
+}
+
+// ConfirmDeposit credits the pending deposit to the user's account
+func (s *Storage) ConfirmDeposit(ctx context.Context, depositID int64, externalID string) (err error) {
+	logger := s.logger(ctx).With(zap.Int64("depositID", depositID))
+	logger.Debug("confirming deposit")
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(ctx); errRollback != nil {
+				logger.Error("error rolls back the transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	d, err := selectPendingDeposit(ctx, tx, depositID, externalID)
+	if err != nil {
+		logger.Error("error returning pending deposit", zap.Error(err))
+		return err
+	}
+
+	err = postDeposit(ctx, tx, s.Accounts.CashBook, d.accountID, d.amount, nil)
+	if err != nil {
+		logger.Error("failed to insert record", zap.Error(err))
+		return err
+	}
+
+	updateExec := `UPDATE pending_deposits SET status = $2, finished_at = $3 WHERE id = $1;`
+
+	_, err = tx.Exec(ctx, updateExec, depositID, DepositStatusSucceeded, time.Now())
+	if err != nil {
+		logger.Error("failed to update record", zap.Error(err))
+		return err
+	}
+
+	err = commit(ctx, tx)
+	observeMoved(string(OperationTypeDeposit), d.amount, err)
+	return err
+}
+
+// FailDeposit marks the pending deposit as failed without crediting it, the reason is stored for the support
+func (s *Storage) FailDeposit(ctx context.Context, depositID int64, externalID string, reason string) (err error) {
+	logger := s.logger(ctx).With(zap.Int64("depositID", depositID))
+	logger.Debug("failing deposit", zap.String("reason", reason))
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(ctx); errRollback != nil {
+				logger.Error("error rolls back the transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	_, err = selectPendingDeposit(ctx, tx, depositID, externalID)
+	if err != nil {
+		logger.Error("error returning pending deposit", zap.Error(err))
+		return err
+	}
+
+	updateExec := `UPDATE pending_deposits SET status = $2, failure_reason = $3, finished_at = $4 WHERE id = $1;`
+
+	_, err = tx.Exec(ctx, updateExec, depositID, DepositStatusFailed, reason, time.Now())
+	if err != nil {
+		logger.Error("failed to update record", zap.Error(err))
+		return err
+	}
+
+	err = commit(ctx, tx)
+	return err
+}
+
+type pendingDeposit struct {
+	accountID int64
+	amount    money.Money
+}
+
+// selectPendingDeposit reads the pending deposit and locks it until the end of the transaction.
+// An empty externalID matches the deposit whose provider payment was not created
+func selectPendingDeposit(ctx context.Context, tx pgx.Tx, depositID int64, externalID string) (d pendingDeposit, err error) {
+	var (
+		status   DepositStatus
+		attached *string
+	)
+
+	selectQuery := `SELECT account_id, amount, status, external_id FROM pending_deposits WHERE id = $1 FOR UPDATE;`
+
+	err = tx.QueryRow(ctx, selectQuery, depositID).Scan(&d.accountID, &d.amount, &status, &attached)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pendingDeposit{}, ErrNoPendingDeposit
+		}
+		return pendingDeposit{}, err
+	}
+
+	switch {
+	case attached != nil && *attached != externalID:
+		return pendingDeposit{}, ErrNoPendingDeposit
+	case attached == nil && externalID != "":
+		return pendingDeposit{}, ErrNoPendingDeposit
+	case status != DepositStatusPending:
+		return pendingDeposit{}, ErrDepositFinished
+	}
+	return d, nil
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"http-avito-test/internal/money"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfirmDeposit(t *testing.T) {
+	s := bootstrap(t)
+
+	id, err := s.CreatePendingDeposit(context.Background(), 2, money.New(10000, money.RUB), "fake")
+	require.NoError(t, err)
+
+	externalID := fmt.Sprintf("fake-%d", id)
+	err = s.AttachDepositPayment(context.Background(), id, externalID)
+	require.NoError(t, err)
+
+	// the pending deposit does not count toward the balance
+	_, err = s.ReadUserByID(context.Background(), 2)
+	assert.ErrorIs(t, err, ErrUserAvailability)
+
+	err = s.ConfirmDeposit(context.Background(), id, "other")
+	assert.ErrorIs(t, err, ErrNoPendingDeposit)
+
+	err = s.ConfirmDeposit(context.Background(), id, externalID)
+	require.NoError(t, err)
+
+	err = s.ConfirmDeposit(context.Background(), id, externalID)
+	assert.ErrorIs(t, err, ErrDepositFinished)
+
+	user, err := s.ReadUserByID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.True(t, money.New(10000, money.RUB).Equal(user.Balance))
+}
+
+func TestFailDeposit(t *testing.T) {
+	s := bootstrap(t)
+
+	id, err := s.CreatePendingDeposit(context.Background(), 2, money.New(10000, money.RUB), "fake")
+	require.NoError(t, err)
+
+	externalID := fmt.Sprintf("fake-%d", id)
+	err = s.AttachDepositPayment(context.Background(), id, externalID)
+	require.NoError(t, err)
+
+	err = s.FailDeposit(context.Background(), id, externalID, "timeout")
+	require.NoError(t, err)
+
+	err = s.ConfirmDeposit(context.Background(), id, externalID)
+	assert.ErrorIs(t, err, ErrDepositFinished)
+
+	_, err = s.ReadUserByID(context.Background(), 2)
+	assert.ErrorIs(t, err, ErrUserAvailability)
+
+	err = s.FailDeposit(context.Background(), 1000000, "", "failed")
+	assert.ErrorIs(t, err, ErrNoPendingDeposit)
+}
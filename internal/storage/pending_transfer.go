@@ -0,0 +1,290 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"http-avito-test/internal/fraud"
+	"http-avito-test/internal/money"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNoPendingTransfer = errors.New("pending transfer does not exist")
+	ErrTransferFinished  = errors.New("transfer is already settled or cancelled")
+	ErrUndoWindowExpired = errors.New("undo window of the transfer is over")
+)
+
+// DelayedTransfer deducts money from the sender at once and holds it on the reserve account.
+// The money is settled to the recipient at settleAt unless the sender cancels the transfer before.
+// As with Transfer to another user, only real money of the sender can be held. The balance is checked and held
+// in a serializable transaction, so the concurrent holds can not take the balance negative
+func (s *Storage) DelayedTransfer(ctx context.Context, sender, recipient int64, amount money.Money, description *string, settleAt time.Time) (transferID int64, err error) {
+	logger := s.logger(ctx).With(zap.Int64("senderID", sender), zap.Int64("recipientID", recipient))
+	logger.Debug("delayed money transfer", zap.Time("settleAt", settleAt))
+
+	tx, err := s.DB.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(ctx); errRollback != nil {
+				logger.Error("error rolls back the transaction", zap.Error(err))
+			}
+			err = serializationError(err)
+		}
+	}()
+
+	holdID, _, err := s.Transfer(ctx, sender, s.Accounts.Reserve, amount, description, asNestedTo(tx), withRealMoney())
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrSerialization):
+			logger.Warn("transaction isolation level error", zap.Error(err))
+			return 0, ErrSerialization
+		case errors.Is(err, ErrTransfer):
+			logger.Error("insufficient funds on the sender's account", zap.Error(ErrTransfer))
+			return 0, ErrTransfer
+		case errors.Is(err, ErrUserAvailability):
+			logger.Error("error returning user balance with specified id: user does not exist", zap.Error(err))
+			return 0, ErrUserAvailability
+		default:
+			logger.Error("error updating balance", zap.Error(err))
+			return 0, err
+		}
+	}
+
+	insertQuery := `INSERT INTO pending_transfers (sender, recipient, amount, description, status, settle_at, hold_tx_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id;`
+
+	err = tx.QueryRow(
+		ctx,
+		insertQuery,
+		sender,
+		recipient,
+		amount,
+		description,
+		TransferStatusPending,
+		settleAt,
+		holdID,
+	).Scan(&transferID)
+	if err != nil {
+		logger.Error("failed to insert record", zap.Error(err))
+		return 0, err
+	}
+
+	// the transfer is checked after it is stored, so the fraud rules count it among the sender's transfers
+	err = s.checkFraud(ctx, tx, fraud.Operation{
+		Type:         fraud.OperationTransfer,
+		AccountID:    sender,
+		Counterparty: recipient,
+		Amount:       amount,
+		At:           time.Now(),
+	}, false)
+	if err != nil {
+		return 0, err
+	}
+
+	err = commit(ctx, tx)
+	observeMoved("delayed_transfer", amount, err)
+	return transferID, err
+}
+
+// CancelTransfer returns the held money of a pending transfer back to the sender
+func (s *Storage) CancelTransfer(ctx context.Context, sender, transferID int64) (err error) {
+	logger := s.logger(ctx).With(zap.Int64("senderID", sender), zap.Int64("transferID", transferID))
+	logger.Debug("cancelling the delayed transfer")
+
+	tx, err := s.DB.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(ctx); errRollback != nil {
+				logger.Error("error rolls back the transaction", zap.Error(err))
+			}
+			err = serializationError(err)
+		}
+	}()
+
+	p, err := selectPendingTransfer(ctx, tx, transferID)
+	if err != nil {
+		logger.Error("error returning pending transfer", zap.Error(err))
+		return err
+	}
+
+	switch {
+	case p.sender != sender:
+		logger.Error("the transfer belongs to another sender", zap.Error(ErrNoPendingTransfer))
+		err = ErrNoPendingTransfer
+		return err
+	case p.status != TransferStatusPending:
+		logger.Error("the transfer is not pending", zap.Error(ErrTransferFinished))
+		err = ErrTransferFinished
+		return err
+	case !time.Now().Before(p.settleAt):
+		logger.Error("the undo window is over", zap.Error(ErrUndoWindowExpired))
+		err = ErrUndoWindowExpired
+		return err
+	}
+
+	var description = fmt.Sprintf(`Cancellation of transfer %d`, transferID)
+
+	err = s.finishPendingTransfer(ctx, tx, transferID, p.sender, p.amount, &description, TransferStatusCancelled)
+	if err != nil {
+		logger.Error("error returning money to the sender", zap.Error(err))
+		return err
+	}
+
+	err = commit(ctx, tx)
+	observeMoved("transfer_cancellation", p.amount, err)
+	return err
+}
+
+// SettleDueTransfers settles to the recipients all pending transfers whose undo window is over at now
+// and returns the number of settled transfers. The transfers blocked by the fraud rules are returned to the senders
+func (s *Storage) SettleDueTransfers(ctx context.Context, now time.Time) (int, error) {
+	logger := s.logger(ctx).With(zap.Time("now", now))
+	logger.Debug("settling due transfers")
+
+	selectQuery := `SELECT id FROM pending_transfers WHERE status = $1 AND settle_at <= $2 ORDER BY settle_at;`
+
+	rows, err := s.DB.Query(ctx, selectQuery, TransferStatusPending, now)
+	if err != nil {
+		logger.Error("Query error", zap.Error(err))
+		return 0, err
+	}
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		err := rows.Scan(&id)
+		if err != nil {
+			rows.Close()
+			logger.Error("scanning row error", zap.Error(err))
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error("Query error", zap.Error(err))
+		return 0, err
+	}
+
+	var settled int
+	for _, id := range ids {
+		status, err := s.settleTransfer(ctx, id, now)
+		if err != nil {
+			if errors.Is(err, ErrTransferFinished) {
+				continue
+			}
+			// the transfer conflicting with another operation is settled by the next run
+			if errors.Is(err, ErrSerialization) {
+				logger.Warn("transaction isolation level error", zap.Int64("transferID", id), zap.Error(err))
+				continue
+			}
+			logger.Error("failed to settle the transfer", zap.Int64("transferID", id), zap.Error(err))
+			return settled, err
+		}
+		if status == TransferStatusSettled {
+			settled++
+		}
+	}
+	return settled, nil
+}
+
+// settleTransfer pays the pending transfer out to the recipient. The transfer blocked by the fraud rules at this moment
+// is returned to the sender, the final status of the transfer is returned
+func (s *Storage) settleTransfer(ctx context.Context, transferID int64, now time.Time) (status TransferStatus, err error) {
+	logger := s.logger(ctx).With(zap.Int64("transferID", transferID))
+	logger.Debug("settling the delayed transfer")
+
+	tx, err := s.DB.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(ctx); errRollback != nil {
+				logger.Error("error rolls back the transaction", zap.Error(err))
+			}
+			err = serializationError(err)
+		}
+	}()
+
+	p, err := selectPendingTransfer(ctx, tx, transferID)
+	if err != nil {
+		return "", err
+	}
+
+	// the transfer could have been cancelled after it was selected for settlement
+	if p.status != TransferStatusPending || now.Before(p.settleAt) {
+		err = ErrTransferFinished
+		return "", err
+	}
+
+	status = TransferStatusSettled
+	err = s.finishPendingTransfer(ctx, tx, transferID, p.recipient, p.amount, p.description, status, onBehalfOf(p.sender, p.recipient))
+	if errors.Is(err, ErrFraudBlocked) {
+		logger.Warn("the transfer is blocked by the fraud rules, returning money to the sender", zap.Error(err))
+
+		var description = fmt.Sprintf(`Cancellation of transfer %d`, transferID)
+
+		status = TransferStatusCancelled
+		err = s.finishPendingTransfer(ctx, tx, transferID, p.sender, p.amount, &description, status)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	err = commit(ctx, tx)
+	if status == TransferStatusSettled {
+		observeMoved("transfer_settlement", p.amount, err)
+	} else {
+		observeMoved("transfer_cancellation", p.amount, err)
+	}
+	return status, err
+}
+
+type pendingTransfer struct {
+	sender      int64
+	recipient   int64
+	amount      money.Money
+	description *string
+	status      TransferStatus
+	settleAt    time.Time
+}
+
+// selectPendingTransfer reads the transfer and locks it until the end of the transaction
+func selectPendingTransfer(ctx context.Context, tx pgx.Tx, transferID int64) (p pendingTransfer, err error) {
+	selectQuery := `SELECT sender, recipient, amount, description, status, settle_at FROM pending_transfers WHERE id = $1 FOR UPDATE;`
+
+	err = tx.QueryRow(ctx, selectQuery, transferID).Scan(&p.sender, &p.recipient, &p.amount, &p.description, &p.status, &p.settleAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pendingTransfer{}, ErrNoPendingTransfer
+		}
+		return pendingTransfer{}, err
+	}
+	return p, nil
+}
+
+// finishPendingTransfer moves the held money from the reserve account to the account and sets the final status of the transfer
+func (s *Storage) finishPendingTransfer(ctx context.Context, tx pgx.Tx, transferID, accountID int64, amount money.Money, description *string, status TransferStatus, options ...TxOption) error {
+	_, finalID, err := s.Transfer(ctx, s.Accounts.Reserve, accountID, amount, description, append([]TxOption{asNestedTo(tx)}, options...)...)
+	if err != nil {
+		return err
+	}
+
+	updateExec := `UPDATE pending_transfers SET status = $2, final_tx_id = $3 WHERE id = $1;`
+
+	_, err = tx.Exec(ctx, updateExec, transferID, status, finalID)
+	return err
+}
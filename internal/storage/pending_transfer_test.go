@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"http-avito-test/internal/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelayedTransferSettlement(t *testing.T) {
+	s := bootstrap(t)
+
+	err := s.Deposit(context.Background(), 2, money.New(10000, money.RUB))
+	require.NoError(t, err)
+
+	description := "test"
+	settleAt := time.Now().Add(time.Minute)
+	_, err = s.DelayedTransfer(context.Background(), 2, 3, money.New(4000, money.RUB), &description, settleAt)
+	require.NoError(t, err)
+
+	sender, err := s.ReadUserByID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.True(t, money.New(6000, money.RUB).Equal(sender.Balance))
+
+	settled, err := s.SettleDueTransfers(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 0, settled)
+
+	settled, err = s.SettleDueTransfers(context.Background(), settleAt)
+	require.NoError(t, err)
+	assert.Equal(t, 1, settled)
+
+	recipient, err := s.ReadUserByID(context.Background(), 3)
+	require.NoError(t, err)
+	assert.True(t, money.New(4000, money.RUB).Equal(recipient.Balance))
+
+	history, err := s.ReadUserHistoryList(context.Background(), 3, OrderByDate, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, string(TransferStatusSettled), history[0].Status.String)
+}
+
+func TestCancelTransfer(t *testing.T) {
+	s := bootstrap(t)
+
+	err := s.Deposit(context.Background(), 2, money.New(10000, money.RUB))
+	require.NoError(t, err)
+
+	description := "test"
+	id, err := s.DelayedTransfer(context.Background(), 2, 3, money.New(4000, money.RUB), &description, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+
+	err = s.CancelTransfer(context.Background(), 3, id)
+	assert.ErrorIs(t, err, ErrNoPendingTransfer)
+
+	err = s.CancelTransfer(context.Background(), 2, id)
+	require.NoError(t, err)
+
+	err = s.CancelTransfer(context.Background(), 2, id)
+	assert.ErrorIs(t, err, ErrTransferFinished)
+
+	sender, err := s.ReadUserByID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.True(t, money.New(10000, money.RUB).Equal(sender.Balance))
+
+	history, err := s.ReadUserHistoryList(context.Background(), 2, OrderByDate, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, string(TransferStatusCancelled), history[1].Status.String)
+	assert.Equal(t, string(TransferStatusCancelled), history[2].Status.String)
+
+	settled, err := s.SettleDueTransfers(context.Background(), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 0, settled)
+}
+
+func TestCancelTransferAfterUndoWindow(t *testing.T) {
+	s := bootstrap(t)
+
+	err := s.Deposit(context.Background(), 2, money.New(10000, money.RUB))
+	require.NoError(t, err)
+
+	id, err := s.DelayedTransfer(context.Background(), 2, 3, money.New(4000, money.RUB), nil, time.Now().Add(-time.Second))
+	require.NoError(t, err)
+
+	err = s.CancelTransfer(context.Background(), 2, id)
+	assert.ErrorIs(t, err, ErrUndoWindowExpired)
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"http-avito-test/internal/money"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// QuoteWithdrawal runs all the withdrawal checks in a transaction that is always rolled back
+// and returns the projected balance of the user
+func (s *Storage) QuoteWithdrawal(ctx context.Context, userID int64, amount money.Money, description *string) (Quote, error) {
+	var q Quote
+	err := s.Withdrawal(ctx, userID, amount, description, withDryRun(&q))
+	return q, err
+}
+
+// QuoteTransfer runs all the transfer checks in a transaction that is always rolled back
+// and returns the projected balance of the sender
+func (s *Storage) QuoteTransfer(ctx context.Context, sender, recipient int64, amount money.Money, description *string) (Quote, error) {
+	var q Quote
+	_, _, err := s.Transfer(ctx, sender, recipient, amount, description, withDryRun(&q))
+	return q, err
+}
+
+// QuoteReservation runs all the reservation checks in a transaction that is always rolled back
+// and returns the projected balance of the user
+func (s *Storage) QuoteReservation(ctx context.Context, UserId int64, ServiceId int64, OrderId int64, Price money.Money, description *string) (Quote, error) {
+	var q Quote
+	err := s.Reservation(ctx, UserId, ServiceId, OrderId, Price, description, withDryRun(&q))
+	return q, err
+}
+
+// finishDryRun reads the projected balance of the account inside the transaction and rolls the transaction back.
+// No fees are charged by the service at the moment, so the fee is always zero
+func finishDryRun(ctx context.Context, tx pgx.Tx, quote *Quote, accountID int64) error {
+	var balance money.Money
+	err := tx.QueryRow(ctx, updateRollUpTable, accountID).Scan(&balance)
+	if err != nil {
+		return err
+	}
+
+	if quote != nil {
+		quote.AccountID = accountID
+		quote.Balance = balance
+		quote.Fee = money.Money{}
+	}
+
+	return tx.Rollback(ctx)
+}
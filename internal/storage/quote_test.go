@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"http-avito-test/internal/money"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func countPostings(t *testing.T, s *Storage) int {
+	var count int
+	err := s.DB.QueryRow(context.Background(), `select count(*) from posting`).Scan(&count)
+	require.NoError(t, err)
+	return count
+}
+
+func TestQuoteWithdrawal(t *testing.T) {
+	s := bootstrap(t)
+
+	err := s.Deposit(context.Background(), 2, money.New(10000, money.RUB))
+	require.NoError(t, err)
+
+	description := "test"
+	quote, err := s.QuoteWithdrawal(context.Background(), 2, money.New(4000, money.RUB), &description)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(2), quote.AccountID)
+	assert.True(t, money.New(6000, money.RUB).Equal(quote.Balance))
+	assert.True(t, quote.Fee.IsZero())
+	assert.Equal(t, 2, countPostings(t, s))
+
+	user, err := s.ReadUserByID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.True(t, money.New(10000, money.RUB).Equal(user.Balance))
+
+	_, err = s.QuoteWithdrawal(context.Background(), 2, money.New(20000, money.RUB), &description)
+	assert.ErrorIs(t, err, ErrWithdrawal)
+}
+
+func TestQuoteTransfer(t *testing.T) {
+	s := bootstrap(t)
+
+	err := s.Deposit(context.Background(), 2, money.New(10000, money.RUB))
+	require.NoError(t, err)
+
+	description := "test"
+	quote, err := s.QuoteTransfer(context.Background(), 2, 3, money.New(10000, money.RUB), &description)
+	require.NoError(t, err)
+
+	assert.True(t, money.New(0, money.RUB).Equal(quote.Balance))
+	assert.Equal(t, 2, countPostings(t, s))
+
+	_, err = s.QuoteTransfer(context.Background(), 2, 3, money.New(20000, money.RUB), &description)
+	assert.ErrorIs(t, err, ErrTransfer)
+}
+
+func TestQuoteReservation(t *testing.T) {
+	s := bootstrap(t)
+
+	err := s.Deposit(context.Background(), 2, money.New(10000, money.RUB))
+	require.NoError(t, err)
+
+	description := "test"
+	quote, err := s.QuoteReservation(context.Background(), 2, 2, 2, money.New(2500, money.RUB), &description)
+	require.NoError(t, err)
+
+	assert.True(t, money.New(7500, money.RUB).Equal(quote.Balance))
+	assert.Equal(t, 2, countPostings(t, s))
+
+	// the order was not stored, so it can be reserved for real afterwards
+	err = s.Reservation(context.Background(), 2, 2, 2, money.New(2500, money.RUB), &description)
+	require.NoError(t, err)
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TakeToken takes a token of the rate limit bucket of the key shared by the server instances.
+// The bucket of burst tokens is refilled at rate tokens a second by the database clock, so the clocks
+// of the instances do not matter. It returns whether the token is taken and the tokens left
+func (s *Storage) TakeToken(ctx context.Context, key string, rate float64, burst int64) (allowed bool, tokens float64, err error) {
+	// the refill and the take are a single statement, so the concurrent requests of the key are executed one by one.
+	// excluded.updated_at is the time of the request, the clock is read once
+	upsertQuery := `INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at, rate, burst)
+		VALUES ($1, $3::float8 - 1, true, clock_timestamp(), $2, $3)
+		ON CONFLICT (key) DO UPDATE SET
+			tokens = CASE
+				WHEN least($3::float8, b.tokens + greatest(0, extract(epoch FROM excluded.updated_at - b.updated_at)::float8) * $2) >= 1
+				THEN least($3::float8, b.tokens + greatest(0, extract(epoch FROM excluded.updated_at - b.updated_at)::float8) * $2) - 1
+				ELSE least($3::float8, b.tokens + greatest(0, extract(epoch FROM excluded.updated_at - b.updated_at)::float8) * $2)
+			END,
+			allowed = least($3::float8, b.tokens + greatest(0, extract(epoch FROM excluded.updated_at - b.updated_at)::float8) * $2) >= 1,
+			updated_at = greatest(b.updated_at, excluded.updated_at),
+			rate = excluded.rate,
+			burst = excluded.burst
+		RETURNING allowed, tokens;`
+
+	err = s.DB.QueryRow(ctx, upsertQuery, key, rate, burst).Scan(&allowed, &tokens)
+	if err != nil {
+		s.logger(ctx).Error("failed to take rate limit token", zap.String("key", key), zap.Error(err))
+		return false, 0, err
+	}
+	return allowed, tokens, nil
+}
+
+// ReturnToken gives the token back to the rate limit bucket of the key, the bucket is not filled above the burst
+func (s *Storage) ReturnToken(ctx context.Context, key string, burst int64) error {
+	updateExec := `UPDATE rate_limit_buckets SET tokens = least($2::float8, tokens + 1) WHERE key = $1;`
+
+	_, err := s.DB.Exec(ctx, updateExec, key, burst)
+	if err != nil {
+		s.logger(ctx).Error("failed to return rate limit token", zap.String("key", key), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// DeleteIdleRateLimitBuckets deletes the buckets refilled for longer than idle and returns the number of deleted buckets.
+// A deleted bucket is created full by the next request of its key, so the limits are not affected
+func (s *Storage) DeleteIdleRateLimitBuckets(ctx context.Context, idle time.Duration) (int64, error) {
+	logger := s.logger(ctx)
+	logger.Debug("deleting idle rate limit buckets", zap.Duration("idle", idle))
+
+	deleteExec := `DELETE FROM rate_limit_buckets
+		WHERE updated_at + make_interval(secs => (burst - tokens) / rate + $1) < clock_timestamp();`
+
+	tag, err := s.DB.Exec(ctx, deleteExec, idle.Seconds())
+	if err != nil {
+		logger.Error("failed to delete records", zap.Error(err))
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTakeToken(t *testing.T) {
+	s := bootstrap(t)
+
+	_, err := s.DB.Exec(context.Background(), `TRUNCATE rate_limit_buckets;`)
+	require.NoError(t, err)
+
+	// the bucket is refilled once an hour, so it is not refilled during the test
+	rate := 1.0 / 3600
+
+	for i := 0; i < 2; i++ {
+		allowed, tokens, err := s.TakeToken(context.Background(), "client:billing:/accountdeposit", rate, 2)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.InDelta(t, float64(1-i), tokens, 0.01)
+	}
+
+	allowed, tokens, err := s.TakeToken(context.Background(), "client:billing:/accountdeposit", rate, 2)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.InDelta(t, 0, tokens, 0.01)
+
+	// the other keys have their own buckets
+	allowed, _, err = s.TakeToken(context.Background(), "client:delivery:/accountdeposit", rate, 2)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	// the returned token is taken again, the bucket is not filled above the burst
+	for i := 0; i < 3; i++ {
+		require.NoError(t, s.ReturnToken(context.Background(), "client:billing:/accountdeposit", 2))
+	}
+
+	allowed, tokens, err = s.TakeToken(context.Background(), "client:billing:/accountdeposit", rate, 2)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.InDelta(t, 1, tokens, 0.01)
+}
+
+func TestDeleteIdleRateLimitBuckets(t *testing.T) {
+	s := bootstrap(t)
+
+	_, err := s.DB.Exec(context.Background(), `TRUNCATE rate_limit_buckets;`)
+	require.NoError(t, err)
+
+	// the first bucket is refilled in a millisecond, the second one in an hour
+	_, _, err = s.TakeToken(context.Background(), "address:192.0.2.1", 1000, 1)
+	require.NoError(t, err)
+	_, _, err = s.TakeToken(context.Background(), "address:192.0.2.2", 1.0/3600, 1)
+	require.NoError(t, err)
+
+	time.Sleep(20 * time.Millisecond)
+
+	deleted, err := s.DeleteIdleRateLimitBuckets(context.Background(), 10*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	// the deleted bucket is created full again
+	allowed, tokens, err := s.TakeToken(context.Background(), "address:192.0.2.1", 1000, 1)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.InDelta(t, 0, tokens, 0.01)
+
+	allowed, _, err = s.TakeToken(context.Background(), "address:192.0.2.2", 1.0/3600, 1)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
@@ -0,0 +1,347 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"http-avito-test/internal/reconcile"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNoStatement       = errors.New("bank statement does not exist")
+	ErrNoStatementLine   = errors.New("bank statement line does not exist")
+	ErrLineReconciled    = errors.New("bank statement line is already matched or resolved")
+	ErrNoLedgerPosting   = errors.New("cash book posting does not exist")
+	ErrPostingReconciled = errors.New("cash book posting is already matched or resolved")
+)
+
+// ImportStatement stores the bank statement and matches its lines to the cash book postings
+// that are not matched or resolved yet. The window limits the distance between the dates of the line and the posting
+func (s *Storage) ImportStatement(ctx context.Context, name string, lines []reconcile.Line, window time.Duration) (statementID int64, err error) {
+	logger := s.logger(ctx).With(zap.String("statement", name))
+	logger.Debug("importing bank statement", zap.Int("lines", len(lines)), zap.Duration("window", window))
+
+	if len(lines) == 0 {
+		return 0, reconcile.ErrStatement
+	}
+
+	var from, to = lines[0].Date, lines[0].Date
+	for _, l := range lines {
+		if l.Date.Before(from) {
+			from = l.Date
+		}
+		if l.Date.After(to) {
+			to = l.Date
+		}
+	}
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(ctx); errRollback != nil {
+				logger.Error("error rolls back the transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	insertQuery := `INSERT INTO bank_statements (name, period_from, period_to, date_window, imported_at)
+			VALUES ($1, $2, $3, $4, $5) RETURNING id;`
+
+	err = tx.QueryRow(ctx, insertQuery, name, from, to, window, time.Now()).Scan(&statementID)
+	if err != nil {
+		logger.Error("failed to insert record", zap.Error(err))
+		return 0, err
+	}
+
+	// only deposits and withdrawals move money through the bank account
+	selectQuery := `SELECT p.id, p.date, p.amount FROM posting p
+		WHERE p.account_id = $1 AND p.cb_journal IN ($4, $5) AND p.date >= $2 AND p.date < $3
+		AND NOT EXISTS (SELECT 1 FROM bank_statement_lines l WHERE l.posting_id = p.id)
+		AND NOT EXISTS (SELECT 1 FROM reconciled_postings r WHERE r.posting_id = p.id)
+		ORDER BY p.id;`
+
+	rows, err := tx.Query(
+		ctx,
+		selectQuery,
+		s.Accounts.CashBook,
+		from.Add(-window),
+		to.AddDate(0, 0, 1).Add(window),
+		OperationTypeDeposit,
+		OperationTypeWithdrawal,
+	)
+	if err != nil {
+		logger.Error("Query error", zap.Error(err))
+		return 0, err
+	}
+
+	var postings []reconcile.Posting
+	for rows.Next() {
+		var p reconcile.Posting
+		err = rows.Scan(&p.ID, &p.Date, &p.Amount)
+		if err != nil {
+			rows.Close()
+			logger.Error("scanning row error", zap.Error(err))
+			return 0, err
+		}
+		postings = append(postings, p)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		logger.Error("Query error", zap.Error(err))
+		return 0, err
+	}
+
+	matches := reconcile.Match(lines, postings, window)
+
+	insertExec := `INSERT INTO bank_statement_lines (statement_id, line_no, date, amount, reference, status, posting_id)
+			VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7);`
+
+	for i, l := range lines {
+		var status = StatementLineStatusUnmatched
+		var postingID *int64
+		if id, ok := matches[i]; ok {
+			status = StatementLineStatusMatched
+			postingID = &id
+		}
+
+		_, err = tx.Exec(ctx, insertExec, statementID, l.Number, l.Date, l.Amount, l.Reference, status, postingID)
+		if err != nil {
+			logger.Error("failed to insert record", zap.Error(err))
+			return 0, err
+		}
+	}
+
+	logger.Info("bank statement is imported", zap.Int64("statementID", statementID), zap.Int("matched", len(matches)))
+
+	err = commit(ctx, tx)
+	return statementID, err
+}
+
+// ReadReconciliation returns the matched and unmatched lines of the statement
+// and the cash book postings of the statement period that are not matched or resolved
+func (s *Storage) ReadReconciliation(ctx context.Context, statementID int64) (Reconciliation, error) {
+	logger := s.logger(ctx).With(zap.Int64("statementID", statementID))
+	logger.Debug("reading reconciliation")
+
+	var exists bool
+
+	err := s.DB.QueryRow(ctx, `SELECT exists (SELECT 1 FROM bank_statements WHERE id = $1);`, statementID).Scan(&exists)
+	if err != nil {
+		logger.Error("QueryRow error", zap.Error(err))
+		return Reconciliation{}, err
+	}
+	if !exists {
+		logger.Error("bank statement does not exist", zap.Error(ErrNoStatement))
+		return Reconciliation{}, ErrNoStatement
+	}
+
+	var rec = Reconciliation{
+		StatementID:       statementID,
+		Matched:           make([]StatementLine, 0),
+		UnmatchedInBank:   make([]StatementLine, 0),
+		UnmatchedInLedger: make([]LedgerPosting, 0),
+	}
+
+	linesQuery := `SELECT id, line_no, date, amount, reference, status, posting_id, resolved_by, note
+		FROM bank_statement_lines WHERE statement_id = $1 ORDER BY line_no;`
+
+	rows, err := s.DB.Query(ctx, linesQuery, statementID)
+	if err != nil {
+		logger.Error("Query error", zap.Error(err))
+		return Reconciliation{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var l StatementLine
+		err := rows.Scan(&l.ID, &l.LineNo, &l.Date, &l.Amount, &l.Reference, &l.Status, &l.PostingID, &l.ResolvedBy, &l.Note)
+		if err != nil {
+			logger.Error("scanning row error", zap.Error(err))
+			return Reconciliation{}, err
+		}
+
+		if l.Status == StatementLineStatusUnmatched {
+			rec.UnmatchedInBank = append(rec.UnmatchedInBank, l)
+		} else {
+			rec.Matched = append(rec.Matched, l)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error("Query error", zap.Error(err))
+		return Reconciliation{}, err
+	}
+
+	ledgerQuery := `SELECT p.id, p.cb_journal, -1 * p.amount, p.date FROM posting p, bank_statements s
+		WHERE s.id = $1 AND p.account_id = $2 AND p.cb_journal IN ($3, $4)
+		AND p.date >= s.period_from - s.date_window AND p.date < s.period_to + interval '1 day' + s.date_window
+		AND NOT EXISTS (SELECT 1 FROM bank_statement_lines l WHERE l.posting_id = p.id)
+		AND NOT EXISTS (SELECT 1 FROM reconciled_postings r WHERE r.posting_id = p.id)
+		ORDER BY p.date, p.id;`
+
+	ledgerRows, err := s.DB.Query(ctx, ledgerQuery, statementID, s.Accounts.CashBook, OperationTypeDeposit, OperationTypeWithdrawal)
+	if err != nil {
+		logger.Error("Query error", zap.Error(err))
+		return Reconciliation{}, err
+	}
+	defer ledgerRows.Close()
+
+	for ledgerRows.Next() {
+		var p LedgerPosting
+		err := ledgerRows.Scan(&p.ID, &p.CashBook, &p.Amount, &p.Date)
+		if err != nil {
+			logger.Error("scanning row error", zap.Error(err))
+			return Reconciliation{}, err
+		}
+		rec.UnmatchedInLedger = append(rec.UnmatchedInLedger, p)
+	}
+	if err := ledgerRows.Err(); err != nil {
+		logger.Error("Query error", zap.Error(err))
+		return Reconciliation{}, err
+	}
+	return rec, nil
+}
+
+// ResolveStatementLine manually reconciles the unmatched statement line on behalf of the operator.
+// The line is matched to the cash book posting if postingID is given, otherwise it is just marked as resolved
+func (s *Storage) ResolveStatementLine(ctx context.Context, lineID int64, postingID *int64, operator, note string) (err error) {
+	logger := s.logger(ctx).With(zap.Int64("lineID", lineID), zap.String("operator", operator))
+	logger.Debug("resolving bank statement line")
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(ctx); errRollback != nil {
+				logger.Error("error rolls back the transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	var status StatementLineStatus
+
+	err = tx.QueryRow(ctx, `SELECT status FROM bank_statement_lines WHERE id = $1 FOR UPDATE;`, lineID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			logger.Error("bank statement line does not exist", zap.Error(ErrNoStatementLine))
+			err = ErrNoStatementLine
+			return err
+		}
+		logger.Error("QueryRow error", zap.Error(err))
+		return err
+	}
+	if status != StatementLineStatusUnmatched {
+		logger.Error("bank statement line is already reconciled", zap.Error(ErrLineReconciled))
+		err = ErrLineReconciled
+		return err
+	}
+
+	if postingID != nil {
+		err = checkFreeLedgerPosting(ctx, tx, s.Accounts.CashBook, *postingID)
+		if err != nil {
+			logger.Error("error returning cash book posting", zap.Error(err))
+			return err
+		}
+	}
+
+	updateExec := `UPDATE bank_statement_lines SET status = $2, posting_id = $3, resolved_by = $4, note = NULLIF($5, '')
+		WHERE id = $1;`
+
+	_, err = tx.Exec(ctx, updateExec, lineID, StatementLineStatusResolved, postingID, operator, note)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			logger.Error("cash book posting is already matched", zap.Error(err))
+			err = ErrPostingReconciled
+			return err
+		}
+		logger.Error("failed to update record", zap.Error(err))
+		return err
+	}
+
+	err = commit(ctx, tx)
+	return err
+}
+
+// ResolveLedgerPosting manually reconciles the cash book posting that has no statement line on behalf of the operator
+func (s *Storage) ResolveLedgerPosting(ctx context.Context, postingID int64, operator, note string) (err error) {
+	logger := s.logger(ctx).With(zap.Int64("postingID", postingID), zap.String("operator", operator))
+	logger.Debug("resolving cash book posting")
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(ctx); errRollback != nil {
+				logger.Error("error rolls back the transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	err = checkFreeLedgerPosting(ctx, tx, s.Accounts.CashBook, postingID)
+	if err != nil {
+		logger.Error("error returning cash book posting", zap.Error(err))
+		return err
+	}
+
+	insertExec := `INSERT INTO reconciled_postings (posting_id, resolved_by, note, resolved_at)
+			VALUES ($1, $2, NULLIF($3, ''), $4);`
+
+	_, err = tx.Exec(ctx, insertExec, postingID, operator, note, time.Now())
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			logger.Error("cash book posting is already resolved", zap.Error(err))
+			err = ErrPostingReconciled
+			return err
+		}
+		logger.Error("failed to insert record", zap.Error(err))
+		return err
+	}
+
+	err = commit(ctx, tx)
+	return err
+}
+
+// checkFreeLedgerPosting checks that the posting is a deposit or a withdrawal in the cash book
+// and is not matched or resolved yet
+func checkFreeLedgerPosting(ctx context.Context, tx pgx.Tx, cashBookID, postingID int64) error {
+	var accountID int64
+	var journal OperationType
+	var reconciled bool
+
+	selectQuery := `SELECT p.account_id, p.cb_journal,
+		exists (SELECT 1 FROM bank_statement_lines l WHERE l.posting_id = p.id)
+		OR exists (SELECT 1 FROM reconciled_postings r WHERE r.posting_id = p.id)
+		FROM posting p WHERE p.id = $1;`
+
+	err := tx.QueryRow(ctx, selectQuery, postingID).Scan(&accountID, &journal, &reconciled)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNoLedgerPosting
+		}
+		return err
+	}
+
+	switch {
+	case accountID != cashBookID, journal != OperationTypeDeposit && journal != OperationTypeWithdrawal:
+		return ErrNoLedgerPosting
+	case reconciled:
+		return ErrPostingReconciled
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+	"http-avito-test/internal/money"
+	"http-avito-test/internal/reconcile"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconciliation(t *testing.T) {
+	s := bootstrap(t)
+
+	_, err := s.DB.Exec(context.Background(), `TRUNCATE bank_statements, bank_statement_lines, reconciled_postings;`)
+	require.NoError(t, err)
+
+	err = s.Deposit(context.Background(), 2, money.New(10000, money.RUB))
+	require.NoError(t, err)
+
+	err = s.Deposit(context.Background(), 3, money.New(5000, money.RUB))
+	require.NoError(t, err)
+
+	err = s.Withdrawal(context.Background(), 2, money.New(2000, money.RUB), nil)
+	require.NoError(t, err)
+
+	// the recognized revenue does not move money through the bank and is not reconciled
+	err = s.Reservation(context.Background(), 3, 1, 1, money.New(1000, money.RUB), nil)
+	require.NoError(t, err)
+
+	err = s.Revenue(context.Background(), 3, 1, 1, money.New(1000, money.RUB), nil)
+	require.NoError(t, err)
+
+	var today = time.Now().UTC().Truncate(24 * time.Hour)
+
+	lines := []reconcile.Line{
+		{Number: 2, Date: today, Amount: money.New(10000, money.RUB)},
+		{Number: 3, Date: today, Amount: money.New(-2000, money.RUB)},
+		{Number: 4, Date: today, Amount: money.New(700, money.RUB), Reference: "bank fee"},
+	}
+
+	id, err := s.ImportStatement(context.Background(), "statement.csv", lines, 48*time.Hour)
+	require.NoError(t, err)
+
+	rec, err := s.ReadReconciliation(context.Background(), id)
+	require.NoError(t, err)
+
+	require.Len(t, rec.Matched, 2)
+	assert.True(t, money.New(10000, money.RUB).Equal(rec.Matched[0].Amount))
+	assert.True(t, money.New(-2000, money.RUB).Equal(rec.Matched[1].Amount))
+
+	require.Len(t, rec.UnmatchedInBank, 1)
+	assert.Equal(t, "bank fee", rec.UnmatchedInBank[0].Reference.String)
+
+	// the deposit of the second user is not in the statement
+	require.Len(t, rec.UnmatchedInLedger, 1)
+	assert.Equal(t, OperationTypeDeposit, rec.UnmatchedInLedger[0].CashBook)
+	assert.True(t, money.New(5000, money.RUB).Equal(rec.UnmatchedInLedger[0].Amount))
+
+	err = s.ResolveLedgerPosting(context.Background(), rec.Matched[0].PostingID.Int64, "operator", "")
+	assert.ErrorIs(t, err, ErrPostingReconciled)
+
+	err = s.ResolveStatementLine(context.Background(), rec.UnmatchedInBank[0].ID, nil, "operator", "bank fee")
+	require.NoError(t, err)
+
+	err = s.ResolveStatementLine(context.Background(), rec.UnmatchedInBank[0].ID, nil, "operator", "")
+	assert.ErrorIs(t, err, ErrLineReconciled)
+
+	err = s.ResolveLedgerPosting(context.Background(), rec.UnmatchedInLedger[0].ID, "operator", "received in cash")
+	require.NoError(t, err)
+
+	rec, err = s.ReadReconciliation(context.Background(), id)
+	require.NoError(t, err)
+	assert.Len(t, rec.Matched, 3)
+	assert.Empty(t, rec.UnmatchedInBank)
+	assert.Empty(t, rec.UnmatchedInLedger)
+
+	_, err = s.ReadReconciliation(context.Background(), id+1)
+	assert.ErrorIs(t, err, ErrNoStatement)
+}
@@ -3,18 +3,18 @@ package storage
 import (
 	"context"
 	"fmt"
+	"http-avito-test/internal/money"
 
-	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
 type report struct {
 	serviceId int64
-	sum       decimal.Decimal
+	sum       money.Money
 }
 
 func (s *Storage) MonthlyReport(ctx context.Context, year int64, month int64) ([][]string, error) {
-	logger := s.Logger.With(zap.Int64("Year", year), zap.Int64("Month", month))
+	logger := s.logger(ctx).With(zap.Int64("Year", year), zap.Int64("Month", month))
 	logger.Debug("reading the consolidated report")
 
 	tx, err := s.DB.Begin(ctx)
@@ -59,9 +59,9 @@ func (s *Storage) MonthlyReport(ctx context.Context, year int64, month int64) ([
 			logger.Error("scanning row error", zap.Error(err))
 			return nil, err
 		}
-		s = append(s, fmt.Sprintf(`%d`, r.serviceId), decimal.New(r.sum.IntPart(), -2).String())
+		s = append(s, fmt.Sprintf(`%d`, r.serviceId), r.sum.Major().String())
 		ss = append(ss, s)
 	}
-	err = tx.Commit(ctx)
+	err = commit(ctx, tx)
 	return ss, nil
 }
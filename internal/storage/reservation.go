@@ -3,17 +3,21 @@ package storage
 import (
 	"context"
 	"errors"
+	"http-avito-test/internal/fraud"
+	"http-avito-test/internal/money"
+	"time"
 
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgerrcode"
-	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
-func (s *Storage) Reservation(ctx context.Context, UserId int64, ServiceId int64, OrderId int64, Price decimal.Decimal, description *string) error {
-	logger := s.Logger.With(zap.Int64("userID", UserId), zap.Int64("ServiceID", ServiceId), zap.Int64("OrderID", OrderId))
+func (s *Storage) Reservation(ctx context.Context, UserId int64, ServiceId int64, OrderId int64, Price money.Money, description *string, options ...TxOption) error {
+	logger := s.logger(ctx).With(zap.Int64("userID", UserId), zap.Int64("ServiceID", ServiceId), zap.Int64("OrderID", OrderId))
 	logger.Debug("reservation of funds")
 
+	txOptions := buildOptions(options...)
+
 	tx, err := s.DB.Begin(ctx)
 	if err != nil {
 		return err
@@ -27,7 +31,7 @@ func (s *Storage) Reservation(ctx context.Context, UserId int64, ServiceId int64
 		}
 	}()
 
-	id, _, err := s.Transfer(ctx, UserId, reserveAccountID, Price, description, asNestedTo(tx))
+	id, _, err := s.Transfer(ctx, UserId, s.Accounts.Reserve, Price, description, asNestedTo(tx))
 	if err != nil {
 		switch {
 		case errors.Is(err, ErrSerialization):
@@ -68,6 +72,22 @@ func (s *Storage) Reservation(ctx context.Context, UserId int64, ServiceId int64
 		return err
 	}
 
-	err = tx.Commit(ctx)
+	err = s.checkFraud(ctx, tx, fraud.Operation{
+		Type:      fraud.OperationReservation,
+		AccountID: UserId,
+		Amount:    Price,
+		At:        time.Now(),
+	}, txOptions.dryRun)
+	if err != nil {
+		return err
+	}
+
+	if txOptions.dryRun {
+		err = finishDryRun(ctx, tx, txOptions.quote, UserId)
+		return err
+	}
+
+	err = commit(ctx, tx)
+	observeMoved("reservation", Price, err)
 	return err
 }
@@ -2,10 +2,10 @@ package storage
 
 import (
 	"context"
+	"http-avito-test/internal/money"
 	"testing"
 	"time"
 
-	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -18,57 +18,57 @@ func TestReservation(t *testing.T) {
 			Posting: ReadUserHistoryResult{
 				AccountID: int64(2),
 				CashBook:  OperationTypeDeposit,
-				Amount:    decimal.NewFromInt(100000),
+				Amount:    money.New(100000, money.RUB),
 			},
 		},
 		{
 			Posting: ReadUserHistoryResult{
-				AccountID: cacheBookAccountID,
+				AccountID: s.Accounts.CashBook,
 				CashBook:  OperationTypeDeposit,
-				Amount:    decimal.NewFromInt(-100000),
+				Amount:    money.New(-100000, money.RUB),
 			},
 		},
 		{
 			Posting: ReadUserHistoryResult{
 				AccountID: int64(2),
 				CashBook:  OperationTypeTransfer,
-				Amount:    decimal.NewFromInt(-10000),
+				Amount:    money.New(-10000, money.RUB),
 			},
 		},
 		{
 			Posting: ReadUserHistoryResult{
-				AccountID: reserveAccountID,
+				AccountID: s.Accounts.Reserve,
 				CashBook:  OperationTypeTransfer,
-				Amount:    decimal.NewFromInt(10000),
+				Amount:    money.New(10000, money.RUB),
 			},
 		},
 		{
 			Posting: ReadUserHistoryResult{
 				AccountID: int64(2),
 				CashBook:  OperationTypeTransfer,
-				Amount:    decimal.NewFromInt(-10000),
+				Amount:    money.New(-10000, money.RUB),
 			},
 		},
 		{
 			Posting: ReadUserHistoryResult{
-				AccountID: reserveAccountID,
+				AccountID: s.Accounts.Reserve,
 				CashBook:  OperationTypeTransfer,
-				Amount:    decimal.NewFromInt(10000),
+				Amount:    money.New(10000, money.RUB),
 			},
 		},
 	}
 
-	err := s.Deposit(context.Background(), 2, decimal.NewFromInt(100000))
+	err := s.Deposit(context.Background(), 2, money.New(100000, money.RUB))
 	require.NoError(t, err)
 
 	description := "test"
-	err = s.Reservation(context.Background(), 2, 2, 3, decimal.NewFromInt(10000), &description)
+	err = s.Reservation(context.Background(), 2, 2, 3, money.New(10000, money.RUB), &description)
 	require.NoError(t, err)
 
-	err = s.Reservation(context.Background(), 2, 2, 2, decimal.NewFromInt(10000), &description)
+	err = s.Reservation(context.Background(), 2, 2, 2, money.New(10000, money.RUB), &description)
 	require.NoError(t, err)
 
-	sql := "select * from posting"
+	sql := "select id, account_id, cb_journal, accounting_period, amount, date, addressee, description from posting"
 	rows, err := s.DB.Query(context.Background(), sql)
 	require.NoError(t, err)
 
@@ -104,27 +104,27 @@ func TestReservation(t *testing.T) {
 func TestNotEnoughMoneyOnReserveAccount(t *testing.T) {
 	s := bootstrap(t)
 
-	err := s.Deposit(context.Background(), 2, decimal.NewFromInt(10000))
+	err := s.Deposit(context.Background(), 2, money.New(10000, money.RUB))
 	require.NoError(t, err)
 
 	description := "test"
-	err = s.Reservation(context.Background(), 2, 2, 3, decimal.NewFromInt(10000), &description)
+	err = s.Reservation(context.Background(), 2, 2, 3, money.New(10000, money.RUB), &description)
 	require.NoError(t, err)
 
-	err = s.Reservation(context.Background(), 2, 2, 3, decimal.NewFromInt(10000), &description)
+	err = s.Reservation(context.Background(), 2, 2, 3, money.New(10000, money.RUB), &description)
 	assert.ErrorIs(t, ErrTransfer, err)
 }
 
 func TestReservationOrderAlreadyExists(t *testing.T) {
 	s := bootstrap(t)
 
-	err := s.Deposit(context.Background(), 2, decimal.NewFromInt(100000))
+	err := s.Deposit(context.Background(), 2, money.New(100000, money.RUB))
 	require.NoError(t, err)
 
 	description := "test"
-	err = s.Reservation(context.Background(), 2, 2, 3, decimal.NewFromInt(10000), &description)
+	err = s.Reservation(context.Background(), 2, 2, 3, money.New(10000, money.RUB), &description)
 	require.NoError(t, err)
 
-	err = s.Reservation(context.Background(), 2, 2, 3, decimal.NewFromInt(10000), &description)
+	err = s.Reservation(context.Background(), 2, 2, 3, money.New(10000, money.RUB), &description)
 	assert.ErrorIs(t, ErrOrderId, err)
 }
@@ -3,16 +3,16 @@ package storage
 import (
 	"context"
 	"errors"
+	"http-avito-test/internal/money"
 
-	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
-func (s *Storage) Revenue(ctx context.Context, UserId int64, ServiceId int64, OrderId int64, Sum decimal.Decimal, description *string) error {
-	logger := s.Logger.With(zap.Int64("userID", UserId), zap.Int64("ServiceID", ServiceId), zap.Int64("OrderID", OrderId))
+func (s *Storage) Revenue(ctx context.Context, UserId int64, ServiceId int64, OrderId int64, Sum money.Money, description *string) error {
+	logger := s.logger(ctx).With(zap.Int64("userID", UserId), zap.Int64("ServiceID", ServiceId), zap.Int64("OrderID", OrderId))
 	logger.Debug("reservation of funds")
 
-	var amount decimal.Decimal
+	var amount money.Money
 	var exist bool
 
 	tx, err := s.DB.Begin(ctx)
@@ -40,7 +40,7 @@ func (s *Storage) Revenue(ctx context.Context, UserId int64, ServiceId int64, Or
 	).Scan(&amount)
 
 	if err != nil {
-		if amount.Equal(decimal.NewFromInt(0)) {
+		if amount.IsZero() {
 			logger.Error("order exists error", zap.Error(ErrReserveExist))
 			return ErrReserveExist
 		}
@@ -53,7 +53,7 @@ func (s *Storage) Revenue(ctx context.Context, UserId int64, ServiceId int64, Or
 		return ErrRevenue
 	}
 
-	id, _, err := s.Transfer(ctx, reserveAccountID, cacheBookAccountID, Sum, description, asNestedTo(tx))
+	id, _, err := s.Transfer(ctx, s.Accounts.Reserve, s.Accounts.Revenue, Sum, description, asNestedTo(tx))
 	if err != nil {
 		switch {
 		case errors.Is(err, ErrSerialization):
@@ -90,7 +90,7 @@ func (s *Storage) Revenue(ctx context.Context, UserId int64, ServiceId int64, Or
 	}
 
 	if exist {
-		s.Logger.Error("", zap.Error(ErrRecordExist))
+		s.logger(ctx).Error("", zap.Error(ErrRecordExist))
 		return ErrRecordExist
 	}
 
@@ -111,6 +111,7 @@ func (s *Storage) Revenue(ctx context.Context, UserId int64, ServiceId int64, Or
 		return err
 	}
 
-	err = tx.Commit(ctx)
+	err = commit(ctx, tx)
+	observeMoved("revenue", Sum, err)
 	return err
 }
@@ -2,28 +2,42 @@ package storage
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"http-avito-test/internal/fraud"
+	"http-avito-test/internal/money"
+	"http-avito-test/internal/requestid"
+	"http-avito-test/internal/zapadapter"
 	"time"
 
+	"github.com/caarlos0/env/v6"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v4"
-	"github.com/jackc/pgx/v4/log/zapadapter"
 	"github.com/jackc/pgx/v4/pgxpool"
-	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
 // Storage defines fields used in interaction processes of database
 type Storage struct {
-	Logger *zap.Logger
-	DB     *pgxpool.Pool
+	Logger     *zap.Logger
+	DB         Pool
+	BonusOrder BonusOrder
+	// Fraud checks withdrawals, transfers and reservations before they are committed, nil disables the checks
+	Fraud *fraud.Engine
+	// Accounts are the system accounts taken from the chart of accounts
+	Accounts SystemAccounts
 }
 
-const (
-	cacheBookAccountID = int64(0)
-	reserveAccountID   = int64(1)
-)
+// StorageConfig defines the business rules of the storage operations
+type StorageConfig struct {
+	BonusOrder     BonusOrder `env:"BONUS_CONSUMPTION_ORDER" envDefault:"bonus_first"`
+	FraudRulesFile string     `env:"FRAUD_RULES_FILE"`
+}
 
 const updateRollUpTable = `
 	with var1 as (
@@ -42,6 +56,28 @@ const updateRollUpTable = `
 	set last_tx_id = (select * from var1),
 	balance = (select * from var2) + (select balance from balances where account_id = $1) returning balance`
 
+const updateRollUpTables = `
+	with ids as (
+	select account_id, n from unnest($1::bigint[]) with ordinality as t(account_id, n)
+	), fresh as (
+	select p.account_id, coalesce(sum(p.amount),0) as delta, max(p.id) as last_id from posting p
+	left join balances b on b.account_id = p.account_id
+	where p.account_id in (select account_id from ids) and p.id > coalesce(b.last_tx_id, 0)
+	group by p.account_id
+	), upsert as (
+	insert into balances (
+	balance,
+	account_id,
+	last_tx_id
+	) select delta, account_id, last_id from fresh
+	on conflict (account_id) do update
+	set last_tx_id = excluded.last_tx_id,
+	balance = balances.balance + excluded.balance returning account_id, balance
+	) select ids.account_id, coalesce(u.balance, b.balance) from ids
+	left join upsert u on u.account_id = ids.account_id
+	left join balances b on b.account_id = ids.account_id
+	order by ids.n`
+
 var (
 	ErrNoRecords        = errors.New("consolidated report records do not exist")
 	ErrRecordExist      = errors.New("unreserve record or consolidated report record already exists")
@@ -53,6 +89,7 @@ var (
 	ErrTransfer         = errors.New("not enough money to transfer")
 	ErrNoUser           = errors.New("user does not exist")
 	ErrSerialization    = errors.New("serialization level error")
+	ErrUnbalancedEntry  = errors.New("postings of the journal entry do not sum to zero")
 )
 
 // NewStore constructs Store instance with configured logger
@@ -61,6 +98,23 @@ func NewStorage(ctx context.Context, logger *zap.Logger) (*Storage, error) {
 		return nil, errors.New("no logger provided")
 	}
 
+	cfg := StorageConfig{}
+	if err := env.Parse(&cfg); err != nil {
+		return nil, err
+	}
+	if cfg.BonusOrder != BonusOrderBonusFirst && cfg.BonusOrder != BonusOrderRealFirst {
+		return nil, fmt.Errorf("unknown bonus consumption order %q", cfg.BonusOrder)
+	}
+
+	var fraudEngine *fraud.Engine
+	if cfg.FraudRulesFile != "" {
+		e, err := fraud.Load(cfg.FraudRulesFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading fraud rules: %w", err)
+		}
+		fraudEngine = e
+	}
+
 	// taking connect info from environment variables
 	config, _ := pgxpool.ParseConfig("")
 
@@ -80,21 +134,37 @@ func NewStorage(ctx context.Context, logger *zap.Logger) (*Storage, error) {
 		return &Storage{}, err
 	}
 
+	accounts, err := loadSystemAccounts(ctx, pool)
+	if err != nil {
+		logger.Error("error reading the chart of accounts", zap.Error(err))
+		return &Storage{}, err
+	}
+
+	registerPoolMetrics(pool)
+
 	return &Storage{
-		Logger: logger,
-		DB:     pool,
+		Logger:     logger,
+		DB:         Pool{pool},
+		BonusOrder: cfg.BonusOrder,
+		Fraud:      fraudEngine,
+		Accounts:   accounts,
 	}, err
 }
 
+// logger returns the logger of the storage that writes the id of the request the context carries
+func (s *Storage) logger(ctx context.Context) *zap.Logger {
+	return requestid.Logger(ctx, s.Logger)
+}
+
 // Close closes all database connections in pool
 func (s *Storage) Close() {
 	s.Logger.Info("closing Storage connection")
 	s.DB.Close()
 }
 
-//ReadUser reads user's balance and returns it's id and balance
+// ReadUser reads user's balance and returns it's id and balance
 func (s *Storage) ReadUserByID(ctx context.Context, userID int64) (u User, err error) {
-	logger := s.Logger.With(zap.Int64("user_ID", userID))
+	logger := s.logger(ctx).With(zap.Int64("user_ID", userID))
 	logger.Debug("reading the user balance")
 
 	tx, err := s.DB.Begin(ctx)
@@ -125,20 +195,68 @@ func (s *Storage) ReadUserByID(ctx context.Context, userID int64) (u User, err e
 
 	u.AccountID = userID
 
-	err = tx.Commit(ctx)
+	err = commit(ctx, tx)
 	return User{
 		u.AccountID,
 		u.Balance,
 	}, err
 }
 
+// ReadUsersByIDs updates the Roll-Up table for all specified users in a single query
+// and returns their balances in the requested order. Unknown users get ErrUserAvailability in their result
+func (s *Storage) ReadUsersByIDs(ctx context.Context, userIDs []int64) (uu []UserResult, err error) {
+	logger := s.logger(ctx).With(zap.Int64s("user_IDs", userIDs))
+	logger.Debug("reading the users balances")
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(ctx); errRollback != nil {
+				logger.Error("error rolls back the transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	rows, err := tx.Query(ctx, updateRollUpTables, userIDs)
+	if err != nil {
+		logger.Error("error returning users balances with specified ids", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r UserResult
+		var balance sql.NullInt64
+		err = rows.Scan(&r.AccountID, &balance)
+		if err != nil {
+			logger.Error("scanning row error", zap.Error(err))
+			return nil, err
+		}
+		if balance.Valid {
+			r.Balance = money.New(balance.Int64, money.RUB)
+		} else {
+			r.Err = ErrUserAvailability
+		}
+		uu = append(uu, r)
+	}
+	if err = rows.Err(); err != nil {
+		logger.Error("error returning users balances with specified ids", zap.Error(err))
+		return nil, err
+	}
+
+	err = commit(ctx, tx)
+	return uu, err
+}
+
 // Deposit charge funds to the user's account
-func (s *Storage) Deposit(ctx context.Context, userID int64, amount decimal.Decimal) (err error) {
-	logger := s.Logger.With(zap.Int64(`user_ID`, userID))
+func (s *Storage) Deposit(ctx context.Context, userID int64, amount money.Money) (err error) {
+	logger := s.logger(ctx).With(zap.Int64(`user_ID`, userID))
 	logger.Debug("money deposit")
 
-	var now = time.Now()
-
 	tx, err := s.DB.Begin(ctx)
 	if err != nil {
 		return err
@@ -152,11 +270,25 @@ func (s *Storage) Deposit(ctx context.Context, userID int64, amount decimal.Deci
 		}
 	}()
 
+	err = postDeposit(ctx, tx, s.Accounts.CashBook, userID, amount, nil)
+	if err != nil {
+		logger.Error("failed to insert record", zap.Error(err))
+		return err
+	}
+	err = commit(ctx, tx)
+	observeMoved(string(OperationTypeDeposit), amount, err)
+	return err
+}
+
+// postDeposit charges funds to the user's account and notes them in the source account
+func postDeposit(ctx context.Context, tx pgx.Tx, sourceAccountID, userID int64, amount money.Money, description *string) error {
+	var now = time.Now()
+
 	// charge funds to the user's account
-	firstInsertExec := `INSERT INTO posting (account_id, cb_journal, accounting_period, amount, date)
-			VALUES ($1, $4, $5, $2, $3);`
+	firstInsertExec := `INSERT INTO posting (account_id, cb_journal, accounting_period, amount, date, description)
+			VALUES ($1, $4, $5, $2, $3, $6);`
 
-	_, err = tx.Exec(
+	_, err := tx.Exec(
 		ctx,
 		firstInsertExec,
 		userID,
@@ -164,13 +296,13 @@ func (s *Storage) Deposit(ctx context.Context, userID int64, amount decimal.Deci
 		now.Format(time.RFC3339),
 		OperationTypeDeposit,
 		now,
+		description,
 	)
 	if err != nil {
-		logger.Error("failed to insert record", zap.Error(err))
 		return err
 	}
 
-	// notes the deposit in the cache book
+	// notes the deposit in the source account
 	secondInsertExec := `INSERT INTO posting (account_id, cb_journal, accounting_period, amount, date)
 			VALUES ($5, $3, $4, -1 * $1, $2);`
 
@@ -181,22 +313,18 @@ func (s *Storage) Deposit(ctx context.Context, userID int64, amount decimal.Deci
 		now.Format(time.RFC3339),
 		OperationTypeDeposit,
 		now,
-		cacheBookAccountID,
+		sourceAccountID,
 	)
-	if err != nil {
-		logger.Error("failed to insert record", zap.Error(err))
-		return err
-	}
-	err = tx.Commit(ctx)
 	return err
 }
 
 // withdrawal deducts money from the user's account
-func (s *Storage) Withdrawal(ctx context.Context, userID int64, amount decimal.Decimal, description *string) (err error) {
-	logger := s.Logger.With(zap.Int64("userID", userID))
+func (s *Storage) Withdrawal(ctx context.Context, userID int64, amount money.Money, description *string, options ...TxOption) (err error) {
+	logger := s.logger(ctx).With(zap.Int64("userID", userID))
 	logger.Debug("money withdrawal")
 
 	var now = time.Now()
+	txOptions := buildOptions(options...)
 
 	// start transaction with transaction isolation level options
 	tx, err := s.DB.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
@@ -234,6 +362,17 @@ func (s *Storage) Withdrawal(ctx context.Context, userID int64, amount decimal.D
 		return ErrWithdrawal
 	}
 
+	// only real money can be withdrawn, so the bonus grants are excluded from the balance
+	active, expired, err := bonusBalance(ctx, tx, userID, now)
+	if err != nil {
+		logger.Error("error returning user bonus balance", zap.Error(err))
+		return err
+	}
+	if amount.GreaterThan(balance.Balance.Sub(active).Sub(expired)) {
+		logger.Error("insufficient real money on the user's account", zap.Error(ErrWithdrawal))
+		return ErrWithdrawal
+	}
+
 	// deducts money from the user's account
 	firstInsertExec := `INSERT INTO posting (account_id, cb_journal, accounting_period, amount, date, description)
 			VALUES ($1, $4, $5, -1 * $2, $3, $6);`
@@ -264,19 +403,58 @@ func (s *Storage) Withdrawal(ctx context.Context, userID int64, amount decimal.D
 		now.Format(time.RFC3339),
 		OperationTypeWithdrawal,
 		now,
-		cacheBookAccountID,
+		s.Accounts.CashBook,
 	)
 	if err != nil {
 		logger.Error("failed to insert record", zap.Error(err))
 		return err
 	}
-	err = tx.Commit(ctx)
+
+	err = s.checkFraud(ctx, tx, fraud.Operation{
+		Type:      fraud.OperationWithdrawal,
+		AccountID: userID,
+		Amount:    amount,
+		At:        now,
+	}, txOptions.dryRun)
+	if err != nil {
+		return err
+	}
+
+	if txOptions.dryRun {
+		err = finishDryRun(ctx, tx, txOptions.quote, userID)
+		return err
+	}
+
+	err = commit(ctx, tx)
+	observeMoved(string(OperationTypeWithdrawal), amount, err)
 	return err
 }
 
 // transfer performs the transfer of money from sender to recipient
-func (s *Storage) Transfer(ctx context.Context, sender, recipient int64, amount decimal.Decimal, description *string, options ...TxOption) (int64, int64, error) {
-	logger := s.Logger.With(zap.Int64("senderID", sender), zap.Int64("recipientID", recipient))
+func (s *Storage) Transfer(ctx context.Context, sender, recipient int64, amount money.Money, description *string, options ...TxOption) (int64, int64, error) {
+	// the stand-alone transfers are traced by the callers, the nested ones get their own span
+	// under the span of the operation they are the part of
+	if !buildOptions(options...).runAsChild {
+		return s.transfer(ctx, sender, recipient, amount, description, options...)
+	}
+
+	ctx, span := tracer.Start(ctx, "Storage.Transfer", trace.WithAttributes(
+		attribute.Int64("sender", sender),
+		attribute.Int64("recipient", recipient),
+		attribute.Bool("nested", true),
+	))
+	defer span.End()
+
+	sendID, receiveID, err := s.transfer(ctx, sender, recipient, amount, description, options...)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return sendID, receiveID, err
+}
+
+func (s *Storage) transfer(ctx context.Context, sender, recipient int64, amount money.Money, description *string, options ...TxOption) (int64, int64, error) {
+	logger := s.logger(ctx).With(zap.Int64("senderID", sender), zap.Int64("recipientID", recipient))
 	logger.Debug("money transfer")
 
 	var now = time.Now()
@@ -286,10 +464,10 @@ func (s *Storage) Transfer(ctx context.Context, sender, recipient int64, amount
 	var tx pgx.Tx
 	var err error
 	if txOptions.runAsChild {
-		s.Logger.Debug("Running Transfer as nested transaction")
+		s.logger(ctx).Debug("Running Transfer as nested transaction")
 		tx, err = txOptions.parentTx.Begin(ctx)
 	} else {
-		s.Logger.Debug("Running Transfer as stand-alone transaction")
+		s.logger(ctx).Debug("Running Transfer as stand-alone transaction")
 		tx, err = s.DB.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
 	}
 
@@ -327,6 +505,22 @@ func (s *Storage) Transfer(ctx context.Context, sender, recipient int64, amount
 		return 0, 0, ErrTransfer
 	}
 
+	// the bonus is spent in the consumption order, only the holds of the money for other users take real money
+	var spendsBonus = !txOptions.skipBonus && !txOptions.realMoney
+	if !txOptions.skipBonus && txOptions.realMoney {
+		var active, expired money.Money
+		active, expired, err = bonusBalance(ctx, tx, sender, now)
+		if err != nil {
+			logger.Error("error returning the sender's bonus balance", zap.Error(err))
+			return 0, 0, err
+		}
+		if amount.GreaterThan(balance.Balance.Sub(active).Sub(expired)) {
+			logger.Error("insufficient real money on the sender's account", zap.Error(ErrTransfer))
+			err = ErrTransfer
+			return 0, 0, err
+		}
+	}
+
 	var sendOperationId int64
 	var receiveOperationId int64
 
@@ -350,6 +544,19 @@ func (s *Storage) Transfer(ctx context.Context, sender, recipient int64, amount
 		return 0, 0, err
 	}
 
+	var spentBonus []bonusSpending
+	if spendsBonus {
+		spentBonus, err = spendBonus(ctx, tx, sender, sendOperationId, balance.Balance, amount, s.BonusOrder, now)
+		if err != nil {
+			if errors.Is(err, ErrTransfer) {
+				logger.Error("insufficient funds on the sender's account: the bonus is expired", zap.Error(ErrTransfer))
+				return 0, 0, ErrTransfer
+			}
+			logger.Error("error spending the sender's bonus", zap.Error(err))
+			return 0, 0, err
+		}
+	}
+
 	// charge funds to the recipient account
 	secondInsertExec := `INSERT INTO posting (account_id, cb_journal, accounting_period, amount, date, addressee) 
 			VALUES ($1, $6, $4, $2, $3, $5) RETURNING id;`
@@ -369,7 +576,42 @@ func (s *Storage) Transfer(ctx context.Context, sender, recipient int64, amount
 		return 0, 0, err
 	}
 
-	err = tx.Commit(ctx)
+	if isUserAccount(recipient) {
+		err = passBonus(ctx, tx, recipient, spentBonus, now)
+		if err != nil {
+			logger.Error("error passing the bonus to the recipient", zap.Error(err))
+			return 0, 0, err
+		}
+	}
+
+	// the money moved between the users is checked even when the transfer is the part of another operation,
+	// the transfers with the system accounts are the bookkeeping of the operations checked by themselves
+	var payer, payee = sender, recipient
+	if txOptions.onBehalf {
+		payer, payee = txOptions.payer, txOptions.payee
+	}
+	if isUserAccount(payer) && isUserAccount(payee) {
+		err = s.checkFraud(ctx, tx, fraud.Operation{
+			Type:         fraud.OperationTransfer,
+			AccountID:    payer,
+			Counterparty: payee,
+			Amount:       amount,
+			At:           now,
+		}, txOptions.dryRun)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if txOptions.dryRun {
+		err = finishDryRun(ctx, tx, txOptions.quote, sender)
+		return 0, 0, err
+	}
+
+	err = commit(ctx, tx)
+	if !txOptions.runAsChild {
+		observeMoved(string(OperationTypeTransfer), amount, err)
+	}
 	return sendOperationId, receiveOperationId, err
 }
 
@@ -379,7 +621,7 @@ func (s *Storage) ReadUserHistoryList(
 	userID int64,
 	order OrdBy,
 	limit, offset int64) ([]ReadUserHistoryResult, error) {
-	logger := s.Logger.With(zap.Int64("user_ID", userID))
+	logger := s.logger(ctx).With(zap.Int64("user_ID", userID))
 	logger.Debug("reading the user history list", zap.String("order", string(order)), zap.Int64("limit", limit), zap.Int64("offset", offset))
 
 	tx, err := s.DB.Begin(ctx)
@@ -416,11 +658,13 @@ func (s *Storage) ReadUserHistoryList(
 
 	var sql string
 
-	amountQuery := `SELECT account_id, cb_journal, amount, date, addressee, description FROM posting 
-		WHERE account_id = $1 ORDER BY amount LIMIT $2 OFFSET $3;`
+	amountQuery := `SELECT p.account_id, p.cb_journal, p.amount, p.date, p.addressee, p.description, pt.status FROM posting p
+		LEFT JOIN pending_transfers pt ON p.id = pt.hold_tx_id OR p.id = pt.final_tx_id
+		WHERE p.account_id = $1 ORDER BY p.amount LIMIT $2 OFFSET $3;`
 
-	dateQuery := `SELECT account_id, cb_journal, amount, date, addressee, description FROM posting 
-		WHERE account_id = $1 ORDER BY date LIMIT $2 OFFSET $3;`
+	dateQuery := `SELECT p.account_id, p.cb_journal, p.amount, p.date, p.addressee, p.description, pt.status FROM posting p
+		LEFT JOIN pending_transfers pt ON p.id = pt.hold_tx_id OR p.id = pt.final_tx_id
+		WHERE p.account_id = $1 ORDER BY p.date LIMIT $2 OFFSET $3;`
 
 	switch order {
 	case OrderByAmount:
@@ -445,14 +689,13 @@ func (s *Storage) ReadUserHistoryList(
 	var rr []ReadUserHistoryResult
 	for rows.Next() {
 		var r ReadUserHistoryResult
-		err := rows.Scan(&r.AccountID, &r.CashBook, &r.Amount, &r.Date, &r.Addressee, &r.Description)
+		err := rows.Scan(&r.AccountID, &r.CashBook, &r.Amount, &r.Date, &r.Addressee, &r.Description, &r.Status)
 		if err != nil {
 			logger.Error("scanning row error", zap.Error(err))
 			return nil, err
 		}
-		r.Amount = decimal.New(r.Amount.IntPart(), -2)
 		rr = append(rr, r)
 	}
-	err = tx.Commit(ctx)
+	err = commit(ctx, tx)
 	return rr, nil
 }
@@ -3,11 +3,11 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"http-avito-test/internal/money"
 	"testing"
 	"time"
 
 	"github.com/joho/godotenv"
-	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
@@ -43,22 +43,22 @@ func TestDeposit(t *testing.T) {
 			Posting: ReadUserHistoryResult{
 				AccountID: int64(2),
 				CashBook:  OperationTypeDeposit,
-				Amount:    decimal.NewFromInt(10000),
+				Amount:    money.New(10000, money.RUB),
 			},
 		},
 		{
 			Posting: ReadUserHistoryResult{
-				AccountID: cacheBookAccountID,
+				AccountID: s.Accounts.CashBook,
 				CashBook:  OperationTypeDeposit,
-				Amount:    decimal.NewFromInt(-10000),
+				Amount:    money.New(-10000, money.RUB),
 			},
 		},
 	}
 
-	err := s.Deposit(context.Background(), 2, decimal.NewFromInt(10000))
+	err := s.Deposit(context.Background(), 2, money.New(10000, money.RUB))
 	require.NoError(t, err)
 
-	sql := "select * from posting"
+	sql := "select id, account_id, cb_journal, accounting_period, amount, date, addressee, description from posting"
 	rows, err := s.DB.Query(context.Background(), sql)
 	require.NoError(t, err)
 
@@ -98,39 +98,39 @@ func TestWithdrawal(t *testing.T) {
 			Posting: ReadUserHistoryResult{
 				AccountID: int64(2),
 				CashBook:  OperationTypeDeposit,
-				Amount:    decimal.NewFromInt(10000),
+				Amount:    money.New(10000, money.RUB),
 			},
 		},
 		{
 			Posting: ReadUserHistoryResult{
-				AccountID: cacheBookAccountID,
+				AccountID: s.Accounts.CashBook,
 				CashBook:  OperationTypeDeposit,
-				Amount:    decimal.NewFromInt(-10000),
+				Amount:    money.New(-10000, money.RUB),
 			},
 		},
 		{
 			Posting: ReadUserHistoryResult{
 				AccountID: int64(2),
 				CashBook:  OperationTypeWithdrawal,
-				Amount:    decimal.NewFromInt(-10000),
+				Amount:    money.New(-10000, money.RUB),
 			},
 		},
 		{
 			Posting: ReadUserHistoryResult{
-				AccountID: cacheBookAccountID,
+				AccountID: s.Accounts.CashBook,
 				CashBook:  OperationTypeWithdrawal,
-				Amount:    decimal.NewFromInt(10000),
+				Amount:    money.New(10000, money.RUB),
 			},
 		},
 	}
-	err := s.Deposit(context.Background(), 2, decimal.NewFromInt(10000))
+	err := s.Deposit(context.Background(), 2, money.New(10000, money.RUB))
 	require.NoError(t, err)
 
 	description := "test"
-	err = s.Withdrawal(context.Background(), 2, decimal.NewFromInt(10000), &description)
+	err = s.Withdrawal(context.Background(), 2, money.New(10000, money.RUB), &description)
 	require.NoError(t, err)
 
-	sql := "select * from posting"
+	sql := "select id, account_id, cb_journal, accounting_period, amount, date, addressee, description from posting"
 	rows, err := s.DB.Query(context.Background(), sql)
 	require.NoError(t, err)
 
@@ -171,39 +171,39 @@ func TestTransfer(t *testing.T) {
 			Posting: ReadUserHistoryResult{
 				AccountID: int64(2),
 				CashBook:  OperationTypeDeposit,
-				Amount:    decimal.NewFromInt(10000),
+				Amount:    money.New(10000, money.RUB),
 			},
 		},
 		{
 			Posting: ReadUserHistoryResult{
-				AccountID: cacheBookAccountID,
+				AccountID: s.Accounts.CashBook,
 				CashBook:  OperationTypeDeposit,
-				Amount:    decimal.NewFromInt(-10000),
+				Amount:    money.New(-10000, money.RUB),
 			},
 		},
 		{
 			Posting: ReadUserHistoryResult{
 				AccountID: int64(2),
 				CashBook:  OperationTypeTransfer,
-				Amount:    decimal.NewFromInt(-10000),
+				Amount:    money.New(-10000, money.RUB),
 			},
 		},
 		{
 			Posting: ReadUserHistoryResult{
 				AccountID: int64(3),
 				CashBook:  OperationTypeTransfer,
-				Amount:    decimal.NewFromInt(10000),
+				Amount:    money.New(10000, money.RUB),
 			},
 		},
 	}
-	err := s.Deposit(context.Background(), 2, decimal.NewFromInt(10000))
+	err := s.Deposit(context.Background(), 2, money.New(10000, money.RUB))
 	require.NoError(t, err)
 
 	description := "test"
-	_, _, err = s.Transfer(context.Background(), 2, 3, decimal.NewFromInt(10000), &description)
+	_, _, err = s.Transfer(context.Background(), 2, 3, money.New(10000, money.RUB), &description)
 	require.NoError(t, err)
 
-	sql := "select * from posting"
+	sql := "select id, account_id, cb_journal, accounting_period, amount, date, addressee, description from posting"
 	rows, err := s.DB.Query(context.Background(), sql)
 	require.NoError(t, err)
 
@@ -238,22 +238,22 @@ func TestTransfer(t *testing.T) {
 
 func TestReadUserById(t *testing.T) {
 	s := bootstrap(t)
-	expectBalance := decimal.NewFromInt(25000)
+	expectBalance := money.New(25000, money.RUB)
 
-	err := s.Deposit(context.Background(), 2, decimal.NewFromInt(10000))
+	err := s.Deposit(context.Background(), 2, money.New(10000, money.RUB))
 	require.NoError(t, err)
 
-	err = s.Deposit(context.Background(), 2, decimal.NewFromInt(20000))
+	err = s.Deposit(context.Background(), 2, money.New(20000, money.RUB))
 	require.NoError(t, err)
 
-	err = s.Deposit(context.Background(), 2, decimal.NewFromInt(15000))
+	err = s.Deposit(context.Background(), 2, money.New(15000, money.RUB))
 	require.NoError(t, err)
 
 	description := "test"
-	err = s.Withdrawal(context.Background(), 2, decimal.NewFromInt(10000), &description)
+	err = s.Withdrawal(context.Background(), 2, money.New(10000, money.RUB), &description)
 	require.NoError(t, err)
 
-	_, _, err = s.Transfer(context.Background(), 2, 3, decimal.NewFromInt(10000), &description)
+	_, _, err = s.Transfer(context.Background(), 2, 3, money.New(10000, money.RUB), &description)
 	require.NoError(t, err)
 
 	user, err := s.ReadUserByID(context.Background(), 2)
@@ -262,6 +262,37 @@ func TestReadUserById(t *testing.T) {
 	assert.Equal(t, expectBalance, user.Balance)
 }
 
+func TestReadUsersByIDs(t *testing.T) {
+	s := bootstrap(t)
+
+	err := s.Deposit(context.Background(), 2, money.New(10000, money.RUB))
+	require.NoError(t, err)
+
+	err = s.Deposit(context.Background(), 3, money.New(20000, money.RUB))
+	require.NoError(t, err)
+
+	_, err = s.ReadUserByID(context.Background(), 2)
+	require.NoError(t, err)
+
+	err = s.Deposit(context.Background(), 2, money.New(5000, money.RUB))
+	require.NoError(t, err)
+
+	users, err := s.ReadUsersByIDs(context.Background(), []int64{3, 1000, 2})
+	require.NoError(t, err)
+	require.Len(t, users, 3)
+
+	assert.Equal(t, int64(3), users[0].AccountID)
+	assert.True(t, money.New(20000, money.RUB).Equal(users[0].Balance))
+	assert.NoError(t, users[0].Err)
+
+	assert.Equal(t, int64(1000), users[1].AccountID)
+	assert.ErrorIs(t, users[1].Err, ErrUserAvailability)
+
+	assert.Equal(t, int64(2), users[2].AccountID)
+	assert.True(t, money.New(15000, money.RUB).Equal(users[2].Balance))
+	assert.NoError(t, users[2].Err)
+}
+
 func TestReadUserHistory(t *testing.T) {
 	s := bootstrap(t)
 
@@ -270,14 +301,14 @@ func TestReadUserHistory(t *testing.T) {
 			Posting: ReadUserHistoryResult{
 				AccountID: int64(2),
 				CashBook:  OperationTypeWithdrawal,
-				Amount:    decimal.New(decimal.NewFromInt(-10000).IntPart(), -2),
+				Amount:    money.New(-10000, money.RUB),
 			},
 		},
 		{
 			Posting: ReadUserHistoryResult{
 				AccountID: int64(2),
 				CashBook:  OperationTypeTransfer,
-				Amount:    decimal.New(decimal.NewFromInt(-10000).IntPart(), -2),
+				Amount:    money.New(-10000, money.RUB),
 				Addressee: sql.NullInt64{
 					Int64: 3,
 					Valid: true,
@@ -288,39 +319,39 @@ func TestReadUserHistory(t *testing.T) {
 			Posting: ReadUserHistoryResult{
 				AccountID: int64(2),
 				CashBook:  OperationTypeDeposit,
-				Amount:    decimal.New(decimal.NewFromInt(10000).IntPart(), -2),
+				Amount:    money.New(10000, money.RUB),
 			},
 		},
 		{
 			Posting: ReadUserHistoryResult{
 				AccountID: int64(2),
 				CashBook:  OperationTypeDeposit,
-				Amount:    decimal.New(decimal.NewFromInt(15000).IntPart(), -2),
+				Amount:    money.New(15000, money.RUB),
 			},
 		},
 		{
 			Posting: ReadUserHistoryResult{
 				AccountID: int64(2),
 				CashBook:  OperationTypeDeposit,
-				Amount:    decimal.New(decimal.NewFromInt(20000).IntPart(), -2),
+				Amount:    money.New(20000, money.RUB),
 			},
 		},
 	}
 
-	err := s.Deposit(context.Background(), 2, decimal.NewFromInt(10000))
+	err := s.Deposit(context.Background(), 2, money.New(10000, money.RUB))
 	require.NoError(t, err)
 
-	err = s.Deposit(context.Background(), 2, decimal.NewFromInt(20000))
+	err = s.Deposit(context.Background(), 2, money.New(20000, money.RUB))
 	require.NoError(t, err)
 
-	err = s.Deposit(context.Background(), 2, decimal.NewFromInt(15000))
+	err = s.Deposit(context.Background(), 2, money.New(15000, money.RUB))
 	require.NoError(t, err)
 
 	description := "test"
-	err = s.Withdrawal(context.Background(), 2, decimal.NewFromInt(10000), &description)
+	err = s.Withdrawal(context.Background(), 2, money.New(10000, money.RUB), &description)
 	require.NoError(t, err)
 
-	_, _, err = s.Transfer(context.Background(), 2, 3, decimal.NewFromInt(10000), &description)
+	_, _, err = s.Transfer(context.Background(), 2, 3, money.New(10000, money.RUB), &description)
 	require.NoError(t, err)
 
 	user, err := s.ReadUserHistoryList(context.Background(), 2, "amount", 100, 0)
@@ -341,56 +372,56 @@ func TestReadUserHistory(t *testing.T) {
 func TestZeroSumOfAmount(t *testing.T) {
 	t.Run("sum", func(t *testing.T) {
 		s := bootstrap(t)
-		var totalAmount decimal.Decimal
+		var totalAmount money.Money
 
-		err := s.Deposit(context.Background(), 2, decimal.NewFromInt(10000))
+		err := s.Deposit(context.Background(), 2, money.New(10000, money.RUB))
 		require.NoError(t, err)
 
-		err = s.Deposit(context.Background(), 2, decimal.NewFromInt(20000))
+		err = s.Deposit(context.Background(), 2, money.New(20000, money.RUB))
 		require.NoError(t, err)
 
-		err = s.Deposit(context.Background(), 2, decimal.NewFromInt(15000))
+		err = s.Deposit(context.Background(), 2, money.New(15000, money.RUB))
 		require.NoError(t, err)
 
 		description := "test"
-		err = s.Withdrawal(context.Background(), 2, decimal.NewFromInt(10000), &description)
+		err = s.Withdrawal(context.Background(), 2, money.New(10000, money.RUB), &description)
 		require.NoError(t, err)
 
-		_, _, err = s.Transfer(context.Background(), 2, 3, decimal.NewFromInt(10000), &description)
+		_, _, err = s.Transfer(context.Background(), 2, 3, money.New(10000, money.RUB), &description)
 		require.NoError(t, err)
 
 		sql := "select sum(amount) from posting;"
 		err = s.DB.QueryRow(context.Background(), sql).Scan(&totalAmount)
 		require.NoError(t, err)
 
-		assert.Equal(t, decimal.NewFromInt(0), totalAmount)
+		assert.Equal(t, money.New(0, money.RUB), totalAmount)
 	})
 
 	t.Run("sum with limit", func(t *testing.T) {
 		s := bootstrap(t)
-		var totalAmount decimal.Decimal
+		var totalAmount money.Money
 
-		err := s.Deposit(context.Background(), 2, decimal.NewFromInt(10000))
+		err := s.Deposit(context.Background(), 2, money.New(10000, money.RUB))
 		require.NoError(t, err)
 
-		err = s.Deposit(context.Background(), 2, decimal.NewFromInt(20000))
+		err = s.Deposit(context.Background(), 2, money.New(20000, money.RUB))
 		require.NoError(t, err)
 
-		err = s.Deposit(context.Background(), 2, decimal.NewFromInt(15000))
+		err = s.Deposit(context.Background(), 2, money.New(15000, money.RUB))
 		require.NoError(t, err)
 
 		description := "test"
-		err = s.Withdrawal(context.Background(), 2, decimal.NewFromInt(10000), &description)
+		err = s.Withdrawal(context.Background(), 2, money.New(10000, money.RUB), &description)
 		require.NoError(t, err)
 
-		_, _, err = s.Transfer(context.Background(), 2, 3, decimal.NewFromInt(10000), &description)
+		_, _, err = s.Transfer(context.Background(), 2, 3, money.New(10000, money.RUB), &description)
 		require.NoError(t, err)
 
 		sql := "select sum(amount) from posting limit 100;"
 		err = s.DB.QueryRow(context.Background(), sql).Scan(&totalAmount)
 		require.NoError(t, err)
 
-		assert.Equal(t, decimal.NewFromInt(0), totalAmount)
+		assert.Equal(t, money.New(0, money.RUB), totalAmount)
 	})
 }
 
@@ -398,24 +429,24 @@ func TestNotEnoughMoney(t *testing.T) {
 	t.Run("withdrawal", func(t *testing.T) {
 		s := bootstrap(t)
 
-		err := s.Deposit(context.Background(), 2, decimal.NewFromInt(10000))
+		err := s.Deposit(context.Background(), 2, money.New(10000, money.RUB))
 		require.NoError(t, err)
 
 		description := "test"
 
-		err = s.Withdrawal(context.Background(), 2, decimal.NewFromInt(20000), &description)
+		err = s.Withdrawal(context.Background(), 2, money.New(20000, money.RUB), &description)
 		assert.ErrorIs(t, ErrWithdrawal, err)
 	})
 
 	t.Run("transfer", func(t *testing.T) {
 		s := bootstrap(t)
 
-		err := s.Deposit(context.Background(), 2, decimal.NewFromInt(10000))
+		err := s.Deposit(context.Background(), 2, money.New(10000, money.RUB))
 		require.NoError(t, err)
 
 		description := "test"
 
-		_, _, err = s.Transfer(context.Background(), 2, 3, decimal.NewFromInt(20000), &description)
+		_, _, err = s.Transfer(context.Background(), 2, 3, money.New(20000, money.RUB), &description)
 		assert.ErrorIs(t, ErrTransfer, err)
 	})
 }
@@ -0,0 +1,206 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+var tracer = otel.Tracer("http-avito-test/internal/storage")
+
+// Pool is the connection pool of the storage recording the span of every statement of the traced operations,
+// the statements of the transactions it begins included. Rollback and the batch and callback methods of pgx
+// are not traced
+type Pool struct {
+	*pgxpool.Pool
+}
+
+func (p Pool) Begin(ctx context.Context) (pgx.Tx, error) {
+	return p.BeginTx(ctx, pgx.TxOptions{})
+}
+
+func (p Pool) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	span := startStatement(ctx, "BEGIN")
+	tx, err := p.Pool.BeginTx(ctx, txOptions)
+	endStatement(span, -1, err)
+	if err != nil {
+		return nil, err
+	}
+	return tracedTx{tx}, nil
+}
+
+func (p Pool) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return tracedExec(ctx, p.Pool, sql, args...)
+}
+
+func (p Pool) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return tracedQuery(ctx, p.Pool, sql, args...)
+}
+
+func (p Pool) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return tracedQueryRow(ctx, p.Pool, sql, args...)
+}
+
+func (p Pool) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	return tracedCopyFrom(ctx, p.Pool, tableName, columnNames, rowSrc)
+}
+
+// tracedTx records the spans of the statements of the transaction, the nested transactions included
+type tracedTx struct {
+	pgx.Tx
+}
+
+func (t tracedTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	span := startStatement(ctx, "SAVEPOINT")
+	tx, err := t.Tx.Begin(ctx)
+	endStatement(span, -1, err)
+	if err != nil {
+		return nil, err
+	}
+	return tracedTx{tx}, nil
+}
+
+func (t tracedTx) Commit(ctx context.Context) error {
+	span := startStatement(ctx, "COMMIT")
+	err := t.Tx.Commit(ctx)
+	endStatement(span, -1, err)
+	return err
+}
+
+func (t tracedTx) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return tracedExec(ctx, t.Tx, sql, args...)
+}
+
+func (t tracedTx) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return tracedQuery(ctx, t.Tx, sql, args...)
+}
+
+func (t tracedTx) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return tracedQueryRow(ctx, t.Tx, sql, args...)
+}
+
+func (t tracedTx) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	return tracedCopyFrom(ctx, t.Tx, tableName, columnNames, rowSrc)
+}
+
+// querier runs the statements of the pool or of the transaction
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+func tracedExec(ctx context.Context, q querier, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	span := startStatement(ctx, sql)
+	tag, err := q.Exec(ctx, sql, args...)
+	endStatement(span, tag.RowsAffected(), err)
+	return tag, err
+}
+
+func tracedQuery(ctx context.Context, q querier, sql string, args ...interface{}) (pgx.Rows, error) {
+	span := startStatement(ctx, sql)
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		endStatement(span, -1, err)
+		return rows, err
+	}
+	return &tracedRows{Rows: rows, span: span}, nil
+}
+
+func tracedQueryRow(ctx context.Context, q querier, sql string, args ...interface{}) pgx.Row {
+	span := startStatement(ctx, sql)
+	return tracedRow{Row: q.QueryRow(ctx, sql, args...), span: span}
+}
+
+func tracedCopyFrom(ctx context.Context, q querier, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	span := startStatement(ctx, "COPY "+tableName.Sanitize())
+	n, err := q.CopyFrom(ctx, tableName, columnNames, rowSrc)
+	endStatement(span, n, err)
+	return n, err
+}
+
+// tracedRows ends the span of the query when the rows are read or closed
+type tracedRows struct {
+	pgx.Rows
+	span  trace.Span
+	ended bool
+}
+
+func (r *tracedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.end()
+	return false
+}
+
+func (r *tracedRows) Close() {
+	r.Rows.Close()
+	r.end()
+}
+
+func (r *tracedRows) end() {
+	if r.ended {
+		return
+	}
+	r.ended = true
+	endStatement(r.span, r.Rows.CommandTag().RowsAffected(), r.Rows.Err())
+}
+
+// tracedRow ends the span of the query when the row is scanned
+type tracedRow struct {
+	pgx.Row
+	span trace.Span
+}
+
+func (r tracedRow) Scan(dest ...interface{}) error {
+	err := r.Row.Scan(dest...)
+	endStatement(r.span, -1, err)
+	return err
+}
+
+// startStatement starts the client span of the statement, the statements outside of the traced operations
+// like the background jobs get the span that is not recorded
+func startStatement(ctx context.Context, sql string) trace.Span {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return noop.Span{}
+	}
+	_, span := tracer.Start(ctx, statementOperation(sql),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.statement", sql),
+		))
+	return span
+}
+
+// endStatement ends the span of the statement, the negative rows are not reported and no rows is not an error
+func endStatement(span trace.Span, rows int64, err error) {
+	if rows >= 0 {
+		span.SetAttributes(attribute.Int64("db.rows", rows))
+	}
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// statementOperation is the span name of the statement, its first keyword like SELECT or INSERT
+func statementOperation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "SQL"
+	}
+	return strings.ToUpper(fields[0])
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestStatementOperation(t *testing.T) {
+	assert.Equal(t, "SELECT", statementOperation("\n\tselect id from posting where account_id = $1"))
+	assert.Equal(t, "WITH", statementOperation(updateRollUpTable))
+	assert.Equal(t, "COMMIT", statementOperation("commit"))
+	assert.Equal(t, "SQL", statementOperation(" "))
+}
+
+func TestStatementSpan(t *testing.T) {
+	// the statements outside of the traced operations are not recorded
+	assert.False(t, startStatement(context.Background(), "select 1").IsRecording())
+
+	rec := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "Storage.ReadUserByID")
+
+	endStatement(startStatement(ctx, "select balance from accounts where id = $1"), -1, pgx.ErrNoRows)
+	endStatement(startStatement(ctx, "update accounts set balance = $1"), 1, errors.New("deadlock detected"))
+	parent.End()
+
+	spans := rec.Ended()
+	require.Len(t, spans, 3)
+
+	sel, update := spans[0], spans[1]
+	assert.Equal(t, "SELECT", sel.Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), sel.Parent().SpanID())
+	assert.Contains(t, sel.Attributes(), attribute.String("db.statement", "select balance from accounts where id = $1"))
+	assert.Equal(t, codes.Unset, sel.Status().Code)
+
+	assert.Equal(t, "UPDATE", update.Name())
+	assert.Contains(t, update.Attributes(), attribute.Int64("db.rows", 1))
+	assert.Equal(t, sdktrace.Status{Code: codes.Error, Description: "deadlock detected"}, update.Status())
+}
@@ -1,16 +1,40 @@
 package storage
 
-import "github.com/jackc/pgx/v4"
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+)
+
+// balancedEntryConstraint is the deferred constraint trigger checking that the postings of a journal entry sum to zero
+const balancedEntryConstraint = "posting_balanced_entry"
 
 type txOptions struct {
 	runAsChild bool
 	parentTx   pgx.Tx
+	dryRun     bool
+	quote      *Quote
+	skipBonus  bool
+	realMoney  bool
+	// onBehalf, payer and payee describe the transfer between the users completed by the transfer
+	// from a system account for the fraud rules
+	onBehalf bool
+	payer    int64
+	payee    int64
 }
 
 func defaultTxOptions() *txOptions {
 	return &txOptions{
 		runAsChild: false,
 		parentTx:   nil,
+		dryRun:     false,
+		quote:      nil,
+		skipBonus:  false,
+		realMoney:  false,
+		onBehalf:   false,
 	}
 }
 
@@ -36,3 +60,59 @@ func asNestedTo(parentTx pgx.Tx) TxOption {
 		opts.parentTx = parentTx
 	})
 }
+
+// withDryRun runs the operation with all its checks and rolls the transaction back instead of committing,
+// the projected outcome is written to quote
+func withDryRun(quote *Quote) TxOption {
+	return txOptionFunc(func(opts *txOptions) {
+		opts.dryRun = true
+		opts.quote = quote
+	})
+}
+
+// withoutBonus debits the amount without spending the sender's bonus grants
+func withoutBonus() TxOption {
+	return txOptionFunc(func(opts *txOptions) {
+		opts.skipBonus = true
+	})
+}
+
+// withRealMoney debits only the real money of the sender, the bonus grants are neither spent nor counted
+// in the available balance
+func withRealMoney() TxOption {
+	return txOptionFunc(func(opts *txOptions) {
+		opts.realMoney = true
+	})
+}
+
+// onBehalfOf checks the transfer by the fraud rules as the transfer from payer to payee,
+// like the payout of the money held for the payee on a system account
+func onBehalfOf(payer, payee int64) TxOption {
+	return txOptionFunc(func(opts *txOptions) {
+		opts.onBehalf = true
+		opts.payer = payer
+		opts.payee = payee
+	})
+}
+
+// commit commits the transaction, the deferred balance check of the journal entry runs at this moment
+// and its violation is returned as ErrUnbalancedEntry
+func commit(ctx context.Context, tx pgx.Tx) error {
+	err := tx.Commit(ctx)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.CheckViolation && pgErr.ConstraintName == balancedEntryConstraint {
+			return ErrUnbalancedEntry
+		}
+	}
+	return err
+}
+
+// serializationError maps the conflict of the serializable transaction with another one to ErrSerialization,
+// the operation can be retried
+func serializationError(err error) error {
+	if IsSerializationFailure(err) {
+		return ErrSerialization
+	}
+	return err
+}
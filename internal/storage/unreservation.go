@@ -3,13 +3,13 @@ package storage
 import (
 	"context"
 	"errors"
+	"http-avito-test/internal/money"
 
-	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
 func (s *Storage) Unreservation(ctx context.Context, UserId int64, ServiceId int64, OrderId int64, description *string) error {
-	logger := s.Logger.With(zap.Int64("userID", UserId), zap.Int64("ServiceID", ServiceId), zap.Int64("OrderID", OrderId))
+	logger := s.logger(ctx).With(zap.Int64("userID", UserId), zap.Int64("ServiceID", ServiceId), zap.Int64("OrderID", OrderId))
 	logger.Debug("unreservation of funds")
 
 	tx, err := s.DB.Begin(ctx)
@@ -25,10 +25,11 @@ func (s *Storage) Unreservation(ctx context.Context, UserId int64, ServiceId int
 		}
 	}()
 
-	var price decimal.Decimal
+	var price money.Money
+	var holdID *int64
 	var exist bool
 
-	firstSelectQuery := `SELECT price FROM deferred_expenses WHERE account_id = $3 AND service_id = $4 AND order_id = $1 AND operation = $2;`
+	firstSelectQuery := `SELECT price, tx_id FROM deferred_expenses WHERE account_id = $3 AND service_id = $4 AND order_id = $1 AND operation = $2;`
 
 	err = tx.QueryRow(
 		ctx,
@@ -37,9 +38,9 @@ func (s *Storage) Unreservation(ctx context.Context, UserId int64, ServiceId int
 		ExpensesTypeReservation,
 		UserId,
 		ServiceId,
-	).Scan(&price)
+	).Scan(&price, &holdID)
 	if err != nil {
-		if price.Equal(decimal.NewFromInt(0)) {
+		if price.IsZero() {
 			logger.Error("order exists error", zap.Error(ErrReserveExist))
 			return ErrReserveExist
 		}
@@ -47,7 +48,7 @@ func (s *Storage) Unreservation(ctx context.Context, UserId int64, ServiceId int
 		return err
 	}
 
-	id, _, err := s.Transfer(ctx, reserveAccountID, UserId, price, nil, asNestedTo(tx))
+	id, _, err := s.Transfer(ctx, s.Accounts.Reserve, UserId, price, nil, asNestedTo(tx))
 	if err != nil {
 		switch {
 		case errors.Is(err, ErrSerialization):
@@ -65,6 +66,15 @@ func (s *Storage) Unreservation(ctx context.Context, UserId int64, ServiceId int
 		}
 	}
 
+	// the bonus spent on the reservation goes back to the grants, so it stays non-withdrawable
+	if holdID != nil {
+		err = restoreBonus(ctx, tx, *holdID)
+		if err != nil {
+			logger.Error("error restoring the user's bonus", zap.Error(err))
+			return err
+		}
+	}
+
 	secondSelectQuery := `SELECT EXISTS (SELECT 1 FROM deferred_expenses df WHERE (account_id = $4 AND service_id = $5 AND order_id = $2 AND operation = $3)
 	 						AND (EXISTS(SELECT 1 FROM deferred_expenses WHERE order_id = $2 AND operation = $1)
 	 						OR EXISTS(SELECT 1 FROM consolidated_report cr WHERE cr.order_id = df.order_id)));`
@@ -84,7 +94,7 @@ func (s *Storage) Unreservation(ctx context.Context, UserId int64, ServiceId int
 	}
 
 	if exist {
-		s.Logger.Error("", zap.Error(ErrRecordExist))
+		s.logger(ctx).Error("", zap.Error(ErrRecordExist))
 		return ErrRecordExist
 	}
 
@@ -106,9 +116,10 @@ func (s *Storage) Unreservation(ctx context.Context, UserId int64, ServiceId int
 		return err
 	}
 
-	err = tx.Commit(ctx)
+	err = commit(ctx, tx)
+	observeMoved("unreservation", price, err)
 	if err != nil {
-		s.Logger.Error("Commit transaction", zap.Error(err))
+		s.logger(ctx).Error("Commit transaction", zap.Error(err))
 		return err
 	}
 	return err
@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"http-avito-test/internal/money"
+	"math/big"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNoVoucher       = errors.New("voucher does not exist")
+	ErrVoucherExpired  = errors.New("voucher is expired")
+	ErrVoucherUsedUp   = errors.New("voucher redemption limit is reached")
+	ErrVoucherRedeemed = errors.New("voucher is already redeemed by the user")
+)
+
+const (
+	voucherCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	voucherCodeLength   = 12
+)
+
+// GenerateVouchers creates a batch of count unique codes each crediting the amount.
+// Every code can be redeemed maxRedemptions times by different users until expiresAt
+func (s *Storage) GenerateVouchers(
+	ctx context.Context,
+	count int64,
+	amount money.Money,
+	maxRedemptions int64,
+	expiresAt time.Time) (batchID int64, codes []string, err error) {
+	logger := s.logger(ctx).With(zap.Int64("count", count), zap.Int64("maxRedemptions", maxRedemptions))
+	logger.Debug("generating vouchers")
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(ctx); errRollback != nil {
+				logger.Error("error rolls back the transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	batchInsertQuery := `INSERT INTO voucher_batches (amount, max_redemptions, expires_at, created_at)
+			VALUES ($1, $2, $3, $4) RETURNING id;`
+
+	err = tx.QueryRow(ctx, batchInsertQuery, amount, maxRedemptions, expiresAt, time.Now()).Scan(&batchID)
+	if err != nil {
+		logger.Error("failed to insert record", zap.Error(err))
+		return 0, nil, err
+	}
+
+	// only the hashes of the codes are stored, the codes that collide with existing ones are skipped and generated again
+	insertQuery := `INSERT INTO vouchers (code_hash, batch_id)
+		SELECT code_hash, $2 FROM unnest($1::bytea[]) AS code_hash
+		ON CONFLICT (code_hash) DO NOTHING RETURNING code_hash;`
+
+	codes = make([]string, 0, count)
+	for int64(len(codes)) < count {
+		var candidates = make(map[string]string, count-int64(len(codes)))
+		var hashes = make([][]byte, 0, count-int64(len(codes)))
+		for int64(len(codes)+len(hashes)) < count {
+			code, err := newVoucherCode()
+			if err != nil {
+				logger.Error("failed to generate voucher code", zap.Error(err))
+				return 0, nil, err
+			}
+			hash := voucherCodeHash(code)
+			if _, ok := candidates[string(hash)]; ok {
+				continue
+			}
+			candidates[string(hash)] = code
+			hashes = append(hashes, hash)
+		}
+
+		rows, err := tx.Query(ctx, insertQuery, hashes, batchID)
+		if err != nil {
+			logger.Error("failed to insert records", zap.Error(err))
+			return 0, nil, err
+		}
+		for rows.Next() {
+			var hash []byte
+			err := rows.Scan(&hash)
+			if err != nil {
+				rows.Close()
+				logger.Error("scanning row error", zap.Error(err))
+				return 0, nil, err
+			}
+			codes = append(codes, candidates[string(hash)])
+		}
+		if err := rows.Err(); err != nil {
+			logger.Error("failed to insert records", zap.Error(err))
+			return 0, nil, err
+		}
+	}
+
+	err = commit(ctx, tx)
+	if err != nil {
+		return 0, nil, err
+	}
+	return batchID, codes, nil
+}
+
+// RedeemVoucher credits the voucher amount to the user's account from the promotions account
+// and returns the credited amount
+func (s *Storage) RedeemVoucher(ctx context.Context, userID int64, code string) (amount money.Money, err error) {
+	logger := s.logger(ctx).With(zap.Int64("userID", userID))
+	logger.Debug("voucher redemption")
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return money.Money{}, err
+	}
+
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(ctx); errRollback != nil {
+				logger.Error("error rolls back the transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	var (
+		voucherID      int64
+		redemptions    int64
+		maxRedemptions int64
+		expiresAt      time.Time
+	)
+
+	// the voucher row is locked so that concurrent redemptions of the code are executed one by one
+	selectQuery := `SELECT v.id, v.redemptions, b.max_redemptions, b.amount, b.expires_at
+		FROM vouchers v JOIN voucher_batches b ON b.id = v.batch_id WHERE v.code_hash = $1 FOR UPDATE OF v;`
+
+	err = tx.QueryRow(ctx, selectQuery, voucherCodeHash(code)).Scan(&voucherID, &redemptions, &maxRedemptions, &amount, &expiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			logger.Error("voucher does not exist", zap.Error(ErrNoVoucher))
+			err = ErrNoVoucher
+			return money.Money{}, err
+		}
+		logger.Error("error returning voucher", zap.Error(err))
+		return money.Money{}, err
+	}
+
+	switch {
+	case !time.Now().Before(expiresAt):
+		logger.Error("voucher is expired", zap.Error(ErrVoucherExpired))
+		err = ErrVoucherExpired
+		return money.Money{}, err
+	case redemptions >= maxRedemptions:
+		logger.Error("voucher is used up", zap.Error(ErrVoucherUsedUp))
+		err = ErrVoucherUsedUp
+		return money.Money{}, err
+	}
+
+	insertExec := `INSERT INTO voucher_redemptions (voucher_id, account_id, redeemed_at) VALUES ($1, $2, $3);`
+
+	_, err = tx.Exec(ctx, insertExec, voucherID, userID, time.Now())
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			logger.Error("voucher is already redeemed by the user", zap.Error(err))
+			err = ErrVoucherRedeemed
+			return money.Money{}, err
+		}
+		logger.Error("failed to insert record", zap.Error(err))
+		return money.Money{}, err
+	}
+
+	updateExec := `UPDATE vouchers SET redemptions = redemptions + 1 WHERE id = $1;`
+
+	_, err = tx.Exec(ctx, updateExec, voucherID)
+	if err != nil {
+		logger.Error("failed to update record", zap.Error(err))
+		return money.Money{}, err
+	}
+
+	// the code is a bearer secret, so the description names the voucher by its id
+	var description = fmt.Sprintf(`Redemption of voucher %d`, voucherID)
+
+	err = postDeposit(ctx, tx, s.Accounts.Promotions, userID, amount, &description)
+	if err != nil {
+		logger.Error("failed to insert record", zap.Error(err))
+		return money.Money{}, err
+	}
+
+	err = commit(ctx, tx)
+	observeMoved("voucher", amount, err)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return amount, nil
+}
+
+// voucherCodeHash is the hash of the code stored instead of the code. The random codes are long enough
+// for the hash to need no salt
+func voucherCodeHash(code string) []byte {
+	hash := sha256.Sum256([]byte(code))
+	return hash[:]
+}
+
+// newVoucherCode returns a random code without easily confused characters
+func newVoucherCode() (string, error) {
+	var code = make([]byte, voucherCodeLength)
+	var max = big.NewInt(int64(len(voucherCodeAlphabet)))
+
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = voucherCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"context"
+	"http-avito-test/internal/money"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedeemVoucher(t *testing.T) {
+	s := bootstrap(t)
+
+	_, codes, err := s.GenerateVouchers(context.Background(), 3, money.New(500, money.RUB), 1, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, codes, 3)
+
+	amount, err := s.RedeemVoucher(context.Background(), 2, codes[0])
+	require.NoError(t, err)
+	assert.True(t, money.New(500, money.RUB).Equal(amount))
+
+	_, err = s.RedeemVoucher(context.Background(), 3, codes[0])
+	assert.ErrorIs(t, err, ErrVoucherUsedUp)
+
+	_, err = s.RedeemVoucher(context.Background(), 2, "UNKNOWN")
+	assert.ErrorIs(t, err, ErrNoVoucher)
+
+	user, err := s.ReadUserByID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.True(t, money.New(500, money.RUB).Equal(user.Balance))
+
+	promotions, err := s.ReadUserByID(context.Background(), s.Accounts.Promotions)
+	require.NoError(t, err)
+	assert.True(t, money.New(-500, money.RUB).Equal(promotions.Balance))
+
+	// the history of the user does not keep the code
+	var stored int
+	err = s.DB.QueryRow(context.Background(), `SELECT count(*) FROM posting WHERE description LIKE '%' || $1 || '%'`, codes[0]).Scan(&stored)
+	require.NoError(t, err)
+	assert.Zero(t, stored)
+}
+
+func TestRedeemMultiUseVoucher(t *testing.T) {
+	s := bootstrap(t)
+
+	_, codes, err := s.GenerateVouchers(context.Background(), 1, money.New(500, money.RUB), 2, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	_, err = s.RedeemVoucher(context.Background(), 2, codes[0])
+	require.NoError(t, err)
+
+	_, err = s.RedeemVoucher(context.Background(), 2, codes[0])
+	assert.ErrorIs(t, err, ErrVoucherRedeemed)
+
+	_, err = s.RedeemVoucher(context.Background(), 3, codes[0])
+	require.NoError(t, err)
+
+	_, err = s.RedeemVoucher(context.Background(), 4, codes[0])
+	assert.ErrorIs(t, err, ErrVoucherUsedUp)
+}
+
+func TestRedeemExpiredVoucher(t *testing.T) {
+	s := bootstrap(t)
+
+	_, codes, err := s.GenerateVouchers(context.Background(), 1, money.New(500, money.RUB), 1, time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+
+	_, err = s.RedeemVoucher(context.Background(), 2, codes[0])
+	assert.ErrorIs(t, err, ErrVoucherExpired)
+}
+
+func TestConcurrentVoucherRedemption(t *testing.T) {
+	s := bootstrap(t)
+
+	_, codes, err := s.GenerateVouchers(context.Background(), 1, money.New(500, money.RUB), 3, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	var redeemed = make(chan struct{}, 10)
+	for i := int64(0); i < 10; i++ {
+		wg.Add(1)
+		go func(userID int64) {
+			defer wg.Done()
+			_, err := s.RedeemVoucher(context.Background(), userID, codes[0])
+			if err == nil {
+				redeemed <- struct{}{}
+				return
+			}
+			assert.ErrorIs(t, err, ErrVoucherUsedUp)
+		}(i + 2)
+	}
+	wg.Wait()
+	close(redeemed)
+
+	assert.Len(t, redeemed, 3)
+
+	promotions, err := s.ReadUserByID(context.Background(), s.Accounts.Promotions)
+	require.NoError(t, err)
+	assert.True(t, money.New(-1500, money.RUB).Equal(promotions.Balance))
+}
@@ -0,0 +1,297 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"http-avito-test/internal/fraud"
+	"http-avito-test/internal/money"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNoWithdrawalRequest = errors.New("withdrawal request does not exist")
+	ErrWithdrawalDecided   = errors.New("withdrawal request is already approved or rejected")
+)
+
+// RequestWithdrawal holds the amount on the reserve account and puts the withdrawal into the review queue.
+// As with Withdrawal, only real money of the user can be withdrawn. The balance is checked and held in a serializable
+// transaction, so the concurrent requests can not take the balance negative
+func (s *Storage) RequestWithdrawal(ctx context.Context, userID int64, amount money.Money, description *string) (requestID int64, err error) {
+	logger := s.logger(ctx).With(zap.Int64("userID", userID))
+	logger.Debug("requesting money withdrawal")
+
+	var now = time.Now()
+
+	tx, err := s.DB.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(ctx); errRollback != nil {
+				logger.Error("error rolls back the transaction", zap.Error(err))
+			}
+			err = serializationError(err)
+		}
+	}()
+
+	var balance User
+	err = tx.QueryRow(ctx, updateRollUpTable, userID).Scan(&balance.Balance)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.NotNullViolation {
+			logger.Error("error returning user balance with specified id: user does not exist", zap.Error(err))
+			return 0, ErrUserAvailability
+		}
+		logger.Error("error returning user balance with specified id", zap.Error(err))
+		return 0, err
+	}
+
+	active, expired, err := bonusBalance(ctx, tx, userID, now)
+	if err != nil {
+		logger.Error("error returning user bonus balance", zap.Error(err))
+		return 0, err
+	}
+	if amount.GreaterThan(balance.Balance.Sub(active).Sub(expired)) {
+		logger.Error("insufficient real money on the user's account", zap.Error(ErrWithdrawal))
+		err = ErrWithdrawal
+		return 0, err
+	}
+
+	holdID, _, err := s.Transfer(ctx, userID, s.Accounts.Reserve, amount, description, asNestedTo(tx), withoutBonus())
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrSerialization):
+			logger.Warn("transaction isolation level error", zap.Error(err))
+			return 0, ErrSerialization
+		case errors.Is(err, ErrTransfer):
+			logger.Error("insufficient funds on the user's account", zap.Error(ErrWithdrawal))
+			return 0, ErrWithdrawal
+		case errors.Is(err, ErrUserAvailability):
+			logger.Error("error returning user balance with specified id: user does not exist", zap.Error(err))
+			return 0, ErrUserAvailability
+		default:
+			logger.Error("error updating balance", zap.Error(err))
+			return 0, err
+		}
+	}
+
+	err = s.checkFraud(ctx, tx, fraud.Operation{
+		Type:      fraud.OperationWithdrawal,
+		AccountID: userID,
+		Amount:    amount,
+		At:        now,
+	}, false)
+	if err != nil {
+		return 0, err
+	}
+
+	insertQuery := `INSERT INTO withdrawal_requests (account_id, amount, description, status, created_at, hold_tx_id)
+			VALUES ($1, $2, $3, $4, $5, $6) RETURNING id;`
+
+	err = tx.QueryRow(
+		ctx,
+		insertQuery,
+		userID,
+		amount,
+		description,
+		WithdrawalStatusPending,
+		now,
+		holdID,
+	).Scan(&requestID)
+	if err != nil {
+		logger.Error("failed to insert record", zap.Error(err))
+		return 0, err
+	}
+
+	err = commit(ctx, tx)
+	observeMoved("withdrawal_hold", amount, err)
+	return requestID, err
+}
+
+// ListWithdrawalRequests returns the withdrawal requests with the status starting from the oldest
+func (s *Storage) ListWithdrawalRequests(ctx context.Context, status WithdrawalStatus, limit, offset int64) ([]WithdrawalRequest, error) {
+	logger := s.logger(ctx).With(zap.String("status", string(status)))
+	logger.Debug("reading withdrawal requests", zap.Int64("limit", limit), zap.Int64("offset", offset))
+
+	selectQuery := `SELECT id, account_id, amount, description, status, created_at, decided_at, operator, reason
+		FROM withdrawal_requests WHERE status = $1 ORDER BY created_at, id LIMIT $2 OFFSET $3;`
+
+	rows, err := s.DB.Query(ctx, selectQuery, status, limit, offset)
+	if err != nil {
+		logger.Error("Query error", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ww = make([]WithdrawalRequest, 0)
+	for rows.Next() {
+		var w WithdrawalRequest
+		err := rows.Scan(&w.ID, &w.AccountID, &w.Amount, &w.Description, &w.Status, &w.CreatedAt, &w.DecidedAt, &w.Operator, &w.Reason)
+		if err != nil {
+			logger.Error("scanning row error", zap.Error(err))
+			return nil, err
+		}
+		ww = append(ww, w)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error("Query error", zap.Error(err))
+		return nil, err
+	}
+	return ww, nil
+}
+
+// ApproveWithdrawal completes the withdrawal postings of the held money on behalf of the operator
+func (s *Storage) ApproveWithdrawal(ctx context.Context, requestID int64, operator, reason string) (err error) {
+	logger := s.logger(ctx).With(zap.Int64("requestID", requestID), zap.String("operator", operator))
+	logger.Debug("approving withdrawal request")
+
+	var now = time.Now()
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(ctx); errRollback != nil {
+				logger.Error("error rolls back the transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	w, err := selectPendingWithdrawal(ctx, tx, requestID)
+	if err != nil {
+		logger.Error("error returning withdrawal request", zap.Error(err))
+		return err
+	}
+
+	var finalID int64
+
+	// deducts the held money from the reserve account on behalf of the user
+	firstInsertQuery := `INSERT INTO posting (account_id, cb_journal, accounting_period, amount, date, addressee, description)
+			VALUES ($1, $4, $5, -1 * $2, $3, $6, $7) RETURNING id;`
+
+	err = tx.QueryRow(
+		ctx,
+		firstInsertQuery,
+		s.Accounts.Reserve,
+		w.Amount,
+		now.Format(time.RFC3339),
+		OperationTypeWithdrawal,
+		now,
+		w.AccountID,
+		w.Description,
+	).Scan(&finalID)
+	if err != nil {
+		logger.Error("failed to insert record", zap.Error(err))
+		return err
+	}
+
+	// notes the withdrawal in the cache book
+	secondInsertExec := `INSERT INTO posting (account_id, cb_journal, accounting_period, amount, date, addressee)
+			VALUES ($5, $3, $4, $1, $2, $6);`
+
+	_, err = tx.Exec(
+		ctx,
+		secondInsertExec,
+		w.Amount,
+		now.Format(time.RFC3339),
+		OperationTypeWithdrawal,
+		now,
+		s.Accounts.CashBook,
+		w.AccountID,
+	)
+	if err != nil {
+		logger.Error("failed to insert record", zap.Error(err))
+		return err
+	}
+
+	err = decideWithdrawal(ctx, tx, requestID, WithdrawalStatusApproved, operator, reason, finalID, now)
+	if err != nil {
+		logger.Error("failed to update record", zap.Error(err))
+		return err
+	}
+
+	err = commit(ctx, tx)
+	observeMoved(string(OperationTypeWithdrawal), w.Amount, err)
+	return err
+}
+
+// RejectWithdrawal returns the held money back to the user on behalf of the operator
+func (s *Storage) RejectWithdrawal(ctx context.Context, requestID int64, operator, reason string) (err error) {
+	logger := s.logger(ctx).With(zap.Int64("requestID", requestID), zap.String("operator", operator))
+	logger.Debug("rejecting withdrawal request")
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(ctx); errRollback != nil {
+				logger.Error("error rolls back the transaction", zap.Error(err))
+			}
+		}
+	}()
+
+	w, err := selectPendingWithdrawal(ctx, tx, requestID)
+	if err != nil {
+		logger.Error("error returning withdrawal request", zap.Error(err))
+		return err
+	}
+
+	var description = fmt.Sprintf(`Rejection of withdrawal %d`, requestID)
+
+	_, finalID, err := s.Transfer(ctx, s.Accounts.Reserve, w.AccountID, w.Amount, &description, asNestedTo(tx), withoutBonus())
+	if err != nil {
+		logger.Error("error returning money to the user", zap.Error(err))
+		return err
+	}
+
+	err = decideWithdrawal(ctx, tx, requestID, WithdrawalStatusRejected, operator, reason, finalID, time.Now())
+	if err != nil {
+		logger.Error("failed to update record", zap.Error(err))
+		return err
+	}
+
+	err = commit(ctx, tx)
+	observeMoved("withdrawal_rejection", w.Amount, err)
+	return err
+}
+
+// selectPendingWithdrawal reads the withdrawal request waiting for review and locks it until the end of the transaction
+func selectPendingWithdrawal(ctx context.Context, tx pgx.Tx, requestID int64) (w WithdrawalRequest, err error) {
+	selectQuery := `SELECT id, account_id, amount, description, status, created_at FROM withdrawal_requests WHERE id = $1 FOR UPDATE;`
+
+	err = tx.QueryRow(ctx, selectQuery, requestID).Scan(&w.ID, &w.AccountID, &w.Amount, &w.Description, &w.Status, &w.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return WithdrawalRequest{}, ErrNoWithdrawalRequest
+		}
+		return WithdrawalRequest{}, err
+	}
+
+	if w.Status != WithdrawalStatusPending {
+		return WithdrawalRequest{}, ErrWithdrawalDecided
+	}
+	return w, nil
+}
+
+// decideWithdrawal records the decision of the operator on the withdrawal request
+func decideWithdrawal(ctx context.Context, tx pgx.Tx, requestID int64, status WithdrawalStatus, operator, reason string, finalID int64, now time.Time) error {
+	updateExec := `UPDATE withdrawal_requests SET status = $2, decided_at = $3, operator = $4, reason = $5, final_tx_id = $6
+		WHERE id = $1;`
+
+	_, err := tx.Exec(ctx, updateExec, requestID, status, now, operator, reason, finalID)
+	return err
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"http-avito-test/internal/money"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApproveWithdrawal(t *testing.T) {
+	s := bootstrap(t)
+
+	err := s.Deposit(context.Background(), 2, money.New(10000, money.RUB))
+	require.NoError(t, err)
+
+	_, err = s.RequestWithdrawal(context.Background(), 2, money.New(20000, money.RUB), nil)
+	assert.ErrorIs(t, err, ErrWithdrawal)
+
+	description := "test"
+	id, err := s.RequestWithdrawal(context.Background(), 2, money.New(8000, money.RUB), &description)
+	require.NoError(t, err)
+
+	// the requested amount is held until the decision
+	user, err := s.ReadUserByID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.True(t, money.New(2000, money.RUB).Equal(user.Balance))
+
+	pending, err := s.ListWithdrawalRequests(context.Background(), WithdrawalStatusPending, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, id, pending[0].ID)
+	assert.True(t, money.New(8000, money.RUB).Equal(pending[0].Amount))
+
+	err = s.ApproveWithdrawal(context.Background(), id, "operator", "verified")
+	require.NoError(t, err)
+
+	err = s.RejectWithdrawal(context.Background(), id, "operator", "too late")
+	assert.ErrorIs(t, err, ErrWithdrawalDecided)
+
+	approved, err := s.ListWithdrawalRequests(context.Background(), WithdrawalStatusApproved, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, approved, 1)
+	assert.Equal(t, "operator", approved[0].Operator.String)
+	assert.Equal(t, "verified", approved[0].Reason.String)
+
+	cashBook, err := s.ReadUserByID(context.Background(), s.Accounts.CashBook)
+	require.NoError(t, err)
+	assert.True(t, money.New(-2000, money.RUB).Equal(cashBook.Balance))
+
+	reserve, err := s.ReadUserByID(context.Background(), s.Accounts.Reserve)
+	require.NoError(t, err)
+	assert.True(t, reserve.Balance.IsZero())
+}
+
+func TestRejectWithdrawal(t *testing.T) {
+	s := bootstrap(t)
+
+	err := s.Deposit(context.Background(), 2, money.New(10000, money.RUB))
+	require.NoError(t, err)
+
+	id, err := s.RequestWithdrawal(context.Background(), 2, money.New(8000, money.RUB), nil)
+	require.NoError(t, err)
+
+	err = s.RejectWithdrawal(context.Background(), id, "operator", "suspicious")
+	require.NoError(t, err)
+
+	err = s.ApproveWithdrawal(context.Background(), id, "operator", "")
+	assert.ErrorIs(t, err, ErrWithdrawalDecided)
+
+	user, err := s.ReadUserByID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.True(t, money.New(10000, money.RUB).Equal(user.Balance))
+
+	err = s.RejectWithdrawal(context.Background(), 1000000, "operator", "")
+	assert.ErrorIs(t, err, ErrNoWithdrawalRequest)
+}
@@ -0,0 +1,64 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.uber.org/zap"
+)
+
+// Config selects the exporter of the spans. The OTLP endpoint and headers and the sampler are read by the SDK
+// from the standard variables OTEL_EXPORTER_OTLP_ENDPOINT, OTEL_EXPORTER_OTLP_HEADERS, OTEL_TRACES_SAMPLER
+// and OTEL_TRACES_SAMPLER_ARG
+type Config struct {
+	// Exporter is none, stdout or otlp
+	Exporter    string `env:"OTEL_TRACES_EXPORTER" envDefault:"none"`
+	ServiceName string `env:"OTEL_SERVICE_NAME" envDefault:"http-avito-test"`
+}
+
+// Setup sets the global tracer provider of the config and returns the function that exports the remaining spans
+// and stops the provider. The provider is not set when the tracing is disabled, so the spans are not recorded.
+// The export errors are logged and the spans not exported are counted by metrics.DroppedSpans
+func Setup(ctx context.Context, cfg Config, logger *zap.Logger) (shutdown func(context.Context) error, err error) {
+	var exporter sdktrace.SpanExporter
+	switch cfg.Exporter {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "otlp":
+		exporter, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unknown traces exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", cfg.ServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.Warn("tracing error", zap.Error(err))
+	}))
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithResource(res),
+		sdktrace.WithSpanProcessor(newBatchProcessor(exporter, queueSize)),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"context"
+	"http-avito-test/internal/metrics"
+	"sync/atomic"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// queueSize limits the spans waiting for the export, the spans over the limit are dropped
+const queueSize = 2048
+
+// batchProcessor exports the spans by the batch processor of the SDK, which drops the spans of the full queue
+// without a trace. The processor counts the spans it hands over until they are exported and drops the spans
+// over the size of the queue itself, so the dropped spans are counted by metrics.DroppedSpans
+type batchProcessor struct {
+	sdktrace.SpanProcessor
+	queued *int64
+	size   int64
+}
+
+func newBatchProcessor(exporter sdktrace.SpanExporter, size int) batchProcessor {
+	queued := new(int64)
+	return batchProcessor{
+		SpanProcessor: sdktrace.NewBatchSpanProcessor(countingExporter{SpanExporter: exporter, queued: queued},
+			sdktrace.WithMaxQueueSize(size)),
+		queued: queued,
+		size:   int64(size),
+	}
+}
+
+func (p batchProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if !s.SpanContext().IsSampled() {
+		return
+	}
+	if atomic.AddInt64(p.queued, 1) > p.size {
+		atomic.AddInt64(p.queued, -1)
+		metrics.DroppedSpans.WithLabelValues("queue_full").Inc()
+		return
+	}
+	p.SpanProcessor.OnEnd(s)
+}
+
+// countingExporter counts the spans leaving the queue and the spans of the failed exports
+type countingExporter struct {
+	sdktrace.SpanExporter
+	queued *int64
+}
+
+func (e countingExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	defer atomic.AddInt64(e.queued, -int64(len(spans)))
+
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+	if err != nil {
+		metrics.DroppedSpans.WithLabelValues("export_failed").Add(float64(len(spans)))
+	}
+	return err
+}
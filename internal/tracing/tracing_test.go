@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"http-avito-test/internal/metrics"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+)
+
+// blockingExporter keeps the export waiting until it is released
+type blockingExporter struct {
+	*tracetest.InMemoryExporter
+	release chan struct{}
+}
+
+func (e blockingExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	<-e.release
+	return e.InMemoryExporter.ExportSpans(ctx, spans)
+}
+
+// Shutdown keeps the exported spans, the in-memory exporter forgets them
+func (blockingExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+// failingExporter fails every export
+type failingExporter struct {
+	*tracetest.InMemoryExporter
+}
+
+func (failingExporter) ExportSpans(context.Context, []sdktrace.ReadOnlySpan) error {
+	return errors.New("collector is unavailable")
+}
+
+func TestBatchProcessorDropsSpans(t *testing.T) {
+	dropped := testutil.ToFloat64(metrics.DroppedSpans.WithLabelValues("queue_full"))
+
+	exporter := blockingExporter{InMemoryExporter: tracetest.NewInMemoryExporter(), release: make(chan struct{})}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(newBatchProcessor(exporter, 2)))
+
+	// the spans wait for the blocked export, so the spans over the size of the queue are dropped
+	for i := 0; i < 5; i++ {
+		_, span := provider.Tracer("test").Start(context.Background(), "Storage.Deposit")
+		span.End()
+	}
+	close(exporter.release)
+	require.NoError(t, provider.Shutdown(context.Background()))
+
+	assert.Len(t, exporter.GetSpans(), 2)
+	assert.Equal(t, dropped+3, testutil.ToFloat64(metrics.DroppedSpans.WithLabelValues("queue_full")))
+}
+
+func TestBatchProcessorCountsFailedExports(t *testing.T) {
+	failed := testutil.ToFloat64(metrics.DroppedSpans.WithLabelValues("export_failed"))
+
+	p := newBatchProcessor(failingExporter{tracetest.NewInMemoryExporter()}, 4)
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(p))
+
+	_, span := provider.Tracer("test").Start(context.Background(), "Storage.Deposit")
+	span.End()
+	_ = provider.Shutdown(context.Background())
+
+	assert.Equal(t, failed+1, testutil.ToFloat64(metrics.DroppedSpans.WithLabelValues("export_failed")))
+	assert.Equal(t, int64(0), *p.queued)
+}
+
+func TestSetup(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Config{Exporter: "none"}, zap.NewNop())
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	shutdown, err = Setup(context.Background(), Config{Exporter: "stdout", ServiceName: "http-avito-test"}, zap.NewNop())
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	_, err = Setup(context.Background(), Config{Exporter: "zipkin"}, zap.NewNop())
+	assert.EqualError(t, err, `unknown traces exporter "zipkin"`)
+}
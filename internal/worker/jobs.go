@@ -0,0 +1,94 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Config defines the intervals of the background jobs
+type Config struct {
+	SettleInterval               time.Duration `env:"SETTLE_INTERVAL" envDefault:"30s"`
+	PaymentRequestExpiryInterval time.Duration `env:"PAYMENT_REQUEST_EXPIRY_INTERVAL" envDefault:"1m"`
+	BonusExpiryInterval          time.Duration `env:"BONUS_EXPIRY_INTERVAL" envDefault:"1m"`
+	// the shared rate limit buckets refilled for longer than RateLimitBucketTTL are deleted
+	RateLimitSweepInterval time.Duration `env:"RATE_LIMIT_SWEEP_INTERVAL" envDefault:"10m"`
+	RateLimitBucketTTL     time.Duration `env:"RATE_LIMIT_BUCKET_TTL" envDefault:"1h"`
+}
+
+type TransferSettler interface {
+	SettleDueTransfers(ctx context.Context, now time.Time) (int, error)
+}
+
+// SettleTransfers builds the job that settles delayed transfers whose undo window is over
+func SettleTransfers(logger *zap.Logger, s TransferSettler, interval time.Duration) Job {
+	return Job{
+		Name:     "transfer settler",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			settled, err := s.SettleDueTransfers(ctx, time.Now())
+			if settled > 0 {
+				logger.Info("delayed transfers are settled", zap.Int("count", settled))
+			}
+			return err
+		},
+	}
+}
+
+type PaymentRequestExpirer interface {
+	ExpirePaymentRequests(ctx context.Context, now time.Time) (int64, error)
+}
+
+// ExpirePaymentRequests builds the job that marks outdated payment requests as expired
+func ExpirePaymentRequests(logger *zap.Logger, s PaymentRequestExpirer, interval time.Duration) Job {
+	return Job{
+		Name:     "payment request expirer",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			expired, err := s.ExpirePaymentRequests(ctx, time.Now())
+			if expired > 0 {
+				logger.Info("payment requests are expired", zap.Int64("count", expired))
+			}
+			return err
+		},
+	}
+}
+
+type BonusExpirer interface {
+	ExpireBonuses(ctx context.Context, now time.Time) (int, error)
+}
+
+// ExpireBonuses builds the job that posts expired bonus grants back to the promotions account
+func ExpireBonuses(logger *zap.Logger, s BonusExpirer, interval time.Duration) Job {
+	return Job{
+		Name:     "bonus expirer",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			expired, err := s.ExpireBonuses(ctx, time.Now())
+			if expired > 0 {
+				logger.Info("bonus grants are expired", zap.Int("count", expired))
+			}
+			return err
+		},
+	}
+}
+
+type RateLimitSweeper interface {
+	DeleteIdleRateLimitBuckets(ctx context.Context, idle time.Duration) (int64, error)
+}
+
+// SweepRateLimits builds the job that deletes the shared rate limit buckets refilled for longer than ttl
+func SweepRateLimits(logger *zap.Logger, s RateLimitSweeper, interval, ttl time.Duration) Job {
+	return Job{
+		Name:     "rate limit sweeper",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			deleted, err := s.DeleteIdleRateLimitBuckets(ctx, ttl)
+			if deleted > 0 {
+				logger.Info("idle rate limit buckets are deleted", zap.Int64("count", deleted))
+			}
+			return err
+		},
+	}
+}
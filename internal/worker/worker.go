@@ -0,0 +1,42 @@
+// package worker runs periodic background jobs of the service
+package worker
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Job is a background task executed with the specified interval
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Start runs each job in its own goroutine until the context is cancelled
+func Start(ctx context.Context, logger *zap.Logger, jobs ...Job) {
+	for _, job := range jobs {
+		go run(ctx, logger.With(zap.String("job", job.Name)), job)
+	}
+}
+
+func run(ctx context.Context, logger *zap.Logger, job Job) {
+	logger.Info("starting background job", zap.Duration("interval", job.Interval))
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("background job is stopped")
+			return
+		case <-ticker.C:
+			if err := job.Run(ctx); err != nil {
+				logger.Error("background job failed", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestStart(t *testing.T) {
+	t.Run("job is executed until the context is cancelled", func(t *testing.T) {
+		var calls int32
+		done := make(chan struct{})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		Start(ctx, zap.NewNop(), Job{
+			Name:     "test",
+			Interval: time.Millisecond,
+			Run: func(ctx context.Context) error {
+				if atomic.AddInt32(&calls, 1) == 3 {
+					close(done)
+					cancel()
+				}
+				return errors.New("job errors do not stop the worker")
+			},
+		})
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("job was not executed")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		stopped := atomic.LoadInt32(&calls)
+		time.Sleep(10 * time.Millisecond)
+		assert.Equal(t, stopped, atomic.LoadInt32(&calls))
+	})
+}
+
+type settlerFunc func(ctx context.Context, now time.Time) (int, error)
+
+func (f settlerFunc) SettleDueTransfers(ctx context.Context, now time.Time) (int, error) {
+	return f(ctx, now)
+}
+
+func TestSettleTransfers(t *testing.T) {
+	var called bool
+	job := SettleTransfers(zap.NewNop(), settlerFunc(func(ctx context.Context, now time.Time) (int, error) {
+		called = true
+		assert.WithinDuration(t, time.Now(), now, time.Second)
+		return 1, nil
+	}), time.Minute)
+
+	assert.Equal(t, time.Minute, job.Interval)
+	assert.NoError(t, job.Run(context.Background()))
+	assert.True(t, called)
+}
+
+type expirerFunc func(ctx context.Context, now time.Time) (int64, error)
+
+func (f expirerFunc) ExpirePaymentRequests(ctx context.Context, now time.Time) (int64, error) {
+	return f(ctx, now)
+}
+
+func TestExpirePaymentRequests(t *testing.T) {
+	var called bool
+	job := ExpirePaymentRequests(zap.NewNop(), expirerFunc(func(ctx context.Context, now time.Time) (int64, error) {
+		called = true
+		assert.WithinDuration(t, time.Now(), now, time.Second)
+		return 2, nil
+	}), time.Minute)
+
+	assert.Equal(t, time.Minute, job.Interval)
+	assert.NoError(t, job.Run(context.Background()))
+	assert.True(t, called)
+}
+
+type bonusExpirerFunc func(ctx context.Context, now time.Time) (int, error)
+
+func (f bonusExpirerFunc) ExpireBonuses(ctx context.Context, now time.Time) (int, error) {
+	return f(ctx, now)
+}
+
+func TestExpireBonuses(t *testing.T) {
+	var called bool
+	job := ExpireBonuses(zap.NewNop(), bonusExpirerFunc(func(ctx context.Context, now time.Time) (int, error) {
+		called = true
+		assert.WithinDuration(t, time.Now(), now, time.Second)
+		return 1, nil
+	}), time.Minute)
+
+	assert.Equal(t, time.Minute, job.Interval)
+	assert.NoError(t, job.Run(context.Background()))
+	assert.True(t, called)
+}
+
+type sweeperFunc func(ctx context.Context, idle time.Duration) (int64, error)
+
+func (f sweeperFunc) DeleteIdleRateLimitBuckets(ctx context.Context, idle time.Duration) (int64, error) {
+	return f(ctx, idle)
+}
+
+func TestSweepRateLimits(t *testing.T) {
+	var called bool
+	job := SweepRateLimits(zap.NewNop(), sweeperFunc(func(ctx context.Context, idle time.Duration) (int64, error) {
+		called = true
+		assert.Equal(t, time.Hour, idle)
+		return 3, nil
+	}), time.Minute, time.Hour)
+
+	assert.Equal(t, time.Minute, job.Interval)
+	assert.NoError(t, job.Run(context.Background()))
+	assert.True(t, called)
+}
@@ -2,6 +2,7 @@ package zapadapter
 
 import (
 	"context"
+	"http-avito-test/internal/requestid"
 
 	"github.com/jackc/pgx/v4"
 	"go.uber.org/zap"
@@ -16,13 +17,17 @@ func NewLogger(logger *zap.Logger) *Logger {
 	return &Logger{logger: logger.WithOptions(zap.AddCallerSkip(1))}
 }
 
+// Log writes the pgx log line with the id of the request the query is executed for
 func (pl *Logger) Log(ctx context.Context, level pgx.LogLevel, msg string, data map[string]interface{}) {
-	fields := make([]zapcore.Field, len(data))
+	fields := make([]zapcore.Field, len(data), len(data)+2)
 	i := 0
 	for k, v := range data {
 		fields[i] = zap.Any(k, v)
 		i++
 	}
+	if id, ok := requestid.FromContext(ctx); ok {
+		fields = append(fields, zap.String("requestID", id))
+	}
 
 	switch level {
 	case pgx.LogLevelTrace:
@@ -0,0 +1,25 @@
+package zapadapter
+
+import (
+	"context"
+	"http-avito-test/internal/requestid"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLog(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	l := NewLogger(zap.New(core))
+
+	ctx := requestid.NewContext(context.Background(), "abc")
+	l.Log(ctx, pgx.LogLevelError, "Query", map[string]interface{}{"sql": "SELECT 1"})
+	l.Log(context.Background(), pgx.LogLevelInfo, "Query", nil)
+
+	entries := logs.AllUntimed()
+	assert.Equal(t, map[string]interface{}{"sql": "SELECT 1", "requestID": "abc"}, entries[0].ContextMap())
+	assert.Empty(t, entries[1].ContextMap())
+}
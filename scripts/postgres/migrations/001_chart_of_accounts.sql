@@ -0,0 +1,41 @@
+-- Moves the postings of the system accounts of a database created before the chart of accounts
+-- to the ids of the chart: cash book 0 -> -1, reserve 1 -> -2, escrow -1 -> -3, promotions -2 -> -4.
+-- The migration does nothing when the chart of accounts already exists, so it is safe to run it again:
+--   psql -v ON_ERROR_STOP=1 -f scripts/postgres/migrations/001_chart_of_accounts.sql
+
+DO $$
+BEGIN
+	IF to_regclass('chart_of_accounts') IS NOT NULL THEN
+		RAISE NOTICE 'chart of accounts already exists, nothing to migrate';
+		RETURN;
+	END IF;
+
+	CREATE TYPE account_type AS ENUM('asset', 'liability', 'revenue', 'expense');
+
+	CREATE TYPE account_role AS ENUM('cash_book', 'reserve', 'escrow', 'promotions');
+
+	CREATE TABLE chart_of_accounts(
+		id bigint PRIMARY KEY CHECK (id < 0),
+		code text NOT NULL unique,
+		name text NOT NULL,
+		type account_type NOT NULL,
+		role account_role unique
+	);
+
+	INSERT INTO chart_of_accounts (id, code, name, type, role) VALUES
+		(-1, '50', 'Cash book', 'asset', 'cash_book'),
+		(-2, '97', 'Reserved funds', 'asset', 'reserve'),
+		(-3, '76', 'Escrow', 'liability', 'escrow'),
+		(-4, '44', 'Promotions', 'expense', 'promotions');
+
+	-- the old and the new ids overlap, so every id is moved by a single statement.
+	-- The cached balances of the old ids are dropped and recalculated from the postings on the next read
+	DELETE FROM balances WHERE account_id IN (0, 1, -1, -2);
+
+	UPDATE posting SET account_id = CASE account_id WHEN 0 THEN -1 WHEN 1 THEN -2 WHEN -1 THEN -3 ELSE -4 END
+		WHERE account_id IN (0, 1, -1, -2);
+
+	UPDATE posting SET addressee = CASE addressee WHEN 0 THEN -1 WHEN 1 THEN -2 WHEN -1 THEN -3 ELSE -4 END
+		WHERE addressee IN (0, 1, -1, -2);
+END
+$$;
@@ -0,0 +1,20 @@
+-- Adds the revenue account to the chart of accounts and moves the revenue recognized before
+-- from the cash book to it. Only the revenue recognition transferred money to the cash book,
+-- deposits and withdrawals have their own journals. Run it after 001_chart_of_accounts.sql without
+-- a wrapping transaction, since the new enum value can be used only after it is committed:
+--   psql -v ON_ERROR_STOP=1 -f scripts/postgres/migrations/002_revenue_account.sql
+
+ALTER TYPE account_role ADD VALUE IF NOT EXISTS 'revenue';
+
+INSERT INTO chart_of_accounts (id, code, name, type, role) VALUES (-5, '90', 'Revenue', 'revenue', 'revenue')
+	ON CONFLICT (id) DO NOTHING;
+
+BEGIN;
+
+DELETE FROM balances WHERE account_id IN (-1, -5);
+
+UPDATE posting SET account_id = -5 WHERE account_id = -1 AND cb_journal = 'transfer';
+
+UPDATE posting SET addressee = -5 WHERE addressee = -1 AND cb_journal = 'transfer';
+
+COMMIT;
@@ -0,0 +1,16 @@
+-- Replaces the voucher codes with their SHA-256 hashes and the codes in the descriptions of the redemption
+-- postings with the voucher ids, so the bearer codes are not stored in plain text:
+--   psql -v ON_ERROR_STOP=1 -f scripts/postgres/migrations/003_voucher_code_hash.sql
+
+BEGIN;
+
+UPDATE posting p SET description = 'Redemption of voucher ' || v.id
+	FROM vouchers v WHERE p.description = 'Redemption of voucher ' || v.code;
+
+ALTER TABLE vouchers ADD COLUMN code_hash bytea;
+
+UPDATE vouchers SET code_hash = sha256(convert_to(code, 'UTF8'));
+
+ALTER TABLE vouchers ALTER COLUMN code_hash SET NOT NULL, ADD UNIQUE (code_hash), DROP COLUMN code;
+
+COMMIT;
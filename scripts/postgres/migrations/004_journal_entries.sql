@@ -0,0 +1,56 @@
+-- Adds the double-entry balancing of the journal entries to the database created before it: the journal_tx
+-- column of posting, the journal_entries table and the triggers. The existing postings get the id of the
+-- migration transaction and are not checked, the entries left by the earlier check are deleted. The script
+-- can be run again:
+--   psql -v ON_ERROR_STOP=1 -f scripts/postgres/migrations/004_journal_entries.sql
+
+BEGIN;
+
+ALTER TABLE posting ADD COLUMN IF NOT EXISTS journal_tx bigint NOT NULL DEFAULT txid_current();
+
+CREATE TABLE IF NOT EXISTS journal_entries(
+	journal_tx bigint PRIMARY KEY,
+	balance bigint NOT NULL
+);
+
+DELETE FROM journal_entries;
+
+CREATE OR REPLACE FUNCTION track_journal_entry() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP IN ('UPDATE', 'DELETE') THEN
+		INSERT INTO journal_entries (journal_tx, balance) VALUES (OLD.journal_tx, -OLD.amount)
+			ON CONFLICT (journal_tx) DO UPDATE SET balance = journal_entries.balance + excluded.balance;
+	END IF;
+	IF TG_OP IN ('INSERT', 'UPDATE') THEN
+		INSERT INTO journal_entries (journal_tx, balance) VALUES (NEW.journal_tx, NEW.amount)
+			ON CONFLICT (journal_tx) DO UPDATE SET balance = journal_entries.balance + excluded.balance;
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS posting_journal_entry ON posting;
+
+CREATE TRIGGER posting_journal_entry
+	AFTER INSERT OR UPDATE OR DELETE ON posting
+	FOR EACH ROW EXECUTE PROCEDURE track_journal_entry();
+
+CREATE OR REPLACE FUNCTION check_balanced_entry() RETURNS trigger AS $$
+BEGIN
+	IF (SELECT balance FROM journal_entries WHERE journal_tx = NEW.journal_tx) <> 0 THEN
+		RAISE EXCEPTION 'postings of journal entry % do not sum to zero', NEW.journal_tx
+			USING ERRCODE = 'check_violation', CONSTRAINT = 'posting_balanced_entry';
+	END IF;
+	DELETE FROM journal_entries WHERE journal_tx = NEW.journal_tx;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS posting_balanced_entry ON journal_entries;
+
+CREATE CONSTRAINT TRIGGER posting_balanced_entry
+	AFTER INSERT OR UPDATE ON journal_entries
+	DEFERRABLE INITIALLY DEFERRED
+	FOR EACH ROW EXECUTE PROCEDURE check_balanced_entry();
+
+COMMIT;
@@ -2,6 +2,41 @@ create type operation_type as enum('deposit', 'withdrawal', 'transfer');
 
 create type expenses_type as enum('reservation', 'unreservation');
 
+create type transfer_status as enum('pending', 'settled', 'cancelled');
+
+create type payment_request_status as enum('pending', 'accepted', 'declined', 'expired');
+
+create type escrow_status as enum('held', 'released', 'refunded', 'split');
+
+create type deposit_status as enum('pending', 'succeeded', 'failed');
+
+create type withdrawal_status as enum('pending', 'approved', 'rejected');
+
+create type fraud_action as enum('block', 'flag');
+
+create type fraud_operation as enum('withdrawal', 'transfer', 'reservation');
+
+create type statement_line_status as enum('unmatched', 'matched', 'resolved');
+
+create type account_type as enum('asset', 'liability', 'revenue', 'expense');
+
+create type account_role as enum('cash_book', 'reserve', 'escrow', 'promotions', 'revenue');
+
+CREATE TABLE chart_of_accounts(
+	id bigint PRIMARY KEY CHECK (id < 0),
+	code text NOT NULL unique,
+	name text NOT NULL,
+	type account_type NOT NULL,
+	role account_role unique
+);
+
+INSERT INTO chart_of_accounts (id, code, name, type, role) VALUES
+	(-1, '50', 'Cash book', 'asset', 'cash_book'),
+	(-2, '97', 'Reserved funds', 'asset', 'reserve'),
+	(-3, '76', 'Escrow', 'liability', 'escrow'),
+	(-4, '44', 'Promotions', 'expense', 'promotions'),
+	(-5, '90', 'Revenue', 'revenue', 'revenue');
+
 CREATE TABLE posting(
 	id BIGSERIAL PRIMARY KEY,
 	account_id bigint NOT NULL,
@@ -10,9 +45,52 @@ CREATE TABLE posting(
 	amount bigint NOT NULL,
 	date timestamp with time zone NOT NULL,
 	addressee bigint,
-	description text 
+	description text,
+	journal_tx bigint NOT NULL DEFAULT txid_current()
+);
+
+-- running sum of the postings of every journal entry, a journal entry is the postings of one database transaction
+CREATE TABLE journal_entries(
+	journal_tx bigint PRIMARY KEY,
+	balance bigint NOT NULL
 );
 
+CREATE FUNCTION track_journal_entry() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP IN ('UPDATE', 'DELETE') THEN
+		INSERT INTO journal_entries (journal_tx, balance) VALUES (OLD.journal_tx, -OLD.amount)
+			ON CONFLICT (journal_tx) DO UPDATE SET balance = journal_entries.balance + excluded.balance;
+	END IF;
+	IF TG_OP IN ('INSERT', 'UPDATE') THEN
+		INSERT INTO journal_entries (journal_tx, balance) VALUES (NEW.journal_tx, NEW.amount)
+			ON CONFLICT (journal_tx) DO UPDATE SET balance = journal_entries.balance + excluded.balance;
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER posting_journal_entry
+	AFTER INSERT OR UPDATE OR DELETE ON posting
+	FOR EACH ROW EXECUTE PROCEDURE track_journal_entry();
+
+-- every journal entry must sum to zero when its transaction commits, the checked entry is deleted,
+-- so the table holds only the entries of the open transactions
+CREATE FUNCTION check_balanced_entry() RETURNS trigger AS $$
+BEGIN
+	IF (SELECT balance FROM journal_entries WHERE journal_tx = NEW.journal_tx) <> 0 THEN
+		RAISE EXCEPTION 'postings of journal entry % do not sum to zero', NEW.journal_tx
+			USING ERRCODE = 'check_violation', CONSTRAINT = 'posting_balanced_entry';
+	END IF;
+	DELETE FROM journal_entries WHERE journal_tx = NEW.journal_tx;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER posting_balanced_entry
+	AFTER INSERT OR UPDATE ON journal_entries
+	DEFERRABLE INITIALLY DEFERRED
+	FOR EACH ROW EXECUTE PROCEDURE check_balanced_entry();
+
 CREATE TABLE balances(
 	balance bigint NOT NULL,
     account_id bigint unique,
@@ -36,3 +114,185 @@ CREATE TABLE consolidated_report(
 	sum bigint NOT NULL,
 	tx_id      bigint references posting (id)
 );
+
+CREATE TABLE pending_transfers(
+	id BIGSERIAL PRIMARY KEY,
+	sender bigint NOT NULL,
+	recipient bigint NOT NULL,
+	amount bigint NOT NULL,
+	description text,
+	status transfer_status NOT NULL,
+	settle_at timestamp with time zone NOT NULL,
+	hold_tx_id bigint references posting (id),
+	final_tx_id bigint references posting (id)
+);
+
+CREATE TABLE payment_requests(
+	id BIGSERIAL PRIMARY KEY,
+	requester bigint NOT NULL,
+	payer bigint NOT NULL,
+	amount bigint NOT NULL,
+	description text,
+	status payment_request_status NOT NULL,
+	created_at timestamp with time zone NOT NULL,
+	expires_at timestamp with time zone NOT NULL,
+	tx_id bigint references posting (id)
+);
+
+CREATE TABLE escrows(
+	id BIGSERIAL PRIMARY KEY,
+	payer bigint NOT NULL,
+	beneficiary bigint NOT NULL,
+	amount bigint NOT NULL,
+	released bigint NOT NULL DEFAULT 0,
+	refunded bigint NOT NULL DEFAULT 0,
+	description text,
+	status escrow_status NOT NULL,
+	created_at timestamp with time zone NOT NULL,
+	finished_at timestamp with time zone,
+	hold_tx_id bigint references posting (id),
+	release_tx_id bigint references posting (id),
+	refund_tx_id bigint references posting (id)
+);
+
+CREATE TABLE voucher_batches(
+	id BIGSERIAL PRIMARY KEY,
+	amount bigint NOT NULL,
+	max_redemptions bigint NOT NULL,
+	expires_at timestamp with time zone NOT NULL,
+	created_at timestamp with time zone NOT NULL
+);
+
+CREATE TABLE vouchers(
+	id BIGSERIAL PRIMARY KEY,
+	code_hash bytea NOT NULL unique,
+	batch_id bigint NOT NULL references voucher_batches (id),
+	redemptions bigint NOT NULL DEFAULT 0
+);
+
+CREATE TABLE voucher_redemptions(
+	voucher_id bigint NOT NULL references vouchers (id),
+	account_id bigint NOT NULL,
+	redeemed_at timestamp with time zone NOT NULL,
+	UNIQUE (voucher_id, account_id)
+);
+
+CREATE TABLE bonus_grants(
+	id BIGSERIAL PRIMARY KEY,
+	account_id bigint NOT NULL,
+	amount bigint NOT NULL,
+	remaining bigint NOT NULL CHECK (remaining >= 0),
+	expires_at timestamp with time zone NOT NULL,
+	created_at timestamp with time zone NOT NULL,
+	expiry_tx_id bigint references posting (id)
+);
+
+CREATE TABLE bonus_spendings(
+	grant_id bigint NOT NULL references bonus_grants (id),
+	tx_id bigint NOT NULL references posting (id),
+	amount bigint NOT NULL CHECK (amount > 0)
+);
+
+CREATE TABLE pending_deposits(
+	id BIGSERIAL PRIMARY KEY,
+	account_id bigint NOT NULL,
+	amount bigint NOT NULL,
+	provider text NOT NULL,
+	external_id text,
+	status deposit_status NOT NULL,
+	failure_reason text,
+	created_at timestamp with time zone NOT NULL,
+	finished_at timestamp with time zone,
+	UNIQUE (provider, external_id)
+);
+
+CREATE TABLE withdrawal_requests(
+	id BIGSERIAL PRIMARY KEY,
+	account_id bigint NOT NULL,
+	amount bigint NOT NULL,
+	description text,
+	status withdrawal_status NOT NULL,
+	created_at timestamp with time zone NOT NULL,
+	decided_at timestamp with time zone,
+	operator text,
+	reason text,
+	hold_tx_id bigint references posting (id),
+	final_tx_id bigint references posting (id)
+);
+
+CREATE TABLE fraud_flags(
+	id BIGSERIAL PRIMARY KEY,
+	rule text NOT NULL,
+	action fraud_action NOT NULL,
+	operation fraud_operation NOT NULL,
+	account_id bigint NOT NULL,
+	counterparty bigint,
+	amount bigint NOT NULL,
+	created_at timestamp with time zone NOT NULL
+);
+
+CREATE TABLE bank_statements(
+	id BIGSERIAL PRIMARY KEY,
+	name text NOT NULL,
+	period_from date NOT NULL,
+	period_to date NOT NULL,
+	date_window interval NOT NULL,
+	imported_at timestamp with time zone NOT NULL
+);
+
+CREATE TABLE bank_statement_lines(
+	id BIGSERIAL PRIMARY KEY,
+	statement_id bigint NOT NULL references bank_statements (id),
+	line_no integer NOT NULL,
+	date date NOT NULL,
+	amount bigint NOT NULL,
+	reference text,
+	status statement_line_status NOT NULL,
+	posting_id bigint unique references posting (id),
+	resolved_by text,
+	note text
+);
+
+CREATE TABLE reconciled_postings(
+	posting_id bigint PRIMARY KEY references posting (id),
+	resolved_by text NOT NULL,
+	note text,
+	resolved_at timestamp with time zone NOT NULL
+);
+
+CREATE TABLE api_keys(
+	id BIGSERIAL PRIMARY KEY,
+	client text NOT NULL,
+	prefix text NOT NULL UNIQUE,
+	hash bytea NOT NULL,
+	scopes text[] NOT NULL,
+	created_at timestamp with time zone NOT NULL,
+	revoked_at timestamp with time zone,
+	rotated_from bigint references api_keys (id)
+);
+
+CREATE TABLE client_services(
+	client text NOT NULL,
+	service_id bigint NOT NULL,
+	PRIMARY KEY (client, service_id)
+);
+
+CREATE TABLE authorization_denials(
+	id BIGSERIAL PRIMARY KEY,
+	client text NOT NULL,
+	key_id bigint NOT NULL references api_keys (id),
+	operation text NOT NULL,
+	account_id bigint NOT NULL,
+	service_id bigint NOT NULL,
+	order_id bigint NOT NULL,
+	created_at timestamp with time zone NOT NULL
+);
+
+CREATE TABLE rate_limit_buckets(
+	key text PRIMARY KEY,
+	tokens double precision NOT NULL,
+	allowed boolean NOT NULL,
+	updated_at timestamp with time zone NOT NULL,
+	rate double precision NOT NULL,
+	burst bigint NOT NULL
+);